@@ -1,6 +1,7 @@
 package twig
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"slices"
@@ -15,17 +16,23 @@ type AddCommand struct {
 	Sync         bool
 	CarryFrom    string
 	FilePatterns []string
+	Scope        StashScope
 	Lock         bool
 	LockReason   string
+	Submodules   SubmoduleMode
+	Sparse       []string
 }
 
 // AddOptions holds options for the add command.
 type AddOptions struct {
 	Sync         bool
-	CarryFrom    string   // empty: no carry, non-empty: resolved path to carry from
-	FilePatterns []string // file patterns to carry (empty means all files)
+	CarryFrom    string     // empty: no carry, non-empty: resolved path to carry from
+	FilePatterns []string   // file patterns to carry (empty means all files)
+	Scope        StashScope // kind of changes to carry or sync
 	Lock         bool
 	LockReason   string
+	Submodules   SubmoduleMode // empty: use the submodules setting
+	Sparse       []string      // empty: use the sparse setting
 }
 
 // NewAddCommand creates an AddCommand with explicit dependencies (for testing).
@@ -37,8 +44,11 @@ func NewAddCommand(fs FileSystem, git *GitRunner, cfg *Config, opts AddOptions)
 		Sync:         opts.Sync,
 		CarryFrom:    opts.CarryFrom,
 		FilePatterns: opts.FilePatterns,
+		Scope:        opts.Scope,
 		Lock:         opts.Lock,
 		LockReason:   opts.LockReason,
+		Submodules:   opts.Submodules,
+		Sparse:       opts.Sparse,
 	}
 }
 
@@ -63,6 +73,18 @@ type AddResult struct {
 	GitOutput      []byte
 	ChangesSynced  bool
 	ChangesCarried bool
+	ChangesScope   StashScope // Kind of changes synced or carried
+	// ChangesFiltered reports that only changes matching --file patterns
+	// were synced or carried.
+	ChangesFiltered bool
+	Profile         string // Name of the profile applied from the branch name
+	Locked          bool
+	// UnmatchedPatterns are --file patterns that matched no changed file.
+	UnmatchedPatterns []string
+	// Submodules are the submodules initialized in the new worktree.
+	Submodules []SubmoduleResult
+	// Sparse lists the checked out directories of a sparse worktree.
+	Sparse []string
 }
 
 // AddFormatOptions configures add output formatting.
@@ -96,22 +118,51 @@ func (r AddResult) formatDefault(opts AddFormatOptions) FormatResult {
 			createdCount++
 		}
 	}
+	for _, p := range r.UnmatchedPatterns {
+		fmt.Fprintf(&stderr, "warning: --file pattern %s matched no changed files\n", p)
+	}
+	var submoduleCount int
+	for _, s := range r.Submodules {
+		if s.Err != nil {
+			fmt.Fprintf(&stderr, "warning: failed to initialize submodule %s: %v\n", s.Path, s.Err)
+		} else {
+			submoduleCount++
+		}
+	}
 
 	if opts.Verbose {
 		if len(r.GitOutput) > 0 {
 			stdout.Write(r.GitOutput)
 		}
+		if r.Profile != "" {
+			fmt.Fprintf(&stdout, "Applied profile: %s\n", r.Profile)
+		}
 		fmt.Fprintf(&stdout, "Created worktree at %s\n", r.WorktreePath)
+		if len(r.Sparse) > 0 {
+			fmt.Fprintf(&stdout, "Sparse checkout: %s\n", strings.Join(r.Sparse, ", "))
+		}
+		if r.Locked {
+			stdout.WriteString("Locked worktree\n")
+		}
 		for _, s := range r.Symlinks {
 			if !s.Skipped {
 				fmt.Fprintf(&stdout, "Created symlink: %s -> %s\n", s.Dst, s.Src)
 			}
 		}
 		if r.ChangesSynced {
-			stdout.WriteString("Synced uncommitted changes\n")
+			fmt.Fprintf(&stdout, "Synced %s\n", r.changesDescription())
 		}
 		if r.ChangesCarried {
-			stdout.WriteString("Carried uncommitted changes (source is now clean)\n")
+			fmt.Fprintf(&stdout, "Carried %s\n", r.changesDescription())
+		}
+		for _, s := range r.Submodules {
+			switch {
+			case s.Err != nil:
+			case s.Reference != "":
+				fmt.Fprintf(&stdout, "Initialized submodule: %s (objects from %s)\n", s.Path, s.Reference)
+			default:
+				fmt.Fprintf(&stdout, "Initialized submodule: %s\n", s.Path)
+			}
 		}
 	}
 
@@ -121,7 +172,20 @@ func (r AddResult) formatDefault(opts AddFormatOptions) FormatResult {
 	} else if r.ChangesCarried {
 		syncInfo = ", carried"
 	}
-	fmt.Fprintf(&stdout, "twig add: %s (%d symlinks%s)\n", r.Branch, createdCount, syncInfo)
+	var submoduleInfo string
+	if submoduleCount > 0 {
+		submoduleInfo = fmt.Sprintf(", %d submodules", submoduleCount)
+	}
+	var sparseInfo string
+	if len(r.Sparse) > 0 {
+		sparseInfo = ", sparse"
+	}
+	var profileInfo string
+	if r.Profile != "" {
+		profileInfo = ", profile " + r.Profile
+	}
+	fmt.Fprintf(&stdout, "twig add: %s (%d symlinks%s%s%s%s)\n",
+		r.Branch, createdCount, syncInfo, submoduleInfo, sparseInfo, profileInfo)
 
 	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
 }
@@ -138,11 +202,18 @@ func (c *AddCommand) Run(name string) (AddResult, error) {
 	if c.Config.WorktreeSourceDir == "" {
 		return result, fmt.Errorf("worktree source directory is not configured")
 	}
-	if c.Config.WorktreeDestBaseDir == "" {
+
+	profile := c.Config.ProfileFor(name)
+	if profile != nil {
+		result.Profile = profile.DisplayName()
+	}
+
+	destBaseDir := c.Config.DestBaseDirFor(profile)
+	if destBaseDir == "" {
 		return result, fmt.Errorf("worktree destination base directory is not configured")
 	}
 
-	wtPath := filepath.Join(c.Config.WorktreeDestBaseDir, name)
+	wtPath := filepath.Join(destBaseDir, name)
 	result.WorktreePath = wtPath
 
 	// Determine stash mode and source
@@ -160,75 +231,124 @@ func (c *AddCommand) Run(name string) (AddResult, error) {
 	}
 
 	// Stash changes if sync or carry is enabled
-	var stashHash string
+	var op StashOperation
+	var store *operationStore
 	if stashMsg != "" {
-		hasChanges, err := stashSourceGit.HasChanges()
+		changed, err := stashSourceGit.ChangedFiles()
 		if err != nil {
 			return result, fmt.Errorf("failed to check for changes: %w", err)
 		}
-		if hasChanges {
-			var pathspecs []string
-			if len(c.FilePatterns) > 0 {
-				// Expand glob patterns to actual file paths using doublestar
-				globDir := c.Config.WorktreeSourceDir
-				if isCarry {
-					globDir = c.CarryFrom
-				}
-				seen := make(map[string]bool)
-				for _, pattern := range c.FilePatterns {
-					matches, err := c.FS.Glob(globDir, pattern)
-					if err != nil {
-						return result, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
-					}
-					for _, match := range matches {
-						if !seen[match] {
-							seen[match] = true
-							pathspecs = append(pathspecs, match)
-						}
-					}
-				}
-			}
-			hash, err := stashSourceGit.StashPush(stashMsg, pathspecs...)
+		var pathspecs []string
+		if len(c.FilePatterns) > 0 {
+			// Match patterns against changed files rather than the files on
+			// disk, so that deletions and both sides of renames are carried.
+			// "!pattern" entries exclude files matched by earlier patterns.
+			matched, err := matchPaths(changed, c.FilePatterns)
 			if err != nil {
+				return result, err
+			}
+			pathspecs = matched.Paths
+			result.UnmatchedPatterns = matched.Unmatched
+			if len(pathspecs) == 0 {
+				changed = nil
+			}
+		}
+		if len(changed) > 0 {
+			// Sync leaves the source untouched; carry removes the changes
+			mode := OperationSync
+			stashOpts := []StashPushOption{WithStashScope(c.Scope)}
+			if isCarry {
+				mode = OperationCarry
+			} else {
+				stashOpts = append(stashOpts, WithKeepChanges())
+			}
+			hash, err := stashSourceGit.StashPush(stashMsg, pathspecs, stashOpts...)
+			switch {
+			case errors.Is(err, errNoLocalChanges):
+				// Nothing in the selected scope
+			case err != nil:
 				return result, fmt.Errorf("failed to stash changes: %w", err)
+			default:
+				// Record the operation so it can be recovered if twig is interrupted
+				op = newStashOperation(mode, c.Scope, hash, stashSourceGit.Dir, wtPath, name)
+				store, err = newOperationStore(c.FS, c.Git)
+				if err == nil {
+					err = store.Save(op)
+				}
+				if err != nil {
+					rollbackStash(stashSourceGit, nil, op)
+					return result, fmt.Errorf("failed to record %s operation: %w", mode, err)
+				}
 			}
-			stashHash = hash
 		}
 	}
 
-	gitOutput, err := c.createWorktree(name, wtPath)
+	lock, lockReason := c.Lock, c.LockReason
+	if !lock && profile != nil && profile.Lock {
+		lock, lockReason = true, profile.LockReason
+	}
+	result.Locked = lock
+
+	sparse := c.Sparse
+	if len(sparse) == 0 {
+		sparse = c.Config.SparseFor(profile)
+	}
+	sparse = sparseDirs(sparse)
+
+	gitOutput, err := c.createWorktree(name, wtPath, lock, lockReason, len(sparse) > 0)
 	if err != nil {
-		if stashHash != "" {
-			_, _ = stashSourceGit.StashPopByHash(stashHash)
+		if op.Hash != "" {
+			rollbackStash(stashSourceGit, store, op)
 		}
 		return result, err
 	}
 	result.GitOutput = gitOutput
 
+	// The worktree was created without a checkout; restrict it before
+	// populating so that directories outside the cone are never written.
+	if len(sparse) > 0 {
+		if err := c.checkoutSparse(wtPath, sparse); err != nil {
+			_, _ = c.Git.WorktreeRemove(wtPath, WithForceRemove(WorktreeForceLevelLocked))
+			if op.Hash != "" {
+				rollbackStash(stashSourceGit, store, op)
+			}
+			return result, err
+		}
+		result.Sparse = sparse
+	}
+
 	// Apply stashed changes to new worktree
-	if stashHash != "" {
-		_, err = c.Git.InDir(wtPath).StashApplyByHash(stashHash)
+	if op.Hash != "" {
+		_, err = c.Git.InDir(wtPath).StashApplyByHash(op.Hash, op.Scope)
 		if err != nil {
 			_, _ = c.Git.WorktreeRemove(wtPath, WithForceRemove(WorktreeForceLevelUnclean))
-			_, _ = stashSourceGit.StashPopByHash(stashHash)
+			rollbackStash(stashSourceGit, store, op)
 			return result, fmt.Errorf("failed to apply changes to new worktree: %w", err)
 		}
+		_, _ = stashSourceGit.StashDropByHash(op.Hash)
+		_ = store.Remove(op.Hash)
 		if isCarry {
-			// Carry: drop stash (source becomes clean)
-			_, _ = stashSourceGit.StashDropByHash(stashHash)
 			result.ChangesCarried = true
 		} else {
-			// Sync: restore stash in source (both have changes)
-			_, err = stashSourceGit.StashPopByHash(stashHash)
-			if err != nil {
-				return result, fmt.Errorf("failed to restore changes in source: %w", err)
-			}
 			result.ChangesSynced = true
 		}
+		result.ChangesScope = op.Scope
+		result.ChangesFiltered = len(c.FilePatterns) > 0
+	}
+
+	// After applying changes: git cannot remove a worktree with populated
+	// submodules without --force, which the rollback above relies on.
+	submodules := c.Submodules
+	if submodules == "" {
+		submodules = c.Config.Submodules
+	}
+	result.Submodules, err = initSubmodules(c.FS, c.Git, c.Config.WorktreeSourceDir, wtPath, submodules)
+	if err != nil {
+		return result, err
 	}
 
 	symlinks, err := c.createSymlinks(
-		c.Config.WorktreeSourceDir, wtPath, c.Config.Symlinks)
+		c.Config.WorktreeSourceDir, wtPath, c.Config.SymlinksFor(profile), c.Config.SymlinkExcludes)
 	if err != nil {
 		return result, err
 	}
@@ -237,9 +357,9 @@ func (c *AddCommand) Run(name string) (AddResult, error) {
 	return result, nil
 }
 
-func (c *AddCommand) createWorktree(branch, path string) ([]byte, error) {
+func (c *AddCommand) createWorktree(branch, path string, lock bool, lockReason string, noCheckout bool) ([]byte, error) {
 	if _, err := c.FS.Stat(path); err == nil {
-		return nil, fmt.Errorf("directory already exists: %s", path)
+		return nil, kindErrorf(ErrDirectoryExists, "directory already exists: %s", path)
 	}
 
 	var opts []WorktreeAddOption
@@ -249,7 +369,7 @@ func (c *AddCommand) createWorktree(branch, path string) ([]byte, error) {
 			return nil, fmt.Errorf("failed to list worktree branches: %w", err)
 		}
 		if slices.Contains(branches, branch) {
-			return nil, fmt.Errorf("branch %s is already checked out in another worktree", branch)
+			return nil, kindErrorf(ErrBranchCheckedOut, "branch %s is already checked out in another worktree", branch)
 		}
 	} else {
 		remote, err := c.Git.FindRemoteForBranch(branch)
@@ -269,13 +389,17 @@ func (c *AddCommand) createWorktree(branch, path string) ([]byte, error) {
 		}
 	}
 
-	if c.Lock {
+	if lock {
 		opts = append(opts, WithLock())
-		if c.LockReason != "" {
-			opts = append(opts, WithLockReason(c.LockReason))
+		if lockReason != "" {
+			opts = append(opts, WithLockReason(lockReason))
 		}
 	}
 
+	if noCheckout {
+		opts = append(opts, WithNoCheckout())
+	}
+
 	output, err := c.Git.WorktreeAdd(path, branch, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create worktree: %w", err)
@@ -284,51 +408,101 @@ func (c *AddCommand) createWorktree(branch, path string) ([]byte, error) {
 	return output, nil
 }
 
+func (c *AddCommand) checkoutSparse(path string, dirs []string) error {
+	git := c.Git.InDir(path)
+	if _, err := git.SparseCheckoutSet(dirs); err != nil {
+		return fmt.Errorf("failed to set up sparse-checkout: %w", err)
+	}
+	if _, err := git.Checkout(); err != nil {
+		return fmt.Errorf("failed to check out sparse worktree: %w", err)
+	}
+	return nil
+}
+
+// sparseDirs normalizes sparse directories to slash-separated paths
+// relative to the repository root, dropping entries that name the root.
+func sparseDirs(dirs []string) []string {
+	var result []string
+	for _, dir := range dirs {
+		dir = strings.Trim(filepath.ToSlash(dir), "/")
+		if dir == "" || dir == "." || slices.Contains(result, dir) {
+			continue
+		}
+		result = append(result, dir)
+	}
+	return result
+}
+
 func (c *AddCommand) createSymlinks(
-	srcDir, dstDir string, patterns []string) ([]SymlinkResult, error) {
+	srcDir, dstDir string, patterns, excludes []string) ([]SymlinkResult, error) {
 	var results []SymlinkResult
 
-	for _, pattern := range patterns {
-		matches, err := c.FS.Glob(srcDir, pattern)
-		if err != nil {
-			return nil, fmt.Errorf("invalid glob pattern %s: %w", pattern, err)
-		}
-		if len(matches) == 0 {
+	expanded, err := expandPatterns(c.FS, srcDir, patterns, excludes)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range expanded.Unmatched {
+		results = append(results, SymlinkResult{
+			Skipped: true,
+			Reason:  fmt.Sprintf("%s does not match any files, skipping", pattern),
+		})
+	}
+
+	for _, match := range expanded.Paths {
+		src := filepath.Join(srcDir, match)
+		dst := filepath.Join(dstDir, match)
+
+		// Skip if destination already exists (e.g., git-tracked file checked out by worktree).
+		if _, err := c.FS.Stat(dst); err == nil {
 			results = append(results, SymlinkResult{
+				Src:     src,
+				Dst:     dst,
 				Skipped: true,
-				Reason:  fmt.Sprintf("%s does not match any files, skipping", pattern),
+				Reason:  fmt.Sprintf("skipping symlink for %s (already exists)", match),
 			})
 			continue
 		}
 
-		for _, match := range matches {
-			src := filepath.Join(srcDir, match)
-			dst := filepath.Join(dstDir, match)
-
-			// Skip if destination already exists (e.g., git-tracked file checked out by worktree).
-			if _, err := c.FS.Stat(dst); err == nil {
-				results = append(results, SymlinkResult{
-					Src:     src,
-					Dst:     dst,
-					Skipped: true,
-					Reason:  fmt.Sprintf("skipping symlink for %s (already exists)", match),
-				})
-				continue
-			}
-
-			if dir := filepath.Dir(dst); dir != dstDir {
-				if err := c.FS.MkdirAll(dir, 0755); err != nil {
-					return nil, fmt.Errorf("failed to create directory for %s: %w", match, err)
-				}
-			}
-
-			if err := c.FS.Symlink(src, dst); err != nil {
-				return nil, fmt.Errorf("failed to create symlink for %s: %w", match, err)
+		if dir := filepath.Dir(dst); dir != dstDir {
+			if err := c.FS.MkdirAll(dir, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create directory for %s: %w", match, err)
 			}
+		}
 
-			results = append(results, SymlinkResult{Src: src, Dst: dst})
+		target, err := linkTarget(src, dst, c.Config.SymlinkStyle)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.FS.Symlink(target, dst); err != nil {
+			return nil, fmt.Errorf("failed to create symlink for %s: %w", match, err)
 		}
+
+		results = append(results, SymlinkResult{Src: src, Dst: dst})
 	}
 
 	return results, nil
 }
+
+// changesDescription describes the changes that were synced or carried and,
+// for a carry, what the source worktree kept.
+func (r AddResult) changesDescription() string {
+	var what, kept string
+	switch r.ChangesScope {
+	case StashScopeStaged:
+		what, kept = "staged changes", "unstaged and untracked changes stay in source"
+	case StashScopeUnstaged:
+		what, kept = "unstaged changes", "staged and untracked changes stay in source"
+	case StashScopeUntracked:
+		what, kept = "untracked files", "tracked changes stay in source"
+	default:
+		what, kept = "uncommitted changes", "source is now clean"
+	}
+	if r.ChangesFiltered {
+		what += " matching --file"
+		kept = "other changes stay in source"
+	}
+	if !r.ChangesCarried {
+		return what
+	}
+	return what + " (" + kept + ")"
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -680,6 +681,121 @@ worktree_destination_base_dir = %q
 		}
 	})
 
+	t.Run("SymlinkNegationAndExcludes", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t,
+			testutil.Symlinks(".claude/**", "config/*.local.*", "!config/db.local.yml"))
+
+		for _, f := range []string{
+			".claude/settings.json",
+			".claude/commands/review.md",
+			".claude/cache/blob.bin",
+			"config/app.local.yml",
+			"config/db.local.yml",
+		} {
+			path := filepath.Join(mainDir, f)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		localSettings := `symlink_excludes = [".claude/cache/**"]
+`
+		if err := os.WriteFile(filepath.Join(mainDir, ".twig", "settings.local.toml"), []byte(localSettings), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+		}
+
+		addResult, err := cmd.Run("feature/symlink-excludes")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		for _, s := range addResult.Symlinks {
+			if s.Skipped {
+				t.Errorf("unexpected skipped symlink: %s", s.Reason)
+			}
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "symlink-excludes")
+
+		// .claude itself is a real directory because part of it is excluded
+		info, err := os.Lstat(filepath.Join(wtPath, ".claude"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			t.Error(".claude should not be a symlink when it has excluded descendants")
+		}
+
+		for _, linked := range []string{".claude/settings.json", ".claude/commands", "config/app.local.yml"} {
+			info, err := os.Lstat(filepath.Join(wtPath, linked))
+			if err != nil {
+				t.Errorf("%s should be symlinked: %v", linked, err)
+				continue
+			}
+			if info.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("%s should be a symlink", linked)
+			}
+		}
+		for _, excluded := range []string{".claude/cache", "config/db.local.yml"} {
+			if _, err := os.Lstat(filepath.Join(wtPath, excluded)); !os.IsNotExist(err) {
+				t.Errorf("%s should not exist in worktree", excluded)
+			}
+		}
+	})
+
+	t.Run("RelativeSymlinkStyle", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t,
+			testutil.Symlinks(".envrc"),
+			testutil.SymlinkStyle("relative"))
+
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("export A=1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+		}
+		if _, err := cmd.Run("feature/relative"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		link := filepath.Join(repoDir, "feature", "relative", ".envrc")
+		target, err := os.Readlink(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != "../../main/.envrc" {
+			t.Errorf("symlink target = %q, want %q", target, "../../main/.envrc")
+		}
+		if _, err := os.Stat(link); err != nil {
+			t.Errorf("relative symlink does not resolve: %v", err)
+		}
+	})
+
 	t.Run("SyncUncommittedChanges", func(t *testing.T) {
 		t.Parallel()
 
@@ -733,7 +849,7 @@ worktree_destination_base_dir = %q
 			t.Errorf("synced file content = %q, want %q", string(content), "uncommitted content")
 		}
 
-		// Verify the file still exists in source (restored via stash pop)
+		// Verify the file still exists in source (sync leaves it untouched)
 		sourceContent, err := os.ReadFile(modifiedFile)
 		if err != nil {
 			t.Fatalf("failed to read source file: %v", err)
@@ -884,6 +1000,92 @@ worktree_destination_base_dir = %q
 		}
 	})
 
+	t.Run("ConcurrentCarriesInSameRepository", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		// Commit .twig/settings.toml first
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		// Each source worktree carries its own file plus a conflicting
+		// version of a shared file.
+		const n = 6
+		sources := make([]string, n)
+		for i := range n {
+			sources[i] = filepath.Join(repoDir, "source", fmt.Sprintf("wt%d", i))
+			testutil.RunGit(t, mainDir, "worktree", "add", "--detach", sources[i])
+			for name, content := range map[string]string{
+				fmt.Sprintf("only%d.txt", i): "mine",
+				"shared.txt":                 fmt.Sprintf("from wt%d", i),
+			} {
+				if err := os.WriteFile(filepath.Join(sources[i], name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The group returns once all parallel carries have finished.
+		t.Run("group", func(t *testing.T) {
+			for i := range n {
+				t.Run(fmt.Sprintf("wt%d", i), func(t *testing.T) {
+					t.Parallel()
+
+					cmd := &AddCommand{
+						FS:        osFS{},
+						Git:       NewGitRunner(mainDir),
+						Config:    result.Config,
+						CarryFrom: sources[i],
+					}
+					if _, err := cmd.Run(fmt.Sprintf("feature/concurrent-%d", i)); err != nil {
+						t.Fatalf("Run failed: %v", err)
+					}
+				})
+			}
+		})
+
+		for i := range n {
+			wtPath := filepath.Join(repoDir, "feature", fmt.Sprintf("concurrent-%d", i))
+			entries, err := os.ReadDir(wtPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, e := range entries {
+				if e.Name() != ".git" && e.Name() != ".twig" {
+					names = append(names, e.Name())
+				}
+			}
+			want := fmt.Sprintf("only%d.txt,shared.txt", i)
+			if strings.Join(names, ",") != want {
+				t.Errorf("concurrent-%d files = %v, want %s", i, names, want)
+			}
+			content, err := os.ReadFile(filepath.Join(wtPath, "shared.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != fmt.Sprintf("from wt%d", i) {
+				t.Errorf("concurrent-%d shared.txt = %q, want %q", i, content, fmt.Sprintf("from wt%d", i))
+			}
+			if status := testutil.RunGit(t, sources[i], "status", "--porcelain"); strings.TrimSpace(status) != "" {
+				t.Errorf("source wt%d should be clean, got %q", i, status)
+			}
+		}
+
+		if refs := testutil.RunGit(t, mainDir, "for-each-ref", StashRefPrefix); strings.TrimSpace(refs) != "" {
+			t.Errorf("private stash refs should be deleted, got %q", refs)
+		}
+		if list := testutil.RunGit(t, mainDir, "stash", "list"); strings.TrimSpace(list) != "" {
+			t.Errorf("shared stash stack should be untouched, got %q", list)
+		}
+	})
+
 	t.Run("SyncWithNoChanges", func(t *testing.T) {
 		t.Parallel()
 
@@ -1183,6 +1385,87 @@ worktree_destination_base_dir = %q
 		}
 	})
 
+	t.Run("ProfileAppliedByBranchName", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc", ".cache/**"))
+
+		agentsDir := filepath.Join(repoDir, "agents")
+		profiles := fmt.Sprintf(`
+[[profile]]
+name = "agent"
+match = "agent/*"
+lock = true
+lock_reason = "agent"
+worktree_destination_base_dir = %q
+
+[[profile]]
+match = "docs/*"
+skip_symlinks = [".cache/**"]
+`, agentsDir)
+		settingsPath := filepath.Join(mainDir, ".twig", "settings.toml")
+		f, err := os.OpenFile(settingsPath, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteString(profiles); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("# envrc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(mainDir, ".cache"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mainDir, ".cache", "blob"), []byte("cache"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		agentResult, err := (&AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+		}).Run("agent/task")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wantPath := filepath.Join(agentsDir, "agent", "task")
+		if agentResult.WorktreePath != wantPath {
+			t.Errorf("WorktreePath = %q, want %q", agentResult.WorktreePath, wantPath)
+		}
+		if agentResult.Profile != "agent" {
+			t.Errorf("Profile = %q, want %q", agentResult.Profile, "agent")
+		}
+		out := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain")
+		if !strings.Contains(out, "worktree "+wantPath+"\n") || !strings.Contains(out, "locked agent") {
+			t.Errorf("agent worktree should be created and locked with reason, got: %s", out)
+		}
+
+		docsResult, err := (&AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+		}).Run("docs/guide")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if _, err := os.Lstat(filepath.Join(docsResult.WorktreePath, ".envrc")); err != nil {
+			t.Errorf(".envrc should be symlinked: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(docsResult.WorktreePath, ".cache")); !os.IsNotExist(err) {
+			t.Errorf(".cache should be skipped by the docs profile, got err: %v", err)
+		}
+	})
+
 	t.Run("CarrySpecificFiles", func(t *testing.T) {
 		t.Parallel()
 
@@ -1269,6 +1552,50 @@ worktree_destination_base_dir = %q
 		}
 	})
 
+	t.Run("CarryNegatedFilePattern", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		for _, name := range []string{"main.go", "main_test.go"} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte("package main"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Carry *.go files except tests
+		cmd := &AddCommand{
+			FS:           osFS{},
+			Git:          NewGitRunner(mainDir),
+			Config:       result.Config,
+			CarryFrom:    mainDir,
+			FilePatterns: []string{"*.go", "!*_test.go"},
+		}
+
+		if _, err := cmd.Run("feature/carry-negated"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "carry-negated")
+		if _, err := os.Stat(filepath.Join(wtPath, "main.go")); err != nil {
+			t.Errorf("main.go should be carried: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(wtPath, "main_test.go")); !os.IsNotExist(err) {
+			t.Errorf("main_test.go should not be carried")
+		}
+		if _, err := os.Stat(filepath.Join(mainDir, "main_test.go")); err != nil {
+			t.Errorf("main_test.go should remain in source: %v", err)
+		}
+	})
+
 	t.Run("CarryMultiplePatterns", func(t *testing.T) {
 		t.Parallel()
 
@@ -1420,6 +1747,282 @@ worktree_destination_base_dir = %q
 		}
 	})
 
+	t.Run("CarryDeletionsAndRenamesWithFilePattern", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		if err := os.MkdirAll(filepath.Join(mainDir, "api"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"api/old.go", "api/a.go", "web.go"} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte("package x\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, mainDir, "add", ".")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add files")
+
+		// Delete one file and rename another; neither old path exists on disk
+		if err := os.Remove(filepath.Join(mainDir, "api", "old.go")); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "mv", "api/a.go", "api/b.go")
+		if err := os.WriteFile(filepath.Join(mainDir, "web.go"), []byte("package web\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &AddCommand{
+			FS:           osFS{},
+			Git:          NewGitRunner(mainDir),
+			Config:       result.Config,
+			CarryFrom:    mainDir,
+			FilePatterns: []string{"api/**", "docs/**"},
+		}
+		addResult, err := cmd.Run("feature/carry-renames")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !slices.Equal(addResult.UnmatchedPatterns, []string{"docs/**"}) {
+			t.Errorf("UnmatchedPatterns = %v, want [docs/**]", addResult.UnmatchedPatterns)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "carry-renames")
+		for name, exists := range map[string]bool{"api/old.go": false, "api/a.go": false, "api/b.go": true} {
+			if _, err := os.Stat(filepath.Join(wtPath, name)); (err == nil) != exists {
+				t.Errorf("target %s exists = %v, want %v", name, err == nil, exists)
+			}
+		}
+
+		// Only the unmatched change is left in the source
+		if got := testutil.RunGit(t, mainDir, "status", "--porcelain"); got != " M web.go\n" {
+			t.Errorf("source status = %q, want only web.go", got)
+		}
+	})
+
+	t.Run("CarryFromSubdirectoryWithFilePattern", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		subDir := filepath.Join(mainDir, "sub")
+		if err := os.MkdirAll(subDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"sub/a.txt", "sub/b.txt"} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte("one\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, mainDir, "add", ".")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add files")
+		for _, name := range []string{"sub/a.txt", "sub/b.txt"} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte("two\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &AddCommand{
+			FS:           osFS{},
+			Git:          NewGitRunner(mainDir),
+			Config:       result.Config,
+			CarryFrom:    subDir,
+			FilePatterns: []string{"sub/a.txt"},
+		}
+		if _, err := cmd.Run("feature/carry-subdir"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "carry-subdir")
+		content, err := os.ReadFile(filepath.Join(wtPath, "sub", "a.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "two\n" {
+			t.Errorf("target sub/a.txt = %q, want %q", content, "two\n")
+		}
+		if got := testutil.RunGit(t, mainDir, "status", "--porcelain"); got != " M sub/b.txt\n" {
+			t.Errorf("source status = %q, want only sub/b.txt", got)
+		}
+	})
+
+	t.Run("CarryFilePatternWithGlobCharacters", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		for _, name := range []string{"x[1].txt", "x1.txt"} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte("one\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, mainDir, "add", ".")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add files")
+		for _, name := range []string{"x[1].txt", "x1.txt"} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte("two\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &AddCommand{
+			FS:           osFS{},
+			Git:          NewGitRunner(mainDir),
+			Config:       result.Config,
+			CarryFrom:    mainDir,
+			FilePatterns: []string{`x\[1\].txt`},
+		}
+		if _, err := cmd.Run("feature/carry-glob-name"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "carry-glob-name")
+		for name, want := range map[string]string{"x[1].txt": "two\n", "x1.txt": "one\n"} {
+			content, err := os.ReadFile(filepath.Join(wtPath, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != want {
+				t.Errorf("target %s = %q, want %q", name, content, want)
+			}
+		}
+		if got := testutil.RunGit(t, mainDir, "status", "--porcelain"); got != " M x1.txt\n" {
+			t.Errorf("source status = %q, want only x1.txt", got)
+		}
+	})
+
+	t.Run("CarryStagedChanges", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		appFile := filepath.Join(mainDir, "app.txt")
+		if err := os.WriteFile(appFile, []byte("a\nb\nc\nd\ne\nf\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", "app.txt")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add app")
+
+		// Stage the first hunk for the new branch, keep working on the last one
+		if err := os.WriteFile(appFile, []byte("A\nb\nc\nd\ne\nf\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", "app.txt")
+		if err := os.WriteFile(appFile, []byte("A\nb\nc\nd\ne\nF\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mainDir, "notes.txt"), []byte("notes\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &AddCommand{
+			FS:        osFS{},
+			Git:       NewGitRunner(mainDir),
+			Config:    result.Config,
+			CarryFrom: mainDir,
+			Scope:     StashScopeStaged,
+		}
+		addResult, err := cmd.Run("feature/carry-staged")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !addResult.ChangesCarried {
+			t.Error("expected ChangesCarried to be true")
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "carry-staged")
+		if got, _ := os.ReadFile(filepath.Join(wtPath, "app.txt")); string(got) != "A\nb\nc\nd\ne\nf\n" {
+			t.Errorf("target app.txt = %q, want only the staged hunk", got)
+		}
+		if got := testutil.RunGit(t, wtPath, "diff", "--cached", "--name-only"); strings.TrimSpace(got) != "app.txt" {
+			t.Errorf("target staged files = %q, want app.txt", got)
+		}
+		if _, err := os.Stat(filepath.Join(wtPath, "notes.txt")); !os.IsNotExist(err) {
+			t.Errorf("untracked notes.txt should not be carried, got err=%v", err)
+		}
+
+		if got, _ := os.ReadFile(appFile); string(got) != "a\nb\nc\nd\ne\nF\n" {
+			t.Errorf("source app.txt = %q, want only the unstaged hunk", got)
+		}
+		if got := testutil.RunGit(t, mainDir, "diff", "--cached", "--name-only"); strings.TrimSpace(got) != "" {
+			t.Errorf("source should have nothing staged, got %q", got)
+		}
+		if _, err := os.Stat(filepath.Join(mainDir, "notes.txt")); err != nil {
+			t.Errorf("untracked notes.txt should remain in source: %v", err)
+		}
+	})
+
+	t.Run("SyncUnstagedKeepsIndex", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+
+		for name, content := range map[string]string{"staged.txt": "one\n", "unstaged.txt": "one\n"} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, mainDir, "add", ".")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add files")
+		for _, name := range []string{"staged.txt", "unstaged.txt"} {
+			if err := os.WriteFile(filepath.Join(mainDir, name), []byte("two\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, mainDir, "add", "staged.txt")
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := &AddCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+			Sync:   true,
+			Scope:  StashScopeUnstaged,
+		}
+		addResult, err := cmd.Run("feature/sync-unstaged")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !addResult.ChangesSynced {
+			t.Error("expected ChangesSynced to be true")
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "sync-unstaged")
+		if got, _ := os.ReadFile(filepath.Join(wtPath, "unstaged.txt")); string(got) != "two\n" {
+			t.Errorf("target unstaged.txt = %q, want %q", got, "two\n")
+		}
+		if got, _ := os.ReadFile(filepath.Join(wtPath, "staged.txt")); string(got) != "one\n" {
+			t.Errorf("target staged.txt = %q, want %q", got, "one\n")
+		}
+
+		// The source keeps its changes and what was staged
+		if got := testutil.RunGit(t, mainDir, "status", "--porcelain", "--untracked-files=no"); got != "M  staged.txt\n M unstaged.txt\n" {
+			t.Errorf("source status = %q", got)
+		}
+	})
+
 	t.Run("RemoteBranchFetchAndCreateWorktree", func(t *testing.T) {
 		t.Parallel()
 
@@ -1694,3 +2297,159 @@ worktree_destination_base_dir = %q
 		}
 	})
 }
+
+func TestAddCommand_Submodules_Integration(t *testing.T) {
+	t.Parallel()
+
+	// file:// submodule URLs are blocked by default since git 2.38.1
+	allowFile := []string{"GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=protocol.file.allow", "GIT_CONFIG_VALUE_0=always"}
+
+	// setup creates a repository whose main worktree has vendor/lib checked
+	// out as a submodule, which itself has a nested submodule "inner".
+	setup := func(t *testing.T) (*Config, string) {
+		t.Helper()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+
+		newRepo := func(name string) string {
+			dir := filepath.Join(repoDir, name)
+			testutil.RunGit(t, repoDir, "init", "-q", "-b", "main", dir)
+			testutil.RunGit(t, dir, "config", "user.email", "test@example.com")
+			testutil.RunGit(t, dir, "config", "user.name", "Test User")
+			testutil.RunGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+			return dir
+		}
+		inner := newRepo("inner")
+		lib := newRepo("lib")
+		testutil.RunGit(t, lib, "-c", "protocol.file.allow=always", "submodule", "add", inner, "inner")
+		testutil.RunGit(t, lib, "commit", "-m", "add inner")
+
+		testutil.RunGit(t, mainDir, "-c", "protocol.file.allow=always", "submodule", "add", lib, "vendor/lib")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add lib")
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Config, repoDir
+	}
+
+	tests := []struct {
+		name      string
+		mode      SubmoduleMode
+		wantLib   bool
+		wantInner bool
+	}{
+		{name: "none", mode: SubmoduleModeNone},
+		{name: "init", mode: SubmoduleModeInit, wantLib: true},
+		{name: "recursive", mode: SubmoduleModeRecursive, wantLib: true, wantInner: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, repoDir := setup(t)
+			git := &GitRunner{Executor: osGitExecutor{}, Dir: cfg.WorktreeSourceDir, Env: allowFile}
+			cmd := NewAddCommand(osFS{}, git, cfg, AddOptions{Submodules: tt.mode})
+
+			result, err := cmd.Run("feat/sub")
+			if err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+
+			wtPath := filepath.Join(repoDir, "feat", "sub")
+			_, err = os.Stat(filepath.Join(wtPath, "vendor", "lib", ".git"))
+			if gotLib := err == nil; gotLib != tt.wantLib {
+				t.Errorf("vendor/lib populated = %v, want %v", gotLib, tt.wantLib)
+			}
+			_, err = os.Stat(filepath.Join(wtPath, "vendor", "lib", "inner", ".git"))
+			if gotInner := err == nil; gotInner != tt.wantInner {
+				t.Errorf("vendor/lib/inner populated = %v, want %v", gotInner, tt.wantInner)
+			}
+
+			if !tt.wantLib {
+				if len(result.Submodules) != 0 {
+					t.Errorf("Submodules = %+v, want none", result.Submodules)
+				}
+				return
+			}
+			want := SubmoduleResult{Path: "vendor/lib", Reference: filepath.Join(cfg.WorktreeSourceDir, "vendor", "lib")}
+			if len(result.Submodules) != 1 || result.Submodules[0] != want {
+				t.Errorf("Submodules = %+v, want [%+v]", result.Submodules, want)
+			}
+
+			// The objects were copied, so the source checkout is not needed afterwards
+			out := testutil.RunGit(t, filepath.Join(wtPath, "vendor", "lib"), "rev-parse", "--git-path", "objects/info/alternates")
+			if _, err := os.Stat(strings.TrimSpace(out)); !os.IsNotExist(err) {
+				t.Errorf("submodule should not borrow objects via alternates, stat err = %v", err)
+			}
+		})
+	}
+}
+
+func TestAddCommand_Sparse_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	for _, f := range []string{"services/api/main.go", "services/web/main.go", "libs/common/util.go"} {
+		path := filepath.Join(mainDir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testutil.RunGit(t, mainDir, "add", ".")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add services")
+
+	// Carried changes outside the cone still reach the new worktree
+	if err := os.WriteFile(filepath.Join(mainDir, "services", "web", "main.go"), []byte("package web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := NewDefaultAddCommand(result.Config, AddOptions{
+		CarryFrom: mainDir,
+		Sparse:    []string{"services/api/", "libs/common"},
+	})
+	addResult, err := cmd.Run("feat/api")
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if !slices.Equal(addResult.Sparse, []string{"services/api", "libs/common"}) {
+		t.Errorf("Sparse = %v", addResult.Sparse)
+	}
+
+	wtPath := filepath.Join(repoDir, "feat", "api")
+	for _, f := range []string{"services/api/main.go", "libs/common/util.go"} {
+		if _, err := os.Stat(filepath.Join(wtPath, f)); err != nil {
+			t.Errorf("%s should be checked out: %v", f, err)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(wtPath, "services", "web", "main.go"))
+	if err != nil || string(content) != "package web\n" {
+		t.Errorf("carried file outside the cone = %q, %v", content, err)
+	}
+
+	if out := testutil.RunGit(t, wtPath, "sparse-checkout", "list"); out != "libs/common\nservices/api\n" {
+		t.Errorf("sparse-checkout list = %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(mainDir, "services", "api", "main.go")); err != nil {
+		t.Errorf("main worktree should stay complete: %v", err)
+	}
+
+	listResult, err := NewDefaultListCommand(mainDir).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, wt := range listResult.Worktrees {
+		if want := wt.Path == wtPath; wt.Sparse != want {
+			t.Errorf("%s: Sparse = %v, want %v", wt.Path, wt.Sparse, want)
+		}
+	}
+}
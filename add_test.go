@@ -19,6 +19,7 @@ func TestAddCommand_Run(t *testing.T) {
 		sync         bool
 		carryFrom    string
 		filePatterns []string
+		scope        StashScope
 		setupFS      func(t *testing.T) *testutil.MockFS
 		setupGit     func(t *testing.T, captured *[]string) *testutil.MockGitExecutor
 		wantErr      bool
@@ -27,6 +28,8 @@ func TestAddCommand_Run(t *testing.T) {
 		checkPath    string
 		wantSynced   bool
 		wantCarried  bool
+		// wantUnmatched are the --file patterns expected to match nothing
+		wantUnmatched []string
 	}{
 		{
 			name:   "new_branch",
@@ -192,11 +195,7 @@ func TestAddCommand_Run(t *testing.T) {
 			filePatterns: []string{"*.go"},
 			setupFS: func(t *testing.T) *testutil.MockFS {
 				t.Helper()
-				return &testutil.MockFS{
-					GlobResults: map[string][]string{
-						"*.go": {"main.go", "util.go"},
-					},
-				}
+				return &testutil.MockFS{}
 			},
 			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
 				t.Helper()
@@ -209,6 +208,27 @@ func TestAddCommand_Run(t *testing.T) {
 			wantBFlag:  true,
 			wantSynced: true,
 		},
+		{
+			name:         "sync_file_pattern_without_changed_files",
+			branch:       "feature/sync-unmatched",
+			config:       &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			sync:         true,
+			filePatterns: []string{"*.go", "docs/**"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				return &testutil.MockGitExecutor{
+					CapturedArgs: captured,
+					HasChanges:   true,
+				}
+			},
+			wantBFlag:     true,
+			wantSynced:    true,
+			wantUnmatched: []string{"docs/**"},
+		},
 		{
 			name:   "sync_stash_push_error",
 			branch: "feature/sync-push-err",
@@ -326,6 +346,50 @@ func TestAddCommand_Run(t *testing.T) {
 			wantErr:     true,
 			errContains: "failed to apply changes",
 		},
+		{
+			name:      "carry_staged",
+			branch:    "feature/carry-staged",
+			config:    &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			carryFrom: "/repo/main",
+			scope:     StashScopeStaged,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				base := &testutil.MockGitExecutor{CapturedArgs: captured, HasChanges: true}
+				return &testutil.MockGitExecutor{
+					RunFunc: func(args ...string) ([]byte, error) {
+						// Staged stashes are applied with their index
+						if slices.Contains(args, "apply") && !slices.Contains(args, "--index") {
+							return nil, errors.New("stash applied without --index")
+						}
+						return base.Run(args...)
+					},
+				}
+			},
+			wantBFlag:   true,
+			wantCarried: true,
+		},
+		{
+			name:   "sync_scope_without_matching_changes",
+			branch: "feature/sync-untracked",
+			config: &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"},
+			sync:   true,
+			scope:  StashScopeUntracked,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{}
+			},
+			setupGit: func(t *testing.T, captured *[]string) *testutil.MockGitExecutor {
+				t.Helper()
+				// Status reports changes, but no untracked files exist
+				return &testutil.MockGitExecutor{CapturedArgs: captured, HasChanges: true}
+			},
+			wantBFlag:  true,
+			wantSynced: false,
+		},
 		{
 			name:   "remote_branch_single_remote",
 			branch: "feature/remote-only",
@@ -448,6 +512,7 @@ func TestAddCommand_Run(t *testing.T) {
 				Sync:         tt.sync,
 				CarryFrom:    tt.carryFrom,
 				FilePatterns: tt.filePatterns,
+				Scope:        tt.scope,
 			}
 
 			result, err := cmd.Run(tt.branch)
@@ -485,6 +550,10 @@ func TestAddCommand_Run(t *testing.T) {
 			if result.ChangesCarried != tt.wantCarried {
 				t.Errorf("ChangesCarried = %v, want %v", result.ChangesCarried, tt.wantCarried)
 			}
+
+			if !slices.Equal(result.UnmatchedPatterns, tt.wantUnmatched) {
+				t.Errorf("UnmatchedPatterns = %v, want %v", result.UnmatchedPatterns, tt.wantUnmatched)
+			}
 		})
 	}
 }
@@ -595,6 +664,232 @@ func TestAddCommand_Run_Lock(t *testing.T) {
 	}
 }
 
+func TestAddCommand_Run_Submodules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		config     SubmoduleMode
+		flag       SubmoduleMode
+		sourceHas  bool
+		updateErr  error
+		wantCall   string
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "config_init_with_reference",
+			config:     SubmoduleModeInit,
+			sourceHas:  true,
+			wantCall:   "submodule update --init --reference /repo/main/vendor/lib --dissociate -- vendor/lib",
+			wantStdout: "twig add: feature/sub (0 symlinks, 1 submodules)\n",
+		},
+		{
+			name:       "flag_recursive_without_source_checkout",
+			config:     SubmoduleModeNone,
+			flag:       SubmoduleModeRecursive,
+			wantCall:   "submodule update --init --recursive -- vendor/lib",
+			wantStdout: "twig add: feature/sub (0 symlinks, 1 submodules)\n",
+		},
+		{
+			name:       "flag_none_overrides_config",
+			config:     SubmoduleModeRecursive,
+			flag:       SubmoduleModeNone,
+			wantStdout: "twig add: feature/sub (0 symlinks)\n",
+		},
+		{
+			name:       "update_failure_is_a_warning",
+			config:     SubmoduleModeInit,
+			updateErr:  errors.New("exit status 1"),
+			wantCall:   "submodule update --init -- vendor/lib",
+			wantStdout: "twig add: feature/sub (0 symlinks)\n",
+			wantStderr: "warning: failed to initialize submodule vendor/lib: failed to update submodule: exit status 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			base := &testutil.MockGitExecutor{}
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					if args[0] == "-C" {
+						args = args[2:]
+					}
+					switch args[0] {
+					case "config":
+						return []byte("submodule.lib.path\nvendor/lib\x00"), nil
+					case "submodule":
+						calls = append(calls, strings.Join(args, " "))
+						return nil, tt.updateErr
+					}
+					return base.Run(args...)
+				},
+			}
+			mockFS := &testutil.MockFS{}
+			if tt.sourceHas {
+				mockFS.ExistingPaths = []string{"/repo/main/vendor/lib/.git"}
+			}
+
+			cmd := &AddCommand{
+				FS:  mockFS,
+				Git: &GitRunner{Executor: mockGit},
+				Config: &Config{
+					WorktreeSourceDir:   "/repo/main",
+					WorktreeDestBaseDir: "/repo/main-worktree",
+					Submodules:          tt.config,
+				},
+				Submodules: tt.flag,
+			}
+
+			result, err := cmd.Run("feature/sub")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantCall == "" {
+				if len(calls) > 0 {
+					t.Errorf("expected no submodule calls, got %v", calls)
+				}
+			} else if !slices.Equal(calls, []string{tt.wantCall}) {
+				t.Errorf("submodule calls = %v, want [%s]", calls, tt.wantCall)
+			}
+
+			formatted := result.Format(AddFormatOptions{})
+			if formatted.Stdout != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", formatted.Stdout, tt.wantStdout)
+			}
+			if formatted.Stderr != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", formatted.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestAddCommand_Run_Sparse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		config     []string
+		profile    []string
+		flag       []string
+		setErr     error
+		wantCalls  []string
+		wantSparse []string
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "config",
+			config:     []string{"services/api/", "libs"},
+			wantCalls:  []string{"worktree add --no-checkout -b feature/svc /repo/main-worktree/feature/svc", "sparse-checkout set --cone -- services/api libs", "checkout"},
+			wantSparse: []string{"services/api", "libs"},
+			wantStdout: "twig add: feature/svc (0 symlinks, sparse)\n",
+		},
+		{
+			name:       "profile_replaces_config",
+			config:     []string{"libs"},
+			profile:    []string{"services/web"},
+			wantCalls:  []string{"worktree add --no-checkout -b feature/svc /repo/main-worktree/feature/svc", "sparse-checkout set --cone -- services/web", "checkout"},
+			wantSparse: []string{"services/web"},
+			wantStdout: "twig add: feature/svc (0 symlinks, sparse, profile feature/*)\n",
+		},
+		{
+			name:       "flag_overrides_config",
+			config:     []string{"libs"},
+			flag:       []string{"tools"},
+			wantCalls:  []string{"worktree add --no-checkout -b feature/svc /repo/main-worktree/feature/svc", "sparse-checkout set --cone -- tools", "checkout"},
+			wantSparse: []string{"tools"},
+			wantStdout: "twig add: feature/svc (0 symlinks, sparse)\n",
+		},
+		{
+			name:       "not_configured",
+			wantCalls:  []string{"worktree add -b feature/svc /repo/main-worktree/feature/svc"},
+			wantStdout: "twig add: feature/svc (0 symlinks)\n",
+		},
+		{
+			name:   "set_failure_removes_worktree",
+			config: []string{"libs"},
+			setErr: errors.New("exit status 128"),
+			wantCalls: []string{
+				"worktree add --no-checkout -b feature/svc /repo/main-worktree/feature/svc",
+				"sparse-checkout set --cone -- libs",
+				"worktree remove -f -f /repo/main-worktree/feature/svc",
+			},
+			wantErr: "failed to set up sparse-checkout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			base := &testutil.MockGitExecutor{}
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					if args[0] == "-C" {
+						args = args[2:]
+					}
+					switch args[0] {
+					case "worktree":
+						if args[1] == "add" || args[1] == "remove" {
+							calls = append(calls, strings.Join(args, " "))
+						}
+					case "sparse-checkout":
+						calls = append(calls, strings.Join(args, " "))
+						return nil, tt.setErr
+					case "checkout":
+						calls = append(calls, strings.Join(args, " "))
+						return nil, nil
+					}
+					return base.Run(args...)
+				},
+			}
+
+			cfg := &Config{
+				WorktreeSourceDir:   "/repo/main",
+				WorktreeDestBaseDir: "/repo/main-worktree",
+				Sparse:              tt.config,
+			}
+			if tt.profile != nil {
+				cfg.Profiles = []Profile{{Match: "feature/*", Sparse: tt.profile}}
+			}
+			cmd := &AddCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: mockGit},
+				Config: cfg,
+				Sparse: tt.flag,
+			}
+
+			result, err := cmd.Run("feature/svc")
+
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("git calls = %q, want %q", calls, tt.wantCalls)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(result.Sparse, tt.wantSparse) {
+				t.Errorf("Sparse = %v, want %v", result.Sparse, tt.wantSparse)
+			}
+			if got := result.Format(AddFormatOptions{}).Stdout; got != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", got, tt.wantStdout)
+			}
+		})
+	}
+}
+
 func TestAddResult_Format(t *testing.T) {
 	t.Parallel()
 
@@ -675,23 +970,89 @@ func TestAddResult_Format(t *testing.T) {
 		}
 	})
 
+	t.Run("unmatched_file_patterns", func(t *testing.T) {
+		t.Parallel()
+
+		result := AddResult{
+			Branch:            "feature/test",
+			WorktreePath:      "/worktrees/feature/test",
+			UnmatchedPatterns: []string{"docs/**"},
+		}
+
+		got := result.Format(AddFormatOptions{})
+		want := "warning: --file pattern docs/** matched no changed files\n"
+
+		if got.Stderr != want {
+			t.Errorf("Stderr = %q, want %q", got.Stderr, want)
+		}
+	})
+
 	t.Run("verbose_output_carried", func(t *testing.T) {
 		t.Parallel()
 
-		carriedResult := AddResult{
+		tests := []struct {
+			name     string
+			scope    StashScope
+			filtered bool
+			synced   bool
+			want     string
+		}{
+			{name: "all", want: "Carried uncommitted changes (source is now clean)\n"},
+			{name: "staged", scope: StashScopeStaged,
+				want: "Carried staged changes (unstaged and untracked changes stay in source)\n"},
+			{name: "unstaged", scope: StashScopeUnstaged,
+				want: "Carried unstaged changes (staged and untracked changes stay in source)\n"},
+			{name: "untracked", scope: StashScopeUntracked,
+				want: "Carried untracked files (tracked changes stay in source)\n"},
+			{name: "file_patterns", filtered: true,
+				want: "Carried uncommitted changes matching --file (other changes stay in source)\n"},
+			{name: "synced_staged", scope: StashScopeStaged, synced: true,
+				want: "Synced staged changes\n"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				carriedResult := AddResult{
+					Branch:       "feature/test",
+					WorktreePath: "/worktrees/feature/test",
+					Symlinks: []SymlinkResult{
+						{Src: "/repo/.envrc", Dst: "/worktrees/feature/test/.envrc"},
+					},
+					ChangesCarried:  !tt.synced,
+					ChangesSynced:   tt.synced,
+					ChangesScope:    tt.scope,
+					ChangesFiltered: tt.filtered,
+				}
+
+				got := carriedResult.Format(AddFormatOptions{Verbose: true})
+				if !strings.Contains(got.Stdout, tt.want) {
+					t.Errorf("Stdout = %q, should contain %q", got.Stdout, tt.want)
+				}
+				if strings.Contains(got.Stdout, "source is now clean") && (tt.scope != StashScopeAll || tt.filtered) {
+					t.Errorf("Stdout = %q, should not claim the source is clean", got.Stdout)
+				}
+			})
+		}
+	})
+
+	t.Run("verbose_output_sparse", func(t *testing.T) {
+		t.Parallel()
+
+		sparseResult := AddResult{
 			Branch:       "feature/test",
 			WorktreePath: "/worktrees/feature/test",
-			Symlinks: []SymlinkResult{
-				{Src: "/repo/.envrc", Dst: "/worktrees/feature/test/.envrc"},
-			},
-			ChangesCarried: true,
+			Sparse:       []string{"services/api", "libs"},
 		}
 
-		got := carriedResult.Format(AddFormatOptions{Verbose: true})
-		wantContains := "Carried uncommitted changes (source is now clean)"
+		got := sparseResult.Format(AddFormatOptions{Verbose: true})
+		want := "Created worktree at /worktrees/feature/test\n" +
+			"Sparse checkout: services/api, libs\n" +
+			"twig add: feature/test (0 symlinks, sparse)\n"
 
-		if !strings.Contains(got.Stdout, wantContains) {
-			t.Errorf("Stdout = %q, should contain %q", got.Stdout, wantContains)
+		if got.Stdout != want {
+			t.Errorf("Stdout = %q, want %q", got.Stdout, want)
 		}
 	})
 }
@@ -702,6 +1063,8 @@ func TestAddCommand_createSymlinks(t *testing.T) {
 	tests := []struct {
 		name           string
 		targets        []string
+		excludes       []string
+		style          SymlinkStyle
 		setupFS        func(t *testing.T) *testutil.MockFS
 		wantErr        bool
 		errContains    string
@@ -768,6 +1131,58 @@ func TestAddCommand_createSymlinks(t *testing.T) {
 			wantSkipped:    1,
 			wantReasonLike: "already exists",
 		},
+		{
+			name:    "negated_pattern",
+			targets: []string{"config/*.local.*", "!config/db.local.yml"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						"config/*.local.*":    {"config/app.local.yml", "config/db.local.yml"},
+						"config/db.local.yml": {"config/db.local.yml"},
+					},
+				}
+			},
+			wantErr:     false,
+			wantCreated: 1,
+		},
+		{
+			name:     "excluded_pattern",
+			targets:  []string{".envrc", ".tool-versions"},
+			excludes: []string{".tool-versions"},
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						".envrc":         {".envrc"},
+						".tool-versions": {".tool-versions"},
+					},
+				}
+			},
+			wantErr:     false,
+			wantCreated: 1,
+		},
+		{
+			name:    "relative_style",
+			targets: []string{"config/app.local.yml"},
+			style:   SymlinkStyleRelative,
+			setupFS: func(t *testing.T) *testutil.MockFS {
+				t.Helper()
+				return &testutil.MockFS{
+					GlobResults: map[string][]string{
+						"config/app.local.yml": {"config/app.local.yml"},
+					},
+					SymlinkFunc: func(oldname, newname string) error {
+						if want := "../../src/config/app.local.yml"; oldname != want {
+							t.Errorf("symlink target = %q, want %q", oldname, want)
+						}
+						return nil
+					},
+				}
+			},
+			wantErr:     false,
+			wantCreated: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -777,10 +1192,11 @@ func TestAddCommand_createSymlinks(t *testing.T) {
 			mockFS := tt.setupFS(t)
 
 			cmd := &AddCommand{
-				FS: mockFS,
+				FS:     mockFS,
+				Config: &Config{SymlinkStyle: tt.style},
 			}
 
-			results, err := cmd.createSymlinks("/src", "/dst", tt.targets)
+			results, err := cmd.createSymlinks("/src", "/dst", tt.targets, tt.excludes)
 
 			if tt.wantErr {
 				if err == nil {
@@ -817,3 +1233,109 @@ func TestAddCommand_createSymlinks(t *testing.T) {
 		})
 	}
 }
+
+func TestAddCommand_Run_Profile(t *testing.T) {
+	t.Parallel()
+
+	config := &Config{
+		WorktreeSourceDir:   "/repo/main",
+		WorktreeDestBaseDir: "/repo/main-worktree",
+		Symlinks:            []string{".envrc", ".cache/**"},
+		Profiles: []Profile{
+			{Name: "agent", Match: "agent/*", Lock: true, LockReason: "agent", WorktreeDestBaseDir: "/repo/agents"},
+			{Match: "docs/**", SkipSymlinks: []string{".cache/**"}, Symlinks: []string{".vale.ini"}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		branch       string
+		lock         bool
+		lockReason   string
+		wantProfile  string
+		wantPath     string
+		wantLock     bool
+		wantReason   string
+		wantPatterns []string
+	}{
+		{
+			name:         "agent_profile_locks_and_relocates",
+			branch:       "agent/task-1",
+			wantProfile:  "agent",
+			wantPath:     "/repo/agents/agent/task-1",
+			wantLock:     true,
+			wantReason:   "agent",
+			wantPatterns: []string{".envrc", ".cache/**"},
+		},
+		{
+			name:         "explicit_lock_reason_wins",
+			branch:       "agent/task-2",
+			lock:         true,
+			lockReason:   "manual",
+			wantProfile:  "agent",
+			wantPath:     "/repo/agents/agent/task-2",
+			wantLock:     true,
+			wantReason:   "manual",
+			wantPatterns: []string{".envrc", ".cache/**"},
+		},
+		{
+			name:         "docs_profile_adjusts_symlinks",
+			branch:       "docs/guide/intro",
+			wantProfile:  "docs/**",
+			wantPath:     "/repo/main-worktree/docs/guide/intro",
+			wantPatterns: []string{".envrc", ".vale.ini"},
+		},
+		{
+			name:         "no_profile",
+			branch:       "feat/x",
+			wantPath:     "/repo/main-worktree/feat/x",
+			wantPatterns: []string{".envrc", ".cache/**"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured []string
+			var globbed []string
+
+			mockFS := &testutil.MockFS{
+				GlobFunc: func(dir, pattern string) ([]string, error) {
+					globbed = append(globbed, pattern)
+					return nil, nil
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{CapturedArgs: &captured}
+
+			cmd := &AddCommand{
+				FS:         mockFS,
+				Git:        &GitRunner{Executor: mockGit},
+				Config:     config,
+				Lock:       tt.lock,
+				LockReason: tt.lockReason,
+			}
+
+			result, err := cmd.Run(tt.branch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Profile != tt.wantProfile {
+				t.Errorf("Profile = %q, want %q", result.Profile, tt.wantProfile)
+			}
+			if result.WorktreePath != tt.wantPath {
+				t.Errorf("WorktreePath = %q, want %q", result.WorktreePath, tt.wantPath)
+			}
+			if got := slices.Contains(captured, "--lock"); got != tt.wantLock {
+				t.Errorf("--lock flag: got %v, want %v; args: %v", got, tt.wantLock, captured)
+			}
+			if tt.wantReason != "" && !slices.Contains(captured, tt.wantReason) {
+				t.Errorf("expected reason %q in args, got: %v", tt.wantReason, captured)
+			}
+			if !slices.Equal(globbed, tt.wantPatterns) {
+				t.Errorf("symlink patterns = %v, want %v", globbed, tt.wantPatterns)
+			}
+		})
+	}
+}
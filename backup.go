@@ -0,0 +1,417 @@
+package twig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// BackupRefPrefix is the ref namespace for backups taken before forced removals.
+// Each backup is stored as <prefix><branch>/<timestamp>.
+const BackupRefPrefix = "refs/twig/backup/"
+
+// backupTimeLayout formats backup timestamps (UTC) in ref names.
+const backupTimeLayout = "20060102-150405"
+
+// maxTimedRefAttempts bounds the suffixes tried when entries created in
+// the same second collide.
+const maxTimedRefAttempts = 100
+
+// Backup is a snapshot of a worktree taken before it was forcibly removed.
+//
+// The backup ref points at a stash-like commit whose first parent is the
+// branch tip, so both the commits and the uncommitted changes of a deleted
+// branch stay reachable. Clean worktrees get a commit with the tip's tree
+// and no other parents.
+type Backup struct {
+	Name   string // <branch>/<timestamp>, the ref without BackupRefPrefix
+	Branch string
+	Time   time.Time
+	Commit string
+	Tip    string // Branch tip when the backup was taken
+	Dirty  bool   // Holds uncommitted or untracked changes
+}
+
+// Ref returns the full ref name of the backup.
+func (b Backup) Ref() string {
+	return BackupRefPrefix + b.Name
+}
+
+// createBackup snapshots the worktree at wtPath and the tip of branch.
+// wtPath may be empty when the worktree directory no longer exists,
+// in which case only the branch tip is recorded.
+// Backups taken in the same second get a numeric suffix instead of
+// replacing each other.
+func createBackup(git *GitRunner, branch, wtPath string, now time.Time) (Backup, error) {
+	tip, err := git.revParse("--verify", RefsHeadsPrefix+branch)
+	if err != nil {
+		return Backup{}, fmt.Errorf("failed to resolve branch %s: %w", branch, err)
+	}
+
+	backup := Backup{
+		Branch: branch,
+		Time:   now.UTC().Truncate(time.Second),
+		Tip:    tip,
+	}
+	for n := 1; ; n++ {
+		backup.Name = timedRefName(branch, now, n)
+		err := writeBackup(git, &backup, wtPath)
+		if !errors.Is(err, errRefExists) || n == maxTimedRefAttempts {
+			return backup, err
+		}
+	}
+}
+
+// writeBackup stores the snapshot of backup under its ref.
+func writeBackup(git *GitRunner, backup *Backup, wtPath string) error {
+	message := "twig backup: " + backup.Branch
+
+	if wtPath != "" {
+		// Untracked files are saved as well; ignored files are not
+		hash, err := git.InDir(wtPath).StashPush(message, nil,
+			WithKeepChanges(), WithStashRef(backup.Ref()))
+		switch {
+		case errors.Is(err, errNoLocalChanges):
+		case err != nil:
+			return err
+		default:
+			backup.Commit = hash
+			backup.Dirty = true
+			return nil
+		}
+	}
+
+	hash, err := git.committer().commitTree(backup.Tip+"^{tree}", message, backup.Tip)
+	if err != nil {
+		return err
+	}
+	if err := git.createRef(message, backup.Ref(), hash); err != nil {
+		return err
+	}
+	backup.Commit = hash
+	return nil
+}
+
+// listBackups returns all backups, ordered by branch and then by time.
+func listBackups(git *GitRunner) ([]Backup, error) {
+	out, err := git.Run(GitCmdForEachRef,
+		"--format=%(refname)%00%(objectname)%00%(parent)%00", BackupRefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []Backup
+	for _, record := range splitRecords(out) {
+		fields := strings.Split(record, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		b, ok := parseBackupRef(fields[0])
+		parents := strings.Fields(fields[2])
+		if !ok || len(parents) == 0 {
+			continue
+		}
+		b.Commit = fields[1]
+		b.Tip = parents[0]
+		b.Dirty = len(parents) > 1
+		backups = append(backups, b)
+	}
+	return backups, nil
+}
+
+// parseBackupRef extracts the branch and time from a backup ref name.
+func parseBackupRef(ref string) (Backup, bool) {
+	name, branch, t, ok := parseTimedRef(BackupRefPrefix, ref)
+	if !ok {
+		return Backup{}, false
+	}
+	return Backup{Name: name, Branch: branch, Time: t}, true
+}
+
+// parseTimedRef splits a ref named <prefix><branch>/<timestamp> into its
+// name (the ref without prefix), branch and time.
+func parseTimedRef(prefix, ref string) (name, branch string, t time.Time, ok bool) {
+	name, ok = strings.CutPrefix(ref, prefix)
+	if !ok {
+		return "", "", time.Time{}, false
+	}
+	i := strings.LastIndex(name, "/")
+	if i <= 0 {
+		return "", "", time.Time{}, false
+	}
+	stamp := name[i+1:]
+	// Entries created in the same second carry a "-<n>" suffix
+	if len(stamp) > len(backupTimeLayout) {
+		suffix, ok := strings.CutPrefix(stamp[len(backupTimeLayout):], "-")
+		if n, err := strconv.Atoi(suffix); !ok || err != nil || n < 2 || strconv.Itoa(n) != suffix {
+			return "", "", time.Time{}, false
+		}
+		stamp = stamp[:len(backupTimeLayout)]
+	}
+	t, err := time.Parse(backupTimeLayout, stamp)
+	if err != nil {
+		return "", "", time.Time{}, false
+	}
+	return name, name[:i], t, true
+}
+
+// timedRefName returns the n-th candidate name for an entry of branch created
+// at now: <branch>/<timestamp>, then <branch>/<timestamp>-2 and so on.
+func timedRefName(branch string, now time.Time, n int) string {
+	name := branch + "/" + now.UTC().Format(backupTimeLayout)
+	if n > 1 {
+		name += "-" + strconv.Itoa(n)
+	}
+	return name
+}
+
+// timedRefNewer reports whether the entry named a, created at ta, is newer
+// than the entry named b, created at tb. Entries created in the same second
+// are ordered by their "-<n>" suffix.
+func timedRefNewer(a string, ta time.Time, b string, tb time.Time) bool {
+	if !ta.Equal(tb) {
+		return ta.After(tb)
+	}
+	return timedRefSeq(a) > timedRefSeq(b)
+}
+
+// timedRefSeq returns n of a name created by timedRefName.
+func timedRefSeq(name string) int {
+	stamp := name[strings.LastIndex(name, "/")+1:]
+	if len(stamp) > len(backupTimeLayout) {
+		if n, err := strconv.Atoi(stamp[len(backupTimeLayout)+1:]); err == nil {
+			return n
+		}
+	}
+	return 1
+}
+
+// ParseAge parses a duration for age filters. In addition to the units of
+// time.ParseDuration it accepts whole days ("7d") and weeks ("2w").
+func ParseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if v, err := strconv.Atoi(n); err == nil && v >= 0 {
+				return time.Duration(v) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 12h, 7d, 2w)", s)
+	}
+	return d, nil
+}
+
+// BackupsCommand lists, restores and prunes backups of removed worktrees.
+type BackupsCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+}
+
+// BackupPruneOptions configures the prune operation.
+type BackupPruneOptions struct {
+	OlderThan time.Duration
+	DryRun    bool
+	Now       time.Time // Zero means the current time
+}
+
+// NewBackupsCommand creates a BackupsCommand with explicit dependencies (for testing).
+func NewBackupsCommand(fs FileSystem, git *GitRunner, cfg *Config) *BackupsCommand {
+	return &BackupsCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+	}
+}
+
+// NewDefaultBackupsCommand creates a BackupsCommand with production defaults.
+func NewDefaultBackupsCommand(cfg *Config) *BackupsCommand {
+	return NewBackupsCommand(osFS{}, NewGitRunner(cfg.WorktreeSourceDir), cfg)
+}
+
+// BackupListResult holds the result of a backups list operation.
+type BackupListResult struct {
+	Backups []Backup
+}
+
+// BackupRestoreResult holds the result of a backups restore operation.
+type BackupRestoreResult struct {
+	Backup        Backup
+	BranchCreated bool // The branch was recreated at the backup's tip
+	Add           AddResult
+}
+
+// BackupPruneResult holds the result of a backups prune operation.
+type BackupPruneResult struct {
+	Pruned []Backup
+	DryRun bool
+}
+
+// Format formats the BackupListResult for display.
+func (r BackupListResult) Format(opts ListFormatOptions) FormatResult {
+	var buf bytes.Buffer
+	if opts.Quiet {
+		for _, b := range r.Backups {
+			fmt.Fprintln(&buf, b.Name)
+		}
+		return FormatResult{Stdout: buf.String()}
+	}
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, b := range r.Backups {
+		status := "clean"
+		if b.Dirty {
+			status = "uncommitted changes"
+		}
+		fmt.Fprintf(w, "%s\t%s %s\n", b.Name, shortHash(b.Tip), status)
+	}
+	w.Flush()
+	return FormatResult{Stdout: buf.String()}
+}
+
+// Format formats the BackupRestoreResult for display.
+func (r BackupRestoreResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+	if opts.Verbose {
+		if r.BranchCreated {
+			fmt.Fprintf(&stdout, "Recreated branch %s at %s\n", r.Backup.Branch, shortHash(r.Backup.Tip))
+		}
+		fmt.Fprintf(&stdout, "Created worktree at %s\n", r.Add.WorktreePath)
+		if r.Backup.Dirty {
+			stdout.WriteString("Restored uncommitted changes\n")
+		}
+	}
+	fmt.Fprintf(&stdout, "twig backups: restored %s into %s\n", r.Backup.Name, r.Add.WorktreePath)
+
+	// Symlink warnings of the new worktree
+	stderr := r.Add.Format(AddFormatOptions{}).Stderr
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr}
+}
+
+// Format formats the BackupPruneResult for display.
+func (r BackupPruneResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+	for _, b := range r.Pruned {
+		if r.DryRun {
+			fmt.Fprintf(&stdout, "Would prune backup: %s\n", b.Name)
+		} else {
+			fmt.Fprintf(&stdout, "twig backups: pruned %s\n", b.Name)
+		}
+	}
+	if len(r.Pruned) == 0 {
+		stdout.WriteString("No backups to prune\n")
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// List returns all backups, optionally limited to branch.
+func (c *BackupsCommand) List(branch string) (BackupListResult, error) {
+	backups, err := listBackups(c.Git)
+	if err != nil {
+		return BackupListResult{}, err
+	}
+	var result BackupListResult
+	for _, b := range backups {
+		if branch == "" || b.Branch == branch {
+			result.Backups = append(result.Backups, b)
+		}
+	}
+	return result, nil
+}
+
+// Restore recreates the worktree of a backup: the branch is recreated at
+// the backed up tip if it no longer exists, a worktree is added like twig
+// add does, and the uncommitted changes are applied with their staged state.
+// name is a backup name or a branch, which selects its latest backup.
+// The backup itself is kept until pruned.
+func (c *BackupsCommand) Restore(name string) (BackupRestoreResult, error) {
+	var result BackupRestoreResult
+
+	backup, err := c.find(name)
+	if err != nil {
+		return result, err
+	}
+	result.Backup = backup
+
+	if c.Git.LocalBranchExists(backup.Branch) {
+		tip, err := c.Git.revParse("--verify", RefsHeadsPrefix+backup.Branch)
+		if err != nil {
+			return result, err
+		}
+		if tip != backup.Tip {
+			return result, fmt.Errorf("branch %s already exists at a different commit, delete or rename it first", backup.Branch)
+		}
+	} else {
+		if _, err := c.Git.Run(GitCmdBranch, backup.Branch, backup.Tip); err != nil {
+			return result, fmt.Errorf("failed to recreate branch %s: %w", backup.Branch, err)
+		}
+		result.BranchCreated = true
+	}
+
+	result.Add, err = NewAddCommand(c.FS, c.Git, c.Config, AddOptions{}).Run(backup.Branch)
+	if err != nil {
+		return result, err
+	}
+
+	if backup.Dirty {
+		if _, err := c.Git.InDir(result.Add.WorktreePath).Run(GitCmdStash, GitStashApply, "--index", backup.Commit); err != nil {
+			return result, fmt.Errorf("failed to restore uncommitted changes into %s: %w", result.Add.WorktreePath, err)
+		}
+	}
+
+	return result, nil
+}
+
+// find returns the backup called name, or the latest backup of branch name.
+func (c *BackupsCommand) find(name string) (Backup, error) {
+	backups, err := listBackups(c.Git)
+	if err != nil {
+		return Backup{}, err
+	}
+	var latest *Backup
+	for i, b := range backups {
+		if b.Name == name {
+			return b, nil
+		}
+		if b.Branch == name && (latest == nil || timedRefNewer(b.Name, b.Time, latest.Name, latest.Time)) {
+			latest = &backups[i]
+		}
+	}
+	if latest == nil {
+		return Backup{}, fmt.Errorf("no backup found for %q", name)
+	}
+	return *latest, nil
+}
+
+// Prune deletes backups older than opts.OlderThan.
+func (c *BackupsCommand) Prune(opts BackupPruneOptions) (BackupPruneResult, error) {
+	result := BackupPruneResult{DryRun: opts.DryRun}
+
+	backups, err := listBackups(c.Git)
+	if err != nil {
+		return result, err
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	for _, b := range backups {
+		if now.Sub(b.Time) < opts.OlderThan {
+			continue
+		}
+		if !opts.DryRun {
+			if _, err := c.Git.Run(GitCmdUpdateRef, "-d", b.Ref()); err != nil {
+				return result, fmt.Errorf("failed to delete backup %s: %w", b.Name, err)
+			}
+		}
+		result.Pruned = append(result.Pruned, b)
+	}
+	return result, nil
+}
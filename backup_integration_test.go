@@ -0,0 +1,183 @@
+//go:build integration
+
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestBackup_Integration(t *testing.T) {
+	t.Parallel()
+
+	// setup creates feat/wip with an unmerged commit and returns the loaded
+	// config, the worktree path and the branch tip.
+	setup := func(t *testing.T) (*Config, string, string) {
+		t.Helper()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewDefaultAddCommand(result.Config, AddOptions{}).Run("feat/wip"); err != nil {
+			t.Fatal(err)
+		}
+		wtPath := filepath.Join(repoDir, "feat", "wip")
+
+		for name, content := range map[string]string{".gitignore": "*.log\n", "tracked.txt": "v1\n"} {
+			if err := os.WriteFile(filepath.Join(wtPath, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, wtPath, "add", ".")
+		testutil.RunGit(t, wtPath, "commit", "-m", "unmerged work")
+		tip := strings.TrimSpace(testutil.RunGit(t, wtPath, "rev-parse", "HEAD"))
+		return result.Config, wtPath, tip
+	}
+
+	t.Run("ForcedRemoveAndRestore", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, wtPath, tip := setup(t)
+		for name, content := range map[string]string{
+			"tracked.txt": "v2\n", "staged.txt": "staged\n", "untracked.txt": "new\n", "debug.log": "ignored\n",
+		} {
+			if err := os.WriteFile(filepath.Join(wtPath, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, wtPath, "add", "staged.txt")
+
+		removed, err := NewDefaultRemoveCommand(cfg).Run("feat/wip", cfg.WorktreeSourceDir, RemoveOptions{
+			Force:  WorktreeForceLevelUnclean,
+			Backup: true,
+		})
+		if err != nil {
+			t.Fatalf("remove failed: %v", err)
+		}
+		if !strings.HasPrefix(removed.Backup, "feat/wip/") {
+			t.Errorf("Backup = %q", removed.Backup)
+		}
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Fatal("worktree should be removed")
+		}
+
+		backups := NewDefaultBackupsCommand(cfg)
+		list, err := backups.List("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Backups) != 1 || list.Backups[0].Name != removed.Backup ||
+			list.Backups[0].Tip != tip || !list.Backups[0].Dirty {
+			t.Fatalf("backups = %+v, want one dirty backup at %s", list.Backups, tip)
+		}
+		// The backup does not show up as a twig stash
+		if out := testutil.RunGit(t, cfg.WorktreeSourceDir, "for-each-ref", StashRefPrefix); out != "" {
+			t.Errorf("stash refs = %q", out)
+		}
+
+		restored, err := backups.Restore("feat/wip")
+		if err != nil {
+			t.Fatalf("restore failed: %v", err)
+		}
+		if !restored.BranchCreated || restored.Add.WorktreePath != wtPath {
+			t.Errorf("restored = %+v", restored)
+		}
+		if got := strings.TrimSpace(testutil.RunGit(t, wtPath, "rev-parse", "HEAD")); got != tip {
+			t.Errorf("HEAD = %s, want %s", got, tip)
+		}
+		status := testutil.RunGit(t, wtPath, "status", "--porcelain")
+		for _, want := range []string{" M tracked.txt", "A  staged.txt", "?? untracked.txt"} {
+			if !strings.Contains(status, want) {
+				t.Errorf("status should contain %q, got:\n%s", want, status)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(wtPath, "debug.log")); !os.IsNotExist(err) {
+			t.Error("ignored files should not be backed up")
+		}
+	})
+
+	t.Run("PrunableRecordsTip", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, wtPath, tip := setup(t)
+		if err := os.RemoveAll(wtPath); err != nil {
+			t.Fatal(err)
+		}
+
+		removed, err := NewDefaultRemoveCommand(cfg).Run("feat/wip", cfg.WorktreeSourceDir, RemoveOptions{
+			Force:  WorktreeForceLevelUnclean,
+			Backup: true,
+		})
+		if err != nil {
+			t.Fatalf("remove failed: %v", err)
+		}
+
+		list, err := NewDefaultBackupsCommand(cfg).List("feat/wip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Backups) != 1 || list.Backups[0].Name != removed.Backup ||
+			list.Backups[0].Tip != tip || list.Backups[0].Dirty {
+			t.Fatalf("backups = %+v, want one clean backup at %s", list.Backups, tip)
+		}
+	})
+
+	t.Run("ForcedCleanOnly", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := setup(t)
+
+		// Without --force the unmerged branch is skipped and nothing is backed up
+		result, err := NewDefaultCleanCommand(cfg).Run(cfg.WorktreeSourceDir, CleanOptions{Yes: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Removed) != 0 {
+			t.Fatalf("Removed = %+v", result.Removed)
+		}
+
+		result, err = NewDefaultCleanCommand(cfg).Run(cfg.WorktreeSourceDir, CleanOptions{
+			Yes:   true,
+			Force: WorktreeForceLevelUnclean,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Removed) != 1 || result.Removed[0].Backup == "" {
+			t.Fatalf("Removed = %+v, want one backed up removal", result.Removed)
+		}
+
+		list, err := NewDefaultBackupsCommand(cfg).List("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Backups) != 1 {
+			t.Errorf("backups = %+v", list.Backups)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := setup(t)
+		disabled := false
+		cfg.Backup = &disabled
+
+		result, err := NewDefaultCleanCommand(cfg).Run(cfg.WorktreeSourceDir, CleanOptions{
+			Yes:   true,
+			Force: WorktreeForceLevelUnclean,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Removed) != 1 || result.Removed[0].Backup != "" {
+			t.Fatalf("Removed = %+v, want one removal without backup", result.Removed)
+		}
+	})
+}
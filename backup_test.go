@@ -0,0 +1,401 @@
+package twig
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// backupRefs is for-each-ref output for a clean backup of feat/a and
+// a dirty backup of feat/b/c.
+const backupRefs = "refs/twig/backup/feat/a/20260101-120000\x00aaaa1111\x00tipa1234567\x00\n" +
+	"refs/twig/backup/feat/b/c/20260110-080000\x00bbbb2222\x00tipb1234567 idx00000 untr0000\x00\n" +
+	"refs/twig/backup/feat/b/c/20260112-080000\x00cccc3333\x00tipc1234567 idx00000\x00\n" +
+	"refs/twig/backup/malformed\x00dddd4444\x00tipd1234567\x00\n"
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "7d", want: 7 * 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "1.5d", wantErr: true},
+		{in: "-1h", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseAge(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseAge(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseAge(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackupsCommand_List(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			return []byte(backupRefs), nil
+		},
+	}
+	cmd := NewBackupsCommand(&testutil.MockFS{}, &GitRunner{Executor: mockGit}, &Config{})
+
+	result, err := cmd.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Backup{
+		{Name: "feat/a/20260101-120000", Branch: "feat/a", Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+			Commit: "aaaa1111", Tip: "tipa1234567"},
+		{Name: "feat/b/c/20260110-080000", Branch: "feat/b/c", Time: time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC),
+			Commit: "bbbb2222", Tip: "tipb1234567", Dirty: true},
+		{Name: "feat/b/c/20260112-080000", Branch: "feat/b/c", Time: time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC),
+			Commit: "cccc3333", Tip: "tipc1234567", Dirty: true},
+	}
+	if !slices.Equal(result.Backups, want) {
+		t.Errorf("Backups = %+v, want %+v", result.Backups, want)
+	}
+
+	result, err = cmd.List("feat/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Backups) != 1 || result.Backups[0].Branch != "feat/a" {
+		t.Errorf("filtered Backups = %+v", result.Backups)
+	}
+
+	formatted := BackupListResult{Backups: want[:2]}.Format(ListFormatOptions{})
+	wantStdout := "feat/a/20260101-120000    tipa123 clean\n" +
+		"feat/b/c/20260110-080000  tipb123 uncommitted changes\n"
+	if formatted.Stdout != wantStdout {
+		t.Errorf("Stdout = %q, want %q", formatted.Stdout, wantStdout)
+	}
+	formatted = BackupListResult{Backups: want[:2]}.Format(ListFormatOptions{Quiet: true})
+	if formatted.Stdout != "feat/a/20260101-120000\nfeat/b/c/20260110-080000\n" {
+		t.Errorf("quiet Stdout = %q", formatted.Stdout)
+	}
+}
+
+func TestBackupsCommand_Restore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		arg         string
+		branchTip   string // empty: branch does not exist
+		wantBackup  string
+		wantCalls   []string
+		wantCreated bool
+		wantErr     string
+	}{
+		{
+			name:        "latest_for_branch",
+			arg:         "feat/b/c",
+			wantBackup:  "feat/b/c/20260112-080000",
+			wantCreated: true,
+			wantCalls: []string{
+				"branch feat/b/c tipc1234567",
+				"worktree add /repo/main-worktree/feat/b/c feat/b/c",
+				"stash apply --index cccc3333",
+			},
+		},
+		{
+			name:       "by_name_clean",
+			arg:        "feat/a/20260101-120000",
+			branchTip:  "tipa1234567",
+			wantBackup: "feat/a/20260101-120000",
+			wantCalls: []string{
+				"worktree add /repo/main-worktree/feat/a feat/a",
+			},
+		},
+		{
+			name:      "branch_moved",
+			arg:       "feat/a",
+			branchTip: "other000",
+			wantErr:   "already exists at a different commit",
+		},
+		{
+			name:    "unknown",
+			arg:     "feat/z",
+			wantErr: `no backup found for "feat/z"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			base := &testutil.MockGitExecutor{}
+			branchTip := tt.branchTip
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					if args[0] == "-C" {
+						args = args[2:]
+					}
+					switch {
+					case args[0] == "for-each-ref" && slices.Contains(args, BackupRefPrefix):
+						return []byte(backupRefs), nil
+					case args[0] == "rev-parse" && len(args) == 3 && strings.HasPrefix(args[2], RefsHeadsPrefix):
+						if branchTip == "" {
+							return nil, errors.New("exit status 128")
+						}
+						return []byte(branchTip + "\n"), nil
+					case args[0] == "branch" && len(args) == 3:
+						branchTip = args[2]
+						calls = append(calls, strings.Join(args, " "))
+						return nil, nil
+					case args[0] == "worktree" && args[1] == "add",
+						args[0] == "stash":
+						calls = append(calls, strings.Join(args, " "))
+						return nil, nil
+					}
+					return base.Run(args...)
+				},
+			}
+			cfg := &Config{WorktreeSourceDir: "/repo/main", WorktreeDestBaseDir: "/repo/main-worktree"}
+			cmd := NewBackupsCommand(&testutil.MockFS{}, &GitRunner{Executor: mockGit}, cfg)
+
+			result, err := cmd.Restore(tt.arg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(calls) > 0 {
+					t.Errorf("expected no changes, got %v", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Backup.Name != tt.wantBackup || result.BranchCreated != tt.wantCreated {
+				t.Errorf("result = %+v", result)
+			}
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBackupsCommand_Find_SameSecond(t *testing.T) {
+	t.Parallel()
+
+	// for-each-ref sorts by name, so "-10" comes before "-2"
+	refs := "refs/twig/backup/feat/a/20260101-115959-3\x00aaaa0000\x00tipa1234567\x00\n" +
+		"refs/twig/backup/feat/a/20260101-120000\x00aaaa1111\x00tipa1234567\x00\n" +
+		"refs/twig/backup/feat/a/20260101-120000-10\x00aaaa0010\x00tipa1234567\x00\n" +
+		"refs/twig/backup/feat/a/20260101-120000-2\x00aaaa0002\x00tipa1234567\x00\n"
+	mockGit := &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			return []byte(refs), nil
+		},
+	}
+	cmd := NewBackupsCommand(&testutil.MockFS{}, &GitRunner{Executor: mockGit}, &Config{})
+
+	b, err := cmd.find("feat/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name != "feat/a/20260101-120000-10" {
+		t.Errorf("find() = %s, want the last backup of the latest second", b.Name)
+	}
+}
+
+func TestBackupsCommand_Prune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 20, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		opts        BackupPruneOptions
+		wantPruned  []string
+		wantDeleted []string
+		wantStdout  string
+	}{
+		{
+			name:        "older_than_10d",
+			opts:        BackupPruneOptions{OlderThan: 10 * 24 * time.Hour, Now: now},
+			wantPruned:  []string{"feat/a/20260101-120000", "feat/b/c/20260110-080000"},
+			wantDeleted: []string{"refs/twig/backup/feat/a/20260101-120000", "refs/twig/backup/feat/b/c/20260110-080000"},
+			wantStdout:  "twig backups: pruned feat/a/20260101-120000\ntwig backups: pruned feat/b/c/20260110-080000\n",
+		},
+		{
+			name:       "dry_run",
+			opts:       BackupPruneOptions{OlderThan: 15 * 24 * time.Hour, Now: now, DryRun: true},
+			wantPruned: []string{"feat/a/20260101-120000"},
+			wantStdout: "Would prune backup: feat/a/20260101-120000\n",
+		},
+		{
+			name:       "nothing_old_enough",
+			opts:       BackupPruneOptions{OlderThan: 30 * 24 * time.Hour, Now: now},
+			wantStdout: "No backups to prune\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var deleted []string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					if args[2] == "update-ref" {
+						deleted = append(deleted, args[4])
+						return nil, nil
+					}
+					return []byte(backupRefs), nil
+				},
+			}
+			cmd := NewBackupsCommand(&testutil.MockFS{}, &GitRunner{Executor: mockGit}, &Config{})
+
+			result, err := cmd.Prune(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var pruned []string
+			for _, b := range result.Pruned {
+				pruned = append(pruned, b.Name)
+			}
+			if !slices.Equal(pruned, tt.wantPruned) {
+				t.Errorf("pruned = %v, want %v", pruned, tt.wantPruned)
+			}
+			if !slices.Equal(deleted, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if got := result.Format(FormatOptions{}).Stdout; got != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got, tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestCreateBackup_SameSecond(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		existing int // backups already taken in the same second
+		wantName string
+		wantErr  bool
+	}{
+		{name: "first", wantName: "feat/a/20260101-120000"},
+		{name: "second", existing: 1, wantName: "feat/a/20260101-120000-2"},
+		{name: "third", existing: 2, wantName: "feat/a/20260101-120000-3"},
+		{name: "exhausted", existing: maxTimedRefAttempts, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var refs []string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					switch args[2] {
+					case "update-ref":
+						refs = append(refs, args[5])
+						// An empty old value must be passed so git refuses to overwrite
+						if args[7] != "" {
+							t.Errorf("update-ref old value = %q, want empty", args[7])
+						}
+						if len(refs) <= tt.existing {
+							return nil, &exec.ExitError{Stderr: []byte("fatal: cannot lock ref '" + args[5] + "': reference already exists\n")}
+						}
+					case "rev-parse":
+						return []byte("tip1234567\n"), nil
+					case "commit-tree":
+						return []byte("bak1234567\n"), nil
+					}
+					return nil, nil
+				},
+			}
+
+			backup, err := createBackup(&GitRunner{Executor: mockGit}, "feat/a", "", now)
+			if tt.wantErr {
+				if !errors.Is(err, errRefExists) {
+					t.Fatalf("err = %v, want errRefExists", err)
+				}
+				if len(refs) != maxTimedRefAttempts {
+					t.Errorf("tried %d refs, want %d", len(refs), maxTimedRefAttempts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if backup.Name != tt.wantName || backup.Commit != "bak1234567" || backup.Tip != "tip1234567" {
+				t.Errorf("backup = %+v, want name %s", backup, tt.wantName)
+			}
+			if len(refs) != tt.existing+1 {
+				t.Errorf("update-ref calls = %v", refs)
+			}
+		})
+	}
+}
+
+func TestParseTimedRef(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ref        string
+		wantBranch string
+		wantOK     bool
+	}{
+		{ref: "refs/twig/backup/feat/a/20260101-120000", wantBranch: "feat/a", wantOK: true},
+		{ref: "refs/twig/backup/feat/a/20260101-120000-2", wantBranch: "feat/a", wantOK: true},
+		{ref: "refs/twig/backup/feat/a/20260101-120000-12", wantBranch: "feat/a", wantOK: true},
+		{ref: "refs/twig/backup/feat/a/20260101-120000-1"},
+		{ref: "refs/twig/backup/feat/a/20260101-120000-02"},
+		{ref: "refs/twig/backup/feat/a/20260101-120000-x"},
+		{ref: "refs/twig/backup/feat/a/20260101-1200002"},
+		{ref: "refs/twig/backup/20260101-120000"},
+		{ref: "refs/twig/trash/feat/a/20260101-120000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			t.Parallel()
+
+			name, branch, ts, ok := parseTimedRef(BackupRefPrefix, tt.ref)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if branch != tt.wantBranch || name != strings.TrimPrefix(tt.ref, BackupRefPrefix) ||
+				!ts.Equal(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("got %q, %q, %v", name, branch, ts)
+			}
+		})
+	}
+}
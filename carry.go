@@ -0,0 +1,275 @@
+package twig
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// carryPatchPrefix names the temporary patches used to check for conflicts,
+// stored in the target worktree's git directory. Each carry adds a random
+// suffix so concurrent carries into the same target do not collide.
+const carryPatchPrefix = "twig-carry-"
+
+// CarryCommand moves or copies uncommitted changes into an existing worktree.
+type CarryCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+}
+
+// CarryOptions configures the carry operation.
+type CarryOptions struct {
+	// From is the resolved path of the worktree to take changes from.
+	From string
+	// Copy keeps the changes in the source worktree (like add --sync).
+	Copy bool
+	// FilePatterns limits the carried files (empty means all changes).
+	FilePatterns []string
+}
+
+// NewCarryCommand creates a CarryCommand with explicit dependencies.
+func NewCarryCommand(fs FileSystem, git *GitRunner, cfg *Config) *CarryCommand {
+	return &CarryCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+	}
+}
+
+// NewDefaultCarryCommand creates a CarryCommand with production defaults.
+func NewDefaultCarryCommand(cfg *Config) *CarryCommand {
+	return NewCarryCommand(osFS{}, NewGitRunner(cfg.WorktreeSourceDir), cfg)
+}
+
+// CarryResult holds the result of a carry operation.
+type CarryResult struct {
+	Branch       string // Target branch
+	WorktreePath string // Target worktree path
+	SourcePath   string
+	Files        []string // Carried files
+	Copied       bool
+	NoChanges    bool
+	// UnmatchedPatterns are --file patterns that matched no changed file.
+	UnmatchedPatterns []string
+}
+
+// Format formats the CarryResult for display.
+func (r CarryResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+	for _, p := range r.UnmatchedPatterns {
+		fmt.Fprintf(&stderr, "warning: --file pattern %s matched no changed files\n", p)
+	}
+	if r.NoChanges {
+		stderr.WriteString("twig carry: no changes to carry\n")
+		return FormatResult{Stderr: stderr.String()}
+	}
+
+	verb := "moved"
+	if r.Copied {
+		verb = "copied"
+	}
+	if opts.Verbose {
+		for _, f := range r.Files {
+			fmt.Fprintf(&stdout, "Carried %s\n", f)
+		}
+	}
+	fmt.Fprintf(&stdout, "twig carry: %s (%d files %s from %s)\n",
+		r.Branch, len(r.Files), verb, r.SourcePath)
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// CarryConflictError reports files that cannot be carried without
+// overwriting changes in the target worktree.
+type CarryConflictError struct {
+	Branch string
+	Files  []string
+	Detail string // Output of the failed patch check, if any
+}
+
+func (e *CarryConflictError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "cannot carry changes to %s: conflicts in target worktree", e.Branch)
+	for _, f := range e.Files {
+		fmt.Fprintf(&sb, "\n  %s", f)
+	}
+	if e.Detail != "" {
+		fmt.Fprintf(&sb, "\n%s", e.Detail)
+	}
+	return sb.String()
+}
+
+// Run carries uncommitted changes from opts.From into the worktree of branch.
+//
+// Conflicts are detected before the source is touched. If applying the
+// changes fails anyway, the target paths are restored and the changes are
+// put back into the source worktree.
+func (c *CarryCommand) Run(branch string, opts CarryOptions) (CarryResult, error) {
+	result := CarryResult{Branch: branch, SourcePath: opts.From, Copied: opts.Copy}
+
+	if opts.From == "" {
+		return result, fmt.Errorf("source worktree is required")
+	}
+
+	target, err := c.Git.WorktreeFindByBranch(branch)
+	if err != nil {
+		return result, fmt.Errorf("failed to find worktree for branch %q: %w", branch, err)
+	}
+	result.WorktreePath = target.Path
+
+	// Changed files are relative to the worktree root, so every source
+	// command runs there even when From is a subdirectory. They are passed
+	// back to git as literal pathspecs.
+	sourceGit, err := c.Git.InDir(opts.From).root()
+	if err != nil {
+		return result, fmt.Errorf("failed to resolve source worktree: %w", err)
+	}
+	if filepath.Clean(target.Path) == filepath.Clean(sourceGit.Dir) {
+		return result, fmt.Errorf("source and target are the same worktree: %s", target.Path)
+	}
+	sourceGit = sourceGit.literal()
+	targetGit := c.Git.InDir(target.Path).literal()
+
+	changed, err := sourceGit.ChangedFiles()
+	if err != nil {
+		return result, fmt.Errorf("failed to check for changes: %w", err)
+	}
+
+	var pathspecs []string
+	if len(opts.FilePatterns) > 0 {
+		// Match against changed files so that deletions and renames are carried
+		matched, err := matchPaths(changed, opts.FilePatterns)
+		if err != nil {
+			return result, err
+		}
+		pathspecs, changed = matched.Paths, matched.Paths
+		result.UnmatchedPatterns = matched.Unmatched
+	}
+	if len(changed) == 0 {
+		result.NoChanges = true
+		return result, nil
+	}
+
+	if err := c.checkConflicts(branch, sourceGit, targetGit, changed, pathspecs); err != nil {
+		return result, err
+	}
+
+	// Copy leaves the source untouched; move removes the changes
+	msg, mode := "twig carry", OperationCarry
+	var stashOpts []StashPushOption
+	if opts.Copy {
+		msg, mode = "twig sync", OperationSync
+		stashOpts = append(stashOpts, WithKeepChanges())
+	}
+	hash, err := sourceGit.StashPush(msg, pathspecs, stashOpts...)
+	if err != nil {
+		return result, fmt.Errorf("failed to stash changes: %w", err)
+	}
+
+	// Record the operation so it can be recovered if twig is interrupted
+	op := newStashOperation(mode, StashScopeAll, hash, sourceGit.Dir, target.Path, branch)
+	store, err := newOperationStore(c.FS, c.Git)
+	if err == nil {
+		err = store.Save(op)
+	}
+	if err != nil {
+		rollbackStash(sourceGit, nil, op)
+		return result, fmt.Errorf("failed to record %s operation: %w", mode, err)
+	}
+
+	files, err := sourceGit.StashFiles(hash)
+	if err != nil {
+		rollbackStash(sourceGit, store, op)
+		return result, fmt.Errorf("failed to read stashed changes: %w", err)
+	}
+	result.Files = files
+
+	if _, err := targetGit.StashApplyByHash(hash, op.Scope); err != nil {
+		c.rollbackTarget(targetGit, files)
+		rollbackStash(sourceGit, store, op)
+		return result, fmt.Errorf("failed to apply changes to %s: %w", branch, err)
+	}
+
+	_, _ = sourceGit.StashDropByHash(hash)
+	_ = store.Remove(hash)
+
+	return result, nil
+}
+
+// checkConflicts fails if carrying files would overwrite changes in the target
+// or if the tracked changes do not apply cleanly to the target's HEAD.
+func (c *CarryCommand) checkConflicts(
+	branch string, sourceGit, targetGit *GitRunner, files, pathspecs []string) error {
+	targetChanged, err := targetGit.ChangedFiles()
+	if err != nil {
+		return fmt.Errorf("failed to check target worktree: %w", err)
+	}
+	tracked, err := sourceGit.TrackedFiles(files...)
+	if err != nil {
+		return fmt.Errorf("failed to list tracked files: %w", err)
+	}
+
+	var conflicts []string
+	for _, f := range files {
+		switch {
+		case slices.Contains(targetChanged, f):
+			conflicts = append(conflicts, f+" (modified in target)")
+		case !slices.Contains(tracked, f):
+			// Untracked in source: must not exist in target
+			if _, err := c.FS.Stat(filepath.Join(targetGit.Dir, f)); err == nil {
+				conflicts = append(conflicts, f+" (already exists in target)")
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return &CarryConflictError{Branch: branch, Files: conflicts}
+	}
+
+	patch, err := sourceGit.DiffHEAD(pathspecs...)
+	if err != nil {
+		return fmt.Errorf("failed to diff changes: %w", err)
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	id, err := newStashID()
+	if err != nil {
+		return err
+	}
+	patchPath, err := targetGit.GitPath(carryPatchPrefix + id + ".patch")
+	if err != nil {
+		return fmt.Errorf("failed to locate git directory: %w", err)
+	}
+	if err := c.FS.WriteFile(patchPath, patch, 0644); err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+	defer func() { _ = c.FS.Remove(patchPath) }()
+
+	if err := targetGit.ApplyCheck(patchPath); err != nil {
+		conflictErr := &CarryConflictError{Branch: branch, Detail: err.Error()}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			conflictErr.Detail = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		return conflictErr
+	}
+	return nil
+}
+
+// rollbackTarget undoes a partially applied stash in the target worktree.
+// Files tracked in the target are reset to HEAD; others are removed.
+// Safe because checkConflicts ensured these files had no changes in the target.
+func (c *CarryCommand) rollbackTarget(targetGit *GitRunner, files []string) {
+	tracked, _ := targetGit.TrackedFiles(files...)
+	if len(tracked) > 0 {
+		_ = targetGit.RestoreFromHEAD(tracked...)
+	}
+	for _, f := range files {
+		if !slices.Contains(tracked, f) {
+			_ = c.FS.Remove(filepath.Join(targetGit.Dir, f))
+		}
+	}
+}
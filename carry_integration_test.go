@@ -0,0 +1,286 @@
+//go:build integration
+
+package twig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestCarryCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	// setup creates a repository with committed files and a worktree for
+	// feat/carry, returning the config, main and worktree paths.
+	setup := func(t *testing.T, files map[string]string) (*Config, string, string) {
+		t.Helper()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		for name, content := range files {
+			path := filepath.Join(mainDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			testutil.RunGit(t, mainDir, "add", name)
+		}
+		testutil.RunGit(t, mainDir, "commit", "-m", "add files")
+
+		wtPath := filepath.Join(repoDir, "feat", "carry")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/carry", wtPath)
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Config, mainDir, wtPath
+	}
+
+	readFile := func(t *testing.T, path string) string {
+		t.Helper()
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	t.Run("MovesMatchingFiles", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, mainDir, wtPath := setup(t, map[string]string{
+			"api/handler.go": "package api\n",
+			"web/index.ts":   "export {}\n",
+		})
+
+		if err := os.WriteFile(filepath.Join(mainDir, "api", "handler.go"), []byte("package api // changed\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mainDir, "api", "new.go"), []byte("package api\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mainDir, "web", "index.ts"), []byte("export const a = 1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultCarryCommand(cfg)
+		result, err := cmd.Run("feat/carry", CarryOptions{From: mainDir, FilePatterns: []string{"api/**"}})
+		if err != nil {
+			t.Fatalf("carry failed: %v", err)
+		}
+		if len(result.Files) != 2 {
+			t.Errorf("Files = %v, want 2 files", result.Files)
+		}
+
+		if got := readFile(t, filepath.Join(wtPath, "api", "handler.go")); got != "package api // changed\n" {
+			t.Errorf("target api/handler.go = %q", got)
+		}
+		if got := readFile(t, filepath.Join(wtPath, "api", "new.go")); got != "package api\n" {
+			t.Errorf("target api/new.go = %q", got)
+		}
+		if got := readFile(t, filepath.Join(wtPath, "web", "index.ts")); got != "export {}\n" {
+			t.Errorf("unmatched file was carried: web/index.ts = %q", got)
+		}
+
+		// Matching changes are gone from the source; others remain
+		if got := readFile(t, filepath.Join(mainDir, "api", "handler.go")); got != "package api\n" {
+			t.Errorf("source api/handler.go = %q, want original content", got)
+		}
+		if _, err := os.Stat(filepath.Join(mainDir, "api", "new.go")); !os.IsNotExist(err) {
+			t.Errorf("source api/new.go should be removed, got err=%v", err)
+		}
+		if got := readFile(t, filepath.Join(mainDir, "web", "index.ts")); got != "export const a = 1\n" {
+			t.Errorf("source web/index.ts = %q, want modified content", got)
+		}
+
+		if out := testutil.RunGit(t, mainDir, "stash", "list"); strings.TrimSpace(out) != "" {
+			t.Errorf("stash should be empty, got %q", out)
+		}
+	})
+
+	t.Run("CopyKeepsSourceChanges", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, mainDir, wtPath := setup(t, map[string]string{"app.txt": "one\n"})
+
+		if err := os.WriteFile(filepath.Join(mainDir, "app.txt"), []byte("two\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultCarryCommand(cfg)
+		if _, err := cmd.Run("feat/carry", CarryOptions{From: mainDir, Copy: true, FilePatterns: []string{"app.txt"}}); err != nil {
+			t.Fatalf("carry failed: %v", err)
+		}
+
+		if got := readFile(t, filepath.Join(wtPath, "app.txt")); got != "two\n" {
+			t.Errorf("target app.txt = %q, want %q", got, "two\n")
+		}
+		if got := readFile(t, filepath.Join(mainDir, "app.txt")); got != "two\n" {
+			t.Errorf("source app.txt = %q, want %q", got, "two\n")
+		}
+	})
+
+	t.Run("FromSubdirectory", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, mainDir, wtPath := setup(t, map[string]string{"sub/a.txt": "one\n"})
+
+		if err := os.WriteFile(filepath.Join(mainDir, "sub", "a.txt"), []byte("two\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mainDir, "b.txt"), []byte("new\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultCarryCommand(cfg)
+		result, err := cmd.Run("feat/carry", CarryOptions{From: filepath.Join(mainDir, "sub")})
+		if err != nil {
+			t.Fatalf("carry failed: %v", err)
+		}
+		if !slices.Contains(result.Files, "sub/a.txt") || !slices.Contains(result.Files, "b.txt") {
+			t.Errorf("Files = %v, want sub/a.txt and b.txt", result.Files)
+		}
+
+		if got := readFile(t, filepath.Join(wtPath, "sub", "a.txt")); got != "two\n" {
+			t.Errorf("target sub/a.txt = %q, want %q", got, "two\n")
+		}
+		if got := readFile(t, filepath.Join(wtPath, "b.txt")); got != "new\n" {
+			t.Errorf("target b.txt = %q, want %q", got, "new\n")
+		}
+		if got := readFile(t, filepath.Join(mainDir, "sub", "a.txt")); got != "one\n" {
+			t.Errorf("source sub/a.txt = %q, want original content", got)
+		}
+	})
+
+	t.Run("FilePatternFromSubdirectory", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, mainDir, wtPath := setup(t, map[string]string{"sub/a.txt": "one\n", "sub/x[1].txt": "one\n", "sub/x1.txt": "one\n"})
+
+		for _, name := range []string{"a.txt", "x[1].txt", "x1.txt"} {
+			if err := os.WriteFile(filepath.Join(mainDir, "sub", name), []byte("two\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cmd := NewDefaultCarryCommand(cfg)
+		result, err := cmd.Run("feat/carry", CarryOptions{
+			From:         filepath.Join(mainDir, "sub"),
+			FilePatterns: []string{"sub/a.txt", `sub/x\[1\].txt`},
+		})
+		if err != nil {
+			t.Fatalf("carry failed: %v", err)
+		}
+		if !slices.Equal(result.Files, []string{"sub/a.txt", "sub/x[1].txt"}) {
+			t.Errorf("Files = %q, want sub/a.txt and sub/x[1].txt", result.Files)
+		}
+
+		for name, want := range map[string]string{"a.txt": "two\n", "x[1].txt": "two\n", "x1.txt": "one\n"} {
+			if got := readFile(t, filepath.Join(wtPath, "sub", name)); got != want {
+				t.Errorf("target sub/%s = %q, want %q", name, got, want)
+			}
+		}
+		if got := readFile(t, filepath.Join(mainDir, "sub", "x1.txt")); got != "two\n" {
+			t.Errorf("source sub/x1.txt = %q, want modified content", got)
+		}
+	})
+
+	t.Run("ConflictLeavesSourceUntouched", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, mainDir, wtPath := setup(t, map[string]string{"app.txt": "one\n"})
+
+		// Diverge the target's HEAD on the same line
+		if err := os.WriteFile(filepath.Join(wtPath, "app.txt"), []byte("target\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "commit", "-am", "target change")
+
+		if err := os.WriteFile(filepath.Join(mainDir, "app.txt"), []byte("source\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultCarryCommand(cfg)
+		_, err := cmd.Run("feat/carry", CarryOptions{From: mainDir, FilePatterns: []string{"app.txt"}})
+		var conflictErr *CarryConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Fatalf("expected CarryConflictError, got %v", err)
+		}
+
+		if got := readFile(t, filepath.Join(mainDir, "app.txt")); got != "source\n" {
+			t.Errorf("source app.txt = %q, want %q", got, "source\n")
+		}
+		if got := readFile(t, filepath.Join(wtPath, "app.txt")); got != "target\n" {
+			t.Errorf("target app.txt = %q, want %q", got, "target\n")
+		}
+		if out := testutil.RunGit(t, mainDir, "stash", "list"); strings.TrimSpace(out) != "" {
+			t.Errorf("stash should be empty, got %q", out)
+		}
+	})
+
+	t.Run("UntrackedFileExistsInTarget", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, mainDir, wtPath := setup(t, map[string]string{"app.txt": "one\n"})
+
+		if err := os.WriteFile(filepath.Join(mainDir, "new.txt"), []byte("source\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(wtPath, "new.txt"), []byte("target\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultCarryCommand(cfg)
+		_, err := cmd.Run("feat/carry", CarryOptions{From: mainDir, FilePatterns: []string{"new.txt"}})
+		var conflictErr *CarryConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Fatalf("expected CarryConflictError, got %v", err)
+		}
+		if got := readFile(t, filepath.Join(mainDir, "new.txt")); got != "source\n" {
+			t.Errorf("source new.txt = %q, want %q", got, "source\n")
+		}
+	})
+
+	t.Run("HostileFileNames", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, mainDir, wtPath := setup(t, map[string]string{`dir with space/quote "q".txt`: "one\n"})
+
+		if err := os.WriteFile(filepath.Join(mainDir, "dir with space", `quote "q".txt`), []byte("two\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mainDir, "ünï -> cødé.txt"), []byte("new\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultCarryCommand(cfg)
+		result, err := cmd.Run("feat/carry", CarryOptions{
+			From:         mainDir,
+			FilePatterns: []string{"dir with space/**", "ünï -> cødé.txt"},
+		})
+		if err != nil {
+			t.Fatalf("carry failed: %v", err)
+		}
+		if len(result.Files) != 2 || len(result.UnmatchedPatterns) != 0 {
+			t.Errorf("Files = %q, UnmatchedPatterns = %q", result.Files, result.UnmatchedPatterns)
+		}
+
+		if got := readFile(t, filepath.Join(wtPath, "dir with space", `quote "q".txt`)); got != "two\n" {
+			t.Errorf("target quoted file = %q, want %q", got, "two\n")
+		}
+		if got := readFile(t, filepath.Join(wtPath, "ünï -> cødé.txt")); got != "new\n" {
+			t.Errorf("target unicode file = %q, want %q", got, "new\n")
+		}
+		if got := readFile(t, filepath.Join(mainDir, "dir with space", `quote "q".txt`)); got != "one\n" {
+			t.Errorf("source quoted file = %q, want original content", got)
+		}
+	})
+}
@@ -0,0 +1,437 @@
+package twig
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// carryGitState describes the per-worktree git state used by carry tests.
+type carryGitState struct {
+	status   map[string]string   // dir -> git status --porcelain output
+	tracked  map[string][]string // dir -> tracked files
+	patch    string
+	checkErr error
+	applyErr error
+	stashed  []string
+}
+
+// newCarryExecutor returns an executor that answers carry's git commands
+// per worktree directory and records them as "<dir>: <args>".
+// Calls are matched by prefix in the tests.
+func newCarryExecutor(s carryGitState, calls *[]string) *testutil.MockGitExecutor {
+	base := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+		},
+	}
+	return &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			dir := ""
+			if len(args) >= 2 && args[0] == "-C" {
+				dir, args = args[1], args[2:]
+			}
+			*calls = append(*calls, dir+": "+strings.Join(args, " "))
+
+			switch args[0] {
+			case "status":
+				return statusZ(s.status[dir]), nil
+			case "ls-files":
+				var out []string
+				if slices.Contains(args, "--others") {
+					// Untracked files of the stash snapshot
+					for _, line := range strings.Split(s.status[dir], "\n") {
+						if f, ok := strings.CutPrefix(line, "?? "); ok && matchesArgs(f, args) {
+							out = append(out, f)
+						}
+					}
+					return []byte(strings.Join(out, "\x00")), nil
+				}
+				for _, f := range args[slices.Index(args, "--")+1:] {
+					if slices.Contains(s.tracked[dir], f) {
+						out = append(out, f)
+					}
+				}
+				return []byte(strings.Join(out, "\x00")), nil
+			case "diff":
+				if slices.Contains(args, "--name-only") {
+					var out []string
+					for _, line := range strings.Split(s.status[dir], "\n") {
+						if len(line) > 3 && !strings.HasPrefix(line, "??") && matchesArgs(line[3:], args) {
+							out = append(out, line[3:])
+						}
+					}
+					return []byte(strings.Join(out, "\x00")), nil
+				}
+				return []byte(s.patch), nil
+			case "apply":
+				return nil, s.checkErr
+			case "rev-parse":
+				switch args[1] {
+				case "--git-path":
+					return []byte("/repo/.git/worktrees/a/" + args[2] + "\n"), nil
+				case "--show-cdup", "--show-prefix":
+					return []byte("\n"), nil
+				}
+				return []byte("head123\n"), nil
+			case "commit-tree":
+				return []byte("abc123\n"), nil
+			case "for-each-ref":
+				return []byte("refs/twig/stash/x\x00\n"), nil
+			case "stash":
+				switch args[1] {
+				case "show":
+					return []byte(strings.Join(s.stashed, "\x00")), nil
+				case "apply":
+					if dir == "/repo/feat/a" && s.applyErr != nil {
+						return nil, s.applyErr
+					}
+				}
+				return nil, nil
+			case "restore", "clean", "add", "read-tree", "write-tree", "update-ref", "var":
+				return nil, nil
+			}
+			return base.Run(args...)
+		},
+	}
+}
+
+// statusZ converts readable git status --porcelain lines into the
+// NUL-separated -z format, where renames list the new path first.
+func statusZ(status string) []byte {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(status, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		if from, to, ok := strings.Cut(line[3:], " -> "); ok {
+			line = line[:3] + to + "\x00" + from
+		}
+		b.WriteString(line + "\x00")
+	}
+	return []byte(b.String())
+}
+
+// matchesArgs reports whether file equals or is inside one of the
+// pathspecs after "--" in args.
+func matchesArgs(file string, args []string) bool {
+	specs := args[slices.Index(args, "--")+1:]
+	return len(specs) == 0 || slices.ContainsFunc(specs, func(p string) bool {
+		return file == p || strings.HasPrefix(file, p+"/")
+	})
+}
+
+func TestCarryCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		branch       string
+		opts         CarryOptions
+		state        carryGitState
+		existing     []string
+		wantErr      string
+		wantConflict bool
+		wantFiles    []string
+		wantNoChange bool
+		// wantUnmatched are the --file patterns expected to match nothing
+		wantUnmatched []string
+		wantCalls     []string
+		wantNoCalls   []string
+		wantRemoved   []string
+	}{
+		{
+			name:   "move",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main"},
+			state: carryGitState{
+				status:  map[string]string{"/repo/main": " M app.go\n?? new.go\n"},
+				tracked: map[string][]string{"/repo/main": {"app.go"}},
+				patch:   "diff --git a/app.go b/app.go\n",
+				stashed: []string{"app.go", "new.go"},
+			},
+			wantFiles: []string{"app.go", "new.go"},
+			wantCalls: []string{
+				"/repo/feat/a: apply --check /repo/.git/worktrees/a/twig-carry-",
+				"/repo/main: update-ref -m twig carry refs/twig/stash/",
+				"/repo/feat/a: stash apply abc123",
+				"/repo/main: update-ref -d refs/twig/stash/x abc123",
+			},
+			wantNoCalls: []string{"/repo/main: stash apply abc123"},
+		},
+		{
+			name:   "copy",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main", Copy: true},
+			state: carryGitState{
+				status:  map[string]string{"/repo/main": "?? new.go\n"},
+				stashed: []string{"new.go"},
+			},
+			wantFiles: []string{"new.go"},
+			wantCalls: []string{
+				"/repo/feat/a: stash apply abc123",
+				"/repo/main: update-ref -d refs/twig/stash/x abc123",
+			},
+			wantNoCalls: []string{"/repo/main: stash apply abc123", "/repo/main: clean -f -q -- new.go", "/repo/feat/a: apply --check /repo/.git/worktrees/a/twig-carry-"},
+		},
+		{
+			name:   "file_patterns_filter_changes",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main", FilePatterns: []string{"api/**"}},
+			state: carryGitState{
+				status:  map[string]string{"/repo/main": " M api/handler.go\n M web/index.ts\n"},
+				tracked: map[string][]string{"/repo/main": {"api/handler.go", "web/index.ts"}},
+				stashed: []string{"api/handler.go"},
+			},
+			wantFiles: []string{"api/handler.go"},
+			wantCalls: []string{
+				"/repo/main: diff --binary HEAD -- api/handler.go",
+				"/repo/main: diff --name-only --no-renames -z HEAD -- api/handler.go",
+			},
+		},
+		{
+			name:   "file_patterns_match_deletions_and_renames",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main", FilePatterns: []string{"api/**", "docs/**"}},
+			state: carryGitState{
+				status:  map[string]string{"/repo/main": " D api/old.go\nR  api/a.go -> api/b.go\n M web/index.ts\n"},
+				tracked: map[string][]string{"/repo/main": {"api/old.go", "api/a.go", "api/b.go", "web/index.ts"}},
+				stashed: []string{"api/a.go", "api/b.go", "api/old.go"},
+			},
+			wantFiles:     []string{"api/a.go", "api/b.go", "api/old.go"},
+			wantUnmatched: []string{"docs/**"},
+			wantCalls: []string{
+				"/repo/main: diff --binary HEAD -- api/old.go api/a.go api/b.go",
+			},
+		},
+		{
+			name:   "file_patterns_without_changed_files",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main", FilePatterns: []string{"docs/**"}},
+			state: carryGitState{
+				status: map[string]string{"/repo/main": " M web/index.ts\n"},
+			},
+			wantNoChange:  true,
+			wantUnmatched: []string{"docs/**"},
+			wantNoCalls:   []string{"/repo/main: update-ref -m twig carry refs/twig/stash/"},
+		},
+		{
+			name:   "no_changes",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main"},
+			state: carryGitState{
+				status: map[string]string{},
+			},
+			wantNoChange: true,
+			wantNoCalls:  []string{"/repo/main: update-ref -m twig carry refs/twig/stash/"},
+		},
+		{
+			name:   "file_modified_in_target",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main"},
+			state: carryGitState{
+				status: map[string]string{
+					"/repo/main":   " M app.go\n",
+					"/repo/feat/a": " M app.go\n",
+				},
+				tracked: map[string][]string{"/repo/main": {"app.go"}},
+			},
+			wantErr:      "app.go (modified in target)",
+			wantConflict: true,
+			wantNoCalls:  []string{"/repo/main: update-ref -m twig carry refs/twig/stash/"},
+		},
+		{
+			name:   "untracked_file_exists_in_target",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main"},
+			state: carryGitState{
+				status: map[string]string{"/repo/main": "?? new.go\n"},
+			},
+			existing:     []string{"/repo/feat/a/new.go"},
+			wantErr:      "new.go (already exists in target)",
+			wantConflict: true,
+			wantNoCalls:  []string{"/repo/main: update-ref -m twig carry refs/twig/stash/"},
+		},
+		{
+			name:   "patch_does_not_apply",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main"},
+			state: carryGitState{
+				status:   map[string]string{"/repo/main": " M app.go\n"},
+				tracked:  map[string][]string{"/repo/main": {"app.go"}},
+				patch:    "diff --git a/app.go b/app.go\n",
+				checkErr: errors.New("patch failed: app.go:1"),
+			},
+			wantErr:      "patch failed",
+			wantConflict: true,
+			wantNoCalls:  []string{"/repo/main: update-ref -m twig carry refs/twig/stash/"},
+		},
+		{
+			name:   "apply_failure_rolls_back",
+			branch: "feat/a",
+			opts:   CarryOptions{From: "/repo/main"},
+			state: carryGitState{
+				status: map[string]string{"/repo/main": " M app.go\n?? new.go\n"},
+				tracked: map[string][]string{
+					"/repo/main":   {"app.go"},
+					"/repo/feat/a": {"app.go"},
+				},
+				stashed:  []string{"app.go", "new.go"},
+				applyErr: errors.New("conflict"),
+			},
+			wantErr:   "failed to apply changes to feat/a",
+			wantFiles: []string{"app.go", "new.go"},
+			wantCalls: []string{
+				"/repo/feat/a: restore --source=HEAD --staged --worktree -- app.go",
+				"/repo/main: stash apply --index abc123",
+				"/repo/main: update-ref -d refs/twig/stash/x abc123",
+			},
+			wantRemoved: []string{"/repo/feat/a/new.go"},
+		},
+		{
+			name:    "same_worktree",
+			branch:  "main",
+			opts:    CarryOptions{From: "/repo/main"},
+			wantErr: "source and target are the same worktree",
+		},
+		{
+			name:    "branch_without_worktree",
+			branch:  "feat/missing",
+			opts:    CarryOptions{From: "/repo/main"},
+			wantErr: "failed to find worktree",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls, removed []string
+			mockFS := &testutil.MockFS{
+				ExistingPaths: tt.existing,
+				RemoveFunc: func(name string) error {
+					removed = append(removed, name)
+					return nil
+				},
+			}
+			git := &GitRunner{Executor: newCarryExecutor(tt.state, &calls), Dir: "/repo/main"}
+
+			cmd := NewCarryCommand(mockFS, git, &Config{})
+			result, err := cmd.Run(tt.branch, tt.opts)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				var conflictErr *CarryConflictError
+				if got := errors.As(err, &conflictErr); got != tt.wantConflict {
+					t.Errorf("CarryConflictError = %v, want %v", got, tt.wantConflict)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(result.Files, tt.wantFiles) {
+				t.Errorf("Files = %v, want %v", result.Files, tt.wantFiles)
+			}
+			if result.NoChanges != tt.wantNoChange {
+				t.Errorf("NoChanges = %v, want %v", result.NoChanges, tt.wantNoChange)
+			}
+			if !slices.Equal(result.UnmatchedPatterns, tt.wantUnmatched) {
+				t.Errorf("UnmatchedPatterns = %v, want %v", result.UnmatchedPatterns, tt.wantUnmatched)
+			}
+			called := func(prefix string) bool {
+				return slices.ContainsFunc(calls, func(c string) bool {
+					return strings.HasPrefix(c, prefix)
+				})
+			}
+			for _, want := range tt.wantCalls {
+				if !called(want) {
+					t.Errorf("expected git call %q, got %v", want, calls)
+				}
+			}
+			for _, unwanted := range tt.wantNoCalls {
+				if called(unwanted) {
+					t.Errorf("unexpected git call %q", unwanted)
+				}
+			}
+			// The conflict check patch is always cleaned up
+			for _, c := range calls {
+				if _, patch, ok := strings.Cut(c, "apply --check "); ok && !slices.Contains(removed, patch) {
+					t.Errorf("patch %s was not removed, got %v", patch, removed)
+				}
+			}
+			for _, want := range tt.wantRemoved {
+				if !slices.Contains(removed, want) {
+					t.Errorf("expected %s to be removed, got %v", want, removed)
+				}
+			}
+		})
+	}
+}
+
+func TestCarryResult_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		result     CarryResult
+		verbose    bool
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name: "move",
+			result: CarryResult{
+				Branch:     "feat/a",
+				SourcePath: "/repo/main",
+				Files:      []string{"app.go", "new.go"},
+			},
+			wantStdout: "twig carry: feat/a (2 files moved from /repo/main)\n",
+		},
+		{
+			name: "copy_verbose",
+			result: CarryResult{
+				Branch:     "feat/a",
+				SourcePath: "/repo/main",
+				Files:      []string{"app.go"},
+				Copied:     true,
+			},
+			verbose:    true,
+			wantStdout: "Carried app.go\ntwig carry: feat/a (1 files copied from /repo/main)\n",
+		},
+		{
+			name:       "no_changes",
+			result:     CarryResult{Branch: "feat/a", NoChanges: true},
+			wantStderr: "twig carry: no changes to carry\n",
+		},
+		{
+			name: "unmatched_patterns",
+			result: CarryResult{
+				Branch:            "feat/a",
+				NoChanges:         true,
+				UnmatchedPatterns: []string{"docs/**"},
+			},
+			wantStderr: "warning: --file pattern docs/** matched no changed files\n" +
+				"twig carry: no changes to carry\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(FormatOptions{Verbose: tt.verbose})
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
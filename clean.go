@@ -2,7 +2,9 @@ package twig
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 )
 
 // CleanCommand removes merged worktrees that are no longer needed.
@@ -19,6 +21,14 @@ type CleanOptions struct {
 	Target  string             // Target branch for merge check (auto-detect if empty)
 	Verbose bool               // Show skip reasons
 	Force   WorktreeForceLevel // Force level: -f for unclean, -ff for locked
+	Now     time.Time          // Reference time for lock expiry; zero means the current time
+	Remote  bool               // Also delete the upstream branches on their remotes
+	// Branches also cleans merged local branches that are not checked out
+	// in any worktree.
+	Branches bool
+	// Trash moves the worktree directories to the trash instead of
+	// deleting them.
+	Trash bool
 }
 
 // NewCleanCommand creates a new CleanCommand with explicit dependencies.
@@ -45,6 +55,10 @@ const (
 	SkipLocked     SkipReason = "locked"
 	SkipCurrentDir SkipReason = "current directory"
 	SkipDetached   SkipReason = "detached HEAD"
+	SkipPrunable   SkipReason = "prunable"
+	SkipUnpushed   SkipReason = "unpushed commits"
+	// SkipUnpushedCheck is used when unpushed commits cannot be counted.
+	SkipUnpushedCheck SkipReason = "cannot check for unpushed commits"
 )
 
 // CleanReason describes why a branch is cleanable.
@@ -63,6 +77,25 @@ type CleanCandidate struct {
 	Skipped      bool
 	SkipReason   SkipReason
 	CleanReason  CleanReason
+	LockExpired  bool  // Locked with an expiry that has passed; unlocked before removal
+	BranchOnly   bool  // Local branch without a worktree, cleaned with CleanOptions.Branches
+	Unpushed     int   // Number of commits only on the local branch, set with SkipUnpushed
+	UnpushedErr  error // Why unpushed commits could not be counted, set with SkipUnpushedCheck
+}
+
+// skipLabel returns the skip reason for display, with the commit count
+// for unpushed commits and the error when they could not be counted.
+func (c CleanCandidate) skipLabel() string {
+	if c.SkipReason == SkipUnpushedCheck && c.UnpushedErr != nil {
+		return fmt.Sprintf("%s: %v", c.SkipReason, c.UnpushedErr)
+	}
+	if c.SkipReason != SkipUnpushed || c.Unpushed == 0 {
+		return string(c.SkipReason)
+	}
+	if c.Unpushed == 1 {
+		return "1 unpushed commit"
+	}
+	return fmt.Sprintf("%d %s", c.Unpushed, SkipUnpushed)
 }
 
 // CleanResult aggregates results from clean operations.
@@ -85,6 +118,11 @@ func (r CleanResult) CleanableCount() int {
 	return count
 }
 
+// Errors returns the errors of the failed removals.
+func (r CleanResult) Errors() []error {
+	return removalErrors(r.Removed)
+}
+
 // Format formats the CleanResult for display.
 func (r CleanResult) Format(opts FormatOptions) FormatResult {
 	var stdout, stderr strings.Builder
@@ -96,27 +134,39 @@ func (r CleanResult) Format(opts FormatOptions) FormatResult {
 				fmt.Fprintf(&stderr, "error: %s: %v\n", wt.Branch, wt.Err)
 				continue
 			}
-			fmt.Fprintf(&stdout, "twig clean: %s\n", wt.Branch)
+			fmt.Fprintf(&stdout, "twig clean: %s%s\n", wt.Branch, wt.summary())
+			for _, d := range wt.Remotes {
+				if d.Err != nil {
+					fmt.Fprintf(&stderr, "error: %s: %v\n", wt.Branch, d.Err)
+				}
+			}
 		}
 		return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
 	}
 
 	// Show candidates (check mode or before execution)
-	var cleanable, skipped []CleanCandidate
+	var cleanable, branches, skipped []CleanCandidate
 	for _, c := range r.Candidates {
-		if c.Skipped {
+		switch {
+		case c.Skipped:
 			skipped = append(skipped, c)
-		} else {
+			// Unlike the other skip reasons, a failed check is always reported
+			if c.SkipReason == SkipUnpushedCheck {
+				fmt.Fprintf(&stderr, "warning: %s: %s\n", c.Branch, c.skipLabel())
+			}
+		case c.BranchOnly:
+			branches = append(branches, c)
+		default:
 			cleanable = append(cleanable, c)
 		}
 	}
 
 	// No cleanable candidates
-	if len(cleanable) == 0 {
+	if len(cleanable) == 0 && len(branches) == 0 {
 		if opts.Verbose && len(skipped) > 0 {
 			fmt.Fprintln(&stdout, "skip:")
 			for _, c := range skipped {
-				fmt.Fprintf(&stdout, "  %s (%s)\n", c.Branch, c.SkipReason)
+				fmt.Fprintf(&stdout, "  %s (%s)\n", c.Branch, c.skipLabel())
 			}
 			fmt.Fprintln(&stdout)
 		}
@@ -125,13 +175,29 @@ func (r CleanResult) Format(opts FormatOptions) FormatResult {
 	}
 
 	// Output cleanable candidates with group header and reasons
-	fmt.Fprintln(&stdout, "clean:")
-	for _, c := range cleanable {
-		reason := string(c.CleanReason)
-		if c.Prunable {
-			reason = "prunable, " + reason
+	if len(cleanable) > 0 {
+		fmt.Fprintln(&stdout, "clean:")
+		for _, c := range cleanable {
+			reason := string(c.CleanReason)
+			if c.Prunable {
+				reason = "prunable, " + reason
+			}
+			if c.LockExpired {
+				reason += ", lock expired"
+			}
+			fmt.Fprintf(&stdout, "  %s (%s)\n", c.Branch, reason)
+		}
+	}
+
+	// Output local branches without a worktree as a separate group
+	if len(branches) > 0 {
+		if len(cleanable) > 0 {
+			fmt.Fprintln(&stdout)
+		}
+		fmt.Fprintln(&stdout, "branches:")
+		for _, c := range branches {
+			fmt.Fprintf(&stdout, "  %s (%s)\n", c.Branch, c.CleanReason)
 		}
-		fmt.Fprintf(&stdout, "  %s (%s)\n", c.Branch, reason)
 	}
 
 	// Output skipped candidates with group header (verbose only)
@@ -139,7 +205,7 @@ func (r CleanResult) Format(opts FormatOptions) FormatResult {
 		fmt.Fprintln(&stdout)
 		fmt.Fprintln(&stdout, "skip:")
 		for _, c := range skipped {
-			fmt.Fprintf(&stdout, "  %s (%s)\n", c.Branch, c.SkipReason)
+			fmt.Fprintf(&stdout, "  %s (%s)\n", c.Branch, c.skipLabel())
 		}
 	}
 
@@ -165,6 +231,11 @@ func (c *CleanCommand) Run(cwd string, opts CleanOptions) (CleanResult, error) {
 		return result, fmt.Errorf("failed to list worktrees: %w", err)
 	}
 
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
 	// Analyze each worktree
 	for i, wt := range worktrees {
 		// Skip main worktree (first non-bare worktree)
@@ -190,12 +261,22 @@ func (c *CleanCommand) Run(cwd string, opts CleanOptions) (CleanResult, error) {
 				Branch:       wt.Branch,
 				WorktreePath: wt.Path,
 			}
+			// Expired locks no longer protect the worktree
+			if wt.Locked && wt.LockInfo().Expired(now) {
+				candidate.LockExpired = true
+				wt.Locked = false
+			}
 			if reason := c.checkSkipReason(wt, cwd, target, opts.Force); reason != "" {
 				candidate.Skipped = true
 				candidate.SkipReason = reason
 			}
 		}
 
+		// Commits made after the upstream was deleted are not merged
+		if !candidate.Skipped && opts.Force < WorktreeForceLevelUnclean {
+			c.checkUnpushed(&candidate, target)
+		}
+
 		// Set clean reason for non-skipped candidates
 		if !candidate.Skipped {
 			candidate.CleanReason = c.getCleanReason(wt.Branch, target)
@@ -204,6 +285,14 @@ func (c *CleanCommand) Run(cwd string, opts CleanOptions) (CleanResult, error) {
 		result.Candidates = append(result.Candidates, candidate)
 	}
 
+	if opts.Branches {
+		branches, err := c.branchCandidates(worktrees, target)
+		if err != nil {
+			return result, err
+		}
+		result.Candidates = append(result.Candidates, branches...)
+	}
+
 	// If check mode, just return candidates (no execution)
 	if result.Check {
 		return result, nil
@@ -227,9 +316,29 @@ func (c *CleanCommand) Run(cwd string, opts CleanOptions) (CleanResult, error) {
 			continue
 		}
 
+		if candidate.LockExpired {
+			if _, err := c.Git.WorktreeUnlock(candidate.WorktreePath); err != nil {
+				result.Removed = append(result.Removed, RemovedWorktree{Branch: candidate.Branch, Err: err})
+				continue
+			}
+		}
+
+		if candidate.BranchOnly {
+			wt, err := removeCmd.removeBranch(candidate.Branch, RemoveOptions{Remote: opts.Remote})
+			if err != nil {
+				wt.Err = err
+			}
+			result.Removed = append(result.Removed, wt)
+			continue
+		}
+
+		// Only forced cleans can lose work; merged, clean worktrees need no backup
 		wt, err := removeCmd.Run(candidate.Branch, cwd, RemoveOptions{
 			Force:  removeForce,
 			DryRun: false,
+			Backup: opts.Force > WorktreeForceLevelNone && c.Config.BackupEnabled(),
+			Remote: opts.Remote,
+			Trash:  opts.Trash,
 		})
 		if err != nil {
 			wt.Branch = candidate.Branch
@@ -249,23 +358,7 @@ func (c *CleanCommand) Run(cwd string, opts CleanOptions) (CleanResult, error) {
 // resolveTarget resolves the target branch for merge checking.
 // If target is specified, use it. Otherwise, auto-detect from first non-bare worktree.
 func (c *CleanCommand) resolveTarget(target string) (string, error) {
-	if target != "" {
-		return target, nil
-	}
-
-	// Find first non-bare worktree (usually main)
-	worktrees, err := c.Git.WorktreeList()
-	if err != nil {
-		return "", fmt.Errorf("failed to list worktrees: %w", err)
-	}
-
-	for _, wt := range worktrees {
-		if !wt.Bare && wt.Branch != "" {
-			return wt.Branch, nil
-		}
-	}
-
-	return "", fmt.Errorf("no target branch found")
+	return resolveTargetBranch(c.Git, target)
 }
 
 // checkSkipReason checks if worktree should be skipped and returns the reason.
@@ -317,16 +410,77 @@ func (c *CleanCommand) checkPrunableSkipReason(branch, target string, force Work
 	return ""
 }
 
+// branchCandidates returns the merged local branches that are not checked
+// out in any of worktrees. The target and default branches are never
+// candidates, and unmerged branches are not listed even with force, since
+// a branch without a worktree may be the only copy of its commits. For the
+// same reason, branches with unpushed commits are always skipped.
+func (c *CleanCommand) branchCandidates(worktrees []Worktree, target string) ([]CleanCandidate, error) {
+	branches, err := c.Git.BranchList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	merged, err := c.Git.MergedBranches(target)
+	if err != nil {
+		return nil, err
+	}
+
+	protected := []string{target}
+	if branch, err := c.Git.DefaultBranch(); err == nil {
+		protected = append(protected, branch)
+	}
+	for _, wt := range worktrees {
+		if wt.Branch != "" {
+			protected = append(protected, wt.Branch)
+		}
+	}
+
+	var candidates []CleanCandidate
+	for _, branch := range branches {
+		if slices.Contains(protected, branch) {
+			continue
+		}
+		reason := CleanMerged
+		if !slices.Contains(merged, branch) {
+			gone, err := c.Git.IsBranchUpstreamGone(branch)
+			if err != nil || !gone {
+				continue
+			}
+			reason = CleanUpstreamGone
+		}
+		candidate := CleanCandidate{Branch: branch, BranchOnly: true}
+		c.checkUnpushed(&candidate, target)
+		if !candidate.Skipped {
+			candidate.CleanReason = reason
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// checkUnpushed skips candidate if its branch has commits that are neither
+// in target nor on any remote. Such commits were made after the branch was
+// merged or its upstream deleted, and would be lost. A branch whose commits
+// cannot be checked is skipped as well.
+func (c *CleanCommand) checkUnpushed(candidate *CleanCandidate, target string) {
+	n, err := c.Git.UnpushedCommits(candidate.Branch, target)
+	switch {
+	case err != nil:
+		candidate.Skipped = true
+		candidate.SkipReason = SkipUnpushedCheck
+		candidate.UnpushedErr = err
+	case n > 0:
+		candidate.Skipped = true
+		candidate.SkipReason = SkipUnpushed
+		candidate.Unpushed = n
+	}
+}
+
 // getCleanReason determines why a branch is cleanable.
 func (c *CleanCommand) getCleanReason(branch, target string) CleanReason {
 	// Check if branch is merged via traditional merge
-	out, err := c.Git.Run(GitCmdBranch, "--merged", target, "--format=%(refname:short)")
-	if err == nil {
-		for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
-			if line == branch {
-				return CleanMerged
-			}
-		}
+	if merged, err := c.Git.MergedBranches(target); err == nil && slices.Contains(merged, branch) {
+		return CleanMerged
 	}
 
 	// Check if upstream is gone (squash/rebase merge)
@@ -754,7 +754,7 @@ func TestCleanCommand_Integration(t *testing.T) {
 		if err := os.WriteFile(mainFile2, []byte("feature content 2"), 0644); err != nil {
 			t.Fatal(err)
 		}
-		testutil.RunGit(t, mainDir, "add", ".")
+		testutil.RunGit(t, mainDir, "add", "feature1.txt", "feature2.txt")
 		testutil.RunGit(t, mainDir, "commit", "-m", "feat: add features (#1)")
 
 		// Delete remote branch (as GitHub does after squash merge)
@@ -927,3 +927,186 @@ func TestCleanCommand_Integration(t *testing.T) {
 	})
 
 }
+
+func TestCleanCommand_Integration_Remote(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	remoteDir := filepath.Join(repoDir, "remote.git")
+	testutil.RunGit(t, repoDir, "init", "--bare", remoteDir)
+	testutil.RunGit(t, mainDir, "remote", "add", "origin", remoteDir)
+	testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+
+	// A merged branch that is still on the remote
+	wtPath := filepath.Join(repoDir, "feature", "merged")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/merged", wtPath)
+	testutil.RunGit(t, wtPath, "push", "-u", "origin", "feature/merged")
+
+	cfgResult, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := &CleanCommand{
+		FS:     osFS{},
+		Git:    NewGitRunner(mainDir),
+		Config: cfgResult.Config,
+	}
+
+	result, err := cmd.Run(mainDir, CleanOptions{Yes: true, Remote: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if errs := result.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if got := result.Format(FormatOptions{}).Stdout; got != "twig clean: feature/merged (deleted origin/feature/merged)\n" {
+		t.Errorf("Stdout = %q", got)
+	}
+	if out := testutil.RunGit(t, remoteDir, "branch", "--list", "feature/merged"); strings.TrimSpace(out) != "" {
+		t.Errorf("remote branch should be deleted, got: %s", out)
+	}
+}
+
+func TestCleanCommand_Integration_Branches(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	remoteDir := filepath.Join(repoDir, "remote.git")
+	testutil.RunGit(t, repoDir, "init", "--bare", remoteDir)
+	testutil.RunGit(t, mainDir, "remote", "add", "origin", remoteDir)
+	testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+
+	// A merged branch that was never checked out in a worktree
+	testutil.RunGit(t, mainDir, "branch", "old/merged")
+
+	// A squash-merged branch whose remote branch was deleted
+	squashDir := filepath.Join(repoDir, "old", "squashed")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "old/squashed", squashDir)
+	if err := os.WriteFile(filepath.Join(squashDir, "squashed.txt"), []byte("squashed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, squashDir, "add", "squashed.txt")
+	testutil.RunGit(t, squashDir, "commit", "-m", "squashed work")
+	testutil.RunGit(t, squashDir, "push", "-u", "origin", "old/squashed")
+	testutil.RunGit(t, mainDir, "worktree", "remove", squashDir)
+	testutil.RunGit(t, mainDir, "merge", "--squash", "old/squashed")
+	testutil.RunGit(t, mainDir, "commit", "-m", "squashed work (#1)")
+	testutil.RunGit(t, mainDir, "push", "origin", "--delete", "old/squashed")
+	testutil.RunGit(t, mainDir, "fetch", "--prune")
+
+	// An unmerged branch without a worktree
+	testutil.RunGit(t, mainDir, "branch", "wip")
+	wipDir := filepath.Join(repoDir, "wip")
+	testutil.RunGit(t, mainDir, "worktree", "add", wipDir, "wip")
+	testutil.RunGit(t, wipDir, "commit", "--allow-empty", "-m", "wip")
+	testutil.RunGit(t, mainDir, "worktree", "remove", wipDir)
+
+	// A merged branch checked out in a worktree is a worktree candidate
+	featDir := filepath.Join(repoDir, "feat", "a")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/a", featDir)
+
+	cfgResult, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := &CleanCommand{
+		FS:     osFS{},
+		Git:    NewGitRunner(mainDir),
+		Config: cfgResult.Config,
+	}
+
+	check, err := cmd.Run(mainDir, CleanOptions{Check: true, Branches: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := "clean:\n  feat/a (merged)\n\nbranches:\n  old/merged (merged)\n  old/squashed (upstream gone)\n"
+	if got := check.Format(FormatOptions{}).Stdout; got != want {
+		t.Errorf("Stdout = %q, want %q", got, want)
+	}
+
+	result, err := cmd.Run(mainDir, CleanOptions{Yes: true, Branches: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if errs := result.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	out := testutil.RunGit(t, mainDir, "branch", "--format=%(refname:short)")
+	if got := strings.Fields(out); strings.Join(got, " ") != "main wip" {
+		t.Errorf("remaining branches = %v, want [main wip]", got)
+	}
+}
+
+func TestCleanCommand_Integration_Unpushed(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	remoteDir := filepath.Join(repoDir, "remote.git")
+	testutil.RunGit(t, repoDir, "init", "--bare", remoteDir)
+	testutil.RunGit(t, mainDir, "remote", "add", "origin", remoteDir)
+	testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+
+	// Two commits are pushed and squash-merged, then the remote branch is deleted
+	wtPath := filepath.Join(repoDir, "feature", "late")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/late", wtPath)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(wtPath, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, wtPath, "add", name)
+		testutil.RunGit(t, wtPath, "commit", "-m", "add "+name)
+	}
+	testutil.RunGit(t, wtPath, "push", "-u", "origin", "feature/late")
+	testutil.RunGit(t, mainDir, "merge", "--squash", "feature/late")
+	testutil.RunGit(t, mainDir, "commit", "-m", "feature (#1)")
+	testutil.RunGit(t, mainDir, "push", "origin", "--delete", "feature/late")
+	testutil.RunGit(t, mainDir, "fetch", "--prune")
+
+	cfgResult, err := LoadConfig(mainDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := &CleanCommand{
+		FS:     osFS{},
+		Git:    NewGitRunner(mainDir),
+		Config: cfgResult.Config,
+	}
+
+	result, err := cmd.Run(mainDir, CleanOptions{Check: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if c := result.Candidates[0]; c.Skipped {
+		t.Fatalf("squash-merged branch should not be skipped, got %s", c.SkipReason)
+	}
+
+	// A commit made after the merge exists only locally
+	if err := os.WriteFile(filepath.Join(wtPath, "c.txt"), []byte("c"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, wtPath, "add", "c.txt")
+	testutil.RunGit(t, wtPath, "commit", "-m", "add c.txt")
+	objects := testutil.RunGit(t, mainDir, "count-objects")
+
+	result, err = cmd.Run(mainDir, CleanOptions{Check: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if c := result.Candidates[0]; !c.Skipped || c.SkipReason != SkipUnpushed || c.Unpushed != 1 {
+		t.Errorf("candidate = %+v, want skipped with 1 unpushed commit", c)
+	}
+	// Squash commits made for the check are not written to the repository
+	if got := testutil.RunGit(t, mainDir, "count-objects"); got != objects {
+		t.Errorf("objects after check = %q, want %q", got, objects)
+	}
+
+	result, err = cmd.Run(mainDir, CleanOptions{Check: true, Force: WorktreeForceLevelUnclean})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if c := result.Candidates[0]; c.Skipped {
+		t.Errorf("-f should bypass unpushed commits, got %s", c.SkipReason)
+	}
+}
@@ -1,8 +1,10 @@
 package twig
 
 import (
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/708u/twig/internal/testutil"
 )
@@ -99,6 +101,46 @@ func TestCleanResult_Format(t *testing.T) {
 			wantStdout: "clean:\n  feat/a (merged)\n\nskip:\n  feat/b (not merged)\n",
 			wantStderr: "",
 		},
+		{
+			name: "check_branches_group",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged},
+					{Branch: "feat/b", Skipped: true, SkipReason: SkipNotMerged},
+					{Branch: "old/x", BranchOnly: true, CleanReason: CleanMerged},
+					{Branch: "old/y", BranchOnly: true, CleanReason: CleanUpstreamGone},
+				},
+				Check: true,
+			},
+			opts:       FormatOptions{Verbose: true},
+			wantStdout: "clean:\n  feat/a (merged)\n\nbranches:\n  old/x (merged)\n  old/y (upstream gone)\n\nskip:\n  feat/b (not merged)\n",
+			wantStderr: "",
+		},
+		{
+			name: "verbose_shows_unpushed_count",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: true, SkipReason: SkipUnpushed, Unpushed: 2},
+					{Branch: "feat/b", Skipped: true, SkipReason: SkipUnpushed, Unpushed: 1},
+				},
+				Check: true,
+			},
+			opts:       FormatOptions{Verbose: true},
+			wantStdout: "skip:\n  feat/a (2 unpushed commits)\n  feat/b (1 unpushed commit)\n\nNo worktrees to clean\n",
+			wantStderr: "",
+		},
+		{
+			name: "check_branches_only",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "old/x", BranchOnly: true, CleanReason: CleanMerged},
+				},
+				Check: true,
+			},
+			opts:       FormatOptions{},
+			wantStdout: "branches:\n  old/x (merged)\n",
+			wantStderr: "",
+		},
 		{
 			name: "no_candidates",
 			result: CleanResult{
@@ -160,6 +202,18 @@ func TestCleanResult_Format(t *testing.T) {
 			wantStdout: "clean:\n  feat/a (merged)\n  feat/prunable (prunable, upstream gone)\n",
 			wantStderr: "",
 		},
+		{
+			name: "expired_lock",
+			result: CleanResult{
+				Candidates: []CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: CleanMerged, LockExpired: true},
+				},
+				Check: true,
+			},
+			opts:       FormatOptions{},
+			wantStdout: "clean:\n  feat/a (merged, lock expired)\n",
+			wantStderr: "",
+		},
 		{
 			name: "clean_prunable_and_skipped_verbose",
 			result: CleanResult{
@@ -439,6 +493,52 @@ func TestCleanCommand_Run(t *testing.T) {
 			wantCandidates: 1,
 			wantSkipped:    1,
 		},
+		{
+			name: "expired_lock_is_cleanable",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true, Now: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a", Locked: true,
+							LockReason: "owner=alice; expires=2026-01-01T00:00:00Z"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    0,
+		},
+		{
+			name: "unexpired_lock_is_skipped",
+			cwd:  "/other/dir",
+			opts: CleanOptions{Check: true, Now: time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)},
+			config: &Config{
+				WorktreeSourceDir: "/repo/main",
+				DefaultSource:     "main",
+			},
+			setupGit: func() *testutil.MockGitExecutor {
+				return &testutil.MockGitExecutor{
+					Worktrees: []testutil.MockWorktree{
+						{Path: "/repo/main", Branch: "main"},
+						{Path: "/repo/feat/a", Branch: "feat/a", Locked: true,
+							LockReason: "owner=alice; expires=2026-01-01T00:00:00Z"},
+					},
+					MergedBranches: map[string][]string{
+						"main": {"main", "feat/a"},
+					},
+				}
+			},
+			wantCandidates: 1,
+			wantSkipped:    1,
+		},
 		{
 			name: "mixed_worktree_and_orphaned",
 			cwd:  "/other/dir",
@@ -509,6 +609,175 @@ func TestCleanCommand_Run(t *testing.T) {
 	}
 }
 
+func TestCleanCommand_Run_Unpushed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		opts         CleanOptions
+		prunable     bool
+		unpushed     int
+		wantSkipped  bool
+		wantUnpushed int
+	}{
+		{
+			name:         "skips_unpushed_commits",
+			unpushed:     2,
+			wantSkipped:  true,
+			wantUnpushed: 2,
+		},
+		{
+			name:         "skips_unpushed_commits_of_prunable",
+			prunable:     true,
+			unpushed:     1,
+			wantSkipped:  true,
+			wantUnpushed: 1,
+		},
+		{
+			name:     "force_bypasses",
+			opts:     CleanOptions{Force: WorktreeForceLevelUnclean},
+			unpushed: 2,
+		},
+		{
+			name: "no_unpushed_commits",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockGit := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/main", Branch: "main"},
+					{Path: "/repo/feat/a", Branch: "feat/a", Prunable: tt.prunable},
+				},
+				UpstreamGoneBranches: []string{"feat/a"},
+				UnpushedCommits:      map[string]int{"feat/a": tt.unpushed},
+			}
+			cmd := &CleanCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: mockGit},
+				Config: &Config{WorktreeSourceDir: "/repo/main"},
+			}
+
+			tt.opts.Check = true
+			result, err := cmd.Run("/other/dir", tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(result.Candidates) != 1 {
+				t.Fatalf("got %d candidates, want 1", len(result.Candidates))
+			}
+
+			got := result.Candidates[0]
+			if got.Skipped != tt.wantSkipped {
+				t.Fatalf("Skipped = %v (%s), want %v", got.Skipped, got.SkipReason, tt.wantSkipped)
+			}
+			if tt.wantSkipped && (got.SkipReason != SkipUnpushed || got.Unpushed != tt.wantUnpushed) {
+				t.Errorf("skip = %s with %d commits, want %s with %d", got.SkipReason, got.Unpushed, SkipUnpushed, tt.wantUnpushed)
+			}
+		})
+	}
+}
+
+func TestCleanCommand_Run_UnpushedCheckFails(t *testing.T) {
+	t.Parallel()
+
+	base := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+		},
+		UpstreamGoneBranches: []string{"feat/a"},
+	}
+	mockGit := &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			if args[2] == "rev-list" {
+				return nil, errors.New("exit status 128")
+			}
+			return base.Run(args...)
+		},
+	}
+	cmd := &CleanCommand{
+		FS:     &testutil.MockFS{},
+		Git:    &GitRunner{Executor: mockGit},
+		Config: &Config{WorktreeSourceDir: "/repo/main"},
+	}
+
+	result, err := cmd.Run("/other/dir", CleanOptions{Check: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1", len(result.Candidates))
+	}
+	got := result.Candidates[0]
+	if !got.Skipped || got.SkipReason != SkipUnpushedCheck || got.Unpushed != 0 || got.UnpushedErr == nil {
+		t.Fatalf("candidate = %+v, want skipped with %s", got, SkipUnpushedCheck)
+	}
+
+	label := "cannot check for unpushed commits: failed to list unpushed commits: exit status 128"
+	formatted := result.Format(FormatOptions{})
+	if formatted.Stderr != "warning: feat/a: "+label+"\n" {
+		t.Errorf("Stderr = %q", formatted.Stderr)
+	}
+	if formatted.Stdout != "No worktrees to clean\n" {
+		t.Errorf("Stdout = %q", formatted.Stdout)
+	}
+	if stdout := result.Format(FormatOptions{Verbose: true}).Stdout; !strings.Contains(stdout, "skip:\n  feat/a ("+label+")\n") {
+		t.Errorf("verbose Stdout = %q", stdout)
+	}
+}
+
+func TestCleanCommand_Run_ExpiredLock(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	base := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a", Locked: true, LockReason: "expires=2026-01-01T00:00:00Z"},
+		},
+		MergedBranches: map[string][]string{
+			"main": {"main", "feat/a"},
+		},
+	}
+	mockGit := &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			if args[0] == "-C" {
+				args = args[2:]
+			}
+			if args[0] == "worktree" && (args[1] == "unlock" || args[1] == "remove") {
+				calls = append(calls, strings.Join(args, " "))
+			}
+			return base.Run(args...)
+		},
+	}
+	cmd := &CleanCommand{
+		FS:     &testutil.MockFS{},
+		Git:    &GitRunner{Executor: mockGit},
+		Config: &Config{WorktreeSourceDir: "/repo/main", DefaultSource: "main"},
+	}
+
+	result, err := cmd.Run("/other/dir", CleanOptions{Yes: true, Now: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Candidates) != 1 || !result.Candidates[0].LockExpired {
+		t.Errorf("Candidates = %+v, want feat/a with LockExpired", result.Candidates)
+	}
+	// Unlocked first, so a single -f removes it
+	want := []string{"worktree unlock /repo/feat/a", "worktree remove -f /repo/feat/a"}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].Err != nil {
+		t.Errorf("Removed = %+v", result.Removed)
+	}
+}
+
 func TestCleanCommand_ResolveTarget(t *testing.T) {
 	t.Parallel()
 
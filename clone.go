@@ -0,0 +1,215 @@
+package twig
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// bareDirName is the directory holding the bare repository in a clone layout.
+const bareDirName = ".bare"
+
+// CloneCommand clones a repository into a bare-repo worktree layout:
+//
+//	<dir>/.bare    bare repository
+//	<dir>/.git     file pointing at .bare
+//	<dir>/<branch> worktree of the default branch
+//
+// Worktrees created later with twig add are placed next to the first one.
+type CloneCommand struct {
+	FS  FileSystem
+	Git *GitRunner
+}
+
+// CloneOptions configures the clone operation.
+type CloneOptions struct {
+	// Dir is the layout directory, relative to cwd.
+	// Defaults to the repository name derived from the URL.
+	Dir string
+	// Branch is checked out in the first worktree instead of the
+	// remote's default branch.
+	Branch string
+}
+
+// CloneResult holds the result of a clone operation.
+type CloneResult struct {
+	URL          string
+	Dir          string
+	GitDir       string
+	Branch       string
+	WorktreePath string
+	Init         InitResult
+}
+
+// NewCloneCommand creates a CloneCommand with explicit dependencies (for testing).
+func NewCloneCommand(fs FileSystem, git *GitRunner) *CloneCommand {
+	return &CloneCommand{
+		FS:  fs,
+		Git: git,
+	}
+}
+
+// NewDefaultCloneCommand creates a CloneCommand with production defaults.
+func NewDefaultCloneCommand() *CloneCommand {
+	return NewCloneCommand(osFS{}, NewGitRunner(""))
+}
+
+// Run clones url into the bare layout and creates the first worktree.
+// If any step fails, everything created in the destination is removed so
+// that the clone can be retried.
+func (c *CloneCommand) Run(cwd, url string, opts CloneOptions) (result CloneResult, err error) {
+	result.URL = url
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = repoNameFromURL(url)
+		if dir == "" {
+			return result, fmt.Errorf("cannot derive a directory name from %q, pass one explicitly", url)
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cwd, dir)
+	}
+	result.Dir = filepath.Clean(dir)
+	result.GitDir = filepath.Join(result.Dir, bareDirName)
+
+	if entries, err := c.FS.ReadDir(result.Dir); err == nil && len(entries) > 0 {
+		return result, kindErrorf(ErrDirectoryExists, "destination %s already exists and is not empty", result.Dir)
+	}
+	_, statErr := c.FS.Stat(result.Dir)
+	existed := statErr == nil
+	defer func() {
+		if err != nil {
+			c.cleanup(result.Dir, existed)
+		}
+	}()
+
+	args := []string{GitCmdClone, "--bare"}
+	if opts.Branch != "" {
+		args = append(args, "--branch", opts.Branch)
+	}
+	args = append(args, "--", url, result.GitDir)
+	if _, err := c.Git.InDir(cwd).Run(args...); err != nil {
+		return result, fmt.Errorf("failed to clone %s: %w", url, withGitStderr(err))
+	}
+
+	if err := c.FS.WriteFile(filepath.Join(result.Dir, ".git"), []byte("gitdir: ./"+bareDirName+"\n"), 0644); err != nil {
+		return result, fmt.Errorf("failed to write .git file: %w", err)
+	}
+
+	// A bare clone maps remote branches onto local ones and fetches nothing
+	// afterwards; restore the usual remote-tracking setup.
+	git := c.Git.InDir(result.Dir)
+	if _, err := git.Run(GitCmdConfig, "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+		return result, fmt.Errorf("failed to configure fetch refspec: %w", err)
+	}
+	if err := git.Fetch("origin"); err != nil {
+		return result, fmt.Errorf("failed to fetch origin: %w", withGitStderr(err))
+	}
+	_, _ = git.Run(GitCmdRemote, "set-head", "origin", "--auto")
+
+	out, err := git.Run(GitCmdSymbolicRef, "--short", "HEAD")
+	if err != nil {
+		return result, fmt.Errorf("failed to detect default branch: %w", withGitStderr(err))
+	}
+	result.Branch = strings.TrimSpace(string(out))
+	// An empty remote, or one whose HEAD names a missing branch, leaves
+	// HEAD pointing at a branch that does not exist
+	if !git.LocalBranchExists(result.Branch) {
+		return result, kindErrorf(ErrBranchNotFound,
+			"default branch %s does not exist in %s (empty repository or dangling HEAD), pass --branch", result.Branch, url)
+	}
+
+	if err := c.excludeSettings(result.GitDir); err != nil {
+		return result, err
+	}
+
+	result.WorktreePath = filepath.Join(result.Dir, result.Branch)
+	if _, err := git.WorktreeAdd(result.WorktreePath, result.Branch); err != nil {
+		return result, fmt.Errorf("failed to create worktree for %s: %w", result.Branch, withGitStderr(err))
+	}
+	_, _ = git.Run(GitCmdBranch, "--set-upstream-to=origin/"+result.Branch, result.Branch)
+
+	// Sibling worktrees share the settings through a symlink. The base
+	// directory is relative to the first worktree, which owns the settings
+	// file, so the layout keeps working when it is moved.
+	destBaseDir, err := filepath.Rel(result.WorktreePath, result.Dir)
+	if err != nil {
+		return result, fmt.Errorf("failed to resolve destination directory: %w", err)
+	}
+	settingsRelPath := filepath.ToSlash(filepath.Join(configDir, configFileName))
+	result.Init, err = NewInitCommand(c.FS, c.Git).Run(result.WorktreePath, InitOptions{
+		DefaultSource: result.Branch,
+		Symlinks:      []string{settingsRelPath},
+		DestBaseDir:   filepath.ToSlash(destBaseDir),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// cleanup removes what a failed clone left in dir. The destination was
+// empty or missing before, so everything in it came from the clone.
+func (c *CloneCommand) cleanup(dir string, existed bool) {
+	if !existed {
+		_ = c.FS.RemoveAll(dir)
+		return
+	}
+	entries, _ := c.FS.ReadDir(dir)
+	for _, e := range entries {
+		_ = c.FS.RemoveAll(filepath.Join(dir, e.Name()))
+	}
+}
+
+// excludeSettings keeps the generated settings file out of git status in
+// every worktree of the layout.
+func (c *CloneCommand) excludeSettings(gitDir string) error {
+	path := filepath.Join(gitDir, "info", "exclude")
+	entry := "/" + filepath.ToSlash(filepath.Join(configDir, configFileName))
+
+	content, err := c.FS.ReadFile(path)
+	if err != nil && !c.FS.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if slices.Contains(strings.Split(string(content), "\n"), entry) {
+		return nil
+	}
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+	content = append(content, entry+"\n"...)
+	if err := c.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := c.FS.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// repoNameFromURL returns the repository name of a clone URL or path,
+// e.g. "twig" for "git@github.com:708u/twig.git".
+func repoNameFromURL(url string) string {
+	name := strings.TrimRight(url, "/")
+	name = strings.TrimSuffix(name, "/.git")
+	name = strings.TrimSuffix(name, ".git")
+	if i := strings.LastIndexAny(name, "/:\\"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// Format formats the CloneResult for display.
+func (r CloneResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+	fmt.Fprintf(&stdout, "Cloned %s into %s\n", r.URL, r.Dir)
+	if opts.Verbose {
+		fmt.Fprintf(&stdout, "  bare repository: %s\n", r.GitDir)
+	}
+	fmt.Fprintf(&stdout, "Created worktree %s [%s]\n", r.WorktreePath, r.Branch)
+	stdout.WriteString(r.Init.Format(InitFormatOptions{Verbose: opts.Verbose}).Stdout)
+	return FormatResult{Stdout: stdout.String()}
+}
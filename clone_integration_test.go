@@ -0,0 +1,172 @@
+//go:build integration
+
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestCloneCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	// setupRemote creates a repository with a committed file on main and
+	// a develop branch, returning its file:// URL.
+	setupRemote := func(t *testing.T) string {
+		t.Helper()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+		if err := os.WriteFile(filepath.Join(mainDir, "README.md"), []byte("# myapp\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", "README.md")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add readme")
+		testutil.RunGit(t, mainDir, "branch", "develop")
+		return "file://" + filepath.ToSlash(mainDir)
+	}
+
+	t.Run("BareLayout", func(t *testing.T) {
+		t.Parallel()
+
+		url := setupRemote(t)
+		workDir, _ := filepath.EvalSymlinks(t.TempDir())
+
+		result, err := NewDefaultCloneCommand().Run(workDir, url, CloneOptions{Dir: "myapp"})
+		if err != nil {
+			t.Fatalf("clone failed: %v", err)
+		}
+
+		root := filepath.Join(workDir, "myapp")
+		mainWT := filepath.Join(root, "main")
+		if result.Dir != root || result.Branch != "main" || result.WorktreePath != mainWT {
+			t.Errorf("result = %+v", result)
+		}
+
+		if out := testutil.RunGit(t, root, "rev-parse", "--is-bare-repository"); strings.TrimSpace(out) != "true" {
+			t.Errorf("layout root should be bare, got %q", out)
+		}
+		if _, err := os.Stat(filepath.Join(mainWT, "README.md")); err != nil {
+			t.Errorf("README.md not checked out: %v", err)
+		}
+		if out := testutil.RunGit(t, mainWT, "rev-parse", "--abbrev-ref", "main@{upstream}"); strings.TrimSpace(out) != "origin/main" {
+			t.Errorf("upstream = %q, want origin/main", out)
+		}
+		if out := testutil.RunGit(t, mainWT, "rev-parse", "--verify", "refs/remotes/origin/develop"); strings.TrimSpace(out) == "" {
+			t.Error("remote-tracking branch origin/develop should exist")
+		}
+		if out := testutil.RunGit(t, mainWT, "status", "--porcelain"); strings.TrimSpace(out) != "" {
+			t.Errorf("generated settings should be excluded, status = %q", out)
+		}
+
+		// twig add places the next worktree next to main/ and shares the settings
+		cfgResult, err := LoadConfig(mainWT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfgResult.Config.DefaultSource != "main" {
+			t.Errorf("default_source = %q, want main", cfgResult.Config.DefaultSource)
+		}
+		if _, err := NewDefaultAddCommand(cfgResult.Config, AddOptions{}).Run("feat/x"); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+		featWT := filepath.Join(root, "feat", "x")
+		target, err := os.Readlink(filepath.Join(featWT, ".twig", "settings.toml"))
+		if err != nil {
+			t.Fatalf("settings.toml should be symlinked: %v", err)
+		}
+		if target != filepath.Join(mainWT, ".twig", "settings.toml") {
+			t.Errorf("settings.toml symlink = %q", target)
+		}
+		if out := testutil.RunGit(t, featWT, "status", "--porcelain"); strings.TrimSpace(out) != "" {
+			t.Errorf("symlinked settings should be excluded, status = %q", out)
+		}
+
+		worktrees, err := NewGitRunner(mainWT).WorktreeList()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(worktrees) != 3 || !worktrees[0].Bare {
+			t.Errorf("worktrees = %+v, want bare entry plus main and feat/x", worktrees)
+		}
+	})
+
+	t.Run("Branch", func(t *testing.T) {
+		t.Parallel()
+
+		url := setupRemote(t)
+		workDir, _ := filepath.EvalSymlinks(t.TempDir())
+
+		result, err := NewDefaultCloneCommand().Run(workDir, url, CloneOptions{Branch: "develop"})
+		if err != nil {
+			t.Fatalf("clone failed: %v", err)
+		}
+		if result.Branch != "develop" || result.WorktreePath != filepath.Join(result.Dir, "develop") {
+			t.Errorf("result = %+v", result)
+		}
+		if filepath.Dir(result.Dir) != workDir {
+			t.Errorf("Dir = %q, want a directory in %q", result.Dir, workDir)
+		}
+	})
+
+	t.Run("EmptyRemote", func(t *testing.T) {
+		t.Parallel()
+
+		remoteDir := t.TempDir()
+		testutil.RunGit(t, remoteDir, "init", "--bare", "-b", "main")
+		url := "file://" + filepath.ToSlash(remoteDir)
+		workDir := t.TempDir()
+
+		for range 2 {
+			_, err := NewDefaultCloneCommand().Run(workDir, url, CloneOptions{Dir: "myapp"})
+			if err == nil || !strings.Contains(err.Error(), "default branch main does not exist") {
+				t.Fatalf("expected missing default branch error, got %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(workDir, "myapp")); !os.IsNotExist(err) {
+				t.Fatalf("failed clone should leave nothing behind: %v", err)
+			}
+		}
+	})
+
+	t.Run("LayoutCanBeMoved", func(t *testing.T) {
+		t.Parallel()
+
+		url := setupRemote(t)
+		workDir, _ := filepath.EvalSymlinks(t.TempDir())
+
+		result, err := NewDefaultCloneCommand().Run(workDir, url, CloneOptions{Dir: "myapp"})
+		if err != nil {
+			t.Fatalf("clone failed: %v", err)
+		}
+		moved := filepath.Join(workDir, "moved")
+		if err := os.Rename(result.Dir, moved); err != nil {
+			t.Fatal(err)
+		}
+
+		cfgResult, err := LoadConfig(filepath.Join(moved, "main"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfgResult.Config.WorktreeDestBaseDir != moved {
+			t.Errorf("WorktreeDestBaseDir = %q, want %q", cfgResult.Config.WorktreeDestBaseDir, moved)
+		}
+	})
+
+	t.Run("DestinationNotEmpty", func(t *testing.T) {
+		t.Parallel()
+
+		url := setupRemote(t)
+		workDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(workDir, "file"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := NewDefaultCloneCommand().Run(workDir, url, CloneOptions{Dir: "."})
+		if err == nil || !strings.Contains(err.Error(), "not empty") {
+			t.Fatalf("expected not empty error, got %v", err)
+		}
+	})
+}
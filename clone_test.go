@@ -0,0 +1,242 @@
+package twig
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRepoNameFromURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://github.com/708u/twig.git", want: "twig"},
+		{url: "https://github.com/708u/twig", want: "twig"},
+		{url: "git@github.com:708u/twig.git", want: "twig"},
+		{url: "host:twig.git", want: "twig"},
+		{url: "file:///srv/git/myapp.git/", want: "myapp"},
+		{url: "/srv/git/myapp/.git", want: "myapp"},
+		{url: "../myapp", want: "myapp"},
+		{url: "/", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			t.Parallel()
+
+			if got := repoNameFromURL(tt.url); got != tt.want {
+				t.Errorf("repoNameFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloneCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		url          string
+		opts         CloneOptions
+		dirContents  map[string][]os.DirEntry
+		cloneErr     error
+		missingHead  bool // the default branch does not exist
+		addErr       error
+		wantDir      string
+		wantWorktree string
+		wantCalls    []string
+		wantErr      string
+		wantRemoved  []string
+	}{
+		{
+			name:         "default_dir_from_url",
+			url:          "file:///srv/myapp.git",
+			wantDir:      "/work/myapp",
+			wantWorktree: "/work/myapp/main",
+			wantCalls: []string{
+				"-C /work clone --bare -- file:///srv/myapp.git /work/myapp/.bare",
+				"-C /work/myapp config remote.origin.fetch +refs/heads/*:refs/remotes/origin/*",
+				"-C /work/myapp fetch origin",
+				"-C /work/myapp worktree add /work/myapp/main main",
+				"-C /work/myapp branch --set-upstream-to=origin/main main",
+			},
+		},
+		{
+			name:         "explicit_dir_and_branch",
+			url:          "file:///srv/myapp.git",
+			opts:         CloneOptions{Dir: "/other/app", Branch: "develop"},
+			wantDir:      "/other/app",
+			wantWorktree: "/other/app/develop",
+			wantCalls: []string{
+				"-C /work clone --bare --branch develop -- file:///srv/myapp.git /other/app/.bare",
+				"-C /other/app worktree add /other/app/develop develop",
+			},
+		},
+		{
+			name:        "destination_not_empty",
+			url:         "file:///srv/myapp.git",
+			dirContents: map[string][]os.DirEntry{"/work/myapp": {nil}},
+			wantErr:     "already exists and is not empty",
+		},
+		{
+			name:        "clone_fails",
+			url:         "file:///srv/missing.git",
+			cloneErr:    &exec.ExitError{Stderr: []byte("fatal: repository not found\n")},
+			wantErr:     "fatal: repository not found",
+			wantRemoved: []string{"/work/missing"},
+		},
+		{
+			name:        "default_branch_missing",
+			url:         "file:///srv/empty.git",
+			missingHead: true,
+			wantErr:     "default branch main does not exist in file:///srv/empty.git",
+			wantRemoved: []string{"/work/empty"},
+		},
+		{
+			name:        "worktree_add_fails",
+			url:         "file:///srv/myapp.git",
+			addErr:      &exec.ExitError{Stderr: []byte("fatal: invalid reference: main\n")},
+			wantErr:     "fatal: invalid reference: main",
+			wantRemoved: []string{"/work/myapp"},
+		},
+		{
+			name:    "no_name_in_url",
+			url:     "/",
+			wantErr: "cannot derive a directory name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			branch := tt.opts.Branch
+			if branch == "" {
+				branch = "main"
+			}
+			git := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					calls = append(calls, strings.Join(args, " "))
+					switch args[2] {
+					case "clone":
+						return nil, tt.cloneErr
+					case "symbolic-ref":
+						return []byte(branch + "\n"), nil
+					case "rev-parse":
+						if tt.missingHead {
+							return nil, errors.New("exit status 128")
+						}
+					case "worktree":
+						return nil, tt.addErr
+					}
+					return nil, nil
+				},
+			}
+			var removed []string
+			fs := &testutil.MockFS{
+				DirContents:  tt.dirContents,
+				WrittenFiles: make(map[string][]byte),
+				RemoveAllFunc: func(path string) error {
+					removed = append(removed, path)
+					return nil
+				},
+			}
+
+			cmd := NewCloneCommand(fs, &GitRunner{Executor: git})
+			result, err := cmd.Run("/work", tt.url, tt.opts)
+
+			if !slices.Equal(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Dir != tt.wantDir || result.WorktreePath != tt.wantWorktree || result.Branch != branch {
+				t.Errorf("result = %+v", result)
+			}
+			for _, want := range tt.wantCalls {
+				if !slices.Contains(calls, want) {
+					t.Errorf("expected git call %q, got %v", want, calls)
+				}
+			}
+
+			if got := string(fs.WrittenFiles[tt.wantDir+"/.git"]); got != "gitdir: ./.bare\n" {
+				t.Errorf(".git file = %q", got)
+			}
+			if got := string(fs.WrittenFiles[tt.wantDir+"/.bare/info/exclude"]); got != "/.twig/settings.toml\n" {
+				t.Errorf("info/exclude = %q", got)
+			}
+			settings := string(fs.WrittenFiles[tt.wantWorktree+"/.twig/settings.toml"])
+			for _, want := range []string{
+				`default_source = "` + branch + `"`,
+				`symlinks = [".twig/settings.toml"]`,
+				`worktree_destination_base_dir = ".."`,
+			} {
+				if !strings.Contains(settings, want) {
+					t.Errorf("settings.toml should contain %q, got:\n%s", want, settings)
+				}
+			}
+		})
+	}
+}
+
+func TestCloneResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := CloneResult{
+		URL:          "file:///srv/myapp.git",
+		Dir:          "/work/myapp",
+		GitDir:       "/work/myapp/.bare",
+		Branch:       "main",
+		WorktreePath: "/work/myapp/main",
+		Init:         InitResult{Created: true, DefaultSource: "main"},
+	}
+
+	tests := []struct {
+		name string
+		opts FormatOptions
+		want string
+	}{
+		{
+			name: "default",
+			want: "Cloned file:///srv/myapp.git into /work/myapp\n" +
+				"Created worktree /work/myapp/main [main]\n" +
+				"Created .twig/settings.toml\n",
+		},
+		{
+			name: "verbose",
+			opts: FormatOptions{Verbose: true},
+			want: "Cloned file:///srv/myapp.git into /work/myapp\n" +
+				"  bare repository: /work/myapp/.bare\n" +
+				"Created worktree /work/myapp/main [main]\n" +
+				"Created .twig/settings.toml\n" +
+				"  default_source: main\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := result.Format(tt.opts).Stdout; got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
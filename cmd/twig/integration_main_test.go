@@ -0,0 +1,24 @@
+//go:build integration
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// TestMain keeps the developer's global settings and TWIG_* variables from
+// leaking into the configuration loaded by the integration tests.
+func TestMain(m *testing.M) {
+	restore, err := testutil.IsolateConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	restore()
+	os.Exit(code)
+}
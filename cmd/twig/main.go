@@ -2,12 +2,15 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/708u/twig"
 	"github.com/spf13/cobra"
@@ -41,15 +44,98 @@ type RemoveCommander interface {
 
 // InitCommander defines the interface for init operations.
 type InitCommander interface {
+	Detect(dir string) twig.InitDetection
 	Run(dir string, opts twig.InitOptions) (twig.InitResult, error)
 }
 
+// CloneCommander defines the interface for clone operations.
+type CloneCommander interface {
+	Run(cwd, url string, opts twig.CloneOptions) (twig.CloneResult, error)
+}
+
+// ConfigCommander defines the interface for config operations.
+type ConfigCommander interface {
+	List(opts twig.ConfigOptions) (twig.ConfigListResult, error)
+	Get(key string, opts twig.ConfigOptions) (twig.ConfigGetResult, error)
+	Set(key string, values []string, opts twig.ConfigOptions) (twig.ConfigSetResult, error)
+	Unset(key string, opts twig.ConfigOptions) (twig.ConfigUnsetResult, error)
+}
+
+// BackupsCommander defines the interface for backup operations.
+type BackupsCommander interface {
+	List(branch string) (twig.BackupListResult, error)
+	Restore(name string) (twig.BackupRestoreResult, error)
+	Prune(opts twig.BackupPruneOptions) (twig.BackupPruneResult, error)
+}
+
+// TrashCommander defines the interface for trash operations.
+type TrashCommander interface {
+	List(branch string) (twig.TrashListResult, error)
+	Restore(name string) (twig.TrashRestoreResult, error)
+	Empty(opts twig.TrashEmptyOptions) (twig.TrashEmptyResult, error)
+}
+
+// LockCommander defines the interface for lock and unlock operations.
+type LockCommander interface {
+	Lock(branches []string, opts twig.LockOptions) (twig.LockResult, error)
+	Unlock(branches []string) (twig.LockResult, error)
+}
+
+// MoveCommander defines the interface for move and migrate operations.
+type MoveCommander interface {
+	Move(branch, dst, cwd string, opts twig.MoveOptions) (twig.MoveResult, error)
+	Migrate(cwd string, opts twig.MoveOptions) (twig.MoveResult, error)
+}
+
+// ExecCommander defines the interface for exec operations.
+type ExecCommander interface {
+	Run(args []string, opts twig.ExecOptions) (twig.ExecResult, error)
+}
+
+// UpdateCommander defines the interface for update operations.
+type UpdateCommander interface {
+	Run(cwd string, opts twig.UpdateOptions) (twig.UpdateResult, error)
+}
+
+// PickCommander defines the interface for interactive worktree selection.
+type PickCommander interface {
+	Run(opts twig.PickOptions) (twig.PickResult, error)
+}
+
+// RelinkCommander defines the interface for relink operations.
+type RelinkCommander interface {
+	Run(branch, cwd string, opts twig.RelinkOptions) (twig.RelinkResult, error)
+}
+
+// CarryCommander defines the interface for carry operations.
+type CarryCommander interface {
+	Run(branch string, opts twig.CarryOptions) (twig.CarryResult, error)
+}
+
+// RecoverCommander defines the interface for recover operations.
+type RecoverCommander interface {
+	Run(opts twig.RecoverOptions) (twig.RecoverResult, error)
+	Pending() ([]twig.StashOperation, error)
+}
+
 type options struct {
-	addCommander    AddCommander    // nil = use default
-	cleanCommander  CleanCommander  // nil = use default
-	listCommander   ListCommander   // nil = use default
-	removeCommander RemoveCommander // nil = use default
-	initCommander   InitCommander   // nil = use default
+	addCommander     AddCommander     // nil = use default
+	cleanCommander   CleanCommander   // nil = use default
+	listCommander    ListCommander    // nil = use default
+	removeCommander  RemoveCommander  // nil = use default
+	initCommander    InitCommander    // nil = use default
+	cloneCommander   CloneCommander   // nil = use default
+	configCommander  ConfigCommander  // nil = use default
+	backupsCommander BackupsCommander // nil = use default
+	trashCommander   TrashCommander   // nil = use default
+	lockCommander    LockCommander    // nil = use default
+	moveCommander    MoveCommander    // nil = use default
+	execCommander    ExecCommander    // nil = use default
+	updateCommander  UpdateCommander  // nil = use default
+	pickCommander    PickCommander    // nil = use default
+	relinkCommander  RelinkCommander  // nil = use default
+	carryCommander   CarryCommander   // nil = use default
+	recoverCommander RecoverCommander // nil = use default
 }
 
 // Option configures newRootCmd.
@@ -90,6 +176,90 @@ func WithInitCommander(cmd InitCommander) Option {
 	}
 }
 
+// WithCloneCommander sets the CloneCommander instance for testing.
+func WithCloneCommander(cmd CloneCommander) Option {
+	return func(o *options) {
+		o.cloneCommander = cmd
+	}
+}
+
+// WithConfigCommander sets the ConfigCommander instance for testing.
+func WithConfigCommander(cmd ConfigCommander) Option {
+	return func(o *options) {
+		o.configCommander = cmd
+	}
+}
+
+// WithBackupsCommander sets the BackupsCommander instance for testing.
+func WithBackupsCommander(cmd BackupsCommander) Option {
+	return func(o *options) {
+		o.backupsCommander = cmd
+	}
+}
+
+// WithTrashCommander sets the TrashCommander instance for testing.
+func WithTrashCommander(cmd TrashCommander) Option {
+	return func(o *options) {
+		o.trashCommander = cmd
+	}
+}
+
+// WithLockCommander sets the LockCommander instance for testing.
+func WithLockCommander(cmd LockCommander) Option {
+	return func(o *options) {
+		o.lockCommander = cmd
+	}
+}
+
+// WithMoveCommander sets the MoveCommander instance for testing.
+func WithMoveCommander(cmd MoveCommander) Option {
+	return func(o *options) {
+		o.moveCommander = cmd
+	}
+}
+
+// WithExecCommander sets the ExecCommander instance for testing.
+func WithExecCommander(cmd ExecCommander) Option {
+	return func(o *options) {
+		o.execCommander = cmd
+	}
+}
+
+// WithUpdateCommander sets the UpdateCommander instance for testing.
+func WithUpdateCommander(cmd UpdateCommander) Option {
+	return func(o *options) {
+		o.updateCommander = cmd
+	}
+}
+
+// WithPickCommander sets the PickCommander instance for testing.
+func WithPickCommander(cmd PickCommander) Option {
+	return func(o *options) {
+		o.pickCommander = cmd
+	}
+}
+
+// WithRelinkCommander sets the RelinkCommander instance for testing.
+func WithRelinkCommander(cmd RelinkCommander) Option {
+	return func(o *options) {
+		o.relinkCommander = cmd
+	}
+}
+
+// WithCarryCommander sets the CarryCommander instance for testing.
+func WithCarryCommander(cmd CarryCommander) Option {
+	return func(o *options) {
+		o.carryCommander = cmd
+	}
+}
+
+// WithRecoverCommander sets the RecoverCommander instance for testing.
+func WithRecoverCommander(cmd RecoverCommander) Option {
+	return func(o *options) {
+		o.recoverCommander = cmd
+	}
+}
+
 // carryFromCurrent is the sentinel value for --carry flag to use current worktree.
 const carryFromCurrent = "<current>"
 
@@ -137,6 +307,10 @@ func resolveDirectory(dirFlag, baseCwd string) (string, error) {
 	return resolved, nil
 }
 
+// pendingCheckCommands are the commands that list or change worktrees and
+// stashes, where an interrupted carry/sync operation is worth pointing out.
+var pendingCheckCommands = []string{"add", "carry", "clean", "list", "move", "remove", "update"}
+
 func newRootCmd(opts ...Option) *cobra.Command {
 	o := &options{}
 	for _, opt := range opts {
@@ -150,6 +324,13 @@ func newRootCmd(opts ...Option) *cobra.Command {
 		dirFlag     string
 	)
 
+	newRecoverCommander := func() RecoverCommander {
+		if o.recoverCommander != nil {
+			return o.recoverCommander
+		}
+		return twig.NewDefaultRecoverCommand(cfg)
+	}
+
 	resolveCompletionDirectory := func(cmd *cobra.Command) (string, error) {
 		currentCwd, err := os.Getwd()
 		if err != nil {
@@ -185,6 +366,15 @@ func newRootCmd(opts ...Option) *cobra.Command {
 				fmt.Fprintln(cmd.ErrOrStderr(), "warning:", w)
 			}
 			cfg = result.Config
+
+			// Point at operations interrupted by a previous invocation
+			if cmd.Parent() == cmd.Root() && slices.Contains(pendingCheckCommands, cmd.Name()) {
+				if pending, err := newRecoverCommander().Pending(); err == nil && len(pending) > 0 {
+					fmt.Fprintf(cmd.ErrOrStderr(),
+						"warning: %d interrupted carry/sync operation(s) found, run 'twig recover' to restore them\n",
+						len(pending))
+				}
+			}
 			return nil
 		},
 	}
@@ -204,7 +394,19 @@ Use --carry to move uncommitted changes (only new worktree has them).
 Use --file with --sync or --carry to target specific files:
 
   twig add feat/new --sync --file "*.go"
-  twig add feat/new --carry --file "*.go" --file "cmd/**"`,
+  twig add feat/new --carry --file "*.go" --file "cmd/**"
+
+Use --staged, --unstaged or --untracked-only to select which changes:
+
+  git add -p && twig add feat/new --carry --staged
+
+Use --submodules to populate submodules (overrides the submodules setting):
+
+  twig add feat/new --submodules recursive
+
+Use --sparse to check out only some directories (overrides the sparse setting):
+
+  twig add feat/api --sparse services/api,libs/common`,
 		Args: cobra.ExactArgs(1),
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) >= 1 {
@@ -231,9 +433,9 @@ Use --file with --sync or --carry to target specific files:
 				return fmt.Errorf("cannot use --sync and --carry together")
 			}
 
-			// Resolve effective source: CLI --source > config default_source
+			// Resolve effective source: CLI --source > profile default_source > config default_source
 			if source == "" {
-				source = cfg.DefaultSource
+				source = cfg.DefaultSourceFor(args[0])
 			}
 
 			if source == "" {
@@ -265,6 +467,7 @@ Use --file with --sync or --carry to target specific files:
 			quiet, _ := cmd.Flags().GetBool("quiet")
 			lock, _ := cmd.Flags().GetBool("lock")
 			lockReason, _ := cmd.Flags().GetString("reason")
+			sparse, _ := cmd.Flags().GetStringSlice("sparse")
 			carryEnabled := cmd.Flags().Changed("carry")
 
 			// Get file patterns from --file flag
@@ -275,11 +478,36 @@ Use --file with --sync or --carry to target specific files:
 				return fmt.Errorf("--file requires --carry or --sync flag")
 			}
 
+			// Scope selectors require --carry or --sync
+			scope := twig.StashScopeAll
+			for flag, s := range map[string]twig.StashScope{
+				"staged":         twig.StashScopeStaged,
+				"unstaged":       twig.StashScopeUnstaged,
+				"untracked-only": twig.StashScopeUntracked,
+			} {
+				if v, _ := cmd.Flags().GetBool(flag); v {
+					if !carryEnabled && !sync {
+						return fmt.Errorf("--%s requires --carry or --sync flag", flag)
+					}
+					scope = s
+				}
+			}
+
 			// --reason requires --lock
 			if lockReason != "" && !lock {
 				return fmt.Errorf("--reason requires --lock")
 			}
 
+			// Empty leaves the choice to the submodules setting
+			var submodules twig.SubmoduleMode
+			if cmd.Flags().Changed("submodules") {
+				value, _ := cmd.Flags().GetString("submodules")
+				var err error
+				if submodules, err = twig.ParseSubmoduleMode(value); err != nil {
+					return err
+				}
+			}
+
 			// Resolve CarryFrom path
 			var carryFrom string
 			if carryEnabled {
@@ -300,8 +528,11 @@ Use --file with --sync or --carry to target specific files:
 					Sync:         sync,
 					CarryFrom:    carryFrom,
 					FilePatterns: filePatterns,
+					Scope:        scope,
 					Lock:         lock,
 					LockReason:   lockReason,
+					Submodules:   submodules,
+					Sparse:       sparse,
 				})
 			}
 			result, err := addCmd.Run(args[0])
@@ -356,10 +587,14 @@ Use --check to only show candidates without prompting.
 
 Safety checks (all must pass):
   - Branch is merged to target
+  - No commits that exist only locally
   - No uncommitted changes
   - Worktree is not locked
   - Not the current directory
-  - Not the main worktree`,
+  - Not the main worktree
+
+Use --branches to also delete merged local branches that are not
+checked out in any worktree.`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			verbose, _ := cmd.Flags().GetBool("verbose")
@@ -367,6 +602,12 @@ Safety checks (all must pass):
 			check, _ := cmd.Flags().GetBool("check")
 			target, _ := cmd.Flags().GetString("target")
 			forceCount, _ := cmd.Flags().GetCount("force")
+			remote, _ := cmd.Flags().GetBool("remote")
+			branches, _ := cmd.Flags().GetBool("branches")
+			trash := cfg.TrashEnabled()
+			if cmd.Flags().Changed("trash") {
+				trash, _ = cmd.Flags().GetBool("trash")
+			}
 
 			var cleanCmd CleanCommander
 			if o.cleanCommander != nil {
@@ -377,10 +618,13 @@ Safety checks (all must pass):
 
 			// First pass: analyze candidates (always in check mode first)
 			result, err := cleanCmd.Run(cwd, twig.CleanOptions{
-				Check:   true,
-				Target:  target,
-				Verbose: verbose,
-				Force:   twig.WorktreeForceLevel(forceCount),
+				Check:    true,
+				Target:   target,
+				Verbose:  verbose,
+				Force:    twig.WorktreeForceLevel(forceCount),
+				Remote:   remote,
+				Branches: branches,
+				Trash:    trash,
 			})
 			if err != nil {
 				return err
@@ -419,10 +663,13 @@ Safety checks (all must pass):
 
 			// Second pass: execute removal
 			result, err = cleanCmd.Run(cwd, twig.CleanOptions{
-				Check:   false,
-				Target:  target,
-				Verbose: verbose,
-				Force:   twig.WorktreeForceLevel(forceCount),
+				Check:    false,
+				Target:   target,
+				Verbose:  verbose,
+				Force:    twig.WorktreeForceLevel(forceCount),
+				Remote:   remote,
+				Branches: branches,
+				Trash:    trash,
 			})
 			if err != nil {
 				return err
@@ -433,6 +680,11 @@ Safety checks (all must pass):
 				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
 			}
 			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+
+			if errs := result.Errors(); len(errs) > 0 {
+				return failedTargetsError(errs, len(result.Removed),
+					"failed to remove %d worktree(s)", len(errs))
+			}
 			return nil
 		},
 	}
@@ -444,11 +696,24 @@ Safety checks (all must pass):
 
 The branch names are used to locate the worktrees.
 By default, fails if there are uncommitted changes or the branch is not merged.
-Use --force to override these checks.
+Use --force to override these checks. Forced removals first back up
+uncommitted changes and the branch tip (see 'twig backups'), unless the
+backup setting is false.
 
 Multiple branches can be specified. Errors on individual branches will not
-stop processing of remaining branches.`,
-		Args: cobra.MinimumNArgs(1),
+stop processing of remaining branches.
+
+With --interactive, branches are chosen in a picker instead: type to filter,
+Tab to select several, Enter to remove them.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if interactive, _ := cmd.Flags().GetBool("interactive"); interactive {
+				if len(args) > 0 {
+					return fmt.Errorf("branches cannot be combined with --interactive")
+				}
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			dir, err := resolveCompletionDirectory(cmd)
 			if err != nil {
@@ -472,6 +737,31 @@ stop processing of remaining branches.`,
 			verbose, _ := cmd.Flags().GetBool("verbose")
 			forceCount, _ := cmd.Flags().GetCount("force")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			interactive, _ := cmd.Flags().GetBool("interactive")
+			remote, _ := cmd.Flags().GetBool("remote")
+			trash := cfg.TrashEnabled()
+			if cmd.Flags().Changed("trash") {
+				trash, _ = cmd.Flags().GetBool("trash")
+			}
+
+			if interactive {
+				var pickCmd PickCommander
+				if o.pickCommander != nil {
+					pickCmd = o.pickCommander
+				} else {
+					pickCmd = twig.NewDefaultPickCommand(cwd)
+				}
+				picked, err := pickCmd.Run(twig.PickOptions{Multi: true, Removable: true})
+				if errors.Is(err, twig.ErrPickCanceled) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				for _, wt := range picked.Worktrees {
+					args = append(args, wt.Branch)
+				}
+			}
 
 			var removeCmd RemoveCommander
 			if o.removeCommander != nil {
@@ -485,6 +775,9 @@ stop processing of remaining branches.`,
 				wt, err := removeCmd.Run(branch, cwd, twig.RemoveOptions{
 					Force:  twig.WorktreeForceLevel(forceCount),
 					DryRun: dryRun,
+					Backup: cfg.BackupEnabled(),
+					Remote: remote,
+					Trash:  trash,
 				})
 				if err != nil {
 					wt.Branch = branch
@@ -500,7 +793,8 @@ stop processing of remaining branches.`,
 			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
 
 			if result.HasErrors() {
-				return fmt.Errorf("failed to remove %d branch(es)", result.ErrorCount())
+				return failedTargetsError(result.Errors(), len(result.Removed),
+					"failed to remove %d branch(es)", result.ErrorCount())
 			}
 			return nil
 		},
@@ -518,6 +812,20 @@ stop processing of remaining branches.`,
 	addCmd.Flags().Bool("lock", false, "Lock the worktree after creation")
 	addCmd.Flags().String("reason", "", "Reason for locking (requires --lock)")
 	addCmd.Flags().StringArrayP("file", "F", nil, "File patterns to sync/carry (requires --sync or --carry)")
+	addCmd.Flags().Bool("staged", false, "Sync/carry only staged changes (requires --sync or --carry)")
+	addCmd.Flags().Bool("unstaged", false, "Sync/carry only unstaged changes of tracked files (requires --sync or --carry)")
+	addCmd.Flags().Bool("untracked-only", false, "Sync/carry only untracked files (requires --sync or --carry)")
+	addCmd.MarkFlagsMutuallyExclusive("staged", "unstaged", "untracked-only")
+	addCmd.Flags().String("submodules", "", "Populate submodules: none, init or recursive (default: submodules setting)")
+	addCmd.RegisterFlagCompletionFunc("submodules", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			string(twig.SubmoduleModeNone), string(twig.SubmoduleModeInit), string(twig.SubmoduleModeRecursive),
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	addCmd.Flags().StringSlice("sparse", nil, "Check out only these directories with cone-mode sparse-checkout (default: sparse setting)")
+	addCmd.RegisterFlagCompletionFunc("sparse", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	})
 	addCmd.RegisterFlagCompletionFunc("file", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		// Resolve target directory from -C flag
 		dir, err := resolveCompletionDirectory(cmd)
@@ -555,74 +863,1203 @@ stop processing of remaining branches.`,
 	listCmd.Flags().BoolP("quiet", "q", false, "Output only worktree paths")
 	rootCmd.AddCommand(listCmd)
 
+	pickCmd := &cobra.Command{
+		Use:   "pick",
+		Short: "Choose a worktree interactively",
+		Long: `Choose a worktree in an interactive picker and print its path.
+
+Type to fuzzy-filter worktrees by branch and path. The preview pane shows
+the status and recent commits of the highlighted worktree.
+
+Keys:
+  Up, Down, Ctrl-P, Ctrl-N  Move the cursor
+  Tab                       Select several worktrees (with --multi)
+  Enter                     Choose the highlighted or selected worktrees
+  Ctrl-U                    Clear the query
+  Esc, Ctrl-C               Quit without choosing
+
+The picker draws on the terminal directly, so its output can be captured.`,
+		Example: `  cd "$(twig pick)"
+  twig pick --multi --branch | xargs twig lock`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			multi, _ := cmd.Flags().GetBool("multi")
+			query, _ := cmd.Flags().GetString("query")
+			branch, _ := cmd.Flags().GetBool("branch")
+
+			var pickCmd PickCommander
+			if o.pickCommander != nil {
+				pickCmd = o.pickCommander
+			} else {
+				pickCmd = twig.NewDefaultPickCommand(cwd)
+			}
+			result, err := pickCmd.Run(twig.PickOptions{Multi: multi, Query: query})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(twig.PickFormatOptions{Branch: branch})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	pickCmd.Flags().BoolP("multi", "m", false, "Allow choosing several worktrees")
+	pickCmd.Flags().String("query", "", "Start with this filter query")
+	pickCmd.Flags().Bool("branch", false, "Print branch names instead of paths")
+	rootCmd.AddCommand(pickCmd)
+
 	cleanCmd.Flags().BoolP("yes", "y", false, "Execute removal without confirmation")
 	cleanCmd.Flags().Bool("check", false, "Show candidates without prompting or removing")
 	cleanCmd.Flags().String("target", "", "Target branch for merge check (default: auto-detect)")
 	cleanCmd.Flags().CountP("force", "f", "Force clean (-f: unmerged/uncommitted, -ff: also locked)")
+	cleanCmd.Flags().Bool("remote", false, "Also delete the upstream branches on their remotes")
+	cleanCmd.Flags().Bool("branches", false, "Also clean merged local branches without a worktree")
+	cleanCmd.Flags().Bool("trash", false, "Move worktree directories to the trash instead of deleting them (default from config)")
 	rootCmd.AddCommand(cleanCmd)
 
 	removeCmd.Flags().CountP("force", "f", "Force removal (-f: uncommitted/unmerged, -ff: also locked)")
 	removeCmd.Flags().Bool("dry-run", false, "Show what would be removed without making changes")
+	removeCmd.Flags().BoolP("interactive", "i", false, "Choose the worktrees to remove in a picker")
+	removeCmd.Flags().Bool("remote", false, "Also delete the upstream branch on its remote")
+	removeCmd.Flags().Bool("trash", false, "Move the worktree directory to the trash instead of deleting it (default from config)")
 	rootCmd.AddCommand(removeCmd)
 
-	initCmd := &cobra.Command{
-		Use:   "init",
-		Short: "Initialize twig configuration",
-		Long:  `Create a .twig/settings.toml configuration file in the current directory.`,
-		Args:  cobra.NoArgs,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			// Override parent's PersistentPreRunE to skip config loading
-			// since init creates the config file
-			var err error
-			originalCwd, err = os.Getwd()
+	writeFormatted := func(cmd *cobra.Command, formatted twig.FormatResult) {
+		if formatted.Stderr != "" {
+			fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+		}
+		fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+	}
+
+	getLockCommander := func() LockCommander {
+		if o.lockCommander != nil {
+			return o.lockCommander
+		}
+		return twig.NewDefaultLockCommand(cwd)
+	}
+
+	// completeLockBranches completes branches of worktrees whose lock state is locked.
+	completeLockBranches := func(locked bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			dir, err := resolveCompletionDirectory(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to get current directory: %w", err)
+				return nil, cobra.ShellCompDirectiveError
+			}
+			worktrees, err := twig.NewGitRunner(dir).WorktreeList()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			var branches []string
+			for _, wt := range worktrees[min(1, len(worktrees)):] {
+				if wt.Branch != "" && wt.Locked == locked && !slices.Contains(args, wt.Branch) {
+					branches = append(branches, wt.Branch)
+				}
 			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
 
-			cwd, err = resolveDirectory(dirFlag, originalCwd)
+	lockCmd := &cobra.Command{
+		Use:   "lock <branch>...",
+		Short: "Lock worktrees",
+		Long: `Lock the worktrees of the given branches.
+
+Locked worktrees are protected from 'twig remove', 'twig clean' and
+'git worktree prune'. The owner, reason and expiry are recorded in the
+git lock reason and shown by 'twig list'. The owner defaults to git's
+user.name. 'twig clean' treats locks whose expiry has passed as unlocked.
+
+--expires accepts an age (e.g. 8h, 2d, 1w) or an RFC 3339 timestamp.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeLockBranches(false),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			reason, _ := cmd.Flags().GetString("reason")
+			owner, _ := cmd.Flags().GetString("owner")
+			expiresFlag, _ := cmd.Flags().GetString("expires")
+
+			var expires time.Time
+			if expiresFlag != "" {
+				var err error
+				expires, err = twig.ParseLockExpiry(expiresFlag, time.Now())
+				if err != nil {
+					return err
+				}
+			}
+
+			result, err := getLockCommander().Lock(args, twig.LockOptions{
+				Owner:   owner,
+				Purpose: reason,
+				Expires: expires,
+			})
 			if err != nil {
 				return err
 			}
-
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			if result.HasErrors() {
+				return fmt.Errorf("failed to lock %d branch(es)", result.ErrorCount())
+			}
 			return nil
 		},
+	}
+	lockCmd.Flags().String("reason", "", "Reason for locking")
+	lockCmd.Flags().String("owner", "", "Owner of the lock (default: git user.name)")
+	lockCmd.Flags().String("expires", "", "Expire the lock after an age or at a time (e.g. 8h, 2d, 2026-01-02T15:04:05Z)")
+	rootCmd.AddCommand(lockCmd)
+
+	unlockCmd := &cobra.Command{
+		Use:               "unlock <branch>...",
+		Short:             "Unlock worktrees",
+		Long:              `Unlock the worktrees of the given branches.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeLockBranches(true),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			force, _ := cmd.Flags().GetBool("force")
-
-			var initCommand InitCommander
-			if o.initCommander != nil {
-				initCommand = o.initCommander
-			} else {
-				initCommand = twig.NewDefaultInitCommand()
-			}
-			result, err := initCommand.Run(cwd, twig.InitOptions{Force: force})
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			result, err := getLockCommander().Unlock(args)
 			if err != nil {
 				return err
 			}
-
-			formatted := result.Format(twig.InitFormatOptions{})
-			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			if result.HasErrors() {
+				return fmt.Errorf("failed to unlock %d branch(es)", result.ErrorCount())
+			}
 			return nil
 		},
 	}
-	initCmd.Flags().BoolP("force", "f", false, "Overwrite existing configuration file")
-	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(unlockCmd)
 
-	versionCmd := &cobra.Command{
-		Use:   "version",
-		Short: "Print version information",
-		Args:  cobra.NoArgs,
-		Run: func(cmd *cobra.Command, args []string) {
-			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 1, ' ', 0)
-			fmt.Fprintf(w, "version:\t%s\n", version)
-			fmt.Fprintf(w, "commit:\t%s\n", commit)
-			fmt.Fprintf(w, "date:\t%s\n", date)
-			w.Flush()
+	getMoveCommander := func() MoveCommander {
+		if o.moveCommander != nil {
+			return o.moveCommander
+		}
+		return twig.NewDefaultMoveCommand(cfg)
+	}
+
+	// runMove prints the result of a move or migrate and reports failures.
+	runMove := func(cmd *cobra.Command, result twig.MoveResult, err error) error {
+		if err != nil {
+			return err
+		}
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+		if result.HasErrors() {
+			return fmt.Errorf("%d error(s) occurred", result.ErrorCount())
+		}
+		return nil
+	}
+
+	moveCmd := &cobra.Command{
+		Use:   "move <branch> <path>",
+		Short: "Move a worktree to another path",
+		Long: `Move the worktree of a branch to another path with 'git worktree move'.
+
+Missing parent directories of <path> are created, and 'git worktree repair'
+is run on the moved worktree. Symlinks that pointed into the worktree from
+other worktrees, and relative symlinks inside it that point elsewhere, are
+re-pointed so that they resolve to the same files. Empty parent directories
+left behind are removed.
+
+Locked worktrees are moved only with -ff.`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return nil, cobra.ShellCompDirectiveFilterDirs
+			}
+			if len(args) > 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			dir, err := resolveCompletionDirectory(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			branches, err := twig.NewGitRunner(dir).WorktreeListBranches()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forceCount, _ := cmd.Flags().GetCount("force")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			dst := args[1]
+			if !filepath.IsAbs(dst) {
+				dst = filepath.Join(cwd, dst)
+			}
+			result, err := getMoveCommander().Move(args[0], dst, cwd, twig.MoveOptions{
+				Force:  twig.WorktreeForceLevel(forceCount),
+				DryRun: dryRun,
+			})
+			return runMove(cmd, result, err)
 		},
 	}
-	rootCmd.AddCommand(versionCmd)
+	moveCmd.Flags().CountP("force", "f", "Force move (-ff: also locked worktrees)")
+	moveCmd.Flags().Bool("dry-run", false, "Show what would be moved without making changes")
+	rootCmd.AddCommand(moveCmd)
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move worktrees into the configured layout",
+		Long: `Move every worktree into the location 'twig add' would use today.
+
+After worktree_destination_base_dir or a profile's destination changes,
+existing worktrees stay where they were. migrate moves each of them to
+<destination base dir>/<branch>, like 'twig move' does.
+
+The main worktree and detached HEAD worktrees are never moved. Worktrees
+that are prunable, contain the current directory, are locked (without -ff)
+or whose destination already exists are skipped; use --verbose to list them.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forceCount, _ := cmd.Flags().GetCount("force")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			result, err := getMoveCommander().Migrate(cwd, twig.MoveOptions{
+				Force:  twig.WorktreeForceLevel(forceCount),
+				DryRun: dryRun,
+			})
+			return runMove(cmd, result, err)
+		},
+	}
+	migrateCmd.Flags().CountP("force", "f", "Force move (-ff: also locked worktrees)")
+	migrateCmd.Flags().Bool("dry-run", false, "Show what would be moved without making changes")
+	rootCmd.AddCommand(migrateCmd)
+
+	execCmd := &cobra.Command{
+		Use:   "exec [--all | --filter <glob> | <branch>...] -- <command> [args...]",
+		Short: "Run a command in worktrees",
+		Long: `Run a command inside the directory of each selected worktree.
+
+Select worktrees by branch name, with --all (every worktree including the
+main one), or with --filter (branches matching a glob, e.g. 'feat/**').
+Bare and prunable entries are never run in.
+
+Commands run in parallel, at most --jobs at a time. Each output line is
+prefixed with the branch name and written as soon as it is complete, so
+lines of different worktrees never mix. A summary of the exit statuses is
+printed at the end, and twig exits with 1 if any command failed.
+
+The command is run directly, not through a shell. Use 'sh -c' for pipes
+and other shell syntax.`,
+		Example: `  twig exec --all -- git pull --ff-only
+  twig exec --filter 'feat/**' -j 2 -- make test
+  twig exec feat/a feat/b -- sh -c 'git log --oneline -1'`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() < 0 || cmd.ArgsLenAtDash() == len(args) {
+				return errors.New("missing command, use: twig exec <selection> -- <command>")
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if cmd.ArgsLenAtDash() >= 0 {
+				return nil, cobra.ShellCompDirectiveDefault
+			}
+			dir, err := resolveCompletionDirectory(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			branches, err := twig.NewGitRunner(dir).WorktreeListBranches()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			var candidates []string
+			for _, b := range branches {
+				if !slices.Contains(args, b) {
+					candidates = append(candidates, b)
+				}
+			}
+			return candidates, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			filter, _ := cmd.Flags().GetString("filter")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			dash := cmd.ArgsLenAtDash()
 
-	return rootCmd
+			var execCommander ExecCommander
+			if o.execCommander != nil {
+				execCommander = o.execCommander
+			} else {
+				execCommander = twig.NewDefaultExecCommand(cwd)
+			}
+
+			result, err := execCommander.Run(args[dash:], twig.ExecOptions{
+				All:      all,
+				Filter:   filter,
+				Branches: args[:dash],
+				Jobs:     jobs,
+				Stdout:   cmd.OutOrStdout(),
+				Stderr:   cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			if result.HasErrors() {
+				return fmt.Errorf("command failed in %d worktree(s)", result.ErrorCount())
+			}
+			return nil
+		},
+	}
+	execCmd.Flags().BoolP("all", "a", false, "Run in every worktree")
+	execCmd.Flags().String("filter", "", "Run in worktrees whose branch matches a glob")
+	execCmd.Flags().IntP("jobs", "j", runtime.NumCPU(), "Maximum number of commands running at once")
+	execCmd.MarkFlagsMutuallyExclusive("all", "filter")
+	rootCmd.AddCommand(execCmd)
+
+	updateCmd := &cobra.Command{
+		Use:   "update [<branch>...]",
+		Short: "Bring worktrees up to date",
+		Long: `Fetch all remotes once, then bring worktrees up to date.
+
+Without arguments the current worktree is updated; pass branches or --all
+to update others.
+
+Strategies:
+  ff      Fast-forward each branch to its upstream (default)
+  rebase  Rebase each branch onto the target branch
+  merge   Merge the target branch into each branch
+
+With rebase and merge, the target branch itself is fast-forwarded to its
+upstream first when selected. If the local target is still behind its
+upstream, branches build on the upstream instead. The target defaults to
+the main worktree's branch, like 'twig clean'.
+
+Worktrees that are detached, prunable, locked or have uncommitted changes
+are skipped. A rebase or merge that stops on conflicts is aborted, leaving
+the worktree as it was, and twig exits with 1.`,
+		Example: `  twig update --all
+  twig update --all --strategy rebase
+  twig update feat/a --strategy merge --target develop`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			dir, err := resolveCompletionDirectory(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			branches, err := twig.NewGitRunner(dir).WorktreeListBranches()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			var candidates []string
+			for _, b := range branches {
+				if !slices.Contains(args, b) {
+					candidates = append(candidates, b)
+				}
+			}
+			return candidates, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			strategyName, _ := cmd.Flags().GetString("strategy")
+			target, _ := cmd.Flags().GetString("target")
+			noFetch, _ := cmd.Flags().GetBool("no-fetch")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+
+			strategy, err := twig.ParseUpdateStrategy(strategyName)
+			if err != nil {
+				return err
+			}
+
+			var updateCommander UpdateCommander
+			if o.updateCommander != nil {
+				updateCommander = o.updateCommander
+			} else {
+				updateCommander = twig.NewDefaultUpdateCommand(cwd)
+			}
+
+			result, err := updateCommander.Run(cwd, twig.UpdateOptions{
+				All:      all,
+				Branches: args,
+				Strategy: strategy,
+				Target:   target,
+				NoFetch:  noFetch,
+			})
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			if result.HasErrors() {
+				return fmt.Errorf("failed to update %d worktree(s)", result.ErrorCount())
+			}
+			return nil
+		},
+	}
+	updateCmd.Flags().BoolP("all", "a", false, "Update every worktree")
+	updateCmd.Flags().String("strategy", string(twig.UpdateStrategyFF), "How to update: ff, rebase or merge")
+	updateCmd.Flags().String("target", "", "Branch to rebase onto or merge (default: auto-detect)")
+	updateCmd.Flags().Bool("no-fetch", false, "Do not fetch before updating")
+	updateCmd.RegisterFlagCompletionFunc("strategy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{
+			string(twig.UpdateStrategyFF), string(twig.UpdateStrategyRebase), string(twig.UpdateStrategyMerge),
+		}, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(updateCmd)
+
+	carryCmd := &cobra.Command{
+		Use:   "carry --to <branch>",
+		Short: "Move uncommitted changes into an existing worktree",
+		Long: `Move uncommitted changes into the existing worktree of another branch.
+
+Changes are taken from the current worktree, or from the worktree of
+--from <branch>. Use --copy to keep the changes in the source as well.
+Use --file to carry only matching files:
+
+  twig carry --to feat/api --file "api/**"
+
+Conflicts with changes in the target worktree are detected before the
+source is touched. If applying fails, both worktrees are restored.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			to, _ := cmd.Flags().GetString("to")
+			from, _ := cmd.Flags().GetString("from")
+			copyChanges, _ := cmd.Flags().GetBool("copy")
+			filePatterns, _ := cmd.Flags().GetStringArray("file")
+
+			fromPath := originalCwd
+			if from != "" {
+				var err error
+				fromPath, err = resolveCarryFrom(from, originalCwd, twig.NewGitRunner(cwd))
+				if err != nil {
+					return err
+				}
+			}
+
+			var carryCmd CarryCommander
+			if o.carryCommander != nil {
+				carryCmd = o.carryCommander
+			} else {
+				carryCmd = twig.NewDefaultCarryCommand(cfg)
+			}
+			result, err := carryCmd.Run(to, twig.CarryOptions{
+				From:         fromPath,
+				Copy:         copyChanges,
+				FilePatterns: filePatterns,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(twig.FormatOptions{Verbose: verbose})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	completeWorktreeBranch := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		dir, err := resolveCompletionDirectory(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		branches, err := twig.NewGitRunner(dir).WorktreeListBranches()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	}
+	carryCmd.Flags().String("to", "", "Branch whose worktree receives the changes (required)")
+	carryCmd.Flags().String("from", "", "Branch whose worktree the changes are taken from (default: current)")
+	carryCmd.Flags().Bool("copy", false, "Keep the changes in the source worktree")
+	carryCmd.Flags().StringArrayP("file", "F", nil, "File patterns to carry")
+	carryCmd.MarkFlagRequired("to")
+	carryCmd.RegisterFlagCompletionFunc("to", completeWorktreeBranch)
+	carryCmd.RegisterFlagCompletionFunc("from", completeWorktreeBranch)
+	rootCmd.AddCommand(carryCmd)
+
+	recoverCmd := &cobra.Command{
+		Use:   "recover [<hash>...]",
+		Short: "Recover interrupted carry and sync operations",
+		Long: `Recover carry and sync operations that were interrupted.
+
+While twig add --carry/--sync or twig carry runs, the stashed changes are
+recorded in an operation marker. If twig is interrupted, the marker remains
+and the changes can be recovered:
+
+  twig recover              # list interrupted operations and orphaned stashes
+  twig recover --restore    # put the changes back into the source worktree
+  twig recover --apply      # finish the operations
+
+Pass stash hashes (or prefixes) to recover specific operations.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			apply, _ := cmd.Flags().GetBool("apply")
+			restore, _ := cmd.Flags().GetBool("restore")
+
+			action := twig.RecoverList
+			switch {
+			case apply:
+				action = twig.RecoverApply
+			case restore:
+				action = twig.RecoverRestore
+			case len(args) > 0:
+				return fmt.Errorf("--apply or --restore is required when hashes are given")
+			}
+
+			result, err := newRecoverCommander().Run(twig.RecoverOptions{Action: action, Hashes: args})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(twig.FormatOptions{Verbose: verbose})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	recoverCmd.Flags().Bool("apply", false, "Finish the operations by applying changes to their destination")
+	recoverCmd.Flags().Bool("restore", false, "Put changes back into the source worktree")
+	recoverCmd.MarkFlagsMutuallyExclusive("apply", "restore")
+	rootCmd.AddCommand(recoverCmd)
+
+	relinkCmd := &cobra.Command{
+		Use:   "relink [<branch>]",
+		Short: "Convert worktree symlinks between absolute and relative style",
+		Long: `Convert the symlinks twig created in a worktree between absolute and
+relative style.
+
+Without a branch, the worktree containing the current directory is used.
+The target style defaults to the symlink_style setting.
+
+Only symlinks pointing to the same path in another worktree are converted;
+other symlinks are left untouched.`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) >= 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			dir, err := resolveCompletionDirectory(cmd)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			git := twig.NewGitRunner(dir)
+			branches, err := git.WorktreeListBranches()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return branches, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			style, _ := cmd.Flags().GetString("style")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			var branch string
+			if len(args) > 0 {
+				branch = args[0]
+			}
+
+			var relinkCmd RelinkCommander
+			if o.relinkCommander != nil {
+				relinkCmd = o.relinkCommander
+			} else {
+				relinkCmd = twig.NewDefaultRelinkCommand(cfg)
+			}
+			result, err := relinkCmd.Run(branch, cwd, twig.RelinkOptions{
+				Style:  twig.SymlinkStyle(style),
+				DryRun: dryRun,
+			})
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(twig.FormatOptions{Verbose: verbose})
+			if formatted.Stderr != "" {
+				fmt.Fprint(cmd.ErrOrStderr(), formatted.Stderr)
+			}
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	relinkCmd.Flags().String("style", "", "Target style: absolute or relative (default: symlink_style setting)")
+	relinkCmd.Flags().Bool("dry-run", false, "Show what would be converted without making changes")
+	relinkCmd.RegisterFlagCompletionFunc("style", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(twig.SymlinkStyleAbsolute), string(twig.SymlinkStyleRelative)}, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(relinkCmd)
+
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Initialize twig configuration",
+		Long: `Create a .twig/settings.toml configuration file in the current directory.
+
+init inspects the repository to propose initial settings:
+
+  - default_source is set to the detected default branch
+  - Ignored per-developer files that exist (.envrc, .env*, .tool-versions,
+    CLAUDE.local.md, .claude/settings.local.json, .vscode/) are proposed
+    as symlinks
+  - .twig/settings.local.toml can be created for personal settings and
+    added to .gitignore
+
+Use --yes to accept all proposals without prompting.`,
+		Args: cobra.NoArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Override parent's PersistentPreRunE to skip config loading
+			// since init creates the config file
+			var err error
+			originalCwd, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+
+			cwd, err = resolveDirectory(dirFlag, originalCwd)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			yes, _ := cmd.Flags().GetBool("yes")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+
+			var initCommand InitCommander
+			if o.initCommander != nil {
+				initCommand = o.initCommander
+			} else {
+				initCommand = twig.NewDefaultInitCommand()
+			}
+
+			opts := twig.InitOptions{Force: force}
+			detection := initCommand.Detect(cwd)
+			if !detection.Exists || force {
+				opts.DefaultSource = detection.DefaultSource
+				opts.Local = yes
+
+				// Unanswered prompts (e.g. stdin closed) take the default answer
+				reader := bufio.NewReader(cmd.InOrStdin())
+				confirm := func(question string, defaultYes bool) bool {
+					if yes {
+						return true
+					}
+					choices := "[y/N]"
+					if defaultYes {
+						choices = "[Y/n]"
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "%s %s: ", question, choices)
+					input, _ := reader.ReadString('\n')
+					switch strings.TrimSpace(strings.ToLower(input)) {
+					case "y", "yes":
+						return true
+					case "n", "no":
+						return false
+					default:
+						return defaultYes
+					}
+				}
+
+				if len(detection.Symlinks) > 0 {
+					if confirm(fmt.Sprintf("Symlink ignored local files into new worktrees (%s)?",
+						strings.Join(detection.Symlinks, ", ")), true) {
+						opts.Symlinks = detection.Symlinks
+					}
+				}
+				if !yes {
+					opts.Local = confirm("Create .twig/settings.local.toml for personal settings and add it to .gitignore?", false)
+				}
+			}
+
+			result, err := initCommand.Run(cwd, opts)
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(twig.InitFormatOptions{Verbose: verbose})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	initCmd.Flags().BoolP("force", "f", false, "Overwrite existing configuration file")
+	initCmd.Flags().BoolP("yes", "y", false, "Accept all proposed settings without prompting")
+	rootCmd.AddCommand(initCmd)
+
+	cloneCmd := &cobra.Command{
+		Use:   "clone <url> [dir]",
+		Short: "Clone a repository into a bare-repo worktree layout",
+		Long: `Clone a repository into a bare-repo worktree layout.
+
+The repository is cloned as <dir>/.bare with a <dir>/.git file pointing
+at it, and the default branch is checked out in <dir>/<branch>:
+
+  myapp/
+    .bare/
+    .git
+    main/
+
+The remote is configured to fetch into refs/remotes/origin/*, and
+main/.twig/settings.toml is created so that 'twig add' places new
+worktrees next to main/ and symlinks the settings into them.
+
+<dir> defaults to the repository name derived from <url>.`,
+		Args: cobra.RangeArgs(1, 2),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Override parent's PersistentPreRunE to skip config loading
+			// since there is no repository yet
+			var err error
+			originalCwd, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+
+			cwd, err = resolveDirectory(dirFlag, originalCwd)
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			branch, _ := cmd.Flags().GetString("branch")
+
+			var cloneCommand CloneCommander
+			if o.cloneCommander != nil {
+				cloneCommand = o.cloneCommander
+			} else {
+				cloneCommand = twig.NewDefaultCloneCommand()
+			}
+
+			opts := twig.CloneOptions{Branch: branch}
+			if len(args) > 1 {
+				opts.Dir = args[1]
+			}
+
+			result, err := cloneCommand.Run(cwd, args[0], opts)
+			if err != nil {
+				return err
+			}
+
+			formatted := result.Format(twig.FormatOptions{Verbose: verbose})
+			fmt.Fprint(cmd.OutOrStdout(), formatted.Stdout)
+			return nil
+		},
+	}
+	cloneCmd.Flags().StringP("branch", "b", "", "Check out <branch> instead of the remote's default branch")
+	rootCmd.AddCommand(cloneCmd)
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and edit twig settings",
+		Long: `Inspect and edit twig settings.
+
+Settings are merged from several sources, from lowest to highest precedence:
+
+  global   $XDG_CONFIG_HOME/twig/settings.toml (default ~/.config/twig/settings.toml)
+  project  .twig/settings.toml
+  local    .twig/settings.local.toml
+  env      TWIG_<KEY> environment variables (scalar keys only)
+
+'list' and 'get' show the effective values and where each one came from.
+'set' and 'unset' edit a single settings file in place, preserving comments.
+They write to the project file unless --local or --global is given.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Invalid settings only warn here, so that set and unset can
+			// repair them
+			err := rootCmd.PersistentPreRunE(cmd, args)
+			if errors.Is(err, twig.ErrConfig) {
+				fmt.Fprintln(cmd.ErrOrStderr(), "warning:", err)
+				return nil
+			}
+			return err
+		},
+	}
+
+	getConfigCommander := func() ConfigCommander {
+		if o.configCommander != nil {
+			return o.configCommander
+		}
+		return twig.NewDefaultConfigCommand(cwd)
+	}
+
+	configScope := func(cmd *cobra.Command) twig.ConfigScope {
+		for _, scope := range []twig.ConfigScope{
+			twig.ConfigScopeGlobal, twig.ConfigScopeProject, twig.ConfigScopeLocal,
+		} {
+			if on, _ := cmd.Flags().GetBool(string(scope)); on {
+				return scope
+			}
+		}
+		return ""
+	}
+
+	completeConfigKey := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return twig.ConfigKeys(), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	configListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List effective settings with their origin",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			result, err := getConfigCommander().List(twig.ConfigOptions{Scope: configScope(cmd)})
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			return nil
+		},
+	}
+
+	configGetCmd := &cobra.Command{
+		Use:               "get <key>",
+		Short:             "Print the effective value of a setting",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKey,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			result, err := getConfigCommander().Get(args[0], twig.ConfigOptions{Scope: configScope(cmd)})
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			return nil
+		},
+	}
+
+	configSetCmd := &cobra.Command{
+		Use:               "set <key> <value>...",
+		Short:             "Set a value in a settings file",
+		Long:              "Set a value in a settings file.\n\nList keys (symlinks, extra_symlinks, symlink_excludes, sparse) accept multiple values and replace the whole list.",
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeConfigKey,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			result, err := getConfigCommander().Set(args[0], args[1:], twig.ConfigOptions{Scope: configScope(cmd)})
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			return nil
+		},
+	}
+
+	configUnsetCmd := &cobra.Command{
+		Use:               "unset <key>",
+		Short:             "Remove a value from a settings file",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKey,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			result, err := getConfigCommander().Unset(args[0], twig.ConfigOptions{Scope: configScope(cmd)})
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			return nil
+		},
+	}
+
+	configCmd.PersistentFlags().Bool("global", false, "Use the global settings file")
+	configCmd.PersistentFlags().Bool("project", false, "Use the project settings file (.twig/settings.toml)")
+	configCmd.PersistentFlags().Bool("local", false, "Use the local settings file (.twig/settings.local.toml)")
+	configCmd.MarkFlagsMutuallyExclusive("global", "project", "local")
+	configCmd.AddCommand(configListCmd, configGetCmd, configSetCmd, configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+
+	backupsCmd := &cobra.Command{
+		Use:   "backups",
+		Short: "Manage backups of forcibly removed worktrees",
+		Long: `Manage backups of forcibly removed worktrees.
+
+Before 'twig remove --force' or 'twig clean --force' removes a worktree,
+its uncommitted changes (including untracked files, excluding ignored ones)
+and the branch tip are saved under refs/twig/backup/<branch>/<timestamp>.
+Set backup = false in the settings to turn this off.`,
+	}
+
+	getBackupsCommander := func() BackupsCommander {
+		if o.backupsCommander != nil {
+			return o.backupsCommander
+		}
+		return twig.NewDefaultBackupsCommand(cfg)
+	}
+
+	completeBackup := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		dir, err := resolveCompletionDirectory(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		result, err := twig.NewBackupsCommand(nil, twig.NewGitRunner(dir), nil).List("")
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var names []string
+		for _, b := range result.Backups {
+			names = append(names, b.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	backupsListCmd := &cobra.Command{
+		Use:   "list [branch]",
+		Short: "List backups",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			var branch string
+			if len(args) > 0 {
+				branch = args[0]
+			}
+			result, err := getBackupsCommander().List(branch)
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.ListFormatOptions{Quiet: quiet}))
+			return nil
+		},
+	}
+	backupsListCmd.Flags().BoolP("quiet", "q", false, "Output only backup names")
+
+	backupsRestoreCmd := &cobra.Command{
+		Use:   "restore <backup|branch>",
+		Short: "Recreate a worktree from a backup",
+		Long: `Recreate a worktree from a backup.
+
+The branch is recreated at the backed up commit if it no longer exists,
+a worktree is added like 'twig add' does, and the uncommitted changes are
+applied with their staged state. Given a branch name, its latest backup is
+used. The backup is kept until pruned.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeBackup,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			result, err := getBackupsCommander().Restore(args[0])
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			return nil
+		},
+	}
+
+	backupsPruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old backups",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			olderThan, _ := cmd.Flags().GetString("older-than")
+			age, err := twig.ParseAge(olderThan)
+			if err != nil {
+				return err
+			}
+			result, err := getBackupsCommander().Prune(twig.BackupPruneOptions{
+				OlderThan: age,
+				DryRun:    dryRun,
+			})
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			return nil
+		},
+	}
+	backupsPruneCmd.Flags().String("older-than", "30d", "Delete backups older than this age (e.g. 12h, 7d, 2w; 0 deletes all)")
+	backupsPruneCmd.Flags().Bool("dry-run", false, "Show what would be deleted")
+
+	backupsCmd.AddCommand(backupsListCmd, backupsRestoreCmd, backupsPruneCmd)
+	rootCmd.AddCommand(backupsCmd)
+
+	trashCmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Manage worktrees moved to the trash",
+		Long: `Manage worktrees moved to the trash.
+
+With 'twig remove --trash' or 'twig clean --trash', or trash = true in the
+settings, removed worktree directories are moved to .twig-trash under the
+worktree destination base directory instead of being deleted, including
+ignored and untracked files. The branch tip is kept under
+refs/twig/trash/<branch>/<timestamp>.`,
+	}
+
+	getTrashCommander := func() TrashCommander {
+		if o.trashCommander != nil {
+			return o.trashCommander
+		}
+		return twig.NewDefaultTrashCommand(cfg)
+	}
+
+	completeTrash := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		dir, err := resolveCompletionDirectory(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		result, err := twig.NewTrashCommand(nil, twig.NewGitRunner(dir), &twig.Config{}).List("")
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var names []string
+		for _, e := range result.Entries {
+			names = append(names, e.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	trashListCmd := &cobra.Command{
+		Use:   "list [branch]",
+		Short: "List trashed worktrees",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			var branch string
+			if len(args) > 0 {
+				branch = args[0]
+			}
+			result, err := getTrashCommander().List(branch)
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.ListFormatOptions{Quiet: quiet}))
+			return nil
+		},
+	}
+	trashListCmd.Flags().BoolP("quiet", "q", false, "Output only entry names")
+
+	trashRestoreCmd := &cobra.Command{
+		Use:   "restore <entry|branch>",
+		Short: "Put a trashed worktree back",
+		Long: `Put a trashed worktree back.
+
+The branch is recreated at the trashed commit if it no longer exists, the
+worktree is registered again with 'git worktree add' at the path 'twig add'
+would use, and the preserved directory is moved there. Given a branch name,
+its latest entry is used.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeTrash,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			result, err := getTrashCommander().Restore(args[0])
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			return nil
+		},
+	}
+
+	trashEmptyCmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Delete trashed worktrees",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			olderThan, _ := cmd.Flags().GetString("older-than")
+			age, err := twig.ParseAge(olderThan)
+			if err != nil {
+				return err
+			}
+			result, err := getTrashCommander().Empty(twig.TrashEmptyOptions{
+				OlderThan: age,
+				DryRun:    dryRun,
+			})
+			if err != nil {
+				return err
+			}
+			writeFormatted(cmd, result.Format(twig.FormatOptions{Verbose: verbose}))
+			return nil
+		},
+	}
+	trashEmptyCmd.Flags().String("older-than", "0", "Delete only entries older than this age (e.g. 12h, 7d, 2w)")
+	trashEmptyCmd.Flags().Bool("dry-run", false, "Show what would be deleted")
+
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd, trashEmptyCmd)
+	rootCmd.AddCommand(trashCmd)
+
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print version information",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 1, ' ', 0)
+			fmt.Fprintf(w, "version:\t%s\n", version)
+			fmt.Fprintf(w, "commit:\t%s\n", commit)
+			fmt.Fprintf(w, "date:\t%s\n", date)
+			w.Flush()
+		},
+	}
+	rootCmd.AddCommand(versionCmd)
+
+	return rootCmd
+}
+
+// Exit codes. They are stable, so that scripts can tell error classes apart.
+const (
+	exitCodeFailure          = 1
+	exitCodeBranchNotFound   = 2
+	exitCodeBranchCheckedOut = 3
+	exitCodeDirectoryExists  = 4
+	exitCodeWorktreeDirty    = 5
+	exitCodeWorktreeLocked   = 6
+	exitCodeBranchNotMerged  = 7
+	exitCodePartialFailure   = 8
+	exitCodeConfig           = 9
+)
+
+// errorKindExitCodes maps the error kinds of twig to exit codes.
+var errorKindExitCodes = []struct {
+	kind error
+	code int
+}{
+	{twig.ErrBranchNotFound, exitCodeBranchNotFound},
+	{twig.ErrBranchCheckedOut, exitCodeBranchCheckedOut},
+	{twig.ErrDirectoryExists, exitCodeDirectoryExists},
+	{twig.ErrWorktreeDirty, exitCodeWorktreeDirty},
+	{twig.ErrWorktreeLocked, exitCodeWorktreeLocked},
+	{twig.ErrBranchNotMerged, exitCodeBranchNotMerged},
+	{twig.ErrConfig, exitCodeConfig},
+}
+
+// exitCodeError is an error with an explicit exit code.
+type exitCodeError struct {
+	err  error
+	code int
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+func (e *exitCodeError) Unwrap() error { return e.err }
+
+// exitCode returns the exit code for err.
+func exitCode(err error) int {
+	var codeErr *exitCodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.code
+	}
+	for _, k := range errorKindExitCodes {
+		if errors.Is(err, k.kind) {
+			return k.code
+		}
+	}
+	return exitCodeFailure
+}
+
+// failedTargetsError returns the error of a command that failed for some of
+// its targets. It exits with the code of the failures if they all share one,
+// and every target failed; otherwise with exitCodePartialFailure.
+func failedTargetsError(errs []error, targets int, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	code := exitCodePartialFailure
+	if len(errs) == targets {
+		code = exitCode(errs[0])
+		for _, e := range errs[1:] {
+			if exitCode(e) != code {
+				code = exitCodePartialFailure
+				break
+			}
+		}
+	}
+	return &exitCodeError{err: err, code: code}
 }
 
 var rootCmd = newRootCmd()
@@ -630,6 +2067,6 @@ var rootCmd = newRootCmd()
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(rootCmd.ErrOrStderr(), "twig:", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
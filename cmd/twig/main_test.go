@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/708u/twig"
 	"github.com/708u/twig/internal/testutil"
@@ -192,6 +195,7 @@ func TestCleanCmd(t *testing.T) {
 		result     twig.CleanResult
 		wantStdout string
 		wantErr    bool
+		wantCode   int
 	}{
 		{
 			name:  "check_shows_candidates",
@@ -240,6 +244,22 @@ func TestCleanCmd(t *testing.T) {
 			},
 			wantStdout: "clean:\n  feat/a (merged)\n\nskip:\n  feat/b (not merged)\n",
 		},
+		{
+			name: "partial_failure",
+			args: []string{"clean", "--yes"},
+			result: twig.CleanResult{
+				Candidates: []twig.CleanCandidate{
+					{Branch: "feat/a", Skipped: false, CleanReason: twig.CleanMerged},
+					{Branch: "feat/b", Skipped: false, CleanReason: twig.CleanMerged},
+				},
+				Removed: []twig.RemovedWorktree{
+					{Branch: "feat/a"},
+					{Branch: "feat/b", Err: errors.New("failed to remove worktree")},
+				},
+			},
+			wantErr:  true,
+			wantCode: exitCodePartialFailure,
+		},
 	}
 
 	for _, tt := range tests {
@@ -265,6 +285,9 @@ func TestCleanCmd(t *testing.T) {
 				if err == nil {
 					t.Fatal("expected error, got nil")
 				}
+				if tt.wantCode != 0 && exitCode(err) != tt.wantCode {
+					t.Errorf("exitCode() = %d, want %d", exitCode(err), tt.wantCode)
+				}
 				return
 			}
 
@@ -425,18 +448,39 @@ func (m *mockRemoveCommander) Run(branch, cwd string, opts twig.RemoveOptions) (
 
 // mockInitCommander implements InitCommander for testing.
 type mockInitCommander struct {
+	detection  twig.InitDetection
 	result     twig.InitResult
 	err        error
 	calledDir  string
 	calledOpts twig.InitOptions
 }
 
+func (m *mockInitCommander) Detect(dir string) twig.InitDetection {
+	return m.detection
+}
+
 func (m *mockInitCommander) Run(dir string, opts twig.InitOptions) (twig.InitResult, error) {
 	m.calledDir = dir
 	m.calledOpts = opts
 	return m.result, m.err
 }
 
+// mockCloneCommander implements CloneCommander for testing.
+type mockCloneCommander struct {
+	result     twig.CloneResult
+	err        error
+	calledCwd  string
+	calledURL  string
+	calledOpts twig.CloneOptions
+}
+
+func (m *mockCloneCommander) Run(cwd, url string, opts twig.CloneOptions) (twig.CloneResult, error) {
+	m.calledCwd = cwd
+	m.calledURL = url
+	m.calledOpts = opts
+	return m.result, m.err
+}
+
 func TestAddCmd(t *testing.T) {
 	t.Parallel()
 
@@ -914,6 +958,117 @@ func TestAddCmd(t *testing.T) {
 		}
 	})
 
+	t.Run("scope_flags", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name    string
+			args    []string
+			wantErr string
+		}{
+			{
+				name:    "staged_requires_carry_or_sync",
+				args:    []string{"--staged"},
+				wantErr: "--staged requires --carry or --sync flag",
+			},
+			{
+				name:    "untracked_only_requires_carry_or_sync",
+				args:    []string{"--untracked-only"},
+				wantErr: "--untracked-only requires --carry or --sync flag",
+			},
+			{
+				name:    "mutually_exclusive",
+				args:    []string{"--sync", "--staged", "--unstaged"},
+				wantErr: "none of the others can be",
+			},
+			{
+				name: "staged_with_carry",
+				args: []string{"--carry", "--staged"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+				mock := &mockAddCommander{
+					result: twig.AddResult{Branch: "feat/scope", WorktreePath: "/path/to/worktree"},
+				}
+				cmd := newRootCmd(WithAddCommander(mock))
+
+				var stdout, stderr bytes.Buffer
+				cmd.SetOut(&stdout)
+				cmd.SetErr(&stderr)
+				cmd.SetArgs(append(append([]string{"-C", mainDir, "add"}, tt.args...), "feat/scope"))
+
+				err := cmd.Execute()
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if mock.calledName != "feat/scope" {
+					t.Errorf("calledName = %q, want %q", mock.calledName, "feat/scope")
+				}
+			})
+		}
+	})
+
+	t.Run("submodules_flag", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name    string
+			args    []string
+			wantErr string
+		}{
+			{
+				name: "recursive",
+				args: []string{"--submodules", "recursive"},
+			},
+			{
+				name:    "invalid_mode",
+				args:    []string{"--submodules", "all"},
+				wantErr: `invalid submodules mode "all"`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+				mock := &mockAddCommander{
+					result: twig.AddResult{Branch: "feat/sub", WorktreePath: "/path/to/worktree"},
+				}
+				cmd := newRootCmd(WithAddCommander(mock))
+
+				var stdout, stderr bytes.Buffer
+				cmd.SetOut(&stdout)
+				cmd.SetErr(&stderr)
+				cmd.SetArgs(append(append([]string{"-C", mainDir, "add"}, tt.args...), "feat/sub"))
+
+				err := cmd.Execute()
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+		}
+	})
+
 	t.Run("file_with_carry", func(t *testing.T) {
 		t.Parallel()
 
@@ -942,10 +1097,12 @@ func TestRemoveCmd(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name      string
-		args      []string
-		wantForce twig.WorktreeForceLevel
-		wantDry   bool
+		name       string
+		args       []string
+		wantForce  twig.WorktreeForceLevel
+		wantDry    bool
+		wantRemote bool
+		wantTrash  bool
 	}{
 		{
 			name:      "no_flags",
@@ -989,6 +1146,18 @@ func TestRemoveCmd(t *testing.T) {
 			wantForce: twig.WorktreeForceLevelUnclean,
 			wantDry:   true,
 		},
+		{
+			name:       "remote_flag",
+			args:       []string{"remove", "--remote", "feat/a"},
+			wantForce:  twig.WorktreeForceLevelNone,
+			wantRemote: true,
+		},
+		{
+			name:      "trash_flag",
+			args:      []string{"remove", "--trash", "feat/a"},
+			wantForce: twig.WorktreeForceLevelNone,
+			wantTrash: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1019,6 +1188,12 @@ func TestRemoveCmd(t *testing.T) {
 			if call.opts.DryRun != tt.wantDry {
 				t.Errorf("DryRun = %v, want %v", call.opts.DryRun, tt.wantDry)
 			}
+			if call.opts.Remote != tt.wantRemote {
+				t.Errorf("Remote = %v, want %v", call.opts.Remote, tt.wantRemote)
+			}
+			if call.opts.Trash != tt.wantTrash {
+				t.Errorf("Trash = %v, want %v", call.opts.Trash, tt.wantTrash)
+			}
 		})
 	}
 }
@@ -1117,6 +1292,80 @@ func TestRemoveCmd_MultipleBranches(t *testing.T) {
 	}
 }
 
+func TestCloneCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantURL  string
+		wantOpts twig.CloneOptions
+		wantErr  bool
+	}{
+		{
+			name:    "url_only",
+			args:    []string{"clone", "file:///srv/myapp.git"},
+			wantURL: "file:///srv/myapp.git",
+		},
+		{
+			name:     "with_dir_and_branch",
+			args:     []string{"clone", "--branch", "develop", "file:///srv/myapp.git", "work"},
+			wantURL:  "file:///srv/myapp.git",
+			wantOpts: twig.CloneOptions{Dir: "work", Branch: "develop"},
+		},
+		{
+			name:    "missing_url",
+			args:    []string{"clone"},
+			wantErr: true,
+		},
+		{
+			name:    "too_many_args",
+			args:    []string{"clone", "a", "b", "c"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			mock := &mockCloneCommander{
+				result: twig.CloneResult{URL: tt.wantURL, Dir: "/work/myapp", Branch: "main", WorktreePath: "/work/myapp/main"},
+			}
+
+			cmd := newRootCmd(WithCloneCommander(mock))
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", tmpDir}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mock.calledCwd != tmpDir {
+				t.Errorf("cwd = %q, want %q", mock.calledCwd, tmpDir)
+			}
+			if mock.calledURL != tt.wantURL {
+				t.Errorf("url = %q, want %q", mock.calledURL, tt.wantURL)
+			}
+			if mock.calledOpts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", mock.calledOpts, tt.wantOpts)
+			}
+			if !strings.Contains(stdout.String(), "Created worktree /work/myapp/main [main]") {
+				t.Errorf("stdout = %q", stdout.String())
+			}
+		})
+	}
+}
+
 func TestInitCmd(t *testing.T) {
 	t.Parallel()
 
@@ -1136,6 +1385,7 @@ func TestInitCmd(t *testing.T) {
 		var stdout, stderr bytes.Buffer
 		cmd.SetOut(&stdout)
 		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader(""))
 		cmd.SetArgs([]string{"-C", tmpDir, "init"})
 
 		if err := cmd.Execute(); err != nil {
@@ -1166,6 +1416,7 @@ func TestInitCmd(t *testing.T) {
 		var stdout, stderr bytes.Buffer
 		cmd.SetOut(&stdout)
 		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader(""))
 		cmd.SetArgs([]string{"-C", tmpDir, "init", "--force"})
 
 		if err := cmd.Execute(); err != nil {
@@ -1194,6 +1445,7 @@ func TestInitCmd(t *testing.T) {
 		var stdout, stderr bytes.Buffer
 		cmd.SetOut(&stdout)
 		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader(""))
 		cmd.SetArgs([]string{"-C", tmpDir, "init", "-f"})
 
 		if err := cmd.Execute(); err != nil {
@@ -1219,6 +1471,7 @@ func TestInitCmd(t *testing.T) {
 		var stdout, stderr bytes.Buffer
 		cmd.SetOut(&stdout)
 		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader(""))
 		cmd.SetArgs([]string{"-C", tmpDir, "init"})
 
 		err := cmd.Execute()
@@ -1246,6 +1499,7 @@ func TestInitCmd(t *testing.T) {
 		var stdout, stderr bytes.Buffer
 		cmd.SetOut(&stdout)
 		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader(""))
 		cmd.SetArgs([]string{"-C", tmpDir, "init"})
 
 		if err := cmd.Execute(); err != nil {
@@ -1273,6 +1527,7 @@ func TestInitCmd(t *testing.T) {
 		var stdout, stderr bytes.Buffer
 		cmd.SetOut(&stdout)
 		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader(""))
 		cmd.SetArgs([]string{"-C", tmpDir, "init"})
 
 		if err := cmd.Execute(); err != nil {
@@ -1283,4 +1538,1548 @@ func TestInitCmd(t *testing.T) {
 			t.Errorf("stdout = %q, want to contain 'Created'", stdout.String())
 		}
 	})
+
+	t.Run("PromptsForProposals", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name         string
+			args         []string
+			input        string
+			wantSymlinks []string
+			wantLocal    bool
+			wantPrompt   bool
+		}{
+			{
+				name:         "defaults",
+				args:         []string{"init"},
+				input:        "\n\n",
+				wantSymlinks: []string{".envrc", ".vscode"},
+				wantPrompt:   true,
+			},
+			{
+				name:       "decline symlinks and accept local",
+				args:       []string{"init"},
+				input:      "n\ny\n",
+				wantLocal:  true,
+				wantPrompt: true,
+			},
+			{
+				name:         "yes accepts all without prompting",
+				args:         []string{"init", "--yes"},
+				wantSymlinks: []string{".envrc", ".vscode"},
+				wantLocal:    true,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				mock := &mockInitCommander{
+					detection: twig.InitDetection{
+						DefaultSource: "develop",
+						Symlinks:      []string{".envrc", ".vscode"},
+					},
+					result: twig.InitResult{Created: true},
+				}
+
+				cmd := newRootCmd(WithInitCommander(mock))
+
+				var stdout, stderr bytes.Buffer
+				cmd.SetOut(&stdout)
+				cmd.SetErr(&stderr)
+				cmd.SetIn(strings.NewReader(tt.input))
+				cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+				if err := cmd.Execute(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if mock.calledOpts.DefaultSource != "develop" {
+					t.Errorf("DefaultSource = %q, want %q", mock.calledOpts.DefaultSource, "develop")
+				}
+				if !slices.Equal(mock.calledOpts.Symlinks, tt.wantSymlinks) {
+					t.Errorf("Symlinks = %v, want %v", mock.calledOpts.Symlinks, tt.wantSymlinks)
+				}
+				if mock.calledOpts.Local != tt.wantLocal {
+					t.Errorf("Local = %v, want %v", mock.calledOpts.Local, tt.wantLocal)
+				}
+				if got := strings.Contains(stdout.String(), "?"); got != tt.wantPrompt {
+					t.Errorf("prompted = %v, want %v (stdout %q)", got, tt.wantPrompt, stdout.String())
+				}
+			})
+		}
+	})
+
+	t.Run("ExistingSettingsSkipDetection", func(t *testing.T) {
+		t.Parallel()
+
+		mock := &mockInitCommander{
+			detection: twig.InitDetection{Exists: true, Symlinks: []string{".envrc"}},
+			result:    twig.InitResult{Skipped: true},
+		}
+
+		cmd := newRootCmd(WithInitCommander(mock))
+
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetIn(strings.NewReader(""))
+		cmd.SetArgs([]string{"-C", t.TempDir(), "init"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if stdout.String() != "Skipped .twig/settings.toml (already exists)\n" {
+			t.Errorf("stdout = %q, want only the skipped message", stdout.String())
+		}
+		if mock.calledOpts.Symlinks != nil {
+			t.Errorf("Symlinks = %v, want nil", mock.calledOpts.Symlinks)
+		}
+	})
+}
+
+// mockConfigCommander implements ConfigCommander for testing.
+type mockConfigCommander struct {
+	calledOp     string
+	calledKey    string
+	calledValues []string
+	calledOpts   twig.ConfigOptions
+}
+
+func (m *mockConfigCommander) List(opts twig.ConfigOptions) (twig.ConfigListResult, error) {
+	m.calledOp = "list"
+	m.calledOpts = opts
+	return twig.ConfigListResult{
+		Values: []twig.ConfigValue{
+			{Key: "default_source", Value: "main", Source: twig.ConfigSource{Scope: twig.ConfigScopeDefault}},
+		},
+	}, nil
+}
+
+func (m *mockConfigCommander) Get(key string, opts twig.ConfigOptions) (twig.ConfigGetResult, error) {
+	m.calledOp = "get"
+	m.calledKey = key
+	m.calledOpts = opts
+	return twig.ConfigGetResult{Key: key, Values: []twig.ConfigValue{{Key: key, Value: "main"}}}, nil
+}
+
+func (m *mockConfigCommander) Set(key string, values []string, opts twig.ConfigOptions) (twig.ConfigSetResult, error) {
+	m.calledOp = "set"
+	m.calledKey = key
+	m.calledValues = values
+	m.calledOpts = opts
+	return twig.ConfigSetResult{Key: key, Path: ".twig/settings.toml", Values: values}, nil
+}
+
+func (m *mockConfigCommander) Unset(key string, opts twig.ConfigOptions) (twig.ConfigUnsetResult, error) {
+	m.calledOp = "unset"
+	m.calledKey = key
+	m.calledOpts = opts
+	return twig.ConfigUnsetResult{Key: key, Path: ".twig/settings.toml", Removed: true}, nil
+}
+
+func TestConfigCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOp     string
+		wantKey    string
+		wantValues []string
+		wantScope  twig.ConfigScope
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "list",
+			args:       []string{"config", "list"},
+			wantOp:     "list",
+			wantStdout: "default  default_source=main\n",
+		},
+		{
+			name:       "get_local",
+			args:       []string{"config", "get", "default_source", "--local"},
+			wantOp:     "get",
+			wantKey:    "default_source",
+			wantScope:  twig.ConfigScopeLocal,
+			wantStdout: "main\n",
+		},
+		{
+			name:       "set_list_values",
+			args:       []string{"config", "set", "symlinks", ".envrc", ".tool-versions", "--global"},
+			wantOp:     "set",
+			wantKey:    "symlinks",
+			wantValues: []string{".envrc", ".tool-versions"},
+			wantScope:  twig.ConfigScopeGlobal,
+			wantStdout: "twig config: set symlinks in .twig/settings.toml\n",
+		},
+		{
+			name:       "unset_project",
+			args:       []string{"config", "unset", "default_source", "--project"},
+			wantOp:     "unset",
+			wantKey:    "default_source",
+			wantScope:  twig.ConfigScopeProject,
+			wantStdout: "twig config: unset default_source in .twig/settings.toml\n",
+		},
+		{
+			name:    "scopes_are_exclusive",
+			args:    []string{"config", "list", "--local", "--global"},
+			wantErr: "none of the others can be",
+		},
+		{
+			name:    "set_requires_value",
+			args:    []string{"config", "set", "default_source"},
+			wantErr: "requires at least 2 arg(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockConfigCommander{}
+			cmd := newRootCmd(WithConfigCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mock.calledOp != tt.wantOp {
+				t.Errorf("op = %q, want %q", mock.calledOp, tt.wantOp)
+			}
+			if mock.calledKey != tt.wantKey {
+				t.Errorf("key = %q, want %q", mock.calledKey, tt.wantKey)
+			}
+			if strings.Join(mock.calledValues, ",") != strings.Join(tt.wantValues, ",") {
+				t.Errorf("values = %v, want %v", mock.calledValues, tt.wantValues)
+			}
+			if mock.calledOpts.Scope != tt.wantScope {
+				t.Errorf("scope = %q, want %q", mock.calledOpts.Scope, tt.wantScope)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestInvalidSettings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    bool
+		wantStderr string
+	}{
+		{
+			name:    "fails_other_commands",
+			args:    []string{"relink"},
+			wantErr: true,
+		},
+		{
+			name:       "config_can_repair",
+			args:       []string{"config", "set", "symlink_style", "absolute"},
+			wantStderr: "warning: failed to load config: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, ".twig"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, ".twig", "settings.toml"), []byte("symlink_style = \"hard\"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := newRootCmd(
+				WithConfigCommander(&mockConfigCommander{}),
+				WithRelinkCommander(&mockRelinkCommander{}),
+			)
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", dir}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr {
+				if code := exitCode(err); code != exitCodeConfig {
+					t.Fatalf("exit code = %d (error %v), want %d", code, err, exitCodeConfig)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want prefix %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+type mockRelinkCommander struct {
+	calledBranch string
+	calledOpts   twig.RelinkOptions
+}
+
+func (m *mockRelinkCommander) Run(branch, cwd string, opts twig.RelinkOptions) (twig.RelinkResult, error) {
+	m.calledBranch = branch
+	m.calledOpts = opts
+	return twig.RelinkResult{Branch: "feat/a", Style: twig.SymlinkStyleRelative}, nil
+}
+
+func TestRelinkCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantBranch string
+		wantOpts   twig.RelinkOptions
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "current_worktree",
+			args:       []string{"relink"},
+			wantStdout: "twig relink: feat/a (0 symlinks converted to relative)\n",
+		},
+		{
+			name:       "branch_with_style",
+			args:       []string{"relink", "feat/a", "--style", "relative"},
+			wantBranch: "feat/a",
+			wantOpts:   twig.RelinkOptions{Style: twig.SymlinkStyleRelative},
+			wantStdout: "twig relink: feat/a (0 symlinks converted to relative)\n",
+		},
+		{
+			name:       "dry_run",
+			args:       []string{"relink", "--dry-run"},
+			wantOpts:   twig.RelinkOptions{DryRun: true},
+			wantStdout: "twig relink: feat/a (0 symlinks converted to relative)\n",
+		},
+		{
+			name:    "too_many_args",
+			args:    []string{"relink", "feat/a", "feat/b"},
+			wantErr: "accepts at most 1 arg(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockRelinkCommander{}
+			cmd := newRootCmd(WithRelinkCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mock.calledBranch != tt.wantBranch {
+				t.Errorf("branch = %q, want %q", mock.calledBranch, tt.wantBranch)
+			}
+			if mock.calledOpts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", mock.calledOpts, tt.wantOpts)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockCarryCommander struct {
+	calledBranch string
+	calledOpts   twig.CarryOptions
+	result       twig.CarryResult
+}
+
+func (m *mockCarryCommander) Run(branch string, opts twig.CarryOptions) (twig.CarryResult, error) {
+	m.calledBranch = branch
+	m.calledOpts = opts
+	return m.result, nil
+}
+
+func TestCarryCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		args         []string
+		result       twig.CarryResult
+		wantCopy     bool
+		wantPatterns []string
+		wantStdout   string
+		wantStderr   string
+		wantErr      string
+	}{
+		{
+			name:       "move",
+			args:       []string{"carry", "--to", "feat/a"},
+			result:     twig.CarryResult{Branch: "feat/a", SourcePath: "/repo/main", Files: []string{"a.go"}},
+			wantStdout: "twig carry: feat/a (1 files moved from /repo/main)\n",
+		},
+		{
+			name:         "copy_with_files",
+			args:         []string{"carry", "--to", "feat/a", "--copy", "-F", "api/**", "--file", "*.go"},
+			result:       twig.CarryResult{Branch: "feat/a", SourcePath: "/repo/main", Files: []string{"a.go"}, Copied: true},
+			wantCopy:     true,
+			wantPatterns: []string{"api/**", "*.go"},
+			wantStdout:   "twig carry: feat/a (1 files copied from /repo/main)\n",
+		},
+		{
+			name:       "no_changes",
+			args:       []string{"carry", "--to", "feat/a"},
+			result:     twig.CarryResult{Branch: "feat/a", NoChanges: true},
+			wantStderr: "twig carry: no changes to carry\n",
+		},
+		{
+			name:    "missing_to",
+			args:    []string{"carry"},
+			wantErr: `required flag(s) "to" not set`,
+		},
+		{
+			name:    "positional_args_rejected",
+			args:    []string{"carry", "--to", "feat/a", "extra"},
+			wantErr: "unknown command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockCarryCommander{result: tt.result}
+			cmd := newRootCmd(WithCarryCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mock.calledBranch != "feat/a" {
+				t.Errorf("branch = %q, want %q", mock.calledBranch, "feat/a")
+			}
+			if mock.calledOpts.From == "" {
+				t.Error("From should default to the current directory")
+			}
+			if mock.calledOpts.Copy != tt.wantCopy {
+				t.Errorf("Copy = %v, want %v", mock.calledOpts.Copy, tt.wantCopy)
+			}
+			if !slices.Equal(mock.calledOpts.FilePatterns, tt.wantPatterns) {
+				t.Errorf("FilePatterns = %v, want %v", mock.calledOpts.FilePatterns, tt.wantPatterns)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+type mockRecoverCommander struct {
+	calledOpts *twig.RecoverOptions
+	pending    []twig.StashOperation
+}
+
+func (m *mockRecoverCommander) Run(opts twig.RecoverOptions) (twig.RecoverResult, error) {
+	m.calledOpts = &opts
+	return twig.RecoverResult{Action: opts.Action}, nil
+}
+
+func (m *mockRecoverCommander) Pending() ([]twig.StashOperation, error) {
+	return m.pending, nil
+}
+
+func TestRecoverCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOpts   twig.RecoverOptions
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "list",
+			args:       []string{"recover"},
+			wantStdout: "twig recover: nothing to recover\n",
+		},
+		{
+			name:       "restore",
+			args:       []string{"recover", "--restore"},
+			wantOpts:   twig.RecoverOptions{Action: twig.RecoverRestore},
+			wantStdout: "twig recover: 0 operations restored\n",
+		},
+		{
+			name:       "apply_hashes",
+			args:       []string{"recover", "--apply", "abc1234", "def5678"},
+			wantOpts:   twig.RecoverOptions{Action: twig.RecoverApply, Hashes: []string{"abc1234", "def5678"}},
+			wantStdout: "twig recover: 0 operations applied\n",
+		},
+		{
+			name:    "hashes_without_action",
+			args:    []string{"recover", "abc1234"},
+			wantErr: "--apply or --restore is required",
+		},
+		{
+			name:    "apply_and_restore",
+			args:    []string{"recover", "--apply", "--restore"},
+			wantErr: "none of the others can be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockRecoverCommander{pending: []twig.StashOperation{{Hash: "abc1234"}}}
+			cmd := newRootCmd(WithRecoverCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mock.calledOpts == nil {
+				t.Fatal("Run was not called")
+			}
+			if mock.calledOpts.Action != tt.wantOpts.Action ||
+				!slices.Equal(mock.calledOpts.Hashes, tt.wantOpts.Hashes) {
+				t.Errorf("opts = %+v, want %+v", *mock.calledOpts, tt.wantOpts)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			// recover itself does not warn about pending operations
+			if stderr.String() != "" {
+				t.Errorf("stderr = %q, want empty", stderr.String())
+			}
+		})
+	}
+}
+
+type mockBackupsCommander struct {
+	calls     []string
+	pruneOpts twig.BackupPruneOptions
+}
+
+func (m *mockBackupsCommander) List(branch string) (twig.BackupListResult, error) {
+	m.calls = append(m.calls, "list "+branch)
+	return twig.BackupListResult{Backups: []twig.Backup{{Name: "feat/a/20260101-120000", Tip: "abc1234def"}}}, nil
+}
+
+func (m *mockBackupsCommander) Restore(name string) (twig.BackupRestoreResult, error) {
+	m.calls = append(m.calls, "restore "+name)
+	return twig.BackupRestoreResult{
+		Backup: twig.Backup{Name: "feat/a/20260101-120000"},
+		Add:    twig.AddResult{WorktreePath: "/repo/feat/a"},
+	}, nil
+}
+
+func (m *mockBackupsCommander) Prune(opts twig.BackupPruneOptions) (twig.BackupPruneResult, error) {
+	m.calls = append(m.calls, "prune")
+	m.pruneOpts = opts
+	return twig.BackupPruneResult{DryRun: opts.DryRun}, nil
+}
+
+func TestBackupsCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		args          []string
+		wantCalls     []string
+		wantOlderThan time.Duration
+		wantDryRun    bool
+		wantStdout    string
+		wantErr       string
+	}{
+		{
+			name:       "list",
+			args:       []string{"backups", "list"},
+			wantCalls:  []string{"list "},
+			wantStdout: "feat/a/20260101-120000  abc1234 clean\n",
+		},
+		{
+			name:       "list_branch_quiet",
+			args:       []string{"backups", "list", "feat/a", "-q"},
+			wantCalls:  []string{"list feat/a"},
+			wantStdout: "feat/a/20260101-120000\n",
+		},
+		{
+			name:       "restore",
+			args:       []string{"backups", "restore", "feat/a"},
+			wantCalls:  []string{"restore feat/a"},
+			wantStdout: "twig backups: restored feat/a/20260101-120000 into /repo/feat/a\n",
+		},
+		{
+			name:          "prune_default_age",
+			args:          []string{"backups", "prune"},
+			wantCalls:     []string{"prune"},
+			wantOlderThan: 30 * 24 * time.Hour,
+			wantStdout:    "No backups to prune\n",
+		},
+		{
+			name:          "prune_dry_run",
+			args:          []string{"backups", "prune", "--older-than", "12h", "--dry-run"},
+			wantCalls:     []string{"prune"},
+			wantOlderThan: 12 * time.Hour,
+			wantDryRun:    true,
+			wantStdout:    "No backups to prune\n",
+		},
+		{
+			name:    "prune_invalid_age",
+			args:    []string{"backups", "prune", "--older-than", "soon"},
+			wantErr: `invalid age "soon"`,
+		},
+		{
+			name:    "restore_requires_arg",
+			args:    []string{"backups", "restore"},
+			wantErr: "accepts 1 arg(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockBackupsCommander{}
+			cmd := newRootCmd(WithBackupsCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(mock.calls) > 0 {
+					t.Errorf("calls = %v, want none", mock.calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(mock.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", mock.calls, tt.wantCalls)
+			}
+			if mock.pruneOpts.OlderThan != tt.wantOlderThan || mock.pruneOpts.DryRun != tt.wantDryRun {
+				t.Errorf("prune opts = %+v", mock.pruneOpts)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockTrashCommander struct {
+	calls     []string
+	emptyOpts twig.TrashEmptyOptions
+}
+
+func (m *mockTrashCommander) List(branch string) (twig.TrashListResult, error) {
+	m.calls = append(m.calls, "list "+branch)
+	return twig.TrashListResult{Entries: []twig.TrashEntry{
+		{Name: "feat/a/20260101-120000", Tip: "abc1234def", Dir: "/repo/.twig-trash/feat/a/20260101-120000"},
+	}}, nil
+}
+
+func (m *mockTrashCommander) Restore(name string) (twig.TrashRestoreResult, error) {
+	m.calls = append(m.calls, "restore "+name)
+	return twig.TrashRestoreResult{
+		Entry:        twig.TrashEntry{Name: "feat/a/20260101-120000"},
+		WorktreePath: "/repo/feat/a",
+	}, nil
+}
+
+func (m *mockTrashCommander) Empty(opts twig.TrashEmptyOptions) (twig.TrashEmptyResult, error) {
+	m.calls = append(m.calls, "empty")
+	m.emptyOpts = opts
+	return twig.TrashEmptyResult{DryRun: opts.DryRun}, nil
+}
+
+func TestTrashCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		args          []string
+		wantCalls     []string
+		wantOlderThan time.Duration
+		wantDryRun    bool
+		wantStdout    string
+		wantErr       string
+	}{
+		{
+			name:       "list",
+			args:       []string{"trash", "list"},
+			wantCalls:  []string{"list "},
+			wantStdout: "feat/a/20260101-120000  abc1234 /repo/.twig-trash/feat/a/20260101-120000\n",
+		},
+		{
+			name:       "list_branch_quiet",
+			args:       []string{"trash", "list", "feat/a", "-q"},
+			wantCalls:  []string{"list feat/a"},
+			wantStdout: "feat/a/20260101-120000\n",
+		},
+		{
+			name:       "restore",
+			args:       []string{"trash", "restore", "feat/a"},
+			wantCalls:  []string{"restore feat/a"},
+			wantStdout: "twig trash: restored feat/a/20260101-120000 into /repo/feat/a\n",
+		},
+		{
+			name:       "empty_everything",
+			args:       []string{"trash", "empty"},
+			wantCalls:  []string{"empty"},
+			wantStdout: "No trashed worktrees to delete\n",
+		},
+		{
+			name:          "empty_older_than_dry_run",
+			args:          []string{"trash", "empty", "--older-than", "7d", "--dry-run"},
+			wantCalls:     []string{"empty"},
+			wantOlderThan: 7 * 24 * time.Hour,
+			wantDryRun:    true,
+			wantStdout:    "No trashed worktrees to delete\n",
+		},
+		{
+			name:    "empty_invalid_age",
+			args:    []string{"trash", "empty", "--older-than", "soon"},
+			wantErr: `invalid age "soon"`,
+		},
+		{
+			name:    "restore_requires_arg",
+			args:    []string{"trash", "restore"},
+			wantErr: "accepts 1 arg(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockTrashCommander{}
+			cmd := newRootCmd(WithTrashCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(mock.calls) > 0 {
+					t.Errorf("calls = %v, want none", mock.calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(mock.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", mock.calls, tt.wantCalls)
+			}
+			if mock.emptyOpts.OlderThan != tt.wantOlderThan || mock.emptyOpts.DryRun != tt.wantDryRun {
+				t.Errorf("empty opts = %+v", mock.emptyOpts)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockLockCommander struct {
+	calledBranches []string
+	calledOpts     *twig.LockOptions
+	unlock         bool
+	err            error
+}
+
+func (m *mockLockCommander) Lock(branches []string, opts twig.LockOptions) (twig.LockResult, error) {
+	m.calledBranches = branches
+	m.calledOpts = &opts
+	var result twig.LockResult
+	for _, b := range branches {
+		result.Worktrees = append(result.Worktrees, twig.LockedWorktree{Branch: b, Err: m.err})
+	}
+	return result, nil
+}
+
+func (m *mockLockCommander) Unlock(branches []string) (twig.LockResult, error) {
+	m.calledBranches = branches
+	m.unlock = true
+	result := twig.LockResult{Unlock: true}
+	for _, b := range branches {
+		result.Worktrees = append(result.Worktrees, twig.LockedWorktree{Branch: b, Err: m.err})
+	}
+	return result, nil
+}
+
+func TestLockCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		args         []string
+		err          error
+		wantBranches []string
+		wantUnlock   bool
+		wantOwner    string
+		wantPurpose  string
+		wantExpires  bool
+		wantStdout   string
+		wantErr      string
+	}{
+		{
+			name:         "lock",
+			args:         []string{"lock", "feat/a", "feat/b", "--reason", "agent run", "--owner", "alice"},
+			wantBranches: []string{"feat/a", "feat/b"},
+			wantOwner:    "alice",
+			wantPurpose:  "agent run",
+			wantStdout:   "twig lock: feat/a\ntwig lock: feat/b\n",
+		},
+		{
+			name:         "lock_expires",
+			args:         []string{"lock", "feat/a", "--expires", "2d"},
+			wantBranches: []string{"feat/a"},
+			wantExpires:  true,
+			wantStdout:   "twig lock: feat/a\n",
+		},
+		{
+			name:    "lock_invalid_expires",
+			args:    []string{"lock", "feat/a", "--expires", "someday"},
+			wantErr: `invalid expiry "someday"`,
+		},
+		{
+			name:    "lock_requires_branch",
+			args:    []string{"lock"},
+			wantErr: "requires at least 1 arg(s)",
+		},
+		{
+			name:         "lock_failure",
+			args:         []string{"lock", "feat/a"},
+			err:          errors.New("boom"),
+			wantBranches: []string{"feat/a"},
+			wantErr:      "failed to lock 1 branch(es)",
+		},
+		{
+			name:         "unlock",
+			args:         []string{"unlock", "feat/a"},
+			wantBranches: []string{"feat/a"},
+			wantUnlock:   true,
+			wantStdout:   "twig unlock: feat/a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockLockCommander{err: tt.err}
+			cmd := newRootCmd(WithLockCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if !slices.Equal(mock.calledBranches, tt.wantBranches) {
+				t.Errorf("branches = %v, want %v", mock.calledBranches, tt.wantBranches)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mock.unlock != tt.wantUnlock {
+				t.Errorf("unlock = %v, want %v", mock.unlock, tt.wantUnlock)
+			}
+			if !tt.wantUnlock {
+				if mock.calledOpts.Owner != tt.wantOwner || mock.calledOpts.Purpose != tt.wantPurpose ||
+					mock.calledOpts.Expires.IsZero() == tt.wantExpires {
+					t.Errorf("opts = %+v", *mock.calledOpts)
+				}
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockMoveCommander struct {
+	calledBranch string
+	calledDst    string
+	calledOpts   *twig.MoveOptions
+	migrate      bool
+	err          error
+}
+
+func (m *mockMoveCommander) Move(branch, dst, cwd string, opts twig.MoveOptions) (twig.MoveResult, error) {
+	m.calledBranch = branch
+	m.calledDst = dst
+	m.calledOpts = &opts
+	return twig.MoveResult{
+		DryRun:    opts.DryRun,
+		Worktrees: []twig.MovedWorktree{{Branch: branch, From: "/repo/old", To: dst, Err: m.err}},
+	}, nil
+}
+
+func (m *mockMoveCommander) Migrate(cwd string, opts twig.MoveOptions) (twig.MoveResult, error) {
+	m.migrate = true
+	m.calledOpts = &opts
+	return twig.MoveResult{Migrate: true, DryRun: opts.DryRun}, nil
+}
+
+func TestMoveCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		args        []string
+		err         error
+		wantBranch  string
+		wantDst     string // relative to the -C directory unless absolute
+		wantMigrate bool
+		wantOpts    twig.MoveOptions
+		wantStdout  string
+		wantErr     string
+	}{
+		{
+			name:       "move_relative_path",
+			args:       []string{"move", "feat/a", "../elsewhere/a"},
+			wantBranch: "feat/a",
+			wantDst:    "../elsewhere/a",
+		},
+		{
+			name:       "move_absolute_path_forced_dry_run",
+			args:       []string{"move", "feat/a", "/tmp/elsewhere/a", "-ff", "--dry-run"},
+			wantBranch: "feat/a",
+			wantDst:    "/tmp/elsewhere/a",
+			wantOpts:   twig.MoveOptions{Force: twig.WorktreeForceLevelLocked, DryRun: true},
+			wantStdout: "Would move worktree: /repo/old -> /tmp/elsewhere/a\n",
+		},
+		{
+			name:       "move_failure",
+			args:       []string{"move", "feat/a", "/tmp/elsewhere/a"},
+			err:        errors.New("boom"),
+			wantBranch: "feat/a",
+			wantDst:    "/tmp/elsewhere/a",
+			wantErr:    "1 error(s) occurred",
+		},
+		{
+			name:    "move_requires_path",
+			args:    []string{"move", "feat/a"},
+			wantErr: "accepts 2 arg(s)",
+		},
+		{
+			name:        "migrate",
+			args:        []string{"migrate", "--dry-run"},
+			wantMigrate: true,
+			wantOpts:    twig.MoveOptions{DryRun: true},
+			wantStdout:  "twig migrate: nothing to move\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			mock := &mockMoveCommander{err: tt.err}
+			cmd := newRootCmd(WithMoveCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", dir}, tt.args...))
+
+			err := cmd.Execute()
+			if mock.calledBranch != tt.wantBranch || mock.migrate != tt.wantMigrate {
+				t.Errorf("branch = %q, migrate = %v", mock.calledBranch, mock.migrate)
+			}
+			if tt.wantDst != "" {
+				wantDst := tt.wantDst
+				if !filepath.IsAbs(wantDst) {
+					wantDst = filepath.Join(dir, wantDst)
+				}
+				if mock.calledDst != wantDst {
+					t.Errorf("dst = %q, want %q", mock.calledDst, wantDst)
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *mock.calledOpts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", *mock.calledOpts, tt.wantOpts)
+			}
+			if tt.wantStdout != "" && stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockExecCommander struct {
+	calledArgs []string
+	calledOpts *twig.ExecOptions
+	err        error
+}
+
+func (m *mockExecCommander) Run(args []string, opts twig.ExecOptions) (twig.ExecResult, error) {
+	m.calledArgs = args
+	m.calledOpts = &opts
+	fmt.Fprintln(opts.Stdout, "[feat/a] output")
+	return twig.ExecResult{Worktrees: []twig.ExecWorktree{{Label: "feat/a", Err: m.err}}}, nil
+}
+
+func TestExecCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		err        error
+		wantArgs   []string
+		wantOpts   twig.ExecOptions
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "branches",
+			args:       []string{"exec", "feat/a", "feat/b", "--", "git", "status", "-s"},
+			wantArgs:   []string{"git", "status", "-s"},
+			wantOpts:   twig.ExecOptions{Branches: []string{"feat/a", "feat/b"}, Jobs: runtime.NumCPU()},
+			wantStdout: "[feat/a] output\ntwig exec: 1 succeeded\n",
+		},
+		{
+			name:     "all_with_jobs",
+			args:     []string{"exec", "--all", "-j", "2", "--", "make", "test"},
+			wantArgs: []string{"make", "test"},
+			wantOpts: twig.ExecOptions{All: true, Jobs: 2},
+		},
+		{
+			name:     "filter",
+			args:     []string{"exec", "--filter", "feat/*", "--", "make"},
+			wantArgs: []string{"make"},
+			wantOpts: twig.ExecOptions{Filter: "feat/*", Jobs: runtime.NumCPU()},
+		},
+		{
+			name:     "failure",
+			args:     []string{"exec", "--all", "--", "false"},
+			err:      errors.New("exit status 1"),
+			wantArgs: []string{"false"},
+			wantErr:  "command failed in 1 worktree(s)",
+		},
+		{
+			name:    "missing_dash",
+			args:    []string{"exec", "--all", "make"},
+			wantErr: "missing command",
+		},
+		{
+			name:    "missing_command",
+			args:    []string{"exec", "--all", "--"},
+			wantErr: "missing command",
+		},
+		{
+			name:    "all_and_filter",
+			args:    []string{"exec", "--all", "--filter", "x", "--", "make"},
+			wantErr: "none of the others can be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockExecCommander{err: tt.err}
+			cmd := newRootCmd(WithExecCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if !slices.Equal(mock.calledArgs, tt.wantArgs) {
+				t.Errorf("args = %q, want %q", mock.calledArgs, tt.wantArgs)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := *mock.calledOpts
+			if got.All != tt.wantOpts.All || got.Filter != tt.wantOpts.Filter ||
+				!slices.Equal(got.Branches, tt.wantOpts.Branches) || got.Jobs != tt.wantOpts.Jobs {
+				t.Errorf("opts = %+v, want %+v", got, tt.wantOpts)
+			}
+			if tt.wantStdout != "" && stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockUpdateCommander struct {
+	calledCwd  string
+	calledOpts *twig.UpdateOptions
+	result     twig.UpdateResult
+	err        error
+}
+
+func (m *mockUpdateCommander) Run(cwd string, opts twig.UpdateOptions) (twig.UpdateResult, error) {
+	m.calledCwd = cwd
+	m.calledOpts = &opts
+	return m.result, m.err
+}
+
+func TestUpdateCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		result     twig.UpdateResult
+		wantOpts   twig.UpdateOptions
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name: "current_worktree",
+			args: []string{"update"},
+			result: twig.UpdateResult{Worktrees: []twig.UpdatedWorktree{
+				{Branch: "feat/a", Outcome: twig.UpdateUpdated, From: "1111111aaaa", To: "2222222bbbb"},
+			}},
+			wantOpts:   twig.UpdateOptions{Strategy: twig.UpdateStrategyFF},
+			wantStdout: "updated:\n  feat/a (1111111..2222222)\n",
+		},
+		{
+			name:     "all_rebase",
+			args:     []string{"update", "--all", "--strategy", "rebase", "--target", "develop", "--no-fetch"},
+			wantOpts: twig.UpdateOptions{All: true, Strategy: twig.UpdateStrategyRebase, Target: "develop", NoFetch: true},
+		},
+		{
+			name:     "branches",
+			args:     []string{"update", "feat/a", "feat/b", "--strategy", "merge"},
+			wantOpts: twig.UpdateOptions{Branches: []string{"feat/a", "feat/b"}, Strategy: twig.UpdateStrategyMerge},
+		},
+		{
+			name: "aborted",
+			args: []string{"update", "--all", "--strategy", "rebase"},
+			result: twig.UpdateResult{Worktrees: []twig.UpdatedWorktree{
+				{Branch: "feat/a", Outcome: twig.UpdateAborted, Err: errors.New("rebase onto main had conflicts, aborted")},
+			}},
+			wantErr: "failed to update 1 worktree(s)",
+		},
+		{
+			name:    "invalid_strategy",
+			args:    []string{"update", "--strategy", "squash"},
+			wantErr: `invalid strategy "squash"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockUpdateCommander{result: tt.result}
+			cmd := newRootCmd(WithUpdateCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			dir := t.TempDir()
+			cmd.SetArgs(append([]string{"-C", dir}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mock.calledCwd != dir {
+				t.Errorf("cwd = %q, want %q", mock.calledCwd, dir)
+			}
+			got := *mock.calledOpts
+			if got.All != tt.wantOpts.All || !slices.Equal(got.Branches, tt.wantOpts.Branches) ||
+				got.Strategy != tt.wantOpts.Strategy || got.Target != tt.wantOpts.Target || got.NoFetch != tt.wantOpts.NoFetch {
+				t.Errorf("opts = %+v, want %+v", got, tt.wantOpts)
+			}
+			if tt.wantStdout != "" && stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+type mockPickCommander struct {
+	calledOpts *twig.PickOptions
+	result     twig.PickResult
+	err        error
+}
+
+func (m *mockPickCommander) Run(opts twig.PickOptions) (twig.PickResult, error) {
+	m.calledOpts = &opts
+	return m.result, m.err
+}
+
+func TestPickCmd(t *testing.T) {
+	t.Parallel()
+
+	picked := twig.PickResult{Worktrees: []twig.Worktree{
+		{Path: "/repo/feat/a", Branch: "feat/a"},
+		{Path: "/repo/feat/b", Branch: "feat/b"},
+	}}
+
+	tests := []struct {
+		name       string
+		args       []string
+		err        error
+		wantOpts   twig.PickOptions
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "path",
+			args:       []string{"pick"},
+			wantStdout: "/repo/feat/a\n/repo/feat/b\n",
+		},
+		{
+			name:       "multi_branch_with_query",
+			args:       []string{"pick", "-m", "--branch", "--query", "feat"},
+			wantOpts:   twig.PickOptions{Multi: true, Query: "feat"},
+			wantStdout: "feat/a\nfeat/b\n",
+		},
+		{
+			name:    "canceled",
+			args:    []string{"pick"},
+			err:     twig.ErrPickCanceled,
+			wantErr: "pick canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := &mockPickCommander{result: picked, err: tt.err}
+			cmd := newRootCmd(WithPickCommander(mock))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if stdout.String() != "" {
+					t.Errorf("stdout = %q, want empty", stdout.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *mock.calledOpts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", *mock.calledOpts, tt.wantOpts)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestRemoveCmd_Interactive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		args         []string
+		pickErr      error
+		wantBranches []string
+		wantErr      string
+	}{
+		{
+			name:         "removes_picked",
+			args:         []string{"remove", "-i", "--dry-run"},
+			wantBranches: []string{"feat/a", "feat/b"},
+		},
+		{
+			name:    "canceled_removes_nothing",
+			args:    []string{"remove", "--interactive"},
+			pickErr: twig.ErrPickCanceled,
+		},
+		{
+			name:    "branches_with_interactive",
+			args:    []string{"remove", "-i", "feat/a"},
+			wantErr: "branches cannot be combined with --interactive",
+		},
+		{
+			name:    "no_branches",
+			args:    []string{"remove"},
+			wantErr: "requires at least 1 arg(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pick := &mockPickCommander{
+				result: twig.PickResult{Worktrees: []twig.Worktree{
+					{Path: "/repo/feat/a", Branch: "feat/a"},
+					{Path: "/repo/feat/b", Branch: "feat/b"},
+				}},
+				err: tt.pickErr,
+			}
+			remove := &mockRemoveCommander{}
+			cmd := newRootCmd(WithPickCommander(pick), WithRemoveCommander(remove))
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := (twig.PickOptions{Multi: true, Removable: true}); *pick.calledOpts != want {
+				t.Errorf("pick opts = %+v, want %+v", *pick.calledOpts, want)
+			}
+			var branches []string
+			for _, c := range remove.calls {
+				branches = append(branches, c.branch)
+			}
+			if !slices.Equal(branches, tt.wantBranches) {
+				t.Errorf("removed = %v, want %v", branches, tt.wantBranches)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"generic", errors.New("boom"), exitCodeFailure},
+		{"branch_not_found", fmt.Errorf("wrapped: %w", twig.ErrBranchNotFound), exitCodeBranchNotFound},
+		{"branch_checked_out", twig.ErrBranchCheckedOut, exitCodeBranchCheckedOut},
+		{"directory_exists", twig.ErrDirectoryExists, exitCodeDirectoryExists},
+		{"dirty", &twig.GitError{Op: twig.OpWorktreeRemove, Err: errors.New("exit status 128"), Kind: twig.ErrWorktreeDirty}, exitCodeWorktreeDirty},
+		{"locked", twig.ErrWorktreeLocked, exitCodeWorktreeLocked},
+		{"not_merged", twig.ErrBranchNotMerged, exitCodeBranchNotMerged},
+		{"config", fmt.Errorf("failed to load config: %w", twig.ErrConfig), exitCodeConfig},
+		{"explicit", &exitCodeError{err: errors.New("x"), code: exitCodePartialFailure}, exitCodePartialFailure},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveCmd_ExitCode(t *testing.T) {
+	t.Parallel()
+
+	ok := removeResult{wt: twig.RemovedWorktree{Branch: "feat/ok"}}
+	dirty := removeResult{err: &twig.GitError{Op: twig.OpWorktreeRemove, Err: errors.New("exit status 128"), Kind: twig.ErrWorktreeDirty}}
+	missing := removeResult{err: fmt.Errorf("wrapped: %w", twig.ErrBranchNotFound)}
+
+	tests := []struct {
+		name    string
+		args    []string
+		results []removeResult
+		want    int
+	}{
+		{"single_failure", []string{"remove", "feat/a"}, []removeResult{dirty}, exitCodeWorktreeDirty},
+		{"all_failed_same_kind", []string{"remove", "feat/a", "feat/b"}, []removeResult{dirty, dirty}, exitCodeWorktreeDirty},
+		{"all_failed_mixed_kinds", []string{"remove", "feat/a", "feat/b"}, []removeResult{dirty, missing}, exitCodePartialFailure},
+		{"some_failed", []string{"remove", "feat/ok", "feat/a"}, []removeResult{ok, missing}, exitCodePartialFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := newRootCmd(WithRemoveCommander(&mockRemoveCommander{results: tt.results}))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			err := cmd.Execute()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("exitCode() = %d, want %d (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestPendingOperationsWarning(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		pending    []twig.StashOperation
+		wantStderr string
+	}{
+		{
+			name:       "pending",
+			args:       []string{"list"},
+			pending:    []twig.StashOperation{{Hash: "abc1234"}, {Hash: "def5678"}},
+			wantStderr: "warning: 2 interrupted carry/sync operation(s) found, run 'twig recover' to restore them\n",
+		},
+		{
+			name: "none",
+			args: []string{"list"},
+		},
+		{
+			name:    "not checked for other commands",
+			args:    []string{"relink"},
+			pending: []twig.StashOperation{{Hash: "abc1234"}},
+		},
+		{
+			name:    "not checked for subcommands",
+			args:    []string{"config", "list"},
+			pending: []twig.StashOperation{{Hash: "abc1234"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := newRootCmd(
+				WithRecoverCommander(&mockRecoverCommander{pending: tt.pending}),
+				WithListCommander(&mockListCommander{}),
+				WithRelinkCommander(&mockRelinkCommander{}),
+			)
+
+			var stdout, stderr bytes.Buffer
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			cmd.SetArgs(append([]string{"-C", t.TempDir()}, tt.args...))
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
 }
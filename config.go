@@ -4,85 +4,300 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/bmatcuk/doublestar/v4"
 )
 
 const (
 	configDir           = ".twig"
 	configFileName      = "settings.toml"
 	localConfigFileName = "settings.local.toml"
+	globalConfigDirName = "twig"
+	configEnvPrefix     = "TWIG_"
+)
+
+// Configuration keys as they appear in settings files.
+const (
+	ConfigKeySymlinks            = "symlinks"
+	ConfigKeyExtraSymlinks       = "extra_symlinks"
+	ConfigKeySymlinkExcludes     = "symlink_excludes"
+	ConfigKeySymlinkStyle        = "symlink_style"
+	ConfigKeySubmodules          = "submodules"
+	ConfigKeySparse              = "sparse"
+	ConfigKeyBackup              = "backup"
+	ConfigKeyTrash               = "trash"
+	ConfigKeyWorktreeDestBaseDir = "worktree_destination_base_dir"
+	ConfigKeyDefaultSource       = "default_source"
+	ConfigKeyProfile             = "profile"
 )
 
 // Config holds the merged configuration for the application.
 // All path fields are resolved to absolute paths by LoadConfig.
 type Config struct {
-	Symlinks            []string `toml:"symlinks"`
-	ExtraSymlinks       []string `toml:"extra_symlinks"`
-	WorktreeDestBaseDir string   `toml:"worktree_destination_base_dir"`
-	DefaultSource       string   `toml:"default_source"`
-	WorktreeSourceDir   string   // Set by LoadConfig to the config load directory
+	Symlinks            []string      `toml:"symlinks"`
+	ExtraSymlinks       []string      `toml:"extra_symlinks"`
+	SymlinkExcludes     []string      `toml:"symlink_excludes"`
+	SymlinkStyle        SymlinkStyle  `toml:"symlink_style"`
+	Submodules          SubmoduleMode `toml:"submodules"`
+	Sparse              []string      `toml:"sparse"`
+	Backup              *bool         `toml:"backup"` // nil means enabled
+	Trash               *bool         `toml:"trash"`  // nil means disabled
+	WorktreeDestBaseDir string        `toml:"worktree_destination_base_dir"`
+	DefaultSource       string        `toml:"default_source"`
+	Profiles            []Profile     `toml:"profile"`
+	WorktreeSourceDir   string        // Set by LoadConfig to the config load directory
+}
+
+// ConfigScope identifies the layer a configuration value was read from.
+type ConfigScope string
+
+const (
+	ConfigScopeDefault ConfigScope = "default"
+	ConfigScopeGlobal  ConfigScope = "global"
+	ConfigScopeProject ConfigScope = "project"
+	ConfigScopeLocal   ConfigScope = "local"
+	ConfigScopeEnv     ConfigScope = "env"
+)
+
+// ConfigSource describes where a configuration value came from.
+// Path is the settings file path, or the environment variable name for env scope.
+type ConfigSource struct {
+	Scope ConfigScope
+	Path  string
+}
+
+func (s ConfigSource) String() string {
+	if s.Path == "" {
+		return string(s.Scope)
+	}
+	return string(s.Scope) + ":" + s.Path
+}
+
+// ConfigValue is a single configuration value together with its origin.
+// List values are reported as one ConfigValue per element.
+type ConfigValue struct {
+	Key        string
+	Value      string
+	Source     ConfigSource
+	Overridden bool // Shadowed by a higher-precedence source
 }
 
 // LoadConfigResult contains the loaded config and any warnings.
 type LoadConfigResult struct {
 	Config   *Config
 	Warnings []string
+	// Values lists every configured value with its origin, in key order.
+	Values []ConfigValue
+}
+
+type loadConfigOptions struct {
+	globalPath string
+	getenv     func(string) string
+}
+
+// LoadConfigOption is a functional option for LoadConfig.
+type LoadConfigOption func(*loadConfigOptions)
+
+// WithGlobalConfigPath overrides the global settings file location.
+// An empty path disables the global layer.
+func WithGlobalConfigPath(path string) LoadConfigOption {
+	return func(o *loadConfigOptions) {
+		o.globalPath = path
+	}
+}
+
+// WithGetenv overrides the environment lookup used for TWIG_* variables.
+func WithGetenv(getenv func(string) string) LoadConfigOption {
+	return func(o *loadConfigOptions) {
+		o.getenv = getenv
+	}
+}
+
+// GlobalConfigPath returns the path of the user-wide settings file.
+// Uses $XDG_CONFIG_HOME/twig/settings.toml, falling back to ~/.config.
+// Returns an empty string if no home directory can be determined.
+func GlobalConfigPath() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, globalConfigDirName, configFileName)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", globalConfigDirName, configFileName)
+}
+
+// BackupEnabled reports whether forced removals back up the worktree first.
+func (c *Config) BackupEnabled() bool {
+	return c.Backup == nil || *c.Backup
+}
+
+// TrashEnabled reports whether removals move the worktree directory to the
+// trash instead of deleting it.
+func (c *Config) TrashEnabled() bool {
+	return c.Trash != nil && *c.Trash
+}
+
+// ConfigEnvVar returns the environment variable that overrides key.
+func ConfigEnvVar(key string) string {
+	return configEnvPrefix + strings.ToUpper(key)
 }
 
-func LoadConfig(dir string) (*LoadConfigResult, error) {
+// configLayer is a single settings file that was found on disk.
+type configLayer struct {
+	source ConfigSource
+	cfg    *Config
+}
+
+// LoadConfig loads and merges the global, project and local settings for dir.
+// Precedence from lowest to highest: global, project, local, environment.
+func LoadConfig(dir string, opts ...LoadConfigOption) (*LoadConfigResult, error) {
+	o := loadConfigOptions{
+		globalPath: GlobalConfigPath(),
+		getenv:     os.Getenv,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var warnings []string
 
-	projCfg, err := loadConfigFile(filepath.Join(dir, configDir, configFileName))
+	paths := []ConfigSource{
+		{Scope: ConfigScopeGlobal, Path: o.globalPath},
+		{Scope: ConfigScopeProject, Path: filepath.Join(dir, configDir, configFileName)},
+		{Scope: ConfigScopeLocal, Path: filepath.Join(dir, configDir, localConfigFileName)},
+	}
+	var layers []configLayer
+	for _, src := range paths {
+		if src.Path == "" {
+			continue
+		}
+		cfg, err := loadConfigFile(src.Path)
+		if err != nil {
+			return nil, err
+		}
+		if cfg != nil {
+			layers = append(layers, configLayer{source: src, cfg: cfg})
+		}
+	}
+
+	var values []ConfigValue
+
+	destBaseDirConfig, v := resolveScalar(ConfigKeyWorktreeDestBaseDir, layers, o.getenv,
+		func(c *Config) string { return c.WorktreeDestBaseDir })
+	values = append(values, v...)
+	var destBaseDirSource ConfigSource
+	if len(v) > 0 {
+		destBaseDirSource = v[len(v)-1].Source
+	}
+
+	defaultSource, v := resolveScalar(ConfigKeyDefaultSource, layers, o.getenv,
+		func(c *Config) string { return c.DefaultSource })
+	values = append(values, v...)
+
+	symlinkStyleConfig, v := resolveScalar(ConfigKeySymlinkStyle, layers, o.getenv,
+		func(c *Config) string { return string(c.SymlinkStyle) })
+	values = append(values, v...)
+	symlinkStyle, err := ParseSymlinkStyle(symlinkStyleConfig)
 	if err != nil {
-		return nil, err
+		return nil, invalidConfigValue(v, err)
 	}
 
-	localCfg, err := loadConfigFile(filepath.Join(dir, configDir, localConfigFileName))
+	submodulesConfig, v := resolveScalar(ConfigKeySubmodules, layers, o.getenv,
+		func(c *Config) string { return string(c.Submodules) })
+	values = append(values, v...)
+	submodules, err := ParseSubmoduleMode(submodulesConfig)
 	if err != nil {
-		return nil, err
+		return nil, invalidConfigValue(v, err)
 	}
 
-	// symlinks: local overrides project if local has any symlinks
-	var symlinks []string
-	if localCfg != nil && len(localCfg.Symlinks) > 0 {
-		symlinks = localCfg.Symlinks
-	} else if projCfg != nil {
-		symlinks = projCfg.Symlinks
+	backupConfig, v := resolveScalar(ConfigKeyBackup, layers, o.getenv,
+		func(c *Config) string {
+			if c.Backup == nil {
+				return ""
+			}
+			return strconv.FormatBool(*c.Backup)
+		})
+	values = append(values, v...)
+	backup := true
+	if backupConfig != "" {
+		if backup, err = strconv.ParseBool(backupConfig); err != nil {
+			return nil, invalidConfigValue(v, fmt.Errorf("invalid backup value %q (must be true or false)", backupConfig))
+		}
 	}
 
-	// extra_symlinks: collect from both configs, deduplicate, append to symlinks
+	trashConfig, v := resolveScalar(ConfigKeyTrash, layers, o.getenv,
+		func(c *Config) string {
+			if c.Trash == nil {
+				return ""
+			}
+			return strconv.FormatBool(*c.Trash)
+		})
+	values = append(values, v...)
+	trash := false
+	if trashConfig != "" {
+		if trash, err = strconv.ParseBool(trashConfig); err != nil {
+			return nil, invalidConfigValue(v, fmt.Errorf("invalid trash value %q (must be true or false)", trashConfig))
+		}
+	}
+
+	// symlinks: the highest layer with any symlinks overrides the others
+	symlinks, v := resolveList(ConfigKeySymlinks, layers,
+		func(c *Config) []string { return c.Symlinks })
+	values = append(values, v...)
+
+	// extra_symlinks: collect from all layers, deduplicate, append to symlinks
 	seen := make(map[string]bool)
 	for _, s := range symlinks {
 		seen[s] = true
 	}
 	var extraSymlinks []string
-	if projCfg != nil {
-		for _, s := range projCfg.ExtraSymlinks {
+	for _, l := range layers {
+		for _, s := range l.cfg.ExtraSymlinks {
+			values = append(values, ConfigValue{
+				Key:        ConfigKeyExtraSymlinks,
+				Value:      s,
+				Source:     l.source,
+				Overridden: seen[s],
+			})
 			if !seen[s] {
 				seen[s] = true
 				extraSymlinks = append(extraSymlinks, s)
 			}
 		}
 	}
-	if localCfg != nil {
-		for _, s := range localCfg.ExtraSymlinks {
-			if !seen[s] {
-				seen[s] = true
-				extraSymlinks = append(extraSymlinks, s)
+	symlinks = append(symlinks, extraSymlinks...)
+
+	// symlink_excludes: collect from all layers, deduplicate
+	var symlinkExcludes []string
+	for _, l := range layers {
+		for _, s := range l.cfg.SymlinkExcludes {
+			dup := slices.Contains(symlinkExcludes, s)
+			values = append(values, ConfigValue{
+				Key:        ConfigKeySymlinkExcludes,
+				Value:      s,
+				Source:     l.source,
+				Overridden: dup,
+			})
+			if dup {
+				continue
 			}
+			if !doublestar.ValidatePattern(s) {
+				warnings = append(warnings, fmt.Sprintf("%s: symlink_excludes pattern %q is not a valid glob, ignored", l.source.Path, s))
+				continue
+			}
+			symlinkExcludes = append(symlinkExcludes, s)
 		}
 	}
-	symlinks = append(symlinks, extraSymlinks...)
 
-	// default_source: local overrides project
-	var defaultSource string
-	if projCfg != nil && projCfg.DefaultSource != "" {
-		defaultSource = projCfg.DefaultSource
-	}
-	if localCfg != nil && localCfg.DefaultSource != "" {
-		defaultSource = localCfg.DefaultSource
-	}
+	// sparse: overridden as a whole like symlinks
+	sparse, v := resolveList(ConfigKeySparse, layers,
+		func(c *Config) []string { return c.Sparse })
+	values = append(values, v...)
 
 	// SourceDir is always the directory where config is loaded from
 	srcDir, err := filepath.Abs(dir)
@@ -90,36 +305,184 @@ func LoadConfig(dir string) (*LoadConfigResult, error) {
 		return nil, fmt.Errorf("failed to resolve source directory: %w", err)
 	}
 
-	// worktree_destination_base_dir: local overrides project
-	var destBaseDirConfig string
-	if projCfg != nil && projCfg.WorktreeDestBaseDir != "" {
-		destBaseDirConfig = projCfg.WorktreeDestBaseDir
-	}
-	if localCfg != nil && localCfg.WorktreeDestBaseDir != "" {
-		destBaseDirConfig = localCfg.WorktreeDestBaseDir
-	}
-	destBaseDir := destBaseDirConfig
-	if destBaseDir == "" {
-		repoName := filepath.Base(srcDir)
-		destBaseDir = filepath.Join(srcDir, "..", repoName+"-worktree")
+	destBaseDir := filepath.Join(srcDir, "..", filepath.Base(srcDir)+"-worktree")
+	if destBaseDirConfig != "" {
+		destBaseDir = resolveConfigPath(destBaseDirConfig, destBaseDirSource, srcDir)
 	}
 	destBaseDir, err = filepath.Abs(destBaseDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve worktree destination base directory: %w", err)
 	}
+	if destBaseDirConfig == "" {
+		values = insertDefault(values, ConfigKeyWorktreeDestBaseDir, destBaseDir)
+	}
+
+	// profile: higher-precedence files are matched first
+	var profiles []Profile
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		for _, p := range l.cfg.Profiles {
+			if p.Match == "" {
+				warnings = append(warnings, fmt.Sprintf("%s: profile without match is ignored", l.source.Path))
+				continue
+			}
+			if !doublestar.ValidatePattern(p.Match) {
+				warnings = append(warnings, fmt.Sprintf("%s: profile match %q is not a valid glob, ignored", l.source.Path, p.Match))
+				continue
+			}
+			if p.WorktreeDestBaseDir != "" {
+				p.WorktreeDestBaseDir, err = filepath.Abs(resolveConfigPath(p.WorktreeDestBaseDir, l.source, srcDir))
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve profile %s destination directory: %w", p.DisplayName(), err)
+				}
+			}
+			profiles = append(profiles, p)
+			values = append(values, ConfigValue{Key: ConfigKeyProfile, Value: p.DisplayName(), Source: l.source})
+		}
+	}
 
 	return &LoadConfigResult{
 		Config: &Config{
 			Symlinks:            symlinks,
 			ExtraSymlinks:       extraSymlinks,
+			SymlinkExcludes:     symlinkExcludes,
+			SymlinkStyle:        symlinkStyle,
+			Submodules:          submodules,
+			Sparse:              sparse,
+			Backup:              &backup,
+			Trash:               &trash,
 			WorktreeDestBaseDir: destBaseDir,
 			DefaultSource:       defaultSource,
+			Profiles:            profiles,
 			WorktreeSourceDir:   srcDir,
 		},
 		Warnings: warnings,
+		Values:   values,
 	}, nil
 }
 
+// resolveList resolves a list setting where the highest layer defining any
+// elements overrides the others.
+// Returns the effective list and every element found, in precedence order.
+func resolveList(key string, layers []configLayer, get func(*Config) []string) ([]string, []ConfigValue) {
+	winner := -1
+	for i, l := range layers {
+		if len(get(l.cfg)) > 0 {
+			winner = i
+		}
+	}
+	var list []string
+	if winner >= 0 {
+		list = get(layers[winner].cfg)
+	}
+	var values []ConfigValue
+	for i, l := range layers {
+		for _, s := range get(l.cfg) {
+			values = append(values, ConfigValue{
+				Key:        key,
+				Value:      s,
+				Source:     l.source,
+				Overridden: i != winner,
+			})
+		}
+	}
+	return list, values
+}
+
+// resolveScalar resolves a string setting where higher layers override lower ones
+// and the TWIG_* environment variable overrides all files.
+// Returns the effective value and every definition found, in precedence order.
+func resolveScalar(
+	key string, layers []configLayer, getenv func(string) string,
+	get func(*Config) string) (string, []ConfigValue) {
+	var values []ConfigValue
+	for _, l := range layers {
+		if s := get(l.cfg); s != "" {
+			values = append(values, ConfigValue{Key: key, Value: s, Source: l.source})
+		}
+	}
+	if env := ConfigEnvVar(key); getenv(env) != "" {
+		values = append(values, ConfigValue{
+			Key:    key,
+			Value:  getenv(env),
+			Source: ConfigSource{Scope: ConfigScopeEnv, Path: env},
+		})
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	for i := range values[:len(values)-1] {
+		values[i].Overridden = true
+	}
+	return values[len(values)-1].Value, values
+}
+
+// invalidConfigValue reports that the effective entry of values, the last
+// one, is invalid. The error names the file or environment variable it
+// came from.
+func invalidConfigValue(values []ConfigValue, err error) error {
+	return kindErrorf(ErrConfig, "%s: %w", values[len(values)-1].Source.Path, err)
+}
+
+// validateConfigValue checks values for key against the rules LoadConfig
+// applies to them, so that settings can be rejected before they are written.
+func validateConfigValue(key string, values []string) error {
+	switch key {
+	case ConfigKeySymlinkStyle:
+		_, err := ParseSymlinkStyle(values[0])
+		return err
+	case ConfigKeySubmodules:
+		_, err := ParseSubmoduleMode(values[0])
+		return err
+	case ConfigKeySymlinkExcludes:
+		for _, s := range values {
+			if !doublestar.ValidatePattern(s) {
+				return fmt.Errorf("symlink_excludes pattern %q is not a valid glob", s)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveConfigPath resolves a relative path setting. Paths from project and
+// local settings are relative to the worktree the settings file belongs to,
+// following a symlinked settings file to its target so that worktrees
+// sharing one file agree on the result. Other paths are relative to dir.
+func resolveConfigPath(path string, src ConfigSource, dir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	base := dir
+	if src.Scope == ConfigScopeProject || src.Scope == ConfigScopeLocal {
+		if real, err := filepath.EvalSymlinks(src.Path); err == nil {
+			base = filepath.Dir(filepath.Dir(real))
+		}
+	}
+	return filepath.Join(base, path)
+}
+
+// insertDefault places a default value for key before the first value of any key
+// that comes after it in configKeys, keeping Values in key order.
+func insertDefault(values []ConfigValue, key, value string) []ConfigValue {
+	def := ConfigValue{Key: key, Value: value, Source: ConfigSource{Scope: ConfigScopeDefault}}
+	order := configKeyOrder(key)
+	for i, v := range values {
+		if configKeyOrder(v.Key) > order {
+			return slices.Insert(values, i, def)
+		}
+	}
+	return append(values, def)
+}
+
+// configKeyOrder returns the display position of key. Keys not listed in
+// configKeys, such as profile, come last.
+func configKeyOrder(key string) int {
+	if i := slices.IndexFunc(configKeys, func(s configKeySpec) bool { return s.Name == key }); i >= 0 {
+		return i
+	}
+	return len(configKeys)
+}
+
 func loadConfigFile(path string) (*Config, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, nil
@@ -127,7 +490,7 @@ func loadConfigFile(path string) (*Config, error) {
 
 	var config Config
 	if _, err := toml.DecodeFile(path, &config); err != nil {
-		return nil, err
+		return nil, kindErrorf(ErrConfig, "%s: %w", path, err)
 	}
 
 	return &config, nil
@@ -0,0 +1,359 @@
+package twig
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configKeySpec describes a configuration key that can be edited with twig config.
+type configKeySpec struct {
+	Name string
+	List bool
+	Bool bool
+}
+
+// configKeys lists editable keys in display order.
+var configKeys = []configKeySpec{
+	{Name: ConfigKeyWorktreeDestBaseDir},
+	{Name: ConfigKeyDefaultSource},
+	{Name: ConfigKeySymlinkStyle},
+	{Name: ConfigKeySubmodules},
+	{Name: ConfigKeyBackup, Bool: true},
+	{Name: ConfigKeyTrash, Bool: true},
+	{Name: ConfigKeySymlinks, List: true},
+	{Name: ConfigKeyExtraSymlinks, List: true},
+	{Name: ConfigKeySymlinkExcludes, List: true},
+	{Name: ConfigKeySparse, List: true},
+}
+
+// ConfigKeys returns the names of all known configuration keys.
+func ConfigKeys() []string {
+	names := make([]string, len(configKeys))
+	for i, k := range configKeys {
+		names[i] = k.Name
+	}
+	return names
+}
+
+func lookupConfigKey(key string) (configKeySpec, error) {
+	for _, k := range configKeys {
+		if k.Name == key {
+			return k, nil
+		}
+	}
+	return configKeySpec{}, fmt.Errorf("unknown config key %q (valid keys: %s)",
+		key, strings.Join(ConfigKeys(), ", "))
+}
+
+// ConfigCommand inspects and edits twig settings files.
+type ConfigCommand struct {
+	FS         FileSystem
+	Dir        string // Directory containing .twig/
+	GlobalPath string // Global settings file (empty disables the global scope)
+	Getenv     func(string) string
+}
+
+// ConfigOptions configures the config operations.
+type ConfigOptions struct {
+	// Scope restricts the operation to a single settings file.
+	// Empty means the merged view for list/get and project for set/unset.
+	Scope ConfigScope
+}
+
+// NewConfigCommand creates a ConfigCommand with explicit dependencies (for testing).
+func NewConfigCommand(fs FileSystem, dir, globalPath string, getenv func(string) string) *ConfigCommand {
+	return &ConfigCommand{
+		FS:         fs,
+		Dir:        dir,
+		GlobalPath: globalPath,
+		Getenv:     getenv,
+	}
+}
+
+// NewDefaultConfigCommand creates a ConfigCommand with production defaults.
+func NewDefaultConfigCommand(dir string) *ConfigCommand {
+	return NewConfigCommand(osFS{}, dir, GlobalConfigPath(), nil)
+}
+
+// ConfigListResult holds the result of a config list operation.
+type ConfigListResult struct {
+	Dir    string
+	Values []ConfigValue
+}
+
+// ConfigGetResult holds the result of a config get operation.
+type ConfigGetResult struct {
+	Dir    string
+	Key    string
+	Values []ConfigValue
+}
+
+// ConfigSetResult holds the result of a config set operation.
+type ConfigSetResult struct {
+	Key     string
+	Path    string
+	Values  []string
+	Created bool // Settings file did not exist before
+}
+
+// ConfigUnsetResult holds the result of a config unset operation.
+type ConfigUnsetResult struct {
+	Key     string
+	Path    string
+	Removed bool // false if the key was not set in the file
+}
+
+// Format formats the ConfigListResult for display.
+// Overridden values are only shown in verbose mode.
+func (r ConfigListResult) Format(opts FormatOptions) FormatResult {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, v := range r.Values {
+		if v.Overridden && !opts.Verbose {
+			continue
+		}
+		line := fmt.Sprintf("%s\t%s=%s", displaySource(r.Dir, v.Source), v.Key, v.Value)
+		if v.Overridden {
+			line += " (overridden)"
+		}
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+	return FormatResult{Stdout: buf.String()}
+}
+
+// Format formats the ConfigGetResult for display.
+// List values are printed one per line. Verbose mode prefixes each value with its origin.
+func (r ConfigGetResult) Format(opts FormatOptions) FormatResult {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, v := range r.Values {
+		if opts.Verbose {
+			fmt.Fprintf(w, "%s\t%s\n", displaySource(r.Dir, v.Source), v.Value)
+		} else {
+			fmt.Fprintln(w, v.Value)
+		}
+	}
+	w.Flush()
+	return FormatResult{Stdout: buf.String()}
+}
+
+// Format formats the ConfigSetResult for display.
+func (r ConfigSetResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+	if opts.Verbose && r.Created {
+		fmt.Fprintf(&stdout, "Created %s\n", r.Path)
+	}
+	fmt.Fprintf(&stdout, "twig config: set %s in %s\n", r.Key, r.Path)
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// Format formats the ConfigUnsetResult for display.
+func (r ConfigUnsetResult) Format(opts FormatOptions) FormatResult {
+	if !r.Removed {
+		return FormatResult{Stderr: fmt.Sprintf("warning: %s is not set in %s\n", r.Key, r.Path)}
+	}
+	return FormatResult{Stdout: fmt.Sprintf("twig config: unset %s in %s\n", r.Key, r.Path)}
+}
+
+// displaySource shortens settings file paths inside dir to relative paths.
+func displaySource(dir string, s ConfigSource) string {
+	if s.Scope == ConfigScopeProject || s.Scope == ConfigScopeLocal {
+		if rel, err := filepath.Rel(dir, s.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			return string(s.Scope) + ":" + rel
+		}
+	}
+	return s.String()
+}
+
+// List returns all configured values with their origins.
+// With a scope, only values defined in that settings file are returned.
+func (c *ConfigCommand) List(opts ConfigOptions) (ConfigListResult, error) {
+	result := ConfigListResult{Dir: c.Dir}
+
+	loaded, err := c.load()
+	if err != nil {
+		return result, err
+	}
+
+	for _, v := range loaded.Values {
+		if opts.Scope != "" && v.Source.Scope != opts.Scope {
+			continue
+		}
+		result.Values = append(result.Values, v)
+	}
+	return result, nil
+}
+
+// Get returns the effective value of key, or the value defined in opts.Scope.
+func (c *ConfigCommand) Get(key string, opts ConfigOptions) (ConfigGetResult, error) {
+	result := ConfigGetResult{Dir: c.Dir, Key: key}
+
+	if _, err := lookupConfigKey(key); err != nil {
+		return result, err
+	}
+
+	loaded, err := c.load()
+	if err != nil {
+		return result, err
+	}
+
+	for _, v := range loaded.Values {
+		if v.Key != key {
+			continue
+		}
+		if opts.Scope != "" {
+			if v.Source.Scope != opts.Scope {
+				continue
+			}
+		} else if v.Overridden {
+			continue
+		}
+		result.Values = append(result.Values, v)
+	}
+
+	if len(result.Values) == 0 {
+		return result, fmt.Errorf("config key %q is not set", key)
+	}
+	return result, nil
+}
+
+// Set writes key to the settings file selected by opts.Scope.
+// Existing comments and other keys in the file are preserved.
+func (c *ConfigCommand) Set(key string, values []string, opts ConfigOptions) (ConfigSetResult, error) {
+	result := ConfigSetResult{Key: key, Values: values}
+
+	spec, err := lookupConfigKey(key)
+	if err != nil {
+		return result, err
+	}
+	if !spec.List && len(values) != 1 {
+		return result, fmt.Errorf("%s takes exactly one value, got %d", key, len(values))
+	}
+	if err := validateConfigValue(key, values); err != nil {
+		return result, err
+	}
+
+	path, err := c.scopePath(opts.Scope)
+	if err != nil {
+		return result, err
+	}
+	result.Path = path
+
+	content, err := c.FS.ReadFile(path)
+	if err != nil {
+		if !c.FS.IsNotExist(err) {
+			return result, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		result.Created = true
+	}
+
+	var value any = values[0]
+	switch {
+	case spec.List:
+		value = values
+	case spec.Bool:
+		if value, err = strconv.ParseBool(values[0]); err != nil {
+			return result, fmt.Errorf("%s must be true or false, got %q", key, values[0])
+		}
+	}
+	assignment, err := encodeTOMLAssignment(key, value)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	updated := setTOMLKey(content, key, assignment)
+	if err := verifyTOMLEdit(content, updated, key, assignment); err != nil {
+		return result, fmt.Errorf("cannot safely edit %s, edit it by hand: %w", path, err)
+	}
+	if err := c.write(path, updated); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// Unset removes key from the settings file selected by opts.Scope.
+func (c *ConfigCommand) Unset(key string, opts ConfigOptions) (ConfigUnsetResult, error) {
+	result := ConfigUnsetResult{Key: key}
+
+	if _, err := lookupConfigKey(key); err != nil {
+		return result, err
+	}
+
+	path, err := c.scopePath(opts.Scope)
+	if err != nil {
+		return result, err
+	}
+	result.Path = path
+
+	content, err := c.FS.ReadFile(path)
+	if err != nil {
+		if c.FS.IsNotExist(err) {
+			return result, nil
+		}
+		return result, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	updated, removed := unsetTOMLKey(content, key)
+	if !removed {
+		return result, nil
+	}
+	if err := verifyTOMLEdit(content, updated, key, ""); err != nil {
+		return result, fmt.Errorf("cannot safely edit %s, edit it by hand: %w", path, err)
+	}
+	if err := c.write(path, updated); err != nil {
+		return result, err
+	}
+	result.Removed = true
+	return result, nil
+}
+
+func (c *ConfigCommand) load() (*LoadConfigResult, error) {
+	opts := []LoadConfigOption{WithGlobalConfigPath(c.GlobalPath)}
+	if c.Getenv != nil {
+		opts = append(opts, WithGetenv(c.Getenv))
+	}
+	loaded, err := LoadConfig(c.Dir, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return loaded, nil
+}
+
+// write validates content as a settings file before writing it to path.
+func (c *ConfigCommand) write(path string, content []byte) error {
+	var cfg Config
+	if _, err := toml.Decode(string(content), &cfg); err != nil {
+		return fmt.Errorf("refusing to write invalid settings to %s: %w", path, err)
+	}
+	if err := c.FS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := c.FS.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// scopePath returns the settings file for a writable scope.
+func (c *ConfigCommand) scopePath(scope ConfigScope) (string, error) {
+	switch scope {
+	case "", ConfigScopeProject:
+		return filepath.Join(c.Dir, configDir, configFileName), nil
+	case ConfigScopeLocal:
+		return filepath.Join(c.Dir, configDir, localConfigFileName), nil
+	case ConfigScopeGlobal:
+		if c.GlobalPath == "" {
+			return "", fmt.Errorf("global config path could not be determined")
+		}
+		return c.GlobalPath, nil
+	default:
+		return "", fmt.Errorf("cannot write to %s scope", scope)
+	}
+}
@@ -0,0 +1,326 @@
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestConfigCommand(t *testing.T, project, local string) *ConfigCommand {
+	t.Helper()
+
+	dir := t.TempDir()
+	twigDir := filepath.Join(dir, configDir)
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if project != "" {
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(project), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if local != "" {
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(local), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	globalPath := filepath.Join(dir, "global", configFileName)
+	return NewConfigCommand(osFS{}, dir, globalPath, func(string) string { return "" })
+}
+
+func TestConfigCommand_List(t *testing.T) {
+	t.Parallel()
+
+	cmd := newTestConfigCommand(t,
+		"symlinks = [\".envrc\"]\nextra_symlinks = [\".project-extra\"]\n",
+		"symlinks = [\".local-only\"]\n")
+
+	result, err := cmd.List(ConfigOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := result.Format(FormatOptions{}).Stdout
+	for _, want := range []string{
+		"local:.twig/settings.local.toml",
+		"symlinks=.local-only",
+		"extra_symlinks=.project-extra",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "symlinks=.envrc") {
+		t.Errorf("overridden value should be hidden without verbose, got:\n%s", out)
+	}
+
+	verbose := result.Format(FormatOptions{Verbose: true}).Stdout
+	if !strings.Contains(verbose, "symlinks=.envrc (overridden)") {
+		t.Errorf("verbose output should show overridden value, got:\n%s", verbose)
+	}
+
+	scoped, err := cmd.List(ConfigOptions{Scope: ConfigScopeProject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range scoped.Values {
+		if v.Source.Scope != ConfigScopeProject {
+			t.Errorf("scoped list returned %s value %s=%s", v.Source.Scope, v.Key, v.Value)
+		}
+	}
+}
+
+func TestConfigCommand_Get(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		key         string
+		opts        ConfigOptions
+		want        string
+		errContains string
+	}{
+		{
+			name: "effective scalar",
+			key:  ConfigKeyDefaultSource,
+			want: "develop\n",
+		},
+		{
+			name: "scoped scalar",
+			key:  ConfigKeyDefaultSource,
+			opts: ConfigOptions{Scope: ConfigScopeProject},
+			want: "main\n",
+		},
+		{
+			name: "list value",
+			key:  ConfigKeySymlinks,
+			want: ".envrc\nconfig/**\n",
+		},
+		{
+			name:        "unset key",
+			key:         ConfigKeyExtraSymlinks,
+			errContains: "is not set",
+		},
+		{
+			name:        "unknown key",
+			key:         "nope",
+			errContains: "unknown config key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := newTestConfigCommand(t,
+				"default_source = \"main\"\nsymlinks = [\".envrc\", \"config/**\"]\n",
+				"default_source = \"develop\"\n")
+
+			result, err := cmd.Get(tt.key, tt.opts)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("error = %v, want containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Format(FormatOptions{}).Stdout; got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigCommand_SetUnset(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SetPreservesComments", func(t *testing.T) {
+		t.Parallel()
+
+		cmd := newTestConfigCommand(t, "# team settings\nsymlinks = [\".envrc\"]\n", "")
+
+		result, err := cmd.Set(ConfigKeySymlinks, []string{".envrc", ".tool-versions"}, ConfigOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := os.ReadFile(result.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "# team settings\nsymlinks = [\".envrc\", \".tool-versions\"]\n"
+		if string(content) != want {
+			t.Errorf("content = %q, want %q", content, want)
+		}
+	})
+
+	t.Run("SetLocalCreatesFile", func(t *testing.T) {
+		t.Parallel()
+
+		cmd := newTestConfigCommand(t, "", "")
+
+		result, err := cmd.Set(ConfigKeyDefaultSource, []string{"develop"}, ConfigOptions{Scope: ConfigScopeLocal})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Created {
+			t.Error("expected Created to be true")
+		}
+		if filepath.Base(result.Path) != localConfigFileName {
+			t.Errorf("Path = %q, want local settings file", result.Path)
+		}
+
+		got, err := cmd.Get(ConfigKeyDefaultSource, ConfigOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Values[0].Value != "develop" {
+			t.Errorf("effective default_source = %q, want %q", got.Values[0].Value, "develop")
+		}
+	})
+
+	t.Run("SetScalarRejectsMultipleValues", func(t *testing.T) {
+		t.Parallel()
+
+		cmd := newTestConfigCommand(t, "", "")
+
+		_, err := cmd.Set(ConfigKeyDefaultSource, []string{"a", "b"}, ConfigOptions{})
+		if err == nil || !strings.Contains(err.Error(), "exactly one value") {
+			t.Fatalf("error = %v, want 'exactly one value'", err)
+		}
+	})
+
+	t.Run("SetBool", func(t *testing.T) {
+		t.Parallel()
+
+		cmd := newTestConfigCommand(t, "", "")
+
+		if _, err := cmd.Set(ConfigKeyBackup, []string{"off"}, ConfigOptions{}); err == nil ||
+			!strings.Contains(err.Error(), "must be true or false") {
+			t.Fatalf("error = %v, want 'must be true or false'", err)
+		}
+		result, err := cmd.Set(ConfigKeyBackup, []string{"false"}, ConfigOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(result.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "backup = false\n" {
+			t.Errorf("content = %q, want %q", content, "backup = false\n")
+		}
+	})
+
+	t.Run("SetRejectsInvalidValue", func(t *testing.T) {
+		t.Parallel()
+
+		cmd := newTestConfigCommand(t, "", "")
+
+		if _, err := cmd.Set(ConfigKeySymlinkStyle, []string{"hard"}, ConfigOptions{}); err == nil ||
+			!strings.Contains(err.Error(), "invalid symlink style") {
+			t.Fatalf("error = %v, want 'invalid symlink style'", err)
+		}
+		if _, err := cmd.Set(ConfigKeySubmodules, []string{"all"}, ConfigOptions{}); err == nil ||
+			!strings.Contains(err.Error(), "invalid submodules mode") {
+			t.Fatalf("error = %v, want 'invalid submodules mode'", err)
+		}
+		if _, err := cmd.Set(ConfigKeySymlinkExcludes, []string{"[a"}, ConfigOptions{}); err == nil ||
+			!strings.Contains(err.Error(), "not a valid glob") {
+			t.Fatalf("error = %v, want 'not a valid glob'", err)
+		}
+		if _, err := os.Stat(filepath.Join(cmd.Dir, configDir, configFileName)); !os.IsNotExist(err) {
+			t.Errorf("settings file should not be written, stat error = %v", err)
+		}
+	})
+
+	t.Run("SetQuotedKey", func(t *testing.T) {
+		t.Parallel()
+
+		cmd := newTestConfigCommand(t, "\"symlink_style\" = \"absolute\" # style\n", "")
+
+		result, err := cmd.Set(ConfigKeySymlinkStyle, []string{"relative"}, ConfigOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(result.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "symlink_style = \"relative\" # style\n"
+		if string(content) != want {
+			t.Errorf("content = %q, want %q", content, want)
+		}
+	})
+
+	t.Run("SetMultilineValue", func(t *testing.T) {
+		t.Parallel()
+
+		project := "default_source = \"\"\"\nmain\"\"\"\nsymlinks = [\".envrc\"]\n"
+		cmd := newTestConfigCommand(t, project, "")
+
+		result, err := cmd.Set(ConfigKeyDefaultSource, []string{"develop"}, ConfigOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(result.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "default_source = \"develop\"\nsymlinks = [\".envrc\"]\n"
+		if string(content) != want {
+			t.Errorf("content = %q, want %q", content, want)
+		}
+	})
+
+	t.Run("SetRefusesUnsupportedForm", func(t *testing.T) {
+		t.Parallel()
+
+		project := "symlinks = [\".envrc\"]\nsubmodules.mode = \"init\"\n"
+		cmd := newTestConfigCommand(t, project, "")
+
+		_, err := cmd.Set(ConfigKeySubmodules, []string{"recursive"}, ConfigOptions{})
+		if err == nil || !strings.Contains(err.Error(), "cannot safely edit") {
+			t.Fatalf("error = %v, want 'cannot safely edit'", err)
+		}
+		content, err := os.ReadFile(filepath.Join(cmd.Dir, configDir, configFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != project {
+			t.Errorf("content = %q, want it unchanged", content)
+		}
+	})
+
+	t.Run("UnsetRemovesKey", func(t *testing.T) {
+		t.Parallel()
+
+		cmd := newTestConfigCommand(t, "default_source = \"main\"\n# keep\n", "")
+
+		result, err := cmd.Unset(ConfigKeyDefaultSource, ConfigOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Removed {
+			t.Error("expected Removed to be true")
+		}
+		content, err := os.ReadFile(result.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "# keep\n" {
+			t.Errorf("content = %q, want %q", content, "# keep\n")
+		}
+
+		again, err := cmd.Unset(ConfigKeyDefaultSource, ConfigOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.Removed {
+			t.Error("expected Removed to be false for missing key")
+		}
+	})
+}
@@ -1,9 +1,12 @@
 package twig
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -262,6 +265,354 @@ extra_symlinks = [".local-extra"]
 	})
 }
 
+func TestLoadConfig_SymlinkExcludes(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	twigDir := filepath.Join(tmpDir, configDir)
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	projectSettings := `symlinks = [".claude/**"]
+symlink_excludes = [".claude/cache/**", "[invalid"]
+`
+	if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	localSettings := `symlink_excludes = [".claude/cache/**", ".claude/*.local.json"]
+`
+	if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadConfig(tmpDir, WithGlobalConfigPath(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Excludes are collected from all layers and deduplicated
+	expected := []string{".claude/cache/**", ".claude/*.local.json"}
+	if !reflect.DeepEqual(result.Config.SymlinkExcludes, expected) {
+		t.Errorf("SymlinkExcludes = %v, want %v", result.Config.SymlinkExcludes, expected)
+	}
+
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], `"[invalid"`) {
+		t.Errorf("Warnings = %v, want one warning for invalid pattern", result.Warnings)
+	}
+}
+
+func TestLoadConfig_SymlinkStyle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings string
+		env      map[string]string
+		want     SymlinkStyle
+		wantErr  bool
+	}{
+		{
+			name: "default_absolute",
+			want: SymlinkStyleAbsolute,
+		},
+		{
+			name:     "relative",
+			settings: "symlink_style = \"relative\"\n",
+			want:     SymlinkStyleRelative,
+		},
+		{
+			name:     "env_override",
+			settings: "symlink_style = \"relative\"\n",
+			env:      map[string]string{"TWIG_SYMLINK_STYLE": "absolute"},
+			want:     SymlinkStyleAbsolute,
+		},
+		{
+			name:     "invalid",
+			settings: "symlink_style = \"hard\"\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := LoadConfig(tmpDir, WithGlobalConfigPath(""),
+				WithGetenv(func(key string) string { return tt.env[key] }))
+			if tt.wantErr {
+				if !errors.Is(err, ErrConfig) {
+					t.Fatalf("error = %v, want ErrConfig", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.SymlinkStyle != tt.want {
+				t.Errorf("SymlinkStyle = %q, want %q", result.Config.SymlinkStyle, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Submodules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings string
+		env      map[string]string
+		want     SubmoduleMode
+		wantErr  bool
+	}{
+		{
+			name: "default_none",
+			want: SubmoduleModeNone,
+		},
+		{
+			name:     "recursive",
+			settings: "submodules = \"recursive\"\n",
+			want:     SubmoduleModeRecursive,
+		},
+		{
+			name:     "env_override",
+			settings: "submodules = \"recursive\"\n",
+			env:      map[string]string{"TWIG_SUBMODULES": "init"},
+			want:     SubmoduleModeInit,
+		},
+		{
+			name:     "invalid",
+			settings: "submodules = \"all\"\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := LoadConfig(tmpDir, WithGlobalConfigPath(""),
+				WithGetenv(func(key string) string { return tt.env[key] }))
+			if tt.wantErr {
+				if !errors.Is(err, ErrConfig) {
+					t.Fatalf("error = %v, want ErrConfig", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.Submodules != tt.want {
+				t.Errorf("Submodules = %q, want %q", result.Config.Submodules, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Sparse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		proj  string
+		local string
+		want  []string
+	}{
+		{
+			name: "default_full_checkout",
+			want: nil,
+		},
+		{
+			name: "project",
+			proj: "sparse = [\"services/api\", \"libs\"]\n",
+			want: []string{"services/api", "libs"},
+		},
+		{
+			name:  "local_overrides_project",
+			proj:  "sparse = [\"services/api\", \"libs\"]\n",
+			local: "sparse = [\"services/web\"]\n",
+			want:  []string{"services/web"},
+		},
+		{
+			name:  "empty_local_keeps_project",
+			proj:  "sparse = [\"libs\"]\n",
+			local: "sparse = []\n",
+			want:  []string{"libs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.proj), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(tt.local), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := LoadConfig(tmpDir, WithGlobalConfigPath(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.Config.Sparse, tt.want) {
+				t.Errorf("Sparse = %v, want %v", result.Config.Sparse, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Backup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings string
+		env      map[string]string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name: "default_enabled",
+			want: true,
+		},
+		{
+			name:     "disabled",
+			settings: "backup = false\n",
+			want:     false,
+		},
+		{
+			name:     "env_override",
+			settings: "backup = false\n",
+			env:      map[string]string{"TWIG_BACKUP": "true"},
+			want:     true,
+		},
+		{
+			name:    "invalid",
+			env:     map[string]string{"TWIG_BACKUP": "sometimes"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := LoadConfig(tmpDir, WithGlobalConfigPath(""),
+				WithGetenv(func(key string) string { return tt.env[key] }))
+			if tt.wantErr {
+				if !errors.Is(err, ErrConfig) {
+					t.Fatalf("error = %v, want ErrConfig", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.BackupEnabled(); got != tt.want {
+				t.Errorf("BackupEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Trash(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings string
+		env      map[string]string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name: "default_disabled",
+			want: false,
+		},
+		{
+			name:     "enabled",
+			settings: "trash = true\n",
+			want:     true,
+		},
+		{
+			name:     "env_override",
+			settings: "trash = true\n",
+			env:      map[string]string{"TWIG_TRASH": "false"},
+			want:     false,
+		},
+		{
+			name:    "invalid",
+			env:     map[string]string{"TWIG_TRASH": "sometimes"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpDir := t.TempDir()
+			twigDir := filepath.Join(tmpDir, configDir)
+			if err := os.MkdirAll(twigDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := LoadConfig(tmpDir, WithGlobalConfigPath(""),
+				WithGetenv(func(key string) string { return tt.env[key] }))
+			if tt.wantErr {
+				if !errors.Is(err, ErrConfig) {
+					t.Fatalf("error = %v, want ErrConfig", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Config.TrashEnabled(); got != tt.want {
+				t.Errorf("TrashEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoadConfig_WorktreeDirs(t *testing.T) {
 	t.Parallel()
 
@@ -359,4 +710,232 @@ func TestLoadConfig_WorktreeDirs(t *testing.T) {
 			t.Errorf("expected no warnings, got: %v", result.Warnings)
 		}
 	})
+
+	t.Run("RelativeDestBaseDirFollowsSettingsFile", func(t *testing.T) {
+		t.Parallel()
+
+		// A clone layout: the settings of main are symlinked into feat/x
+		layoutDir, _ := filepath.EvalSymlinks(t.TempDir())
+		mainDir := filepath.Join(layoutDir, "main")
+		featDir := filepath.Join(layoutDir, "feat", "x")
+		for _, d := range []string{filepath.Join(mainDir, configDir), filepath.Join(featDir, configDir)} {
+			if err := os.MkdirAll(d, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		settings := filepath.Join(mainDir, configDir, configFileName)
+		if err := os.WriteFile(settings, []byte("worktree_destination_base_dir = \"..\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(settings, filepath.Join(featDir, configDir, configFileName)); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, dir := range []string{mainDir, featDir} {
+			result, err := LoadConfig(dir, WithGlobalConfigPath(""),
+				WithGetenv(func(string) string { return "" }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Config.WorktreeDestBaseDir != layoutDir {
+				t.Errorf("LoadConfig(%s): WorktreeDestBaseDir = %q, want %q", dir, result.Config.WorktreeDestBaseDir, layoutDir)
+			}
+		}
+	})
+}
+
+func TestLoadConfig_Provenance(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RecordsSourceOfEachValue", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		globalPath := filepath.Join(tmpDir, "global", configFileName)
+		if err := os.MkdirAll(filepath.Dir(globalPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(globalPath, []byte(`default_source = "develop"
+extra_symlinks = [".global-extra"]
+`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(`default_source = "main"
+symlinks = [".envrc"]
+`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(`symlinks = [".local-only"]
+`), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(tmpDir,
+			WithGlobalConfigPath(globalPath),
+			WithGetenv(func(string) string { return "" }))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		projectPath := filepath.Join(twigDir, configFileName)
+		localPath := filepath.Join(twigDir, localConfigFileName)
+		want := []ConfigValue{
+			{Key: ConfigKeyWorktreeDestBaseDir, Value: result.Config.WorktreeDestBaseDir, Source: ConfigSource{Scope: ConfigScopeDefault}},
+			{Key: ConfigKeyDefaultSource, Value: "develop", Source: ConfigSource{Scope: ConfigScopeGlobal, Path: globalPath}, Overridden: true},
+			{Key: ConfigKeyDefaultSource, Value: "main", Source: ConfigSource{Scope: ConfigScopeProject, Path: projectPath}},
+			{Key: ConfigKeySymlinks, Value: ".envrc", Source: ConfigSource{Scope: ConfigScopeProject, Path: projectPath}, Overridden: true},
+			{Key: ConfigKeySymlinks, Value: ".local-only", Source: ConfigSource{Scope: ConfigScopeLocal, Path: localPath}},
+			{Key: ConfigKeyExtraSymlinks, Value: ".global-extra", Source: ConfigSource{Scope: ConfigScopeGlobal, Path: globalPath}},
+		}
+		if !reflect.DeepEqual(result.Values, want) {
+			t.Errorf("Values = %+v, want %+v", result.Values, want)
+		}
+
+		wantSymlinks := []string{".local-only", ".global-extra"}
+		if !reflect.DeepEqual(result.Config.Symlinks, wantSymlinks) {
+			t.Errorf("Symlinks = %v, want %v", result.Config.Symlinks, wantSymlinks)
+		}
+	})
+
+	t.Run("EnvOverridesFiles", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := t.TempDir()
+		twigDir := filepath.Join(tmpDir, configDir)
+		if err := os.MkdirAll(twigDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(`default_source = "main"
+`), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		env := map[string]string{"TWIG_DEFAULT_SOURCE": "release"}
+		result, err := LoadConfig(tmpDir,
+			WithGlobalConfigPath(""),
+			WithGetenv(func(k string) string { return env[k] }))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Config.DefaultSource != "release" {
+			t.Errorf("DefaultSource = %q, want %q", result.Config.DefaultSource, "release")
+		}
+		last := result.Values[len(result.Values)-1]
+		if last.Source != (ConfigSource{Scope: ConfigScopeEnv, Path: "TWIG_DEFAULT_SOURCE"}) {
+			t.Errorf("last value source = %v, want env:TWIG_DEFAULT_SOURCE", last.Source)
+		}
+	})
+}
+
+func TestInsertDefault(t *testing.T) {
+	t.Parallel()
+
+	project := ConfigSource{Scope: ConfigScopeProject, Path: "/repo/.twig/settings.toml"}
+	values := []ConfigValue{
+		{Key: ConfigKeyWorktreeDestBaseDir, Value: "../wt", Source: project},
+		{Key: ConfigKeySymlinkStyle, Value: "relative", Source: project},
+		{Key: ConfigKeySymlinks, Value: ".envrc", Source: project},
+		{Key: ConfigKeyProfile, Value: "agent", Source: project},
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		wantIdx int
+	}{
+		{name: "before_later_key", key: ConfigKeyDefaultSource, wantIdx: 1},
+		{name: "between_scalars_and_lists", key: ConfigKeyBackup, wantIdx: 2},
+		{name: "before_profiles", key: ConfigKeySparse, wantIdx: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := insertDefault(slices.Clone(values), tt.key, "value")
+			if len(got) != len(values)+1 {
+				t.Fatalf("len = %d, want %d", len(got), len(values)+1)
+			}
+			want := ConfigValue{Key: tt.key, Value: "value", Source: ConfigSource{Scope: ConfigScopeDefault}}
+			if got[tt.wantIdx] != want {
+				t.Errorf("Values = %+v, want %+v at index %d", got, want, tt.wantIdx)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Profiles(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	twigDir := filepath.Join(tmpDir, configDir)
+	if err := os.MkdirAll(twigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	projectSettings := `default_source = "main"
+
+[[profile]]
+match = "agent/*"
+lock = true
+lock_reason = "agent"
+
+[[profile]]
+match = "docs/**"
+default_source = "docs-base"
+
+[[profile]]
+name = "broken"
+`
+	if err := os.WriteFile(filepath.Join(twigDir, configFileName), []byte(projectSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	localSettings := `[[profile]]
+name = "my-agents"
+match = "agent/**"
+`
+	if err := os.WriteFile(filepath.Join(twigDir, localConfigFileName), []byte(localSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadConfig(tmpDir, WithGlobalConfigPath(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Config.Profiles) != 3 {
+		t.Fatalf("Profiles = %+v, want 3 valid profiles", result.Config.Profiles)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "profile without match") {
+		t.Errorf("Warnings = %v, want one 'profile without match' warning", result.Warnings)
+	}
+
+	tests := []struct {
+		branch            string
+		wantProfile       string
+		wantDefaultSource string
+	}{
+		{branch: "agent/x", wantProfile: "my-agents", wantDefaultSource: "main"},
+		{branch: "docs/a/b", wantProfile: "docs/**", wantDefaultSource: "docs-base"},
+		{branch: "feat/x", wantProfile: "", wantDefaultSource: "main"},
+	}
+	for _, tt := range tests {
+		var got string
+		if p := result.Config.ProfileFor(tt.branch); p != nil {
+			got = p.DisplayName()
+		}
+		if got != tt.wantProfile {
+			t.Errorf("ProfileFor(%q) = %q, want %q", tt.branch, got, tt.wantProfile)
+		}
+		if ds := result.Config.DefaultSourceFor(tt.branch); ds != tt.wantDefaultSource {
+			t.Errorf("DefaultSourceFor(%q) = %q, want %q", tt.branch, ds, tt.wantDefaultSource)
+		}
+	}
 }
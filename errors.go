@@ -0,0 +1,46 @@
+package twig
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds for scripting. Errors returned by twig match at most one of
+// them with errors.Is; their messages stay specific.
+var (
+	// ErrBranchNotFound means the branch is not checked out in any worktree.
+	ErrBranchNotFound = errors.New("branch not found")
+	// ErrBranchCheckedOut means the branch is already checked out in another worktree.
+	ErrBranchCheckedOut = errors.New("branch already checked out")
+	// ErrDirectoryExists means the worktree directory already exists.
+	ErrDirectoryExists = errors.New("directory already exists")
+	// ErrWorktreeDirty means the worktree has uncommitted changes and force was not given.
+	ErrWorktreeDirty = errors.New("worktree has uncommitted changes")
+	// ErrWorktreeLocked means the worktree is locked and force was not given.
+	ErrWorktreeLocked = errors.New("worktree is locked")
+	// ErrBranchNotMerged means the branch is not merged and force was not given.
+	ErrBranchNotMerged = errors.New("branch is not merged")
+	// ErrConfig means the settings could not be loaded.
+	ErrConfig = errors.New("invalid configuration")
+)
+
+// kindError is an error of a kind, matching both the kind and the
+// underlying error with errors.Is.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// kindErrorf formats an error of the given kind. The message is formatted
+// as with fmt.Errorf and does not repeat the kind.
+func kindErrorf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
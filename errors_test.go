@@ -0,0 +1,83 @@
+package twig
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestKindErrorf(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("permission denied")
+	err := kindErrorf(ErrDirectoryExists, "directory already exists: %s: %w", "/repo/feat/a", cause)
+
+	if got, want := err.Error(), "directory already exists: /repo/feat/a: permission denied"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrDirectoryExists) {
+		t.Error("error should match its kind")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("error should match the wrapped error")
+	}
+	if errors.Is(err, ErrBranchNotFound) {
+		t.Error("error should not match other kinds")
+	}
+}
+
+func TestGitError_Kind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		stderr string
+		want   error
+	}{
+		{
+			name:   "dirty",
+			stderr: "fatal: '/repo/feat/a' contains modified or untracked files, use --force to delete it",
+			want:   ErrWorktreeDirty,
+		},
+		{
+			name:   "locked",
+			stderr: "fatal: cannot remove a locked working tree, lock reason: wip\nuse 'remove -f -f' to override or unlock first",
+			want:   ErrWorktreeLocked,
+		},
+		{
+			name:   "not_merged",
+			stderr: "error: the branch 'feat/a' is not fully merged",
+			want:   ErrBranchNotMerged,
+		},
+		{
+			name:   "not_merged_older_git",
+			stderr: "error: The branch 'feat/a' is not fully merged.",
+			want:   ErrBranchNotMerged,
+		},
+		{
+			name:   "checked_out",
+			stderr: "fatal: 'feat/a' is already used by worktree at '/repo/feat/a'",
+			want:   ErrBranchCheckedOut,
+		},
+		{
+			name:   "other",
+			stderr: "fatal: '/nonexistent' is not a working tree",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := newGitError(OpWorktreeRemove, &exec.ExitError{Stderr: []byte(tt.stderr)})
+			if err.Kind != tt.want {
+				t.Errorf("Kind = %v, want %v", err.Kind, tt.want)
+			}
+			for _, kind := range []error{ErrWorktreeDirty, ErrWorktreeLocked, ErrBranchNotMerged, ErrBranchCheckedOut} {
+				if got := errors.Is(err, kind); got != (kind == tt.want) {
+					t.Errorf("errors.Is(err, %v) = %v", kind, got)
+				}
+			}
+		})
+	}
+}
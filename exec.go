@@ -0,0 +1,296 @@
+package twig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// CommandExecutor abstracts running commands in worktrees for testability.
+type CommandExecutor interface {
+	// Run executes args[0] with args[1:] in dir, writing its output to
+	// stdout and stderr.
+	Run(dir string, args []string, stdout, stderr io.Writer) error
+}
+
+type osCommandExecutor struct{}
+
+func (e osCommandExecutor) Run(dir string, args []string, stdout, stderr io.Writer) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// ExecCommand runs a command in each selected worktree.
+type ExecCommand struct {
+	Git      *GitRunner
+	Executor CommandExecutor
+}
+
+// ExecOptions configures the exec operation.
+// Exactly one of All, Filter and Branches selects the worktrees.
+type ExecOptions struct {
+	All      bool
+	Filter   string // Glob matched against branch names
+	Branches []string
+	Jobs     int // Maximum number of commands running at once; less than 1 means 1
+
+	// Output lines of the commands, prefixed with the branch name,
+	// are written as they are produced.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewExecCommand creates an ExecCommand with explicit dependencies (for testing).
+func NewExecCommand(git *GitRunner, executor CommandExecutor) *ExecCommand {
+	return &ExecCommand{
+		Git:      git,
+		Executor: executor,
+	}
+}
+
+// NewDefaultExecCommand creates an ExecCommand with production defaults.
+func NewDefaultExecCommand(dir string) *ExecCommand {
+	return NewExecCommand(NewGitRunner(dir), osCommandExecutor{})
+}
+
+// ExecWorktree holds the result of running the command in a single worktree.
+type ExecWorktree struct {
+	Label      string // Branch name, or the directory name of a detached worktree
+	Path       string
+	ExitCode   int
+	SkipReason string
+	Err        error // nil if the command succeeded
+}
+
+// ExecResult aggregates results from an exec operation.
+type ExecResult struct {
+	Worktrees []ExecWorktree
+}
+
+// HasErrors returns true if any command failed.
+func (r ExecResult) HasErrors() bool {
+	return r.ErrorCount() > 0
+}
+
+// ErrorCount returns the number of failed commands.
+func (r ExecResult) ErrorCount() int {
+	count := 0
+	for _, wt := range r.Worktrees {
+		if wt.Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Format formats the exit status summary of the ExecResult for display.
+func (r ExecResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+
+	var succeeded, failed, skipped int
+	for _, wt := range r.Worktrees {
+		switch {
+		case wt.SkipReason != "":
+			skipped++
+			fmt.Fprintf(&stdout, "Skipped %s: %s\n", wt.Label, wt.SkipReason)
+		case wt.ExitCode > 0:
+			failed++
+			fmt.Fprintf(&stderr, "error: %s: exit status %d\n", wt.Label, wt.ExitCode)
+		case wt.Err != nil:
+			failed++
+			fmt.Fprintf(&stderr, "error: %s: %v\n", wt.Label, wt.Err)
+		default:
+			succeeded++
+			if opts.Verbose {
+				fmt.Fprintf(&stdout, "Succeeded in %s\n", wt.Path)
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d succeeded", succeeded)
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d failed", failed)
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	fmt.Fprintf(&stdout, "twig exec: %s\n", summary)
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Run runs args in each selected worktree, at most opts.Jobs at a time.
+// A failing command does not stop the others.
+func (c *ExecCommand) Run(args []string, opts ExecOptions) (ExecResult, error) {
+	var result ExecResult
+	if len(args) == 0 {
+		return result, errors.New("no command given")
+	}
+
+	worktrees, err := c.selectWorktrees(opts)
+	if err != nil {
+		return result, err
+	}
+	result.Worktrees = worktrees
+
+	jobs := max(opts.Jobs, 1)
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	// Lines from concurrent commands are written whole, never interleaved
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, jobs)
+	for i := range result.Worktrees {
+		wt := &result.Worktrees[i]
+		if wt.SkipReason != "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			prefix := "[" + wt.Label + "] "
+			out := &prefixWriter{mu: &mu, w: stdout, prefix: prefix}
+			errOut := &prefixWriter{mu: &mu, w: stderr, prefix: prefix}
+			wt.Err = c.Executor.Run(wt.Path, args, out, errOut)
+			out.Flush()
+			errOut.Flush()
+
+			// Satisfied by *exec.ExitError
+			var exitErr interface{ ExitCode() int }
+			if errors.As(wt.Err, &exitErr) {
+				wt.ExitCode = exitErr.ExitCode()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+// selectWorktrees returns the worktrees selected by opts, in list order for
+// All and Filter and in argument order for Branches. Bare entries are never
+// selected; prunable worktrees are selected but skipped.
+func (c *ExecCommand) selectWorktrees(opts ExecOptions) ([]ExecWorktree, error) {
+	selectors := 0
+	for _, set := range []bool{opts.All, opts.Filter != "", len(opts.Branches) > 0} {
+		if set {
+			selectors++
+		}
+	}
+	switch {
+	case selectors == 0:
+		return nil, errors.New("specify branches, --all or --filter")
+	case selectors > 1:
+		return nil, errors.New("branches, --all and --filter cannot be combined")
+	case opts.Filter != "" && !doublestar.ValidatePattern(opts.Filter):
+		return nil, fmt.Errorf("invalid filter pattern %q", opts.Filter)
+	}
+
+	worktrees, err := c.Git.WorktreeList()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []Worktree
+	switch {
+	case len(opts.Branches) > 0:
+		for _, branch := range opts.Branches {
+			wt, err := findWorktree(worktrees, branch)
+			if err != nil {
+				return nil, err
+			}
+			selected = append(selected, wt)
+		}
+	default:
+		for _, wt := range worktrees {
+			if wt.Bare {
+				continue
+			}
+			if opts.Filter != "" {
+				if ok, _ := doublestar.Match(opts.Filter, wt.Branch); !ok || wt.Detached {
+					continue
+				}
+			}
+			selected = append(selected, wt)
+		}
+		switch {
+		case len(selected) > 0:
+		case opts.Filter != "":
+			return nil, fmt.Errorf("no worktree matches %q", opts.Filter)
+		default:
+			return nil, errors.New("no worktrees found")
+		}
+	}
+
+	result := make([]ExecWorktree, 0, len(selected))
+	for _, wt := range selected {
+		e := ExecWorktree{Label: wt.Branch, Path: wt.Path}
+		if wt.Detached {
+			e.Label = filepath.Base(wt.Path)
+		}
+		if wt.Prunable {
+			e.SkipReason = string(SkipPrunable)
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// prefixWriter writes each complete line with a prefix. Writers sharing mu
+// never interleave within a line.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	i := bytes.LastIndexByte(p.buf, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+	p.writeLines(p.buf[:i+1])
+	p.buf = p.buf[i+1:]
+	return len(b), nil
+}
+
+// Flush writes a trailing line that has no newline.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.writeLines(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLines(lines []byte) {
+	var out bytes.Buffer
+	for line := range bytes.Lines(lines) {
+		out.WriteString(p.prefix)
+		out.Write(line)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = p.w.Write(out.Bytes())
+}
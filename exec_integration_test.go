@@ -0,0 +1,56 @@
+//go:build integration
+
+package twig
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestExecCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	for _, branch := range []string{"feat/a", "feat/b"} {
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", branch, filepath.Join(repoDir, branch))
+	}
+	cmd := NewDefaultExecCommand(mainDir)
+
+	t.Run("All", func(t *testing.T) {
+		t.Parallel()
+
+		var stdout bytes.Buffer
+		result, err := cmd.Run([]string{"git", "branch", "--show-current"}, ExecOptions{All: true, Jobs: 2, Stdout: &stdout})
+		if err != nil || result.HasErrors() {
+			t.Fatalf("exec failed: %v %+v", err, result)
+		}
+		// Each command runs inside its worktree
+		for _, want := range []string{"[main] main\n", "[feat/a] feat/a\n", "[feat/b] feat/b\n"} {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("stdout should contain %q, got:\n%s", want, stdout.String())
+			}
+		}
+	})
+
+	t.Run("ExitStatus", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := cmd.Run([]string{"git", "rev-parse", "--verify", "--quiet", "refs/heads/missing"},
+			ExecOptions{Filter: "feat/*"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.ErrorCount() != 2 {
+			t.Fatalf("ErrorCount() = %d, want 2", result.ErrorCount())
+		}
+		for _, wt := range result.Worktrees {
+			if wt.ExitCode != 1 {
+				t.Errorf("%s: ExitCode = %d, want 1", wt.Label, wt.ExitCode)
+			}
+		}
+	})
+}
@@ -0,0 +1,244 @@
+package twig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// mockCommandExecutor records the directories commands ran in and calls fn.
+type mockCommandExecutor struct {
+	mu   sync.Mutex
+	dirs []string
+	fn   func(dir string, stdout, stderr io.Writer) error
+}
+
+func (m *mockCommandExecutor) Run(dir string, args []string, stdout, stderr io.Writer) error {
+	m.mu.Lock()
+	m.dirs = append(m.dirs, dir)
+	m.mu.Unlock()
+	if m.fn == nil {
+		return nil
+	}
+	return m.fn(dir, stdout, stderr)
+}
+
+// exitError is an error carrying an exit code, like *exec.ExitError.
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+func newExecMockGit() *testutil.MockGitExecutor {
+	return &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+			{Path: "/repo/feat/b", Branch: "feat/b"},
+			{Path: "/repo/fix/c", Branch: "fix/c"},
+			{Path: "/repo/gone", Branch: "feat/gone", Prunable: true},
+			{Path: "/repo/detached", HEAD: "abc1234", Detached: true},
+		},
+	}
+}
+
+func TestExecCommand_Run_Selection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		opts        ExecOptions
+		wantDirs    []string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name:        "all",
+			opts:        ExecOptions{All: true},
+			wantDirs:    []string{"/repo/main", "/repo/feat/a", "/repo/feat/b", "/repo/fix/c", "/repo/detached"},
+			wantSkipped: []string{"feat/gone"},
+		},
+		{
+			name:        "filter",
+			opts:        ExecOptions{Filter: "feat/*"},
+			wantDirs:    []string{"/repo/feat/a", "/repo/feat/b"},
+			wantSkipped: []string{"feat/gone"},
+		},
+		{
+			name:     "branches_in_argument_order",
+			opts:     ExecOptions{Branches: []string{"fix/c", "main"}},
+			wantDirs: []string{"/repo/fix/c", "/repo/main"},
+		},
+		{
+			name:    "unknown_branch",
+			opts:    ExecOptions{Branches: []string{"feat/a", "feat/z"}},
+			wantErr: `branch "feat/z" is not checked out`,
+		},
+		{
+			name:    "filter_matches_nothing",
+			opts:    ExecOptions{Filter: "release/*"},
+			wantErr: `no worktree matches "release/*"`,
+		},
+		{
+			name:    "invalid_filter",
+			opts:    ExecOptions{Filter: "feat/[a"},
+			wantErr: "invalid filter pattern",
+		},
+		{
+			name:    "no_selection",
+			wantErr: "specify branches, --all or --filter",
+		},
+		{
+			name:    "combined_selection",
+			opts:    ExecOptions{All: true, Branches: []string{"feat/a"}},
+			wantErr: "cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			executor := &mockCommandExecutor{}
+			tt.opts.Jobs = 1
+			cmd := NewExecCommand(&GitRunner{Executor: newExecMockGit()}, executor)
+
+			result, err := cmd.Run([]string{"true"}, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(executor.dirs) > 0 {
+					t.Errorf("expected nothing to run, ran in %v", executor.dirs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(executor.dirs, tt.wantDirs) {
+				t.Errorf("dirs = %v, want %v", executor.dirs, tt.wantDirs)
+			}
+			var skipped []string
+			for _, wt := range result.Worktrees {
+				if wt.SkipReason != "" {
+					skipped = append(skipped, wt.Label)
+				}
+			}
+			if !slices.Equal(skipped, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestExecCommand_Run_Output(t *testing.T) {
+	t.Parallel()
+
+	executor := &mockCommandExecutor{
+		fn: func(dir string, stdout, stderr io.Writer) error {
+			switch dir {
+			case "/repo/feat/a":
+				// Lines are split across writes and the last one has no newline
+				fmt.Fprint(stdout, "one\ntw")
+				fmt.Fprint(stdout, "o\nthree")
+				fmt.Fprint(stderr, "warning\n")
+				return nil
+			case "/repo/feat/b":
+				fmt.Fprint(stdout, "failing\n")
+				return exitError(2)
+			}
+			return errors.New("exec: not found")
+		},
+	}
+	cmd := NewExecCommand(&GitRunner{Executor: newExecMockGit()}, executor)
+
+	var stdout, stderr bytes.Buffer
+	result, err := cmd.Run([]string{"make"}, ExecOptions{
+		Branches: []string{"feat/a", "feat/b", "fix/c"},
+		Jobs:     3,
+		Stdout:   &stdout,
+		Stderr:   &stderr,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lines of each worktree keep their order, whatever the interleaving
+	var aLines []string
+	for line := range strings.Lines(stdout.String()) {
+		if rest, ok := strings.CutPrefix(line, "[feat/a] "); ok {
+			aLines = append(aLines, rest)
+		} else if line != "[feat/b] failing\n" {
+			t.Errorf("unexpected stdout line %q", line)
+		}
+	}
+	if !slices.Equal(aLines, []string{"one\n", "two\n", "three\n"}) {
+		t.Errorf("feat/a lines = %q", aLines)
+	}
+	if stderr.String() != "[feat/a] warning\n" {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+
+	if result.ErrorCount() != 2 {
+		t.Errorf("ErrorCount() = %d, want 2", result.ErrorCount())
+	}
+	if result.Worktrees[1].ExitCode != 2 {
+		t.Errorf("feat/b ExitCode = %d, want 2", result.Worktrees[1].ExitCode)
+	}
+}
+
+func TestExecResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := ExecResult{Worktrees: []ExecWorktree{
+		{Label: "main", Path: "/repo/main"},
+		{Label: "feat/a", Path: "/repo/feat/a", ExitCode: 2, Err: exitError(2)},
+		{Label: "feat/b", Path: "/repo/feat/b", Err: errors.New(`exec: "mk": executable file not found in $PATH`)},
+		{Label: "feat/gone", Path: "/repo/gone", SkipReason: "prunable"},
+	}}
+
+	tests := []struct {
+		name       string
+		result     ExecResult
+		opts       FormatOptions
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "mixed",
+			result:     result,
+			wantStdout: "Skipped feat/gone: prunable\ntwig exec: 1 succeeded, 2 failed, 1 skipped\n",
+			wantStderr: "error: feat/a: exit status 2\n" +
+				"error: feat/b: exec: \"mk\": executable file not found in $PATH\n",
+		},
+		{
+			name:       "verbose",
+			result:     ExecResult{Worktrees: result.Worktrees[:1]},
+			opts:       FormatOptions{Verbose: true},
+			wantStdout: "Succeeded in /repo/main\ntwig exec: 1 succeeded\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(tt.opts)
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -17,6 +17,10 @@ type FileSystem interface {
 	ReadDir(name string) ([]os.DirEntry, error)
 	Remove(name string) error
 	WriteFile(name string, data []byte, perm fs.FileMode) error
+	ReadFile(name string) ([]byte, error)
+	Readlink(name string) (string, error)
+	Rename(oldpath, newpath string) error
+	RemoveAll(path string) error
 }
 
 type osFS struct{}
@@ -33,3 +37,7 @@ func (osFS) Remove(name string) error                     { return os.Remove(nam
 func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
 	return os.WriteFile(name, data, perm)
 }
+func (osFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+func (osFS) Readlink(name string) (string, error) { return os.Readlink(name) }
+func (osFS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+func (osFS) RemoveAll(path string) error          { return os.RemoveAll(path) }
@@ -1,9 +1,14 @@
 package twig
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -14,30 +19,74 @@ type GitExecutor interface {
 	Run(args ...string) ([]byte, error)
 }
 
+// GitEnvExecutor is implemented by executors that can run git with
+// additional environment variables (e.g. GIT_INDEX_FILE).
+type GitEnvExecutor interface {
+	RunEnv(env []string, args ...string) ([]byte, error)
+}
+
 type osGitExecutor struct{}
 
 func (e osGitExecutor) Run(args ...string) ([]byte, error) {
 	return exec.Command("git", args...).Output()
 }
 
+func (e osGitExecutor) RunEnv(env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.Output()
+}
+
 // GitOp represents the type of git operation.
 type GitOp int
 
 const (
 	OpWorktreeRemove GitOp = iota + 1
 	OpBranchDelete
+	OpSubmoduleUpdate
+	OpWorktreeLock
+	OpWorktreeUnlock
+	OpWorktreeMove
+	OpWorktreeRepair
+	OpRemoteBranchDelete
 )
 
 // Git command names.
 const (
-	GitCmdWorktree   = "worktree"
-	GitCmdBranch     = "branch"
-	GitCmdStash      = "stash"
-	GitCmdStatus     = "status"
-	GitCmdRevParse   = "rev-parse"
-	GitCmdDiff       = "diff"
-	GitCmdFetch      = "fetch"
-	GitCmdForEachRef = "for-each-ref"
+	GitCmdWorktree       = "worktree"
+	GitCmdBranch         = "branch"
+	GitCmdStash          = "stash"
+	GitCmdStatus         = "status"
+	GitCmdRevParse       = "rev-parse"
+	GitCmdDiff           = "diff"
+	GitCmdFetch          = "fetch"
+	GitCmdForEachRef     = "for-each-ref"
+	GitCmdLsFiles        = "ls-files"
+	GitCmdSymbolicRef    = "symbolic-ref"
+	GitCmdCheckIgnore    = "check-ignore"
+	GitCmdApply          = "apply"
+	GitCmdRestore        = "restore"
+	GitCmdAdd            = "add"
+	GitCmdClean          = "clean"
+	GitCmdReadTree       = "read-tree"
+	GitCmdWriteTree      = "write-tree"
+	GitCmdCommitTree     = "commit-tree"
+	GitCmdUpdateRef      = "update-ref"
+	GitCmdVar            = "var"
+	GitCmdClone          = "clone"
+	GitCmdConfig         = "config"
+	GitCmdRemote         = "remote"
+	GitCmdSubmodule      = "submodule"
+	GitCmdSparseCheckout = "sparse-checkout"
+	GitCmdCheckout       = "checkout"
+	GitCmdMerge          = "merge"
+	GitCmdMergeBase      = "merge-base"
+	GitCmdRebase         = "rebase"
+	GitCmdLog            = "log"
+	GitCmdPush           = "push"
+	GitCmdRevList        = "rev-list"
+	GitCmdCherry         = "cherry"
+	GitCmdReset          = "reset"
 )
 
 // Git worktree subcommands.
@@ -46,14 +95,17 @@ const (
 	GitWorktreeRemove = "remove"
 	GitWorktreeList   = "list"
 	GitWorktreePrune  = "prune"
+	GitWorktreeLock   = "lock"
+	GitWorktreeUnlock = "unlock"
+	GitWorktreeMove   = "move"
+	GitWorktreeRepair = "repair"
 )
 
 // Git stash subcommands.
 const (
-	GitStashPush  = "push"
 	GitStashApply = "apply"
-	GitStashDrop  = "drop"
 	GitStashList  = "list"
+	GitStashShow  = "show"
 )
 
 // Porcelain output format prefixes and values.
@@ -70,12 +122,35 @@ const (
 // RefsHeadsPrefix is the git refs prefix for local branches.
 const RefsHeadsPrefix = "refs/heads/"
 
+// StashRefPrefix is the ref namespace for stash commits created by twig.
+// Each stash gets its own ref, so they never share the "refs/stash" stack.
+const StashRefPrefix = "refs/twig/stash/"
+
+// stashIdentEnv is the identity used for stash commits when the user has
+// none configured, mirroring the fallback of git stash.
+var stashIdentEnv = []string{
+	"GIT_AUTHOR_NAME=twig", "GIT_AUTHOR_EMAIL=twig@localhost",
+	"GIT_COMMITTER_NAME=twig", "GIT_COMMITTER_EMAIL=twig@localhost",
+}
+
 func (op GitOp) String() string {
 	switch op {
 	case OpWorktreeRemove:
 		return "remove worktree"
 	case OpBranchDelete:
 		return "delete branch"
+	case OpSubmoduleUpdate:
+		return "update submodule"
+	case OpWorktreeLock:
+		return "lock worktree"
+	case OpWorktreeUnlock:
+		return "unlock worktree"
+	case OpWorktreeMove:
+		return "move worktree"
+	case OpWorktreeRepair:
+		return "repair worktree"
+	case OpRemoteBranchDelete:
+		return "delete remote branch"
 	default:
 		return "unknown operation"
 	}
@@ -86,6 +161,9 @@ type GitError struct {
 	Op     GitOp
 	Stderr string
 	Err    error
+	// Kind is the error kind git refused the operation for, such as
+	// ErrWorktreeDirty, or nil. The GitError matches it with errors.Is.
+	Kind error
 }
 
 func (e *GitError) Error() string {
@@ -99,13 +177,24 @@ func (e *GitError) Unwrap() error {
 	return e.Err
 }
 
+// Is reports whether target is the kind of the error.
+func (e *GitError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
 // Hint returns a helpful hint message based on the error content.
 func (e *GitError) Hint() string {
 	switch {
 	case strings.Contains(e.Stderr, "modified or untracked files"):
 		return "use 'twig remove --force' to force removal"
+	case e.Op == OpWorktreeMove && strings.Contains(e.Stderr, "containing submodules"):
+		return "git cannot move worktrees with initialized submodules, run 'git submodule deinit --all' in it first"
+	case e.Op == OpWorktreeMove && strings.Contains(e.Stderr, "locked working tree"):
+		return "run 'twig unlock <branch>' first, or use 'twig move -ff'"
+	case strings.Contains(e.Stderr, "containing submodules"):
+		return "worktrees with initialized submodules need 'twig remove --force'"
 	case strings.Contains(e.Stderr, "locked working tree"):
-		return "run 'git worktree unlock <path>' first, or use 'twig remove --force'"
+		return "run 'twig unlock <branch>' first, or use 'twig remove -ff'"
 	default:
 		return ""
 	}
@@ -122,13 +211,62 @@ func newGitError(op GitOp, err error) *GitError {
 	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
 		gitErr.Stderr = strings.TrimSpace(string(exitErr.Stderr))
 	}
+	gitErr.Kind = gitErrorKind(gitErr.Stderr)
 	return gitErr
 }
 
+// errRefExists is returned by createRef when the ref already exists.
+var errRefExists = errors.New("reference already exists")
+
+// createRef creates ref pointing at hash. An existing ref is never
+// overwritten: git checks the all-zero old value atomically and
+// errRefExists is returned instead.
+func (g *GitRunner) createRef(message, ref, hash string) error {
+	if _, err := g.Run(GitCmdUpdateRef, "-m", message, ref, hash, ""); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), "reference already exists") {
+			return fmt.Errorf("%s: %w", ref, errRefExists)
+		}
+		return err
+	}
+	return nil
+}
+
+// withGitStderr adds git's stderr to err, which the exit status alone does
+// not explain.
+func withGitStderr(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+// gitErrorKind classifies why git refused an operation from its stderr.
+func gitErrorKind(stderr string) error {
+	lower := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(lower, "modified or untracked files"):
+		return ErrWorktreeDirty
+	case strings.Contains(lower, "locked working tree"):
+		return ErrWorktreeLocked
+	case strings.Contains(lower, "not fully merged"):
+		return ErrBranchNotMerged
+	case strings.Contains(lower, "is already checked out at"),
+		strings.Contains(lower, "is already used by worktree at"):
+		return ErrBranchCheckedOut
+	default:
+		return nil
+	}
+}
+
 // GitRunner provides git operations using GitExecutor.
 type GitRunner struct {
 	Executor GitExecutor
 	Dir      string
+	// Env holds additional environment variables for every command.
+	// Requires Executor to implement GitEnvExecutor.
+	Env []string
 }
 
 // NewGitRunner creates a new GitRunner with the default executor.
@@ -141,18 +279,46 @@ func NewGitRunner(dir string) *GitRunner {
 
 // InDir returns a GitRunner that executes commands in the specified directory.
 func (g *GitRunner) InDir(dir string) *GitRunner {
-	return &GitRunner{Executor: g.Executor, Dir: dir}
+	return &GitRunner{Executor: g.Executor, Dir: dir, Env: g.Env}
+}
+
+// literal returns a GitRunner that matches pathspecs literally, for passing
+// file names that may contain glob characters.
+func (g *GitRunner) literal() *GitRunner {
+	return g.withEnv("GIT_LITERAL_PATHSPECS=1")
+}
+
+// withEnv returns a GitRunner that adds env to every command.
+func (g *GitRunner) withEnv(env ...string) *GitRunner {
+	return &GitRunner{Executor: g.Executor, Dir: g.Dir, Env: append(slices.Clone(g.Env), env...)}
 }
 
 // Run executes git command with -C flag.
 func (g *GitRunner) Run(args ...string) ([]byte, error) {
-	return g.Executor.Run(append([]string{"-C", g.Dir}, args...)...)
+	args = append([]string{"-C", g.Dir}, args...)
+	if len(g.Env) > 0 {
+		envExec, ok := g.Executor.(GitEnvExecutor)
+		if !ok {
+			return nil, errors.New("git executor does not support environment variables")
+		}
+		return envExec.RunEnv(g.Env, args...)
+	}
+	return g.Executor.Run(args...)
 }
 
 type worktreeAddOptions struct {
 	createBranch bool
 	lock         bool
 	lockReason   string
+	noCheckout   bool
+}
+
+func (o worktreeAddOptions) args() []string {
+	var args []string
+	if o.noCheckout {
+		args = append(args, "--no-checkout")
+	}
+	return append(args, o.lockArgs()...)
 }
 
 func (o worktreeAddOptions) lockArgs() []string {
@@ -189,6 +355,14 @@ func WithLockReason(reason string) WorktreeAddOption {
 	}
 }
 
+// WithNoCheckout creates the worktree without populating it, e.g. to
+// configure sparse-checkout before the first checkout.
+func WithNoCheckout() WorktreeAddOption {
+	return func(o *worktreeAddOptions) {
+		o.noCheckout = true
+	}
+}
+
 // WorktreeAdd creates a new worktree at the specified path.
 func (g *GitRunner) WorktreeAdd(path, branch string, opts ...WorktreeAddOption) ([]byte, error) {
 	var o worktreeAddOptions
@@ -210,34 +384,25 @@ func (g *GitRunner) LocalBranchExists(branch string) bool {
 
 // BranchList returns all local branch names.
 func (g *GitRunner) BranchList() ([]string, error) {
-	output, err := g.Run(GitCmdBranch, "--format=%(refname:short)")
+	output, err := g.Run(GitCmdBranch, "--format=%(refname:short)%00")
 	if err != nil {
 		return nil, err
 	}
-	var branches []string
-	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-		if line != "" {
-			branches = append(branches, line)
-		}
-	}
-	return branches, nil
+	return splitRecords(output), nil
 }
 
 // FindRemotesForBranch returns all remotes that have the specified branch
 // in local remote-tracking branches.
 // This checks refs/remotes/*/<branch> locally without network access.
 func (g *GitRunner) FindRemotesForBranch(branch string) []string {
-	out, err := g.Run(GitCmdForEachRef, "--format=%(refname:short)",
+	out, err := g.Run(GitCmdForEachRef, "--format=%(refname:short)%00",
 		fmt.Sprintf("refs/remotes/*/%s", branch))
 	if err != nil {
 		return nil
 	}
 
 	var remotes []string
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		if line == "" {
-			continue
-		}
+	for _, line := range splitRecords(out) {
 		// Extract "origin" from "origin/branch"
 		if idx := strings.Index(line, "/"); idx > 0 {
 			remotes = append(remotes, line[:idx])
@@ -282,6 +447,14 @@ type Worktree struct {
 	Prunable       bool
 	PrunableReason string
 	Bare           bool
+	// Sparse reports a sparse-checkout. Only set by ListCommand, as
+	// detecting it costs a git call per worktree.
+	Sparse bool
+}
+
+// LockInfo returns the structured form of the lock reason.
+func (w Worktree) LockInfo() LockInfo {
+	return ParseLockInfo(w.LockReason)
 }
 
 // ShortHEAD returns the first 7 characters of the HEAD commit hash.
@@ -294,12 +467,12 @@ func (w Worktree) ShortHEAD() string {
 
 // WorktreeList returns all worktrees with their paths and branches.
 func (g *GitRunner) WorktreeList() ([]Worktree, error) {
-	out, err := g.worktreeListPorcelain()
+	lines, err := g.worktreeListPorcelain()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
 
-	// porcelain format:
+	// porcelain format, one attribute per line (NUL-terminated with -z):
 	// worktree /path/to/worktree
 	// HEAD abc123
 	// branch refs/heads/branch-name
@@ -311,7 +484,7 @@ func (g *GitRunner) WorktreeList() ([]Worktree, error) {
 
 	var worktrees []Worktree
 	var current Worktree
-	for _, line := range strings.Split(string(out), "\n") {
+	for _, line := range lines {
 		switch {
 		case strings.HasPrefix(line, PorcelainWorktreePrefix):
 			current = Worktree{Path: strings.TrimPrefix(line, PorcelainWorktreePrefix)}
@@ -343,13 +516,13 @@ func (g *GitRunner) WorktreeList() ([]Worktree, error) {
 
 // WorktreeListBranches returns a list of branch names currently checked out in worktrees.
 func (g *GitRunner) WorktreeListBranches() ([]string, error) {
-	output, err := g.worktreeListPorcelain()
+	lines, err := g.worktreeListPorcelain()
 	if err != nil {
 		return nil, err
 	}
 
 	var branches []string
-	for line := range strings.SplitSeq(string(output), "\n") {
+	for _, line := range lines {
 		if branch, ok := strings.CutPrefix(line, PorcelainBranchPrefix); ok {
 			branches = append(branches, branch)
 		}
@@ -371,7 +544,7 @@ func (g *GitRunner) WorktreeFindByBranch(branch string) (*Worktree, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("branch %q is not checked out in any worktree", branch)
+	return nil, kindErrorf(ErrBranchNotFound, "branch %q is not checked out in any worktree", branch)
 }
 
 // WorktreeForceLevel represents the force level for worktree removal.
@@ -416,6 +589,80 @@ func (g *GitRunner) WorktreeRemove(path string, opts ...WorktreeRemoveOption) ([
 	return out, nil
 }
 
+type worktreeMoveOptions struct {
+	forceLevel WorktreeForceLevel
+}
+
+// WorktreeMoveOption is a functional option for WorktreeMove.
+type WorktreeMoveOption func(*worktreeMoveOptions)
+
+// WithForceMove forces the move. Locked worktrees need WorktreeForceLevelLocked.
+func WithForceMove(level WorktreeForceLevel) WorktreeMoveOption {
+	return func(o *worktreeMoveOptions) {
+		o.forceLevel = level
+	}
+}
+
+// WorktreeMove moves the worktree at src to dst, updating git's metadata.
+// The parent directory of dst must exist.
+func (g *GitRunner) WorktreeMove(src, dst string, opts ...WorktreeMoveOption) ([]byte, error) {
+	var o worktreeMoveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	args := []string{GitCmdWorktree, GitWorktreeMove}
+	for range o.forceLevel {
+		args = append(args, "-f")
+	}
+	out, err := g.Run(append(args, src, dst)...)
+	if err != nil {
+		return nil, newGitError(OpWorktreeMove, err)
+	}
+	return out, nil
+}
+
+// WorktreeRepair repairs the administrative files linking the repository
+// and the worktrees at paths.
+func (g *GitRunner) WorktreeRepair(paths ...string) ([]byte, error) {
+	out, err := g.Run(append([]string{GitCmdWorktree, GitWorktreeRepair}, paths...)...)
+	if err != nil {
+		return nil, newGitError(OpWorktreeRepair, err)
+	}
+	return out, nil
+}
+
+// WorktreeLock locks the worktree at the given path with an optional reason.
+func (g *GitRunner) WorktreeLock(path, reason string) ([]byte, error) {
+	args := []string{GitCmdWorktree, GitWorktreeLock}
+	if reason != "" {
+		args = append(args, "--reason", reason)
+	}
+	out, err := g.Run(append(args, path)...)
+	if err != nil {
+		return nil, newGitError(OpWorktreeLock, err)
+	}
+	return out, nil
+}
+
+// WorktreeUnlock unlocks the worktree at the given path.
+func (g *GitRunner) WorktreeUnlock(path string) ([]byte, error) {
+	out, err := g.Run(GitCmdWorktree, GitWorktreeUnlock, path)
+	if err != nil {
+		return nil, newGitError(OpWorktreeUnlock, err)
+	}
+	return out, nil
+}
+
+// UserName returns the configured user.name, or "" if it is not set.
+func (g *GitRunner) UserName() string {
+	out, err := g.Run(GitCmdConfig, "--get", "user.name")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
 type branchDeleteOptions struct {
 	force bool
 }
@@ -446,29 +693,161 @@ func (g *GitRunner) BranchDelete(branch string, opts ...BranchDeleteOption) ([]b
 }
 
 // ChangedFiles returns a list of files with uncommitted changes
-// including staged, unstaged, and untracked files.
+// including staged, unstaged, untracked and deleted files.
+// Both sides of a rename are listed.
 func (g *GitRunner) ChangedFiles() ([]string, error) {
-	output, err := g.Run(GitCmdStatus, "--porcelain", "-uall")
+	output, err := g.Run(GitCmdStatus, "--porcelain", "-z", "-uall")
 	if err != nil {
 		return nil, fmt.Errorf("failed to check git status: %w", err)
 	}
 
+	// Format: "XY path\0", followed by "orig\0" for renames and copies.
+	// Paths are neither quoted nor escaped with -z.
 	var files []string
-	for _, line := range strings.Split(string(output), "\n") {
-		if len(line) < 3 {
+	records := strings.Split(string(output), "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if len(record) < 4 {
 			continue
 		}
-		// Format: "XY filename" where XY is 2-char status
-		file := strings.TrimSpace(line[2:])
-		// Handle renamed files "old -> new"
-		if idx := strings.Index(file, " -> "); idx != -1 {
-			file = file[idx+4:]
+		if strings.ContainsAny(record[:2], "RC") && i+1 < len(records) {
+			i++
+			files = append(files, records[i])
 		}
-		files = append(files, file)
+		files = append(files, record[3:])
 	}
 	return files, nil
 }
 
+// SubmodulePaths returns the paths of the submodules listed in .gitmodules.
+// Returns nil if the worktree has no .gitmodules.
+func (g *GitRunner) SubmodulePaths() ([]string, error) {
+	out, err := g.Run(GitCmdConfig, "--file", ".gitmodules", "-z", "--get-regexp", `^submodule\..*\.path$`)
+	if err != nil {
+		// git config exits with 1 when nothing matches or the file is missing
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read .gitmodules: %w", err)
+	}
+
+	// Format: "submodule.<name>.path\n<path>\0"
+	var paths []string
+	for _, entry := range splitNUL(out) {
+		if _, path, ok := strings.Cut(entry, "\n"); ok && path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+type submoduleUpdateOptions struct {
+	recursive bool
+	reference string
+}
+
+// SubmoduleUpdateOption is a functional option for SubmoduleUpdate.
+type SubmoduleUpdateOption func(*submoduleUpdateOptions)
+
+// WithSubmoduleRecursive also initializes nested submodules.
+func WithSubmoduleRecursive() SubmoduleUpdateOption {
+	return func(o *submoduleUpdateOptions) {
+		o.recursive = true
+	}
+}
+
+// WithSubmoduleReference copies objects from an existing checkout of the
+// submodule instead of fetching them. The new submodule does not depend
+// on the reference afterwards.
+func WithSubmoduleReference(repo string) SubmoduleUpdateOption {
+	return func(o *submoduleUpdateOptions) {
+		o.reference = repo
+	}
+}
+
+// SubmoduleUpdate initializes and checks out the submodule at path.
+func (g *GitRunner) SubmoduleUpdate(path string, opts ...SubmoduleUpdateOption) ([]byte, error) {
+	var o submoduleUpdateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	args := []string{GitCmdSubmodule, "update", "--init"}
+	if o.recursive {
+		args = append(args, "--recursive")
+	}
+	if o.reference != "" {
+		args = append(args, "--reference", o.reference, "--dissociate")
+	}
+	args = append(args, "--", path)
+	out, err := g.Run(args...)
+	if err != nil {
+		return out, newGitError(OpSubmoduleUpdate, err)
+	}
+	return out, nil
+}
+
+// SparseCheckoutSet enables cone-mode sparse-checkout limited to dirs.
+// git stores the setting per worktree, leaving other worktrees untouched.
+func (g *GitRunner) SparseCheckoutSet(dirs []string) ([]byte, error) {
+	args := append([]string{GitCmdSparseCheckout, "set", "--cone", "--"}, dirs...)
+	return g.Run(args...)
+}
+
+// IsSparseCheckout reports whether sparse-checkout is enabled in the worktree.
+func (g *GitRunner) IsSparseCheckout() bool {
+	out, err := g.Run(GitCmdConfig, "--bool", "--get", "core.sparseCheckout")
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// Checkout populates the worktree from HEAD, e.g. after WithNoCheckout.
+func (g *GitRunner) Checkout() ([]byte, error) {
+	return g.Run(GitCmdCheckout)
+}
+
+// IgnoredFiles returns untracked files that are ignored by .gitignore and
+// other standard exclude files, limited to pathspecs if given.
+func (g *GitRunner) IgnoredFiles(pathspecs ...string) ([]string, error) {
+	args := []string{GitCmdLsFiles, "-z", "--others", "--ignored", "--exclude-standard"}
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
+	}
+	output, err := g.Run(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ignored files: %w", err)
+	}
+
+	return splitNUL(output), nil
+}
+
+// IsIgnored reports whether path is ignored by git.
+func (g *GitRunner) IsIgnored(path string) bool {
+	_, err := g.Run(GitCmdCheckIgnore, "-q", path)
+	return err == nil
+}
+
+// DefaultBranch detects the repository's default branch.
+// It prefers the branch origin/HEAD points to, then a local main or master
+// branch, and finally the currently checked out branch.
+func (g *GitRunner) DefaultBranch() (string, error) {
+	if out, err := g.Run(GitCmdSymbolicRef, "--short", "refs/remotes/origin/HEAD"); err == nil {
+		if _, branch, ok := strings.Cut(strings.TrimSpace(string(out)), "/"); ok && branch != "" {
+			return branch, nil
+		}
+	}
+	for _, branch := range []string{"main", "master"} {
+		if g.LocalBranchExists(branch) {
+			return branch, nil
+		}
+	}
+	out, err := g.Run(GitCmdSymbolicRef, "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to detect default branch: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // HasChanges checks if there are any uncommitted changes (staged, unstaged, or untracked).
 func (g *GitRunner) HasChanges() (bool, error) {
 	files, err := g.ChangedFiles()
@@ -478,81 +857,582 @@ func (g *GitRunner) HasChanges() (bool, error) {
 	return len(files) > 0, nil
 }
 
+// StashScope selects which changes StashPush saves.
+type StashScope string
+
+const (
+	// StashScopeAll saves staged, unstaged and untracked changes.
+	StashScopeAll StashScope = ""
+	// StashScopeStaged saves only changes in the index.
+	StashScopeStaged StashScope = "staged"
+	// StashScopeUnstaged saves only changes of tracked files that are not
+	// in the index.
+	StashScopeUnstaged StashScope = "unstaged"
+	// StashScopeUntracked saves only untracked files.
+	StashScopeUntracked StashScope = "untracked"
+)
+
+// errNoLocalChanges is returned by StashPush when nothing matches.
+var errNoLocalChanges = errors.New("no local changes to save")
+
+type stashPushOptions struct {
+	scope StashScope
+	keep  bool
+	ref   string
+}
+
+// StashPushOption is a functional option for StashPush.
+type StashPushOption func(*stashPushOptions)
+
+// WithStashScope limits the stash to the given kind of changes.
+func WithStashScope(scope StashScope) StashPushOption {
+	return func(o *stashPushOptions) {
+		o.scope = scope
+	}
+}
+
+// WithKeepChanges leaves the stashed changes in the worktree, like
+// "git stash create" does.
+func WithKeepChanges() StashPushOption {
+	return func(o *stashPushOptions) {
+		o.keep = true
+	}
+}
+
+// WithStashRef stores the stash commit under ref instead of a new ref in
+// StashRefPrefix.
+func WithStashRef(ref string) StashPushOption {
+	return func(o *stashPushOptions) {
+		o.ref = ref
+	}
+}
+
 // StashPush stashes changes including untracked files.
-// If pathspecs are provided, only matching files are stashed.
+// If paths are provided, only those files are stashed. Paths are relative
+// to the worktree root and matched literally, so names containing glob
+// characters select only themselves.
 // Returns the stash commit hash for later reference.
 //
-// Race condition note:
-// There is a small race window between "stash push" and "rev-parse stash@{0}".
-// If another process creates a stash in this window, we may get the wrong hash.
-// However, this window is very small (milliseconds) and acceptable in practice.
+// The stash commit is built like "git stash push -u" does (HEAD, index and
+// untracked-files parents) but through a private index file, and stored
+// under a unique ref in StashRefPrefix instead of the shared stash stack.
+// Concurrent stashes in the same repository therefore never observe each
+// other: nothing is looked up by stack position, and each hash is only
+// reachable through its own ref.
 //
-// Why not use "stash create" + "stash store" pattern?
-// "stash create" does not support -u/--include-untracked option (git limitation).
-// It can only stash tracked file changes, not untracked files.
-// See: https://git-scm.com/docs/git-stash
-func (g *GitRunner) StashPush(message string, pathspecs ...string) (string, error) {
-	args := []string{GitCmdStash, GitStashPush, "-u", "-m", message}
-	if len(pathspecs) > 0 {
-		args = append(args, "--")
-		args = append(args, pathspecs...)
+// Why not "stash push" + "rev-parse stash@{0}"?
+// Another process can push between the two commands, and dropping by
+// position can remove someone else's stash.
+// Why not "stash create"?
+// It does not support untracked files or pathspecs.
+//
+// With StashScopeUnstaged the first parent is a commit of the index rather
+// than HEAD, so that applying the stash only replays the unstaged changes.
+// Other scopes keep HEAD as the first parent and leave out what they do
+// not save.
+func (g *GitRunner) StashPush(message string, paths []string, opts ...StashPushOption) (string, error) {
+	var o stashPushOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	root, err := g.root()
+	if err != nil {
+		return "", err
 	}
-	if _, err := g.Run(args...); err != nil {
+	// Paths listed by git below are passed back to it, so they must not be
+	// expanded as globs either.
+	root = root.literal()
+
+	head, err := root.revParse("--verify", "HEAD")
+	if err != nil {
 		return "", err
 	}
-	out, err := g.Run(GitCmdRevParse, "stash@{0}")
+	var tracked, untracked []string
+	if o.scope != StashScopeUntracked {
+		args := []string{GitCmdDiff, "--name-only", "--no-renames", "-z"}
+		switch o.scope {
+		case StashScopeAll:
+			args = append(args, "HEAD")
+		case StashScopeStaged:
+			args = append(args, "--cached")
+		}
+		out, err := root.Run(append(append(args, "--"), paths...)...)
+		if err != nil {
+			return "", err
+		}
+		tracked = splitNUL(out)
+	}
+	if o.scope == StashScopeAll || o.scope == StashScopeUntracked {
+		untrackedArgs := append([]string{GitCmdLsFiles, "-z", "--others", "--exclude-standard", "--"}, paths...)
+		out, err := root.Run(untrackedArgs...)
+		if err != nil {
+			return "", err
+		}
+		untracked = splitNUL(out)
+	}
+	if len(tracked) == 0 && len(untracked) == 0 {
+		return "", errNoLocalChanges
+	}
+
+	id, err := newStashID()
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(out)), nil
+	ref := o.ref
+	if ref == "" {
+		ref = StashRefPrefix + id
+	}
+	indexFile, err := root.GitPath("twig-stash-" + id + ".index")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(indexFile) }()
+	tmp := root.withEnv("GIT_INDEX_FILE=" + indexFile)
+
+	commit := root.committer()
+
+	// The index commit holds the staged changes of the stashed files only,
+	// so that applying it with --index does not touch other files.
+	var indexTree string
+	if o.scope == StashScopeUntracked {
+		indexTree, err = root.revParse("HEAD^{tree}")
+	} else {
+		indexTree, err = root.stashIndexTree(tmp, head, paths, tracked)
+	}
+	if err != nil {
+		return "", err
+	}
+	// base is the tree the worktree commit is built on
+	base := head
+	if o.scope == StashScopeStaged || o.scope == StashScopeUnstaged {
+		base = indexTree
+	}
+	first := head
+	if o.scope == StashScopeUnstaged {
+		if first, err = commit.commitTree(indexTree, "base on "+message, head); err != nil {
+			return "", err
+		}
+	}
+	indexCommit, err := commit.commitTree(indexTree, "index on "+message, first)
+	if err != nil {
+		return "", err
+	}
+	parents := []string{first, indexCommit}
+
+	if len(untracked) > 0 {
+		if _, err := tmp.Run(GitCmdReadTree, "--empty"); err != nil {
+			return "", err
+		}
+		if _, err := tmp.Run(append([]string{GitCmdAdd, "-f", "--"}, untracked...)...); err != nil {
+			return "", err
+		}
+		tree, err := tmp.writeTree()
+		if err != nil {
+			return "", err
+		}
+		untrackedCommit, err := commit.commitTree(tree, "untracked files on "+message)
+		if err != nil {
+			return "", err
+		}
+		parents = append(parents, untrackedCommit)
+	}
+
+	if _, err := tmp.Run(GitCmdReadTree, base); err != nil {
+		return "", err
+	}
+	if len(tracked) > 0 && o.scope != StashScopeStaged {
+		if _, err := tmp.Run(append([]string{GitCmdAdd, "-f", "-A", "--"}, tracked...)...); err != nil {
+			return "", err
+		}
+	}
+	tree, err := tmp.writeTree()
+	if err != nil {
+		return "", err
+	}
+	hash, err := commit.commitTree(tree, message, parents...)
+	if err != nil {
+		return "", err
+	}
+	if err := root.createRef(message, ref, hash); err != nil {
+		return "", err
+	}
+
+	if o.keep {
+		return hash, nil
+	}
+	if err := root.removeStashed(hash, o.scope, tracked, untracked); err != nil {
+		// Nothing was removed if the staged changes could not be reversed
+		if o.scope == StashScopeStaged {
+			_, _ = root.StashDropByHash(hash)
+			return "", err
+		}
+		return "", fmt.Errorf("changes are saved in %s: %w", hash, err)
+	}
+	return hash, nil
+}
+
+// removeStashed removes the changes saved in the stash hash from the worktree.
+func (g *GitRunner) removeStashed(hash string, scope StashScope, tracked, untracked []string) error {
+	if len(tracked) > 0 {
+		var err error
+		switch scope {
+		case StashScopeStaged:
+			// Reverse the staged changes only, keeping unstaged ones
+			err = g.applyStashIndex(hash, "-R")
+			if err == nil {
+				_, err = g.Run(append([]string{GitCmdRestore, "--staged", "--"}, tracked...)...)
+			}
+		case StashScopeUnstaged:
+			_, err = g.Run(append([]string{GitCmdRestore, "--worktree", "--"}, tracked...)...)
+		default:
+			err = g.RestoreFromHEAD(tracked...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(untracked) > 0 {
+		if _, err := g.Run(append([]string{GitCmdClean, "-f", "-q", "--"}, untracked...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stashIndexTree writes the tree of the index. With pathspecs, only the
+// staged changes of paths are taken over onto head, using tmp as index.
+func (g *GitRunner) stashIndexTree(tmp *GitRunner, head string, specs, paths []string) (string, error) {
+	if len(specs) == 0 {
+		return g.writeTree()
+	}
+	if _, err := tmp.Run(GitCmdReadTree, head); err != nil {
+		return "", err
+	}
+	if len(paths) > 0 {
+		patch, err := g.Run(append([]string{GitCmdDiff, "--cached", "--binary", "--"}, paths...)...)
+		if err != nil {
+			return "", err
+		}
+		if err := tmp.applyPatch(patch, "--cached"); err != nil {
+			return "", err
+		}
+	}
+	return tmp.writeTree()
+}
+
+// applyStashIndex applies the staged changes of the stash hash as a patch,
+// with extra "git apply" args such as "-R" or "--cached".
+// Unlike "stash apply --index", this works when the same files have
+// unstaged changes, as long as they do not overlap.
+func (g *GitRunner) applyStashIndex(hash string, args ...string) error {
+	patch, err := g.Run(GitCmdDiff, "--binary", hash+"^1", hash+"^2")
+	if err != nil {
+		return err
+	}
+	return g.applyPatch(patch, args...)
+}
+
+// applyPatch applies patch with "git apply" and extra args.
+func (g *GitRunner) applyPatch(patch []byte, args ...string) error {
+	if len(patch) == 0 {
+		return nil
+	}
+	id, err := newStashID()
+	if err != nil {
+		return err
+	}
+	patchPath, err := g.GitPath("twig-" + id + ".patch")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(patchPath, patch, 0644); err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(patchPath) }()
+	_, err = g.Run(append(append([]string{GitCmdApply}, args...), patchPath)...)
+	return err
 }
 
 // StashApplyByHash applies the stash with the given hash without dropping it.
-func (g *GitRunner) StashApplyByHash(hash string) ([]byte, error) {
+// Stashes of StashScopeStaged are applied with their index, so that the
+// changes stay staged.
+func (g *GitRunner) StashApplyByHash(hash string, scope StashScope) ([]byte, error) {
+	if scope == StashScopeStaged {
+		return g.Run(GitCmdStash, GitStashApply, "--index", hash)
+	}
 	return g.Run(GitCmdStash, GitStashApply, hash)
 }
 
-// StashPopByHash applies and drops the stash with the given hash.
-func (g *GitRunner) StashPopByHash(hash string) ([]byte, error) {
-	if _, err := g.StashApplyByHash(hash); err != nil {
+// StashRestore puts the changes of a stash pushed with scope back into the
+// worktree they were taken from, including the index, and drops the stash.
+func (g *GitRunner) StashRestore(hash string, scope StashScope) ([]byte, error) {
+	var err error
+	if scope == StashScopeStaged {
+		// The files may still have unstaged changes, which "stash apply"
+		// refuses to merge into
+		if err = g.applyStashIndex(hash); err == nil {
+			err = g.applyStashIndex(hash, "--cached")
+		}
+	} else {
+		_, err = g.Run(GitCmdStash, GitStashApply, "--index", hash)
+	}
+	if err != nil {
 		return nil, err
 	}
 	return g.StashDropByHash(hash)
 }
 
-// StashDropByHash drops the stash with the given hash.
+// StashDropByHash deletes the private ref holding the stash with the given hash.
 func (g *GitRunner) StashDropByHash(hash string) ([]byte, error) {
-	out, err := g.Run(GitCmdStash, GitStashList, "--format=%gd %H")
+	out, err := g.Run(GitCmdForEachRef, "--format=%(refname)%00", "--points-at", hash, StashRefPrefix)
 	if err != nil {
 		return nil, err
 	}
-	for line := range strings.SplitSeq(string(out), "\n") {
-		if strings.HasSuffix(line, hash) {
-			ref := strings.Fields(line)[0]
-			return g.Run(GitCmdStash, GitStashDrop, ref)
-		}
+	if refs := splitRecords(out); len(refs) > 0 {
+		return g.Run(GitCmdUpdateRef, "-d", refs[0], hash)
 	}
 	return nil, fmt.Errorf("stash not found: %s", hash)
 }
 
+// StashRef is a stash commit and the ref that holds it.
+type StashRef struct {
+	Ref     string // refs/twig/stash/<id> or stash@{n}
+	Hash    string
+	Message string
+}
+
+// ShortHash returns the first 7 characters of the stash commit hash.
+func (s StashRef) ShortHash() string {
+	return shortHash(s.Hash)
+}
+
+// PrivateStashes returns the stash commits stored under StashRefPrefix.
+func (g *GitRunner) PrivateStashes() ([]StashRef, error) {
+	out, err := g.Run(GitCmdForEachRef,
+		"--format=%(refname)%00%(objectname)%00%(contents:subject)%00", StashRefPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return parseStashRefs(out), nil
+}
+
+// StashList returns the entries of the shared stash stack.
+func (g *GitRunner) StashList() ([]StashRef, error) {
+	out, err := g.Run(GitCmdStash, GitStashList, "--format=%gd%x00%H%x00%gs%x00")
+	if err != nil {
+		return nil, err
+	}
+	return parseStashRefs(out), nil
+}
+
+// shortHash returns the first 7 characters of hash.
+func shortHash(hash string) string {
+	if len(hash) >= 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// parseStashRefs parses records of NUL-separated ref, hash and message.
+func parseStashRefs(out []byte) []StashRef {
+	var refs []StashRef
+	for _, record := range splitRecords(out) {
+		fields := strings.SplitN(record, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		refs = append(refs, StashRef{Ref: fields[0], Hash: fields[1], Message: fields[2]})
+	}
+	return refs
+}
+
+// StashFiles returns the paths recorded in the stash with the given hash,
+// including untracked files.
+func (g *GitRunner) StashFiles(hash string) ([]string, error) {
+	out, err := g.Run(GitCmdStash, GitStashShow, "-z", "--name-only", "--include-untracked", hash)
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// DiffHEAD returns a binary-safe patch of tracked changes against HEAD,
+// limited to pathspecs if given.
+func (g *GitRunner) DiffHEAD(pathspecs ...string) ([]byte, error) {
+	args := []string{GitCmdDiff, "--binary", "HEAD"}
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
+	}
+	return g.Run(args...)
+}
+
+// ApplyCheck checks whether the patch file applies cleanly without applying it.
+func (g *GitRunner) ApplyCheck(patchPath string) error {
+	_, err := g.Run(GitCmdApply, "--check", patchPath)
+	return err
+}
+
+// GitPath returns the absolute path of name inside the worktree's git directory.
+func (g *GitRunner) GitPath(name string) (string, error) {
+	out, err := g.Run(GitCmdRevParse, "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.Dir, path)
+	}
+	return path, nil
+}
+
+// CommonDir returns the absolute path of the git directory shared by all
+// worktrees of the repository.
+func (g *GitRunner) CommonDir() (string, error) {
+	dir, err := g.revParse("--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(g.Dir, dir)
+	}
+	return dir, nil
+}
+
+// TrackedFiles returns the files matching pathspecs that are tracked in the
+// index or in HEAD (files staged for deletion are included).
+func (g *GitRunner) TrackedFiles(pathspecs ...string) ([]string, error) {
+	args := []string{GitCmdLsFiles, "-z", "--with-tree=HEAD", "--"}
+	args = append(args, pathspecs...)
+	out, err := g.Run(args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// RestoreFromHEAD resets the index and working tree of paths to HEAD.
+func (g *GitRunner) RestoreFromHEAD(paths ...string) error {
+	args := []string{GitCmdRestore, "--source=HEAD", "--staged", "--worktree", "--"}
+	args = append(args, paths...)
+	_, err := g.Run(args...)
+	return err
+}
+
+// root returns a runner for the top of the worktree containing g.Dir.
+func (g *GitRunner) root() (*GitRunner, error) {
+	cdup, err := g.Run(GitCmdRevParse, "--show-cdup")
+	if err != nil {
+		return nil, err
+	}
+	return g.InDir(filepath.Join(g.Dir, strings.TrimSpace(string(cdup)))), nil
+}
+
+func (g *GitRunner) revParse(args ...string) (string, error) {
+	out, err := g.Run(append([]string{GitCmdRevParse}, args...)...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (g *GitRunner) writeTree() (string, error) {
+	out, err := g.Run(GitCmdWriteTree)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// committer returns a runner that can create commits, falling back to a
+// built-in identity like git stash does when the user has none configured.
+func (g *GitRunner) committer() *GitRunner {
+	if _, err := g.Run(GitCmdVar, "GIT_COMMITTER_IDENT"); err != nil {
+		return g.withEnv(stashIdentEnv...)
+	}
+	return g
+}
+
+func (g *GitRunner) commitTree(tree, message string, parents ...string) (string, error) {
+	args := []string{GitCmdCommitTree, tree}
+	for _, p := range parents {
+		args = append(args, "-p", p)
+	}
+	args = append(args, "-m", message)
+	out, err := g.Run(args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// newStashID returns a random identifier for a private stash ref.
+func newStashID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate stash id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// splitNUL splits NUL-separated git output, dropping empty entries.
+func splitNUL(out []byte) []string {
+	var items []string
+	for item := range strings.SplitSeq(string(out), "\x00") {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitRecords splits the output of a --format ending in %00 into records.
+// git terminates each record with that NUL and a newline, so fields may
+// safely contain newlines.
+func splitRecords(out []byte) []string {
+	var records []string
+	for record := range strings.SplitSeq(string(out), "\x00\n") {
+		if record != "" {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
 // private methods for git command execution
 
 func (g *GitRunner) worktreeAdd(path, branch string, o worktreeAddOptions) ([]byte, error) {
 	args := []string{GitCmdWorktree, GitWorktreeAdd}
-	args = append(args, o.lockArgs()...)
+	args = append(args, o.args()...)
 	args = append(args, path, branch)
 	return g.Run(args...)
 }
 
 func (g *GitRunner) worktreeAddWithNewBranch(branch, path string, o worktreeAddOptions) ([]byte, error) {
 	args := []string{GitCmdWorktree, GitWorktreeAdd}
-	args = append(args, o.lockArgs()...)
+	args = append(args, o.args()...)
 	args = append(args, "-b", branch, path)
 	return g.Run(args...)
 }
 
-func (g *GitRunner) worktreeListPorcelain() ([]byte, error) {
-	return g.Run(GitCmdWorktree, GitWorktreeList, "--porcelain")
+// worktreeListPorcelain returns the lines of git worktree list --porcelain.
+// It uses -z so that paths are not quoted, falling back to newline-separated
+// output on git older than 2.36, which rejects the option.
+func (g *GitRunner) worktreeListPorcelain() ([]string, error) {
+	out, err := g.Run(GitCmdWorktree, GitWorktreeList, "--porcelain", "-z")
+	if err == nil {
+		return strings.Split(string(out), "\x00"), nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || !strings.Contains(string(exitErr.Stderr), "unknown switch `z'") {
+		return nil, withGitStderr(err)
+	}
+	out, err = g.Run(GitCmdWorktree, GitWorktreeList, "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(out), "\n"), nil
 }
 
 func (g *GitRunner) worktreeRemove(path string, forceLevel WorktreeForceLevel) ([]byte, error) {
@@ -579,20 +1459,27 @@ func (g *GitRunner) branchDelete(branch string, force bool) ([]byte, error) {
 // First checks using git branch --merged (detects traditional merges).
 // If not found, falls back to checking if upstream is gone (squash/rebase merges).
 func (g *GitRunner) IsBranchMerged(branch, target string) (bool, error) {
-	out, err := g.Run(GitCmdBranch, "--merged", target, "--format=%(refname:short)")
+	merged, err := g.MergedBranches(target)
 	if err != nil {
-		return false, fmt.Errorf("failed to check merged branches: %w", err)
+		return false, err
 	}
-	for line := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
-		if line == branch {
-			return true, nil
-		}
+	if slices.Contains(merged, branch) {
+		return true, nil
 	}
 
 	// Fallback: check if upstream branch is gone (deleted after merge)
 	return g.IsBranchUpstreamGone(branch)
 }
 
+// MergedBranches returns the local branches merged into target.
+func (g *GitRunner) MergedBranches(target string) ([]string, error) {
+	out, err := g.Run(GitCmdBranch, "--merged", target, "--format=%(refname:short)%00")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check merged branches: %w", err)
+	}
+	return splitRecords(out), nil
+}
+
 // IsBranchUpstreamGone checks if the branch's upstream tracking branch is gone.
 // This indicates the remote branch was deleted, typically after a PR merge.
 func (g *GitRunner) IsBranchUpstreamGone(branch string) (bool, error) {
@@ -605,6 +1492,155 @@ func (g *GitRunner) IsBranchUpstreamGone(branch string) (bool, error) {
 	return strings.TrimSpace(string(out)) == "[gone]", nil
 }
 
+// Upstream is the upstream branch of a local branch on a remote.
+type Upstream struct {
+	Remote      string // Remote name, e.g. "origin"
+	Ref         string // Branch ref on the remote, e.g. "refs/heads/feat/a"
+	TrackingRef string // Remote-tracking ref, e.g. "refs/remotes/origin/feat/a"
+}
+
+// String returns the short name of the upstream, e.g. "origin/feat/a".
+func (u Upstream) String() string {
+	return u.Remote + "/" + strings.TrimPrefix(u.Ref, RefsHeadsPrefix)
+}
+
+// BranchUpstream returns the upstream of branch on a remote.
+// Returns nil if the branch has no upstream or tracks a local branch.
+func (g *GitRunner) BranchUpstream(branch string) (*Upstream, error) {
+	out, err := g.Run(GitCmdForEachRef,
+		"--format=%(upstream:remotename)%00%(upstream:remoteref)%00%(upstream)",
+		RefsHeadsPrefix+branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upstream of %s: %w", branch, err)
+	}
+	fields := strings.Split(strings.TrimSpace(string(out)), "\x00")
+	if len(fields) != 3 || fields[0] == "" || fields[0] == "." || fields[2] == "" {
+		return nil, nil
+	}
+	return &Upstream{Remote: fields[0], Ref: fields[1], TrackingRef: fields[2]}, nil
+}
+
+// FetchUpstream updates the remote-tracking ref of upstream from its remote.
+// Returns false if the branch no longer exists on the remote.
+func (g *GitRunner) FetchUpstream(upstream Upstream) (bool, error) {
+	_, err := g.Run(GitCmdFetch, "--no-tags", upstream.Remote,
+		"+"+upstream.Ref+":"+upstream.TrackingRef)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), "couldn't find remote ref") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to fetch %s: %w", upstream, err)
+	}
+	return true, nil
+}
+
+// RemoteBranchDelete deletes the upstream branch on its remote, provided it
+// still points at expect, and removes its remote-tracking ref.
+func (g *GitRunner) RemoteBranchDelete(upstream Upstream, expect string) ([]byte, error) {
+	out, err := g.Run(GitCmdPush, "--porcelain",
+		"--force-with-lease="+upstream.Ref+":"+expect,
+		upstream.Remote, "--delete", upstream.Ref)
+	if err != nil {
+		return nil, newGitError(OpRemoteBranchDelete, err)
+	}
+	if err := g.DeleteRef(upstream.TrackingRef); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// DeleteRef deletes ref. Deleting a missing ref is not an error.
+func (g *GitRunner) DeleteRef(ref string) error {
+	if _, err := g.Run(GitCmdUpdateRef, "-d", ref); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", ref, err)
+	}
+	return nil
+}
+
+// UnpushedCommits counts the commits of branch that exist only locally:
+// they are neither reachable from target or any remote-tracking branch nor
+// part of a squash merge into target. A commit is part of a squash merge if
+// squashing the branch up to it onto the merge base gives a patch that
+// target already has, as reported by git cherry.
+func (g *GitRunner) UnpushedCommits(branch, target string) (int, error) {
+	out, err := g.Run(GitCmdRevList, RefsHeadsPrefix+branch, "--not", target, "--remotes")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unpushed commits: %w", err)
+	}
+	commits := strings.Fields(string(out))
+	if len(commits) == 0 {
+		return 0, nil
+	}
+
+	out, err = g.Run(GitCmdMergeBase, target, RefsHeadsPrefix+branch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find merge base: %w", err)
+	}
+	base := strings.TrimSpace(string(out))
+
+	scratch, cleanup, err := g.withScratchObjects()
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	// Newest first: the commits after the newest squash-merged one are unpushed
+	committer := scratch.committer()
+	for i, commit := range commits {
+		squashed, err := committer.isSquashMerged(commit, base, target)
+		if err != nil {
+			return 0, err
+		}
+		if squashed {
+			return i, nil
+		}
+	}
+	return len(commits), nil
+}
+
+// withScratchObjects returns a GitRunner that writes new objects to a
+// temporary directory and reads the repository's objects as alternates,
+// so that commits made only for comparison never reach the repository.
+// The returned function removes the directory.
+func (g *GitRunner) withScratchObjects() (*GitRunner, func(), error) {
+	common, err := g.CommonDir()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find git directory: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "twig-objects-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create object directory: %w", err)
+	}
+	scratch := g.withEnv(
+		"GIT_OBJECT_DIRECTORY="+dir,
+		"GIT_ALTERNATE_OBJECT_DIRECTORIES="+filepath.Join(common, "objects"),
+	)
+	return scratch, func() { os.RemoveAll(dir) }, nil
+}
+
+// isSquashMerged reports whether target has a commit with the changes from
+// base to commit, as a squash merge of the branch up to commit would.
+// The squash commit is written to g's object directory.
+func (g *GitRunner) isSquashMerged(commit, base, target string) (bool, error) {
+	tree, err := g.revParse(commit + "^{tree}")
+	if err != nil {
+		return false, fmt.Errorf("failed to check squash merge: %w", err)
+	}
+	if baseTree, err := g.revParse(base + "^{tree}"); err == nil && baseTree == tree {
+		return false, nil
+	}
+	squash, err := g.commitTree(tree, "twig squash check", base)
+	if err != nil {
+		return false, fmt.Errorf("failed to check squash merge: %w", err)
+	}
+	out, err := g.Run(GitCmdCherry, target, squash, base)
+	if err != nil {
+		return false, fmt.Errorf("failed to check squash merge: %w", err)
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(out)), "-"), nil
+}
+
 // WorktreePrune removes references to worktrees that no longer exist.
 func (g *GitRunner) WorktreePrune() ([]byte, error) {
 	out, err := g.Run(GitCmdWorktree, GitWorktreePrune)
@@ -3,8 +3,11 @@
 package twig
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -47,6 +50,9 @@ func TestGitRunner_WorktreeFindByBranch_Integration(t *testing.T) {
 		if !strings.Contains(err.Error(), "not checked out in any worktree") {
 			t.Errorf("error %q should contain 'not checked out in any worktree'", err.Error())
 		}
+		if !errors.Is(err, ErrBranchNotFound) {
+			t.Errorf("error should match ErrBranchNotFound: %v", err)
+		}
 	})
 }
 
@@ -226,9 +232,103 @@ func TestGitRunner_ChangedFiles_Integration(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		// Should return the new name
-		if len(files) != 1 || files[0] != "new.txt" {
-			t.Errorf("expected [new.txt], got %v", files)
+		// Should return both sides of the rename
+		if !slices.Equal(files, []string{"old.txt", "new.txt"}) {
+			t.Errorf("expected [old.txt new.txt], got %v", files)
+		}
+	})
+
+	t.Run("DeletedFile", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		writeFile(t, mainDir, "deleted.txt", "content")
+		testutil.RunGit(t, mainDir, "add", "deleted.txt")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add file")
+		if err := os.Remove(filepath.Join(mainDir, "deleted.txt")); err != nil {
+			t.Fatal(err)
+		}
+
+		files, err := NewGitRunner(mainDir).ChangedFiles()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(files, []string{"deleted.txt"}) {
+			t.Errorf("expected [deleted.txt], got %v", files)
+		}
+	})
+	t.Run("HostileNames", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		writeFile(t, mainDir, "old name.txt", "content")
+		testutil.RunGit(t, mainDir, "add", "old name.txt")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add file")
+		testutil.RunGit(t, mainDir, "mv", "old name.txt", "a -> b.txt")
+
+		hostile := []string{`quote "d".txt`, "ünïcødé.txt", "new\nline.txt", "tab\tname.txt"}
+		for _, name := range hostile {
+			writeFile(t, mainDir, name, "content")
+		}
+
+		files, err := NewGitRunner(mainDir).ChangedFiles()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := append([]string{"old name.txt", "a -> b.txt"}, hostile...)
+		slices.Sort(want[2:])
+		got := slices.Clone(files)
+		slices.Sort(got[min(2, len(got)):])
+		if !slices.Equal(got, want) {
+			t.Errorf("ChangedFiles() = %q, want %q", files, want)
+		}
+	})
+}
+
+func TestGitRunner_WorktreeList_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("HostilePaths", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		paths := []string{
+			filepath.Join(repoDir, "with space"),
+			filepath.Join(repoDir, `quote "d"`),
+			filepath.Join(repoDir, "ünïcødé"),
+			filepath.Join(repoDir, "new\nline"),
+		}
+		for i, p := range paths {
+			testutil.RunGit(t, mainDir, "worktree", "add", "-b", fmt.Sprintf("feat/%d", i), p)
+		}
+
+		runner := NewGitRunner(mainDir)
+		worktrees, err := runner.WorktreeList()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(worktrees) != len(paths)+1 {
+			t.Fatalf("WorktreeList() returned %d worktrees, want %d", len(worktrees), len(paths)+1)
+		}
+		for i, p := range paths {
+			wt, err := runner.WorktreeFindByBranch(fmt.Sprintf("feat/%d", i))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if wt.Path != p {
+				t.Errorf("worktree of feat/%d = %q, want %q", i, wt.Path, p)
+			}
+		}
+
+		branches, err := runner.WorktreeListBranches()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(branches) != len(paths)+1 {
+			t.Errorf("WorktreeListBranches() = %v, want %d branches", branches, len(paths)+1)
 		}
 	})
 }
@@ -278,6 +378,23 @@ func TestGitRunner_BranchDelete_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("NotMerged", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		testutil.RunGit(t, mainDir, "checkout", "-b", "unmerged")
+		testutil.RunGit(t, mainDir, "commit", "--allow-empty", "-m", "unmerged commit")
+		testutil.RunGit(t, mainDir, "checkout", "main")
+
+		runner := NewGitRunner(mainDir)
+
+		_, err := runner.BranchDelete("unmerged")
+		if !errors.Is(err, ErrBranchNotMerged) {
+			t.Errorf("error should match ErrBranchNotMerged: %v", err)
+		}
+	})
+
 	t.Run("NotExists", func(t *testing.T) {
 		t.Parallel()
 
@@ -294,3 +411,316 @@ func TestGitRunner_BranchDelete_Integration(t *testing.T) {
 		}
 	})
 }
+
+func TestGitRunner_StashPush_Integration(t *testing.T) {
+	t.Parallel()
+
+	writeFile := func(t *testing.T, path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("LeavesStashStackUntouched", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+		writeFile(t, filepath.Join(mainDir, "tracked.txt"), "base\n")
+		writeFile(t, filepath.Join(mainDir, "deleted.txt"), "base\n")
+		testutil.RunGit(t, mainDir, "add", ".")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add files")
+
+		// A stash created by the user must survive
+		writeFile(t, filepath.Join(mainDir, "tracked.txt"), "user stash\n")
+		testutil.RunGit(t, mainDir, "stash", "push", "-m", "user stash")
+		userStash := testutil.RunGit(t, mainDir, "rev-parse", "refs/stash")
+
+		writeFile(t, filepath.Join(mainDir, "tracked.txt"), "changed\n")
+		writeFile(t, filepath.Join(mainDir, "staged.txt"), "staged\n")
+		testutil.RunGit(t, mainDir, "add", "staged.txt")
+		testutil.RunGit(t, mainDir, "rm", "-q", "deleted.txt")
+		writeFile(t, filepath.Join(mainDir, "dir", "untracked.txt"), "untracked\n")
+
+		runner := NewGitRunner(mainDir)
+		hash, err := runner.StashPush("twig test", nil)
+		if err != nil {
+			t.Fatalf("StashPush failed: %v", err)
+		}
+
+		if status := testutil.RunGit(t, mainDir, "status", "--porcelain"); strings.TrimSpace(status) != "" {
+			t.Errorf("worktree should be clean after StashPush, got %q", status)
+		}
+		if got := testutil.RunGit(t, mainDir, "rev-parse", "refs/stash"); got != userStash {
+			t.Errorf("refs/stash changed: %q -> %q", userStash, got)
+		}
+		if refs := testutil.RunGit(t, mainDir, "for-each-ref", "--format=%(objectname)", StashRefPrefix); strings.TrimSpace(refs) != hash {
+			t.Errorf("private stash refs = %q, want %q", refs, hash)
+		}
+
+		files, err := runner.StashFiles(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"deleted.txt", "dir/untracked.txt", "staged.txt", "tracked.txt"}
+		if strings.Join(files, ",") != strings.Join(want, ",") {
+			t.Errorf("StashFiles = %v, want %v", files, want)
+		}
+
+		wtPath := filepath.Join(repoDir, "apply")
+		testutil.RunGit(t, mainDir, "worktree", "add", "--detach", wtPath)
+		if _, err := runner.InDir(wtPath).StashApplyByHash(hash, StashScopeAll); err != nil {
+			t.Fatalf("StashApplyByHash failed: %v", err)
+		}
+		for name, content := range map[string]string{
+			"tracked.txt":       "changed\n",
+			"staged.txt":        "staged\n",
+			"dir/untracked.txt": "untracked\n",
+		} {
+			got, err := os.ReadFile(filepath.Join(wtPath, name))
+			if err != nil || string(got) != content {
+				t.Errorf("%s = %q (err=%v), want %q", name, got, err, content)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(wtPath, "deleted.txt")); !os.IsNotExist(err) {
+			t.Errorf("deleted.txt should be deleted, got err=%v", err)
+		}
+
+		if _, err := runner.StashDropByHash(hash); err != nil {
+			t.Fatalf("StashDropByHash failed: %v", err)
+		}
+		if refs := testutil.RunGit(t, mainDir, "for-each-ref", StashRefPrefix); strings.TrimSpace(refs) != "" {
+			t.Errorf("private stash ref should be deleted, got %q", refs)
+		}
+		if list := testutil.RunGit(t, mainDir, "stash", "list"); strings.Count(list, "\n") != 1 {
+			t.Errorf("stash list = %q, want only the user stash", list)
+		}
+	})
+
+	t.Run("RootRelativePathsFromSubdirectory", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+		writeFile(t, filepath.Join(mainDir, "sub", "a.txt"), "a\n")
+		writeFile(t, filepath.Join(mainDir, "sub", "b.txt"), "b\n")
+
+		runner := NewGitRunner(filepath.Join(mainDir, "sub"))
+		hash, err := runner.StashPush("twig test", []string{"sub/a.txt"})
+		if err != nil {
+			t.Fatalf("StashPush failed: %v", err)
+		}
+
+		files, err := runner.StashFiles(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(files, ",") != "sub/a.txt" {
+			t.Errorf("StashFiles = %v, want [sub/a.txt]", files)
+		}
+		if _, err := os.Stat(filepath.Join(mainDir, "sub", "b.txt")); err != nil {
+			t.Errorf("unmatched file should remain: %v", err)
+		}
+	})
+
+	t.Run("Scopes", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			base     = "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+			staged   = "one\n2\n3\n4\n5\n6\n7\n8\n9\n"
+			unstaged = "1\n2\n3\n4\n5\n6\n7\n8\nnine\n"
+			both     = "one\n2\n3\n4\n5\n6\n7\n8\nnine\n"
+		)
+
+		tests := []struct {
+			name       string
+			scope      StashScope
+			wantSource map[string]string // "" means the file does not exist
+			wantCached string            // staged files left in the source
+			wantTarget map[string]string
+			wantStaged string // staged files in the target
+		}{
+			{
+				name:       "staged",
+				scope:      StashScopeStaged,
+				wantSource: map[string]string{"f.txt": unstaged, "other.txt": "changed\n", "added.txt": "", "new.txt": "new\n"},
+				wantTarget: map[string]string{"f.txt": staged, "other.txt": "other\n", "added.txt": "added\n", "new.txt": ""},
+				wantStaged: "added.txt\nf.txt",
+			},
+			{
+				name:       "unstaged",
+				scope:      StashScopeUnstaged,
+				wantSource: map[string]string{"f.txt": staged, "other.txt": "other\n", "added.txt": "added\n", "new.txt": "new\n"},
+				wantCached: "added.txt\nf.txt",
+				wantTarget: map[string]string{"f.txt": unstaged, "other.txt": "changed\n", "added.txt": "", "new.txt": ""},
+			},
+			{
+				name:       "untracked",
+				scope:      StashScopeUntracked,
+				wantSource: map[string]string{"f.txt": both, "other.txt": "changed\n", "added.txt": "added\n", "new.txt": ""},
+				wantCached: "added.txt\nf.txt",
+				wantTarget: map[string]string{"f.txt": base, "other.txt": "other\n", "added.txt": "", "new.txt": "new\n"},
+			},
+		}
+
+		check := func(t *testing.T, dir string, want map[string]string) {
+			t.Helper()
+			for name, content := range want {
+				got, err := os.ReadFile(filepath.Join(dir, name))
+				switch {
+				case content == "" && !os.IsNotExist(err):
+					t.Errorf("%s should not exist, got %q (err=%v)", name, got, err)
+				case content != "" && string(got) != content:
+					t.Errorf("%s = %q (err=%v), want %q", name, got, err, content)
+				}
+			}
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+				writeFile(t, filepath.Join(mainDir, "f.txt"), base)
+				writeFile(t, filepath.Join(mainDir, "other.txt"), "other\n")
+				testutil.RunGit(t, mainDir, "add", ".")
+				testutil.RunGit(t, mainDir, "commit", "-m", "add files")
+
+				// f.txt has a staged and an unstaged hunk
+				writeFile(t, filepath.Join(mainDir, "f.txt"), staged)
+				writeFile(t, filepath.Join(mainDir, "added.txt"), "added\n")
+				testutil.RunGit(t, mainDir, "add", "f.txt", "added.txt")
+				writeFile(t, filepath.Join(mainDir, "f.txt"), both)
+				writeFile(t, filepath.Join(mainDir, "other.txt"), "changed\n")
+				writeFile(t, filepath.Join(mainDir, "new.txt"), "new\n")
+
+				runner := NewGitRunner(mainDir)
+				hash, err := runner.StashPush("twig test", nil, WithStashScope(tt.scope))
+				if err != nil {
+					t.Fatalf("StashPush failed: %v", err)
+				}
+				check(t, mainDir, tt.wantSource)
+				if got := strings.TrimSpace(testutil.RunGit(t, mainDir, "diff", "--cached", "--name-only")); got != tt.wantCached {
+					t.Errorf("source staged files = %q, want %q", got, tt.wantCached)
+				}
+
+				wtPath := filepath.Join(repoDir, "apply")
+				testutil.RunGit(t, mainDir, "worktree", "add", "--detach", wtPath)
+				if _, err := runner.InDir(wtPath).StashApplyByHash(hash, tt.scope); err != nil {
+					t.Fatalf("StashApplyByHash failed: %v", err)
+				}
+				check(t, wtPath, tt.wantTarget)
+				if got := strings.TrimSpace(testutil.RunGit(t, wtPath, "diff", "--cached", "--name-only")); got != tt.wantStaged {
+					t.Errorf("target staged files = %q, want %q", got, tt.wantStaged)
+				}
+
+				// Restoring brings back the original state, index included
+				if _, err := runner.StashRestore(hash, tt.scope); err != nil {
+					t.Fatalf("StashRestore failed: %v", err)
+				}
+				check(t, mainDir, map[string]string{"f.txt": both, "other.txt": "changed\n", "added.txt": "added\n", "new.txt": "new\n"})
+				if got := strings.TrimSpace(testutil.RunGit(t, mainDir, "diff", "--cached", "--name-only")); got != "added.txt\nf.txt" {
+					t.Errorf("restored staged files = %q, want %q", got, "added.txt\nf.txt")
+				}
+				if got := testutil.RunGit(t, mainDir, "show", ":f.txt"); got != staged {
+					t.Errorf("restored index f.txt = %q, want %q", got, staged)
+				}
+			})
+		}
+	})
+
+	t.Run("KeepChanges", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+		writeFile(t, filepath.Join(mainDir, "new.txt"), "new\n")
+
+		runner := NewGitRunner(mainDir)
+		hash, err := runner.StashPush("twig test", nil, WithKeepChanges())
+		if err != nil {
+			t.Fatalf("StashPush failed: %v", err)
+		}
+		if status := testutil.RunGit(t, mainDir, "status", "--porcelain"); strings.TrimSpace(status) != "?? new.txt" {
+			t.Errorf("status = %q, want changes kept", status)
+		}
+		if files, _ := runner.StashFiles(hash); strings.Join(files, ",") != "new.txt" {
+			t.Errorf("StashFiles = %v, want [new.txt]", files)
+		}
+	})
+
+	t.Run("NoChanges", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		if _, err := NewGitRunner(mainDir).StashPush("twig test", nil); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("ConcurrentStashesAreIsolated", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+		writeFile(t, filepath.Join(mainDir, "shared.txt"), "base\n")
+		testutil.RunGit(t, mainDir, "add", ".")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add shared")
+
+		const n = 8
+		paths := make([]string, n)
+		for i := range n {
+			paths[i] = filepath.Join(repoDir, fmt.Sprintf("wt%d", i))
+			testutil.RunGit(t, mainDir, "worktree", "add", "--detach", paths[i])
+			writeFile(t, filepath.Join(paths[i], "shared.txt"), fmt.Sprintf("wt%d\n", i))
+			writeFile(t, filepath.Join(paths[i], fmt.Sprintf("only%d.txt", i)), "new\n")
+		}
+
+		hashes := make([]string, n)
+		// The group returns once all parallel subtests have finished.
+		t.Run("group", func(t *testing.T) {
+			for i := range n {
+				t.Run(fmt.Sprintf("wt%d", i), func(t *testing.T) {
+					t.Parallel()
+
+					hash, err := NewGitRunner(paths[i]).StashPush("twig carry", nil)
+					if err != nil {
+						t.Fatalf("StashPush failed: %v", err)
+					}
+					hashes[i] = hash
+				})
+			}
+		})
+
+		runner := NewGitRunner(mainDir)
+		for i, hash := range hashes {
+			if hash == "" {
+				t.Fatalf("wt%d: no stash hash", i)
+			}
+			files, err := runner.StashFiles(hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{fmt.Sprintf("only%d.txt", i), "shared.txt"}
+			if strings.Join(files, ",") != strings.Join(want, ",") {
+				t.Errorf("wt%d: StashFiles = %v, want %v", i, files, want)
+			}
+			content := testutil.RunGit(t, mainDir, "show", hash+":shared.txt")
+			if content != fmt.Sprintf("wt%d\n", i) {
+				t.Errorf("wt%d: stashed shared.txt = %q", i, content)
+			}
+			if _, err := runner.StashDropByHash(hash); err != nil {
+				t.Errorf("wt%d: StashDropByHash failed: %v", i, err)
+			}
+		}
+
+		if refs := testutil.RunGit(t, mainDir, "for-each-ref", StashRefPrefix); strings.TrimSpace(refs) != "" {
+			t.Errorf("private stash refs should be deleted, got %q", refs)
+		}
+		if list := testutil.RunGit(t, mainDir, "stash", "list"); strings.TrimSpace(list) != "" {
+			t.Errorf("shared stash stack should be untouched, got %q", list)
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package twig
 
 import (
+	"errors"
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/708u/twig/internal/testutil"
@@ -138,3 +141,65 @@ func TestGitRunner_IsBranchMerged_WithSquashMerge(t *testing.T) {
 		})
 	}
 }
+
+func TestGitRunner_WorktreeList_PorcelainFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		zErr         error
+		wantFallback bool
+		wantErr      string
+	}{
+		{
+			name:         "-z unsupported",
+			zErr:         &exec.ExitError{Stderr: []byte("error: unknown switch `z'\nusage: git worktree list [<options>]\n")},
+			wantFallback: true,
+		},
+		{
+			name:    "other failure",
+			zErr:    &exec.ExitError{Stderr: []byte("fatal: not a git repository\n")},
+			wantErr: "fatal: not a git repository",
+		},
+		{
+			name:    "executor failure",
+			zErr:    errors.New("git not found"),
+			wantErr: "git not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fallback := false
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					if args[len(args)-1] == "-z" {
+						return nil, tt.zErr
+					}
+					fallback = true
+					return []byte("worktree /repo/main\nHEAD abc123\nbranch refs/heads/main\n\n"), nil
+				},
+			}
+			runner := &GitRunner{Executor: mockGit, Dir: "/repo/main"}
+
+			worktrees, err := runner.WorktreeList()
+			if fallback != tt.wantFallback {
+				t.Errorf("fallback = %v, want %v", fallback, tt.wantFallback)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(worktrees) != 1 || worktrees[0].Path != "/repo/main" {
+				t.Errorf("worktrees = %+v, want /repo/main", worktrees)
+			}
+		})
+	}
+}
@@ -3,42 +3,96 @@ package twig
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
 )
 
 const settingsTemplate = `# twig project configuration
 # See: https://github.com/708u/twig-worktree
 
 # Default source branch for new worktrees (prevents symlink chaining)
-default_source = "main"
+%s
 
 # Symlink patterns to create in new worktrees
 # Recommend: [".twig/settings.local.toml"] to share local settings across worktrees
-symlinks = []
+%s
 
 # Worktree destination base directory (default: ../<repo-name>-worktree)
-# worktree_destination_base_dir = "../my-worktrees"
+%s
 
 # Additional symlink patterns (collected from both project and local configs)
 # extra_symlinks = [".envrc", ".tool-versions"]
 `
 
+const localSettingsTemplate = `# twig local configuration (personal, not committed)
+# Values here override .twig/settings.toml.
+
+# Additional symlink patterns for your own worktrees
+# extra_symlinks = [".envrc", ".tool-versions"]
+`
+
+// fallbackDefaultSource is used when the default branch cannot be detected.
+const fallbackDefaultSource = "main"
+
+// localFileCandidates are typical per-developer files that init proposes as
+// symlinks when they exist and are ignored by git.
+// Entries ending with "/" are directories and are proposed as a whole.
+var localFileCandidates = []string{
+	".envrc",
+	".env*",
+	".tool-versions",
+	"CLAUDE.local.md",
+	".claude/settings.local.json",
+	".vscode/",
+}
+
 // InitCommand initializes twig configuration in a directory.
 type InitCommand struct {
-	FS FileSystem
+	FS  FileSystem
+	Git *GitRunner
 }
 
 // InitOptions holds options for the init command.
 type InitOptions struct {
 	Force bool
+	// DefaultSource is written as default_source (default: "main").
+	DefaultSource string
+	// Symlinks are written as symlinks.
+	Symlinks []string
+	// Local creates .twig/settings.local.toml, adds it to symlinks and
+	// to .gitignore.
+	Local bool
+	// DestBaseDir is written as worktree_destination_base_dir.
+	// Empty leaves the setting commented out.
+	DestBaseDir string
+}
+
+// InitDetection holds settings proposed by inspecting the repository.
+type InitDetection struct {
+	// Exists is true if .twig/settings.toml already exists.
+	Exists bool
+	// DefaultSource is the detected default branch, empty if unknown.
+	DefaultSource string
+	// Symlinks are ignored per-developer files present in the directory.
+	Symlinks []string
 }
 
 // InitResult holds the result of the init command.
 type InitResult struct {
-	ConfigDir    string
-	SettingsPath string
-	Created      bool
-	Skipped      bool
-	Overwritten  bool
+	ConfigDir     string
+	SettingsPath  string
+	Created       bool
+	Skipped       bool
+	Overwritten   bool
+	DefaultSource string
+	Symlinks      []string
+
+	LocalSettingsPath string
+	LocalCreated      bool
+	LocalSkipped      bool // settings.local.toml already existed
+	GitignoreUpdated  bool
 }
 
 // InitFormatOptions holds formatting options for InitResult.
@@ -47,15 +101,62 @@ type InitFormatOptions struct {
 }
 
 // NewInitCommand creates an InitCommand with explicit dependencies (for testing).
-func NewInitCommand(fs FileSystem) *InitCommand {
+func NewInitCommand(fs FileSystem, git *GitRunner) *InitCommand {
 	return &InitCommand{
-		FS: fs,
+		FS:  fs,
+		Git: git,
 	}
 }
 
 // NewDefaultInitCommand creates an InitCommand with production defaults.
 func NewDefaultInitCommand() *InitCommand {
-	return NewInitCommand(osFS{})
+	return NewInitCommand(osFS{}, NewGitRunner(""))
+}
+
+// Detect inspects the repository at dir and proposes initial settings.
+// Detection is best-effort: outside a git repository nothing is proposed.
+func (c *InitCommand) Detect(dir string) InitDetection {
+	var detection InitDetection
+
+	settingsPath := filepath.Join(dir, configDir, configFileName)
+	_, err := c.FS.Stat(settingsPath)
+	detection.Exists = err == nil || !c.FS.IsNotExist(err)
+
+	git := c.Git.InDir(dir)
+	if branch, err := git.DefaultBranch(); err == nil {
+		detection.DefaultSource = branch
+	}
+
+	pathspecs := make([]string, len(localFileCandidates))
+	for i, p := range localFileCandidates {
+		if dirName, ok := strings.CutSuffix(p, "/"); ok {
+			p = dirName + "/**"
+		}
+		pathspecs[i] = ":(glob)" + p
+	}
+	files, err := git.IgnoredFiles(pathspecs...)
+	if err != nil {
+		return detection
+	}
+
+	for _, p := range localFileCandidates {
+		for _, file := range files {
+			symlink := file
+			if dirName, ok := strings.CutSuffix(p, "/"); ok {
+				if !strings.HasPrefix(file, p) {
+					continue
+				}
+				symlink = dirName
+			} else if ok, _ := doublestar.Match(p, file); !ok {
+				continue
+			}
+			if !slices.Contains(detection.Symlinks, symlink) {
+				detection.Symlinks = append(detection.Symlinks, symlink)
+			}
+		}
+	}
+
+	return detection
 }
 
 // Run executes the init command.
@@ -77,13 +178,28 @@ func (c *InitCommand) Run(dir string, opts InitOptions) (InitResult, error) {
 		return result, nil
 	}
 
+	result.DefaultSource = opts.DefaultSource
+	if result.DefaultSource == "" {
+		result.DefaultSource = fallbackDefaultSource
+	}
+	result.Symlinks = slices.Clone(opts.Symlinks)
+	localRelPath := filepath.ToSlash(filepath.Join(configDir, localConfigFileName))
+	if opts.Local && !slices.Contains(result.Symlinks, localRelPath) {
+		result.Symlinks = append(result.Symlinks, localRelPath)
+	}
+
+	content, err := renderSettings(result.DefaultSource, result.Symlinks, opts.DestBaseDir)
+	if err != nil {
+		return result, err
+	}
+
 	// Create config directory
 	if err := c.FS.MkdirAll(configDirPath, 0755); err != nil {
 		return result, fmt.Errorf("failed to create config directory: %w", err)
 	}
 
 	// Write settings file
-	if err := c.FS.WriteFile(settingsPath, []byte(settingsTemplate), 0644); err != nil {
+	if err := c.FS.WriteFile(settingsPath, content, 0644); err != nil {
 		return result, fmt.Errorf("failed to write settings file: %w", err)
 	}
 
@@ -92,9 +208,75 @@ func (c *InitCommand) Run(dir string, opts InitOptions) (InitResult, error) {
 		result.Overwritten = true
 	}
 
+	if opts.Local {
+		if err := c.initLocal(dir, &result); err != nil {
+			return result, err
+		}
+	}
+
 	return result, nil
 }
 
+// initLocal creates settings.local.toml if missing and makes sure git ignores it.
+func (c *InitCommand) initLocal(dir string, result *InitResult) error {
+	result.LocalSettingsPath = filepath.Join(result.ConfigDir, localConfigFileName)
+
+	if _, err := c.FS.Stat(result.LocalSettingsPath); err == nil || !c.FS.IsNotExist(err) {
+		result.LocalSkipped = true
+	} else {
+		if err := c.FS.WriteFile(result.LocalSettingsPath, []byte(localSettingsTemplate), 0644); err != nil {
+			return fmt.Errorf("failed to write local settings file: %w", err)
+		}
+		result.LocalCreated = true
+	}
+
+	localRelPath := filepath.ToSlash(filepath.Join(configDir, localConfigFileName))
+	if c.Git.InDir(dir).IsIgnored(localRelPath) {
+		return nil
+	}
+
+	gitignorePath := filepath.Join(dir, ".gitignore")
+	content, err := c.FS.ReadFile(gitignorePath)
+	if err != nil && !c.FS.IsNotExist(err) {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	if slices.Contains(strings.Split(string(content), "\n"), localRelPath) {
+		return nil
+	}
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+	content = append(content, localRelPath+"\n"...)
+	if err := c.FS.WriteFile(gitignorePath, content, 0644); err != nil {
+		return fmt.Errorf("failed to update .gitignore: %w", err)
+	}
+	result.GitignoreUpdated = true
+	return nil
+}
+
+// renderSettings fills settingsTemplate with the given values.
+func renderSettings(defaultSource string, symlinks []string, destBaseDir string) ([]byte, error) {
+	sourceLine, err := encodeTOMLAssignment(ConfigKeyDefaultSource, defaultSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", ConfigKeyDefaultSource, err)
+	}
+	if symlinks == nil {
+		symlinks = []string{}
+	}
+	symlinksLine, err := encodeTOMLAssignment(ConfigKeySymlinks, symlinks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", ConfigKeySymlinks, err)
+	}
+	destLine := `# worktree_destination_base_dir = "../my-worktrees"`
+	if destBaseDir != "" {
+		destLine, err = encodeTOMLAssignment(ConfigKeyWorktreeDestBaseDir, destBaseDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", ConfigKeyWorktreeDestBaseDir, err)
+		}
+	}
+	return []byte(fmt.Sprintf(settingsTemplate, sourceLine, symlinksLine, destLine)), nil
+}
+
 // Format formats the result for output.
 func (r InitResult) Format(opts InitFormatOptions) FormatResult {
 	var stdout string
@@ -110,6 +292,24 @@ func (r InitResult) Format(opts InitFormatOptions) FormatResult {
 		stdout = fmt.Sprintf("Created %s\n", relPath)
 	}
 
+	if opts.Verbose && r.Created {
+		stdout += fmt.Sprintf("  default_source: %s\n", r.DefaultSource)
+		for _, s := range r.Symlinks {
+			stdout += fmt.Sprintf("  symlink: %s\n", s)
+		}
+	}
+
+	localRelPath := filepath.Join(configDir, localConfigFileName)
+	switch {
+	case r.LocalCreated:
+		stdout += fmt.Sprintf("Created %s\n", localRelPath)
+	case r.LocalSkipped:
+		stdout += fmt.Sprintf("Skipped %s (already exists)\n", localRelPath)
+	}
+	if r.GitignoreUpdated {
+		stdout += fmt.Sprintf("Added %s to .gitignore\n", filepath.ToSlash(localRelPath))
+	}
+
 	return FormatResult{
 		Stdout: stdout,
 	}
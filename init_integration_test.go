@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/708u/twig/internal/testutil"
 )
 
 func TestInitCommand_Integration(t *testing.T) {
@@ -194,4 +196,78 @@ func TestInitCommand_Integration(t *testing.T) {
 			t.Errorf("output should contain 'already exists': %s", formatted.Stdout)
 		}
 	})
+	t.Run("DetectsIgnoredLocalFiles", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		gitignore := ".envrc\n.env.local\n.vscode/\nnode_modules/\n"
+		if err := os.WriteFile(filepath.Join(mainDir, ".gitignore"), []byte(gitignore), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "add", ".gitignore")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add gitignore")
+
+		for _, f := range []string{".envrc", ".env.local", ".vscode/settings.json", "node_modules/pkg/index.js", ".tool-versions"} {
+			path := filepath.Join(mainDir, f)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// origin/HEAD takes precedence over the local main branch
+		testutil.RunGit(t, mainDir, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/develop")
+
+		cmd := NewDefaultInitCommand()
+		detection := cmd.Detect(mainDir)
+
+		if detection.DefaultSource != "develop" {
+			t.Errorf("DefaultSource = %q, want %q", detection.DefaultSource, "develop")
+		}
+		// .tool-versions is not ignored and node_modules is not a local file
+		want := []string{".envrc", ".env.local", ".vscode"}
+		if strings.Join(detection.Symlinks, ",") != strings.Join(want, ",") {
+			t.Errorf("Symlinks = %v, want %v", detection.Symlinks, want)
+		}
+
+		result, err := cmd.Run(mainDir, InitOptions{
+			DefaultSource: detection.DefaultSource,
+			Symlinks:      detection.Symlinks,
+			Local:         true,
+		})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if !result.LocalCreated || !result.GitignoreUpdated {
+			t.Errorf("LocalCreated = %v, GitignoreUpdated = %v, want both true",
+				result.LocalCreated, result.GitignoreUpdated)
+		}
+
+		loaded, err := LoadConfig(mainDir, WithGlobalConfigPath(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Config.DefaultSource != "develop" {
+			t.Errorf("default_source = %q, want %q", loaded.Config.DefaultSource, "develop")
+		}
+		wantSymlinks := []string{".envrc", ".env.local", ".vscode", ".twig/settings.local.toml"}
+		if strings.Join(loaded.Config.Symlinks, ",") != strings.Join(wantSymlinks, ",") {
+			t.Errorf("symlinks = %v, want %v", loaded.Config.Symlinks, wantSymlinks)
+		}
+
+		// settings.local.toml is now ignored by git
+		testutil.RunGit(t, mainDir, "check-ignore", "-q", ".twig/settings.local.toml")
+
+		// Running again does not duplicate the .gitignore entry
+		result, err = cmd.Run(mainDir, InitOptions{Force: true, Local: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if result.GitignoreUpdated || !result.LocalSkipped {
+			t.Errorf("GitignoreUpdated = %v, LocalSkipped = %v, want false, true",
+				result.GitignoreUpdated, result.LocalSkipped)
+		}
+	})
 }
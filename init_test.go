@@ -3,6 +3,8 @@ package twig
 import (
 	"errors"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/708u/twig/internal/testutil"
@@ -88,7 +90,7 @@ func TestInitCommand_Run(t *testing.T) {
 			t.Parallel()
 
 			mockFS := tt.setupFS()
-			cmd := NewInitCommand(mockFS)
+			cmd := NewInitCommand(mockFS, &GitRunner{Executor: &testutil.MockGitExecutor{}})
 
 			result, err := cmd.Run("/test", tt.opts)
 
@@ -127,6 +129,154 @@ func TestInitCommand_Run(t *testing.T) {
 	}
 }
 
+func TestInitCommand_Run_Settings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		opts          InitOptions
+		gitignore     string
+		ignoredPaths  []string
+		localExists   bool
+		wantContains  []string
+		wantGitignore string
+		wantLocal     bool
+	}{
+		{
+			name: "detected values are written",
+			opts: InitOptions{DefaultSource: "develop", Symlinks: []string{".envrc", ".vscode"}},
+			wantContains: []string{
+				`default_source = "develop"`,
+				`symlinks = [".envrc", ".vscode"]`,
+			},
+		},
+		{
+			name:         "falls back to main",
+			opts:         InitOptions{},
+			wantContains: []string{`default_source = "main"`, "symlinks = []"},
+		},
+		{
+			name:      "local settings are created and ignored",
+			opts:      InitOptions{Symlinks: []string{".envrc"}, Local: true},
+			gitignore: "node_modules/",
+			wantContains: []string{
+				`symlinks = [".envrc", ".twig/settings.local.toml"]`,
+			},
+			wantGitignore: "node_modules/\n.twig/settings.local.toml\n",
+			wantLocal:     true,
+		},
+		{
+			name:         "already ignored local settings leave .gitignore alone",
+			opts:         InitOptions{Local: true},
+			ignoredPaths: []string{".twig/settings.local.toml"},
+			localExists:  true,
+			wantContains: []string{`symlinks = [".twig/settings.local.toml"]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			localPath := filepath.Join("/test", ".twig", "settings.local.toml")
+			gitignorePath := filepath.Join("/test", ".gitignore")
+			mockFS := &testutil.MockFS{
+				WrittenFiles: make(map[string][]byte),
+				FileContents: map[string][]byte{},
+			}
+			if tt.gitignore != "" {
+				mockFS.FileContents[gitignorePath] = []byte(tt.gitignore)
+			}
+			if tt.localExists {
+				mockFS.ExistingPaths = []string{localPath}
+			}
+			git := &GitRunner{Executor: &testutil.MockGitExecutor{IgnoredPaths: tt.ignoredPaths}}
+
+			result, err := NewInitCommand(mockFS, git).Run("/test", tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content := string(mockFS.WrittenFiles[filepath.Join("/test", ".twig", "settings.toml")])
+			for _, want := range tt.wantContains {
+				if !strings.Contains(content, want) {
+					t.Errorf("settings should contain %q, got:\n%s", want, content)
+				}
+			}
+
+			if _, ok := mockFS.WrittenFiles[localPath]; ok != tt.wantLocal {
+				t.Errorf("settings.local.toml written = %v, want %v", ok, tt.wantLocal)
+			}
+			if result.LocalCreated != tt.wantLocal {
+				t.Errorf("LocalCreated = %v, want %v", result.LocalCreated, tt.wantLocal)
+			}
+			gotGitignore, updated := mockFS.WrittenFiles[gitignorePath]
+			if updated != (tt.wantGitignore != "") || string(gotGitignore) != tt.wantGitignore {
+				t.Errorf(".gitignore = %q (written %v), want %q", gotGitignore, updated, tt.wantGitignore)
+			}
+		})
+	}
+}
+
+func TestInitCommand_Detect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		git        *testutil.MockGitExecutor
+		wantSource string
+		wantLinks  []string
+	}{
+		{
+			name: "remote head and ignored local files",
+			git: &testutil.MockGitExecutor{
+				RemoteHEAD: "origin/develop",
+				IgnoredFiles: []string{
+					".claude/settings.local.json",
+					".env.local",
+					".envrc",
+					".vscode/launch.json",
+					".vscode/settings.json",
+					"CLAUDE.local.md",
+				},
+			},
+			wantSource: "develop",
+			wantLinks: []string{
+				".envrc", ".env.local", "CLAUDE.local.md", ".claude/settings.local.json", ".vscode",
+			},
+		},
+		{
+			name:       "falls back to local main branch",
+			git:        &testutil.MockGitExecutor{ExistingBranches: []string{"master", "main"}},
+			wantSource: "main",
+		},
+		{
+			name:       "falls back to current branch",
+			git:        &testutil.MockGitExecutor{CurrentBranch: "trunk"},
+			wantSource: "trunk",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := NewInitCommand(&testutil.MockFS{}, &GitRunner{Executor: tt.git})
+			got := cmd.Detect("/test")
+
+			if got.Exists {
+				t.Error("Exists = true, want false")
+			}
+			if got.DefaultSource != tt.wantSource {
+				t.Errorf("DefaultSource = %q, want %q", got.DefaultSource, tt.wantSource)
+			}
+			if !slices.Equal(got.Symlinks, tt.wantLinks) {
+				t.Errorf("Symlinks = %v, want %v", got.Symlinks, tt.wantLinks)
+			}
+		})
+	}
+}
+
 func TestInitResult_Format(t *testing.T) {
 	t.Parallel()
 
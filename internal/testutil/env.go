@@ -0,0 +1,37 @@
+package testutil
+
+import (
+	"os"
+	"strings"
+)
+
+// IsolateConfig keeps the user's global twig settings and TWIG_*
+// environment variables away from tests that load configuration the way
+// the CLI does. It points XDG_CONFIG_HOME at an empty directory, unsets
+// every TWIG_* variable, and returns a function restoring the environment.
+//
+// It changes the process environment, so call it from TestMain rather than
+// from parallel tests.
+func IsolateConfig() (restore func(), err error) {
+	configHome, err := os.MkdirTemp("", "twig-config-home-*")
+	if err != nil {
+		return nil, err
+	}
+
+	saved := os.Environ()
+	for _, kv := range saved {
+		if key, _, _ := strings.Cut(kv, "="); strings.HasPrefix(key, "TWIG_") {
+			os.Unsetenv(key)
+		}
+	}
+	os.Setenv("XDG_CONFIG_HOME", configHome)
+
+	return func() {
+		os.Clearenv()
+		for _, kv := range saved {
+			key, value, _ := strings.Cut(kv, "=")
+			os.Setenv(key, value)
+		}
+		os.RemoveAll(configHome)
+	}, nil
+}
@@ -17,6 +17,7 @@ type setupConfig struct {
 	symlinks      []string
 	extraSymlinks []string
 	defaultSource string
+	symlinkStyle  string
 }
 
 // WithoutSettings skips creating .twig/settings.toml.
@@ -48,6 +49,13 @@ func DefaultSource(branch string) SetupOption {
 	}
 }
 
+// SymlinkStyle sets the symlink_style field in settings.toml.
+func SymlinkStyle(style string) SetupOption {
+	return func(c *setupConfig) {
+		c.symlinkStyle = style
+	}
+}
+
 // SetupTestRepo creates a temporary git repository for testing.
 // Returns repoDir (parent directory) and mainDir (git repository root).
 //
@@ -115,6 +123,10 @@ func createSettings(t *testing.T, repoDir, mainDir string, cfg *setupConfig) {
 		content += fmt.Sprintf("default_source = %q\n", cfg.defaultSource)
 	}
 
+	if cfg.symlinkStyle != "" {
+		content += fmt.Sprintf("symlink_style = %q\n", cfg.symlinkStyle)
+	}
+
 	settingsPath := filepath.Join(twigDir, "settings.toml")
 	if err := os.WriteFile(settingsPath, []byte(content), 0644); err != nil {
 		t.Fatal(err)
@@ -18,6 +18,10 @@ type MockFS struct {
 	ReadDirFunc    func(name string) ([]os.DirEntry, error)
 	RemoveFunc     func(name string) error
 	WriteFileFunc  func(name string, data []byte, perm fs.FileMode) error
+	ReadFileFunc   func(name string) ([]byte, error)
+	ReadlinkFunc   func(name string) (string, error)
+	RenameFunc     func(oldpath, newpath string) error
+	RemoveAllFunc  func(path string) error
 
 	// ExistingPaths is a list of paths that exist (Stat returns nil, nil).
 	ExistingPaths []string
@@ -48,6 +52,18 @@ type MockFS struct {
 
 	// WrittenFiles records files written by WriteFile.
 	WrittenFiles map[string][]byte
+
+	// FileContents maps file path to its contents for ReadFile.
+	FileContents map[string][]byte
+
+	// LinkTargets maps symlink path to its target for Readlink.
+	LinkTargets map[string]string
+
+	// RenameErr is returned by Rename if set.
+	RenameErr error
+
+	// RemoveAllErr is returned by RemoveAll if set.
+	RemoveAllErr error
 }
 
 func (m *MockFS) Stat(name string) (fs.FileInfo, error) {
@@ -125,3 +141,40 @@ func (m *MockFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
 	}
 	return m.WriteFileErr
 }
+
+func (m *MockFS) ReadFile(name string) ([]byte, error) {
+	if m.ReadFileFunc != nil {
+		return m.ReadFileFunc(name)
+	}
+	if data, ok := m.WrittenFiles[name]; ok {
+		return data, nil
+	}
+	if data, ok := m.FileContents[name]; ok {
+		return data, nil
+	}
+	return nil, fs.ErrNotExist
+}
+
+func (m *MockFS) Readlink(name string) (string, error) {
+	if m.ReadlinkFunc != nil {
+		return m.ReadlinkFunc(name)
+	}
+	if target, ok := m.LinkTargets[name]; ok {
+		return target, nil
+	}
+	return "", fs.ErrNotExist
+}
+
+func (m *MockFS) Rename(oldpath, newpath string) error {
+	if m.RenameFunc != nil {
+		return m.RenameFunc(oldpath, newpath)
+	}
+	return m.RenameErr
+}
+
+func (m *MockFS) RemoveAll(path string) error {
+	if m.RemoveAllFunc != nil {
+		return m.RemoveAllFunc(path)
+	}
+	return m.RemoveAllErr
+}
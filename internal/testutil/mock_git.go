@@ -17,6 +17,7 @@ type MockWorktree struct {
 	Prunable       bool
 	PrunableReason string
 	Bare           bool
+	Sparse         bool
 }
 
 // MockGitExecutor is a mock implementation of twig.GitExecutor for testing.
@@ -45,10 +46,10 @@ type MockGitExecutor struct {
 	// HasChanges indicates if git status --porcelain returns output.
 	HasChanges bool
 
-	// StashPushErr is returned when stash push is called.
+	// StashPushErr is returned when the stash commit is created (commit-tree).
 	StashPushErr error
 
-	// StashHash is returned when stash push succeeds and used for subsequent stash operations.
+	// StashHash is returned by commit-tree and used for subsequent stash operations.
 	StashHash string
 
 	// StashApplyErr is returned when stash apply is called.
@@ -57,7 +58,7 @@ type MockGitExecutor struct {
 	// StashPopErr is returned when stash pop is called.
 	StashPopErr error
 
-	// StashDropErr is returned when stash drop is called.
+	// StashDropErr is returned when the stash ref is deleted (update-ref -d).
 	StashDropErr error
 
 	// MergedBranches maps target branch to list of branches merged into it.
@@ -67,6 +68,10 @@ type MockGitExecutor struct {
 	// Used by git for-each-ref to detect squash/rebase merged branches.
 	UpstreamGoneBranches []string
 
+	// UnpushedCommits maps branch to the number of its commits listed by
+	// rev-list as not reachable from the target or remotes.
+	UnpushedCommits map[string]int
+
 	// WorktreePruneErr is returned when worktree prune is called.
 	WorktreePruneErr error
 
@@ -79,6 +84,19 @@ type MockGitExecutor struct {
 
 	// FetchErr is returned when fetch is called.
 	FetchErr error
+
+	// IgnoredFiles is returned by ls-files --others --ignored.
+	IgnoredFiles []string
+
+	// IgnoredPaths is a list of paths reported as ignored by check-ignore.
+	IgnoredPaths []string
+
+	// RemoteHEAD is the short ref origin/HEAD points to (e.g. "origin/main").
+	// Empty means origin/HEAD is not set.
+	RemoteHEAD string
+
+	// CurrentBranch is returned by symbolic-ref HEAD.
+	CurrentBranch string
 }
 
 func (m *MockGitExecutor) Run(args ...string) ([]byte, error) {
@@ -88,9 +106,16 @@ func (m *MockGitExecutor) Run(args ...string) ([]byte, error) {
 	return m.defaultRun(args...)
 }
 
+// RunEnv ignores env and behaves like Run.
+func (m *MockGitExecutor) RunEnv(env []string, args ...string) ([]byte, error) {
+	return m.Run(args...)
+}
+
 func (m *MockGitExecutor) defaultRun(args ...string) ([]byte, error) {
 	// Skip -C <dir> option (directory specification, not a command)
+	var dir string
 	for len(args) >= 2 && args[0] == "-C" {
+		dir = args[1]
 		args = args[2:]
 	}
 
@@ -105,7 +130,7 @@ func (m *MockGitExecutor) defaultRun(args ...string) ([]byte, error) {
 		if len(args) > 1 {
 			switch args[1] {
 			case "list":
-				return m.handleWorktreeList()
+				return m.handleWorktreeList(args)
 			case "add":
 				return m.handleWorktreeAdd(args)
 			case "remove":
@@ -124,18 +149,83 @@ func (m *MockGitExecutor) defaultRun(args ...string) ([]byte, error) {
 		return m.handleForEachRef(args)
 	case "fetch":
 		return m.handleFetch(args)
+	case "ls-files":
+		return m.handleLsFiles(args)
+	case "check-ignore":
+		return m.handleCheckIgnore(args)
+	case "symbolic-ref":
+		return m.handleSymbolicRef(args)
+	case "diff":
+		return m.handleDiff(args)
+	case "config":
+		return m.handleConfig(dir, args)
+	case "rev-list":
+		return m.handleRevList(args)
+	case "write-tree":
+		return []byte("tree1234567890\n"), nil
+	case "commit-tree":
+		if m.StashPushErr != nil {
+			return nil, m.StashPushErr
+		}
+		return []byte(m.stashHash() + "\n"), nil
+	case "update-ref":
+		if slices.Contains(args, "-d") {
+			return nil, m.StashDropErr
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+// handleRevList lists UnpushedCommits fake commits for rev-list refs/heads/<branch>.
+func (m *MockGitExecutor) handleRevList(args []string) ([]byte, error) {
+	for _, arg := range args {
+		if branch, ok := strings.CutPrefix(arg, "refs/heads/"); ok {
+			return []byte(strings.Repeat("abc1234\n", m.UnpushedCommits[branch])), nil
+		}
+	}
+	return nil, nil
+}
+
+// handleConfig reports core.sparseCheckout for worktrees marked Sparse.
+func (m *MockGitExecutor) handleConfig(dir string, args []string) ([]byte, error) {
+	if args[len(args)-1] != "core.sparseCheckout" {
+		return nil, nil
+	}
+	for _, wt := range m.Worktrees {
+		if wt.Path == dir && wt.Sparse {
+			return []byte("true\n"), nil
+		}
+	}
+	return nil, errors.New("exit status 1")
+}
+
+func (m *MockGitExecutor) stashHash() string {
+	if m.StashHash == "" {
+		return "abc123def456"
+	}
+	return m.StashHash
+}
+
+func (m *MockGitExecutor) handleDiff(args []string) ([]byte, error) {
+	// args: ["diff", "--name-only", "--no-renames", "-z", "HEAD", "--", ...]
+	if slices.Contains(args, "--name-only") && m.HasChanges {
+		return []byte("modified.go\x00"), nil
 	}
 	return nil, nil
 }
 
 func (m *MockGitExecutor) handleRevParse(args []string) ([]byte, error) {
-	// Handle stash@{0} for StashPush hash retrieval
-	if len(args) >= 2 && args[1] == "stash@{0}" {
-		hash := m.StashHash
-		if hash == "" {
-			hash = "abc123def456"
+	if len(args) == 2 && args[1] == "--git-common-dir" {
+		return []byte(".git\n"), nil
+	}
+	if len(args) >= 3 {
+		switch {
+		case args[1] == "--git-path":
+			return []byte(".git/" + args[2] + "\n"), nil
+		case args[1] == "--verify" && args[2] == "HEAD":
+			return []byte("abc1234567890\n"), nil
 		}
-		return []byte(hash + "\n"), nil
 	}
 
 	// args: ["rev-parse", "--verify", "refs/heads/{branch}"]
@@ -160,7 +250,7 @@ func (m *MockGitExecutor) handleRevParse(args []string) ([]byte, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *MockGitExecutor) handleWorktreeList() ([]byte, error) {
+func (m *MockGitExecutor) handleWorktreeList(args []string) ([]byte, error) {
 	var lines []string
 	for _, wt := range m.Worktrees {
 		head := wt.HEAD
@@ -192,6 +282,10 @@ func (m *MockGitExecutor) handleWorktreeList() ([]byte, error) {
 		}
 		lines = append(lines, "")
 	}
+	// args: ["worktree", "list", "--porcelain", "-z"]
+	if slices.Contains(args, "-z") {
+		return []byte(strings.Join(lines, "\x00")), nil
+	}
 	return []byte(strings.Join(lines, "\n")), nil
 }
 
@@ -221,19 +315,21 @@ func (m *MockGitExecutor) handleBranch(args []string) ([]byte, error) {
 	if len(args) >= 3 && (args[1] == "-d" || args[1] == "-D") {
 		return nil, m.BranchDeleteErr
 	}
-	// args: ["branch", "--merged", "target", "--format=%(refname:short)"]
+	// args: ["branch", "--merged", "target", "--format=%(refname:short)%00"]
 	if len(args) >= 3 && args[1] == "--merged" {
 		target := args[2]
-		branches := m.MergedBranches[target]
-		return []byte(strings.Join(branches, "\n")), nil
+		return records(m.MergedBranches[target]), nil
 	}
 	return nil, nil
 }
 
 func (m *MockGitExecutor) handleStatus(args []string) ([]byte, error) {
-	// args: ["status", "--porcelain"]
+	// args: ["status", "--porcelain", ...]
 	if len(args) >= 2 && args[1] == "--porcelain" {
 		if m.HasChanges {
+			if slices.Contains(args, "-z") {
+				return []byte("M  modified.go\x00"), nil
+			}
 			return []byte("M  modified.go\n"), nil
 		}
 		return []byte{}, nil
@@ -246,37 +342,10 @@ func (m *MockGitExecutor) handleStash(args []string) ([]byte, error) {
 		return nil, nil
 	}
 	switch args[1] {
-	case "create":
-		// stash create returns hash on stdout
-		if m.StashPushErr != nil {
-			return nil, m.StashPushErr
-		}
-		hash := m.StashHash
-		if hash == "" {
-			hash = "abc123def456"
-		}
-		return []byte(hash + "\n"), nil
-	case "store":
-		// stash store adds to reflog, no output
-		return nil, nil
-	case "push":
-		if m.CapturedArgs != nil {
-			*m.CapturedArgs = append(*m.CapturedArgs, args...)
-		}
-		return nil, m.StashPushErr
 	case "apply":
 		return nil, m.StashApplyErr
 	case "pop":
 		return nil, m.StashPopErr
-	case "drop":
-		return nil, m.StashDropErr
-	case "list":
-		// Return stash list with format "%gd %H"
-		hash := m.StashHash
-		if hash == "" {
-			hash = "abc123def456"
-		}
-		return []byte("stash@{0} " + hash + "\n"), nil
 	}
 	return nil, nil
 }
@@ -286,6 +355,11 @@ func (m *MockGitExecutor) handleForEachRef(args []string) ([]byte, error) {
 		return nil, nil
 	}
 
+	// Handle private stash ref lookup by hash
+	if slices.Contains(args, "--points-at") {
+		return records([]string{"refs/twig/stash/0123456789abcdef"}), nil
+	}
+
 	ref := args[2]
 
 	// Handle refs/heads/<branch> for upstream tracking check
@@ -305,10 +379,7 @@ func (m *MockGitExecutor) handleForEachRef(args []string) ([]byte, error) {
 				results = append(results, remote+"/"+branch)
 			}
 		}
-		if len(results) > 0 {
-			return []byte(strings.Join(results, "\n") + "\n"), nil
-		}
-		return []byte{}, nil
+		return records(results), nil
 	}
 
 	return nil, nil
@@ -321,3 +392,50 @@ func (m *MockGitExecutor) handleFetch(args []string) ([]byte, error) {
 	}
 	return nil, m.FetchErr
 }
+
+func (m *MockGitExecutor) handleLsFiles(args []string) ([]byte, error) {
+	// args: ["ls-files", "-z", "--others", "--ignored", "--exclude-standard", ...]
+	if !slices.Contains(args, "--ignored") {
+		return nil, nil
+	}
+	var out []byte
+	for _, f := range m.IgnoredFiles {
+		out = append(out, f...)
+		out = append(out, 0)
+	}
+	return out, nil
+}
+
+func (m *MockGitExecutor) handleCheckIgnore(args []string) ([]byte, error) {
+	// args: ["check-ignore", "-q", path]
+	if slices.Contains(m.IgnoredPaths, args[len(args)-1]) {
+		return nil, nil
+	}
+	return nil, errors.New("not ignored")
+}
+
+func (m *MockGitExecutor) handleSymbolicRef(args []string) ([]byte, error) {
+	// args: ["symbolic-ref", "--short", ref]
+	switch args[len(args)-1] {
+	case "refs/remotes/origin/HEAD":
+		if m.RemoteHEAD == "" {
+			return nil, errors.New("not a symbolic ref")
+		}
+		return []byte(m.RemoteHEAD + "\n"), nil
+	case "HEAD":
+		if m.CurrentBranch == "" {
+			return nil, errors.New("not a symbolic ref")
+		}
+		return []byte(m.CurrentBranch + "\n"), nil
+	}
+	return nil, nil
+}
+
+// records formats items like git output of a --format ending in %00.
+func records(items []string) []byte {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(item + "\x00\n")
+	}
+	return []byte(b.String())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
 // ListCommand lists all worktrees.
@@ -65,7 +66,7 @@ func (r ListResult) formatDefault() FormatResult {
 	return FormatResult{Stdout: buf.String()}
 }
 
-// formatStatus returns the status portion of the worktree line (branch, locked, prunable).
+// formatStatus returns the status portion of the worktree line (branch, lock, prunable, sparse).
 func (w Worktree) formatStatus() string {
 	var sb strings.Builder
 
@@ -82,10 +83,17 @@ func (w Worktree) formatStatus() string {
 
 	if w.Locked {
 		sb.WriteString(" locked")
+		if desc := w.LockInfo().Describe(time.Now()); desc != "" {
+			sb.WriteString(" ")
+			sb.WriteString(desc)
+		}
 	}
 	if w.Prunable {
 		sb.WriteString(" prunable")
 	}
+	if w.Sparse {
+		sb.WriteString(" sparse")
+	}
 
 	return sb.String()
 }
@@ -97,5 +105,11 @@ func (c *ListCommand) Run() (ListResult, error) {
 		return ListResult{}, err
 	}
 
+	for i, wt := range worktrees {
+		if !wt.Bare && !wt.Prunable {
+			worktrees[i].Sparse = c.Git.InDir(wt.Path).IsSparseCheckout()
+		}
+	}
+
 	return ListResult{Worktrees: worktrees}, nil
 }
@@ -52,6 +52,14 @@ func TestListCommand_Run(t *testing.T) {
 			},
 			wantCount: 2,
 		},
+		{
+			name: "sparse worktree",
+			worktrees: []testutil.MockWorktree{
+				{Path: "/repo/main", Branch: "main"},
+				{Path: "/repo/worktree/svc", Branch: "svc", Sparse: true},
+			},
+			wantCount: 2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -92,6 +100,9 @@ func TestListCommand_Run(t *testing.T) {
 				if wt.Locked != tt.worktrees[i].Locked {
 					t.Errorf("worktree[%d].Locked = %v, want %v", i, wt.Locked, tt.worktrees[i].Locked)
 				}
+				if wt.Sparse != tt.worktrees[i].Sparse {
+					t.Errorf("worktree[%d].Sparse = %v, want %v", i, wt.Sparse, tt.worktrees[i].Sparse)
+				}
 			}
 		})
 	}
@@ -128,6 +139,21 @@ func TestListResult_Format(t *testing.T) {
 			},
 			wantStdout: "/repo/worktree/locked  abc1234 [locked-branch] locked\n",
 		},
+		{
+			name: "locked worktree with plain reason",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/locked", Branch: "locked-branch", HEAD: "abc1234567890", Locked: true, LockReason: "in use"},
+			},
+			wantStdout: "/repo/worktree/locked  abc1234 [locked-branch] locked (in use)\n",
+		},
+		{
+			name: "locked worktree with expired lock",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/locked", Branch: "locked-branch", HEAD: "abc1234567890", Locked: true,
+					LockReason: "owner=alice; expires=2000-01-01T00:00:00Z; purpose=agent run"},
+			},
+			wantStdout: "/repo/worktree/locked  abc1234 [locked-branch] locked by alice expired (agent run)\n",
+		},
 		{
 			name: "prunable worktree",
 			worktrees: []Worktree{
@@ -135,6 +161,13 @@ func TestListResult_Format(t *testing.T) {
 			},
 			wantStdout: "/repo/worktree/prunable  abc1234 (detached HEAD) prunable\n",
 		},
+		{
+			name: "sparse worktree",
+			worktrees: []Worktree{
+				{Path: "/repo/worktree/svc", Branch: "svc", HEAD: "abc1234567890", Locked: true, Sparse: true},
+			},
+			wantStdout: "/repo/worktree/svc  abc1234 [svc] locked sparse\n",
+		},
 		{
 			name: "bare repository",
 			worktrees: []Worktree{
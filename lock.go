@@ -0,0 +1,281 @@
+package twig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Lock reason fields. A structured reason looks like
+// "owner=alice; expires=2026-01-02T15:04:05Z; purpose=agent run".
+// purpose comes last so that it may contain any text.
+const (
+	lockKeyOwner   = "owner"
+	lockKeyExpires = "expires"
+	lockKeyPurpose = "purpose"
+	lockFieldSep   = "; "
+)
+
+// lockTimeLayout formats lock expiry times for display.
+const lockTimeLayout = "2006-01-02 15:04"
+
+// LockInfo is the structured form of a worktree lock reason.
+// Reasons not written by twig lock are kept as Purpose.
+type LockInfo struct {
+	Owner   string
+	Purpose string
+	Expires time.Time // Zero means the lock does not expire
+}
+
+// ParseLockInfo parses a lock reason written by LockInfo.String.
+// Any other reason is returned as the Purpose.
+func ParseLockInfo(reason string) LockInfo {
+	var info LockInfo
+	rest := reason
+	for rest != "" {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return LockInfo{Purpose: reason}
+		}
+		if key == lockKeyPurpose {
+			info.Purpose = value
+			return info
+		}
+		value, rest, _ = strings.Cut(value, lockFieldSep)
+		switch key {
+		case lockKeyOwner:
+			info.Owner = value
+		case lockKeyExpires:
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return LockInfo{Purpose: reason}
+			}
+			info.Expires = t
+		default:
+			return LockInfo{Purpose: reason}
+		}
+	}
+	return info
+}
+
+// String encodes the lock info as a lock reason. Without owner and expiry
+// the purpose is used as is, like git worktree lock --reason.
+func (i LockInfo) String() string {
+	var fields []string
+	if i.Owner != "" {
+		fields = append(fields, lockKeyOwner+"="+i.Owner)
+	}
+	if !i.Expires.IsZero() {
+		fields = append(fields, lockKeyExpires+"="+i.Expires.UTC().Format(time.RFC3339))
+	}
+	if len(fields) == 0 && !strings.Contains(i.Purpose, "=") {
+		return i.Purpose
+	}
+	if i.Purpose != "" {
+		fields = append(fields, lockKeyPurpose+"="+i.Purpose)
+	}
+	return strings.Join(fields, lockFieldSep)
+}
+
+// Expired reports whether the lock has an expiry at or before now.
+func (i LockInfo) Expired(now time.Time) bool {
+	return !i.Expires.IsZero() && !now.Before(i.Expires)
+}
+
+// Describe returns a short description for status lines,
+// e.g. "by alice until 2026-01-02 15:04 (agent run)".
+func (i LockInfo) Describe(now time.Time) string {
+	var parts []string
+	if i.Owner != "" {
+		parts = append(parts, "by "+i.Owner)
+	}
+	switch {
+	case i.Expired(now):
+		parts = append(parts, "expired")
+	case !i.Expires.IsZero():
+		parts = append(parts, "until "+i.Expires.Local().Format(lockTimeLayout))
+	}
+	if i.Purpose != "" {
+		parts = append(parts, "("+i.Purpose+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseLockExpiry parses an expiry given as an age relative to now
+// (see ParseAge) or as an RFC 3339 timestamp.
+func ParseLockExpiry(s string, now time.Time) (time.Time, error) {
+	if d, err := ParseAge(s); err == nil {
+		return now.Add(d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q (e.g. 8h, 2d or 2026-01-02T15:04:05Z)", s)
+	}
+	return t, nil
+}
+
+// LockCommand locks and unlocks worktrees by branch name.
+type LockCommand struct {
+	Git *GitRunner
+}
+
+// LockOptions configures the lock operation.
+type LockOptions struct {
+	// Owner defaults to the configured git user.name.
+	Owner   string
+	Purpose string
+	Expires time.Time // Zero means the lock does not expire
+}
+
+// NewLockCommand creates a LockCommand with explicit dependencies (for testing).
+func NewLockCommand(git *GitRunner) *LockCommand {
+	return &LockCommand{
+		Git: git,
+	}
+}
+
+// NewDefaultLockCommand creates a LockCommand with production defaults.
+func NewDefaultLockCommand(dir string) *LockCommand {
+	return NewLockCommand(NewGitRunner(dir))
+}
+
+// LockedWorktree holds the result of locking or unlocking a single worktree.
+type LockedWorktree struct {
+	Branch       string
+	WorktreePath string
+	Lock         LockInfo // The new lock, or the released one when unlocking
+	Err          error    // nil if success
+}
+
+// LockResult aggregates results from lock or unlock operations.
+type LockResult struct {
+	Worktrees []LockedWorktree
+	Unlock    bool
+}
+
+// HasErrors returns true if any errors occurred.
+func (r LockResult) HasErrors() bool {
+	return r.ErrorCount() > 0
+}
+
+// ErrorCount returns the number of failed operations.
+func (r LockResult) ErrorCount() int {
+	count := 0
+	for _, wt := range r.Worktrees {
+		if wt.Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Format formats the LockResult for display.
+func (r LockResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+	now := time.Now()
+
+	for _, wt := range r.Worktrees {
+		if wt.Err != nil {
+			fmt.Fprintf(&stderr, "error: %s: %v\n", wt.Branch, wt.Err)
+			continue
+		}
+		if r.Unlock {
+			if opts.Verbose {
+				fmt.Fprintf(&stdout, "Unlocked worktree at %s\n", wt.WorktreePath)
+			}
+			fmt.Fprintf(&stdout, "twig unlock: %s\n", wt.Branch)
+			continue
+		}
+		if opts.Verbose {
+			fmt.Fprintf(&stdout, "Locked worktree at %s\n", wt.WorktreePath)
+		}
+		if desc := wt.Lock.Describe(now); desc != "" {
+			fmt.Fprintf(&stdout, "twig lock: %s %s\n", wt.Branch, desc)
+		} else {
+			fmt.Fprintf(&stdout, "twig lock: %s\n", wt.Branch)
+		}
+	}
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Lock locks the worktrees of branches with a structured reason.
+// Errors on individual branches do not stop the remaining ones.
+func (c *LockCommand) Lock(branches []string, opts LockOptions) (LockResult, error) {
+	var result LockResult
+
+	info := LockInfo{Owner: opts.Owner, Purpose: opts.Purpose, Expires: opts.Expires}
+	if info.Owner == "" {
+		info.Owner = c.Git.UserName()
+	}
+	if strings.ContainsAny(info.Owner, ";\n") {
+		return result, fmt.Errorf("invalid owner %q: must not contain ';' or newlines", info.Owner)
+	}
+	if strings.Contains(info.Purpose, "\n") {
+		return result, errors.New("lock reason must not contain newlines")
+	}
+
+	worktrees, err := c.Git.WorktreeList()
+	if err != nil {
+		return result, err
+	}
+
+	for _, branch := range branches {
+		locked := LockedWorktree{Branch: branch, Lock: info}
+		wt, err := findWorktree(worktrees, branch)
+		switch {
+		case err != nil:
+			locked.Err = err
+		case wt.Locked:
+			locked.WorktreePath = wt.Path
+			locked.Err = fmt.Errorf("already locked, run 'twig unlock %s' first", branch)
+		default:
+			locked.WorktreePath = wt.Path
+			_, locked.Err = c.Git.WorktreeLock(wt.Path, info.String())
+		}
+		result.Worktrees = append(result.Worktrees, locked)
+	}
+
+	return result, nil
+}
+
+// Unlock unlocks the worktrees of branches.
+// Errors on individual branches do not stop the remaining ones.
+func (c *LockCommand) Unlock(branches []string) (LockResult, error) {
+	result := LockResult{Unlock: true}
+
+	worktrees, err := c.Git.WorktreeList()
+	if err != nil {
+		return result, err
+	}
+
+	for _, branch := range branches {
+		unlocked := LockedWorktree{Branch: branch}
+		wt, err := findWorktree(worktrees, branch)
+		switch {
+		case err != nil:
+			unlocked.Err = err
+		case !wt.Locked:
+			unlocked.WorktreePath = wt.Path
+			unlocked.Err = errors.New("not locked")
+		default:
+			unlocked.WorktreePath = wt.Path
+			unlocked.Lock = wt.LockInfo()
+			_, unlocked.Err = c.Git.WorktreeUnlock(wt.Path)
+		}
+		result.Worktrees = append(result.Worktrees, unlocked)
+	}
+
+	return result, nil
+}
+
+// findWorktree returns the worktree with branch checked out.
+func findWorktree(worktrees []Worktree, branch string) (Worktree, error) {
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			return wt, nil
+		}
+	}
+	return Worktree{}, kindErrorf(ErrBranchNotFound, "branch %q is not checked out in any worktree", branch)
+}
@@ -0,0 +1,101 @@
+//go:build integration
+
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestLockCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("LockListUnlock", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		wtPath := filepath.Join(repoDir, "feat", "a")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/a", wtPath)
+
+		cmd := NewDefaultLockCommand(mainDir)
+		expires := time.Now().Add(time.Hour).Truncate(time.Second)
+		result, err := cmd.Lock([]string{"feat/a"}, LockOptions{Owner: "alice", Purpose: "agent run", Expires: expires})
+		if err != nil || result.HasErrors() {
+			t.Fatalf("lock failed: %v %+v", err, result)
+		}
+
+		// git itself sees the encoded reason
+		porcelain := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain")
+		if !strings.Contains(porcelain, "locked owner=alice; expires=") {
+			t.Errorf("porcelain output should contain the lock reason:\n%s", porcelain)
+		}
+
+		list, err := NewDefaultListCommand(mainDir).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := list.Worktrees[1].LockInfo()
+		if got.Owner != "alice" || got.Purpose != "agent run" || !got.Expires.Equal(expires) {
+			t.Errorf("LockInfo() = %+v", got)
+		}
+		if out := list.Format(ListFormatOptions{}).Stdout; !strings.Contains(out, "[feat/a] locked by alice until ") {
+			t.Errorf("list output = %q", out)
+		}
+
+		// Locking again fails without touching the lock
+		result, err = cmd.Lock([]string{"feat/a"}, LockOptions{Owner: "bob"})
+		if err != nil || !result.HasErrors() {
+			t.Errorf("second lock should fail, got %v %+v", err, result)
+		}
+
+		result, err = cmd.Unlock([]string{"feat/a"})
+		if err != nil || result.HasErrors() {
+			t.Fatalf("unlock failed: %v %+v", err, result)
+		}
+		if porcelain := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain"); strings.Contains(porcelain, "locked") {
+			t.Errorf("worktree should be unlocked:\n%s", porcelain)
+		}
+	})
+
+	t.Run("CleanRemovesExpiredLock", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		for _, branch := range []string{"feat/expired", "feat/active"} {
+			testutil.RunGit(t, mainDir, "worktree", "add", "-b", branch, filepath.Join(repoDir, branch))
+		}
+
+		cmd := NewDefaultLockCommand(mainDir)
+		now := time.Now()
+		if _, err := cmd.Lock([]string{"feat/expired"}, LockOptions{Owner: "alice", Expires: now.Add(-time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cmd.Lock([]string{"feat/active"}, LockOptions{Owner: "alice", Expires: now.Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+
+		cfgResult, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := NewDefaultCleanCommand(cfgResult.Config).Run(mainDir, CleanOptions{Yes: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Removed) != 1 || result.Removed[0].Branch != "feat/expired" || result.Removed[0].Err != nil {
+			t.Fatalf("Removed = %+v, want feat/expired only", result.Removed)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feat", "expired")); !os.IsNotExist(err) {
+			t.Error("worktree with expired lock should be removed")
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feat", "active")); err != nil {
+			t.Error("worktree with active lock should be kept")
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package twig
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestLockInfo_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		info   LockInfo
+		reason string
+	}{
+		{
+			name:   "purpose_only",
+			info:   LockInfo{Purpose: "agent run"},
+			reason: "agent run",
+		},
+		{
+			name:   "all_fields",
+			info:   LockInfo{Owner: "alice", Purpose: "review; do not touch", Expires: expires},
+			reason: "owner=alice; expires=2026-01-02T15:04:05Z; purpose=review; do not touch",
+		},
+		{
+			name:   "owner_only",
+			info:   LockInfo{Owner: "Alice Smith"},
+			reason: "owner=Alice Smith",
+		},
+		{
+			name:   "purpose_with_equals",
+			info:   LockInfo{Purpose: "owner=bob"},
+			reason: "purpose=owner=bob",
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.info.String(); got != tt.reason {
+				t.Errorf("String() = %q, want %q", got, tt.reason)
+			}
+			if got := ParseLockInfo(tt.reason); got != tt.info {
+				t.Errorf("ParseLockInfo(%q) = %+v, want %+v", tt.reason, got, tt.info)
+			}
+		})
+	}
+}
+
+func TestParseLockInfo_Unstructured(t *testing.T) {
+	t.Parallel()
+
+	for _, reason := range []string{
+		"in use",
+		"key=value",
+		"owner=alice; expires=tomorrow",
+		"owner=alice; note=x",
+	} {
+		if got := ParseLockInfo(reason); got != (LockInfo{Purpose: reason}) {
+			t.Errorf("ParseLockInfo(%q) = %+v, want the reason as purpose", reason, got)
+		}
+	}
+}
+
+func TestLockInfo_Describe(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := now.Add(48 * time.Hour)
+
+	tests := []struct {
+		name string
+		info LockInfo
+		want string
+	}{
+		{name: "empty", want: ""},
+		{name: "purpose", info: LockInfo{Purpose: "agent"}, want: "(agent)"},
+		{
+			name: "active",
+			info: LockInfo{Owner: "alice", Purpose: "agent", Expires: later},
+			want: "by alice until " + later.Local().Format(lockTimeLayout) + " (agent)",
+		},
+		{name: "expired", info: LockInfo{Owner: "alice", Expires: now}, want: "by alice expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.info.Describe(now); got != tt.want {
+				t.Errorf("Describe() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLockExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "8h", want: now.Add(8 * time.Hour)},
+		{in: "2d", want: now.Add(48 * time.Hour)},
+		{in: "2026-02-01T09:00:00Z", want: time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{in: "tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseLockExpiry(tt.in, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseLockExpiry(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("ParseLockExpiry(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// newLockMock returns a mock with a main worktree, an unlocked feat/a and a
+// locked feat/b, recording lock and unlock calls.
+func newLockMock(calls *[]string) *testutil.MockGitExecutor {
+	base := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+			{Path: "/repo/feat/b", Branch: "feat/b", Locked: true, LockReason: "owner=bob; purpose=review"},
+		},
+	}
+	return &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			if args[0] == "-C" {
+				args = args[2:]
+			}
+			switch {
+			case args[0] == "config" && slices.Contains(args, "user.name"):
+				return []byte("Git User\n"), nil
+			case args[0] == "worktree" && (args[1] == "lock" || args[1] == "unlock"):
+				*calls = append(*calls, strings.Join(args, " "))
+				return nil, nil
+			}
+			return base.Run(args...)
+		},
+	}
+}
+
+func TestLockCommand_Lock(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		branches   []string
+		opts       LockOptions
+		wantCalls  []string
+		wantErrs   int
+		wantLock   LockInfo
+		wantErr    string
+		wantStderr string
+	}{
+		{
+			name:      "owner_defaults_to_user_name",
+			branches:  []string{"feat/a"},
+			opts:      LockOptions{Purpose: "agent run", Expires: expires},
+			wantCalls: []string{"worktree lock --reason owner=Git User; expires=2026-01-02T00:00:00Z; purpose=agent run /repo/feat/a"},
+			wantLock:  LockInfo{Owner: "Git User", Purpose: "agent run", Expires: expires},
+		},
+		{
+			name:      "explicit_owner",
+			branches:  []string{"feat/a"},
+			opts:      LockOptions{Owner: "alice"},
+			wantCalls: []string{"worktree lock --reason owner=alice /repo/feat/a"},
+			wantLock:  LockInfo{Owner: "alice"},
+		},
+		{
+			name:       "already_locked_and_unknown",
+			branches:   []string{"feat/b", "feat/a", "feat/z"},
+			opts:       LockOptions{Owner: "alice"},
+			wantCalls:  []string{"worktree lock --reason owner=alice /repo/feat/a"},
+			wantErrs:   2,
+			wantLock:   LockInfo{Owner: "alice"},
+			wantStderr: "error: feat/b: already locked, run 'twig unlock feat/b' first\nerror: feat/z: branch \"feat/z\" is not checked out in any worktree\n",
+		},
+		{
+			name:     "invalid_owner",
+			branches: []string{"feat/a"},
+			opts:     LockOptions{Owner: "a;b"},
+			wantErr:  "must not contain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			cmd := NewLockCommand(&GitRunner{Executor: newLockMock(&calls)})
+
+			result, err := cmd.Lock(tt.branches, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+			if result.ErrorCount() != tt.wantErrs {
+				t.Errorf("ErrorCount() = %d, want %d", result.ErrorCount(), tt.wantErrs)
+			}
+			for _, wt := range result.Worktrees {
+				if wt.Err == nil && wt.Lock != tt.wantLock {
+					t.Errorf("Lock = %+v, want %+v", wt.Lock, tt.wantLock)
+				}
+			}
+			if got := result.Format(FormatOptions{}).Stderr; got != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestLockCommand_Unlock(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	cmd := NewLockCommand(&GitRunner{Executor: newLockMock(&calls)})
+
+	result, err := cmd.Unlock([]string{"feat/b", "feat/a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"worktree unlock /repo/feat/b"}; !slices.Equal(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	if got := result.Worktrees[0].Lock; got != (LockInfo{Owner: "bob", Purpose: "review"}) {
+		t.Errorf("released Lock = %+v", got)
+	}
+
+	formatted := result.Format(FormatOptions{Verbose: true})
+	if formatted.Stdout != "Unlocked worktree at /repo/feat/b\ntwig unlock: feat/b\n" {
+		t.Errorf("Stdout = %q", formatted.Stdout)
+	}
+	if formatted.Stderr != "error: feat/a: not locked\n" {
+		t.Errorf("Stderr = %q", formatted.Stderr)
+	}
+}
+
+func TestLockResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := LockResult{Worktrees: []LockedWorktree{
+		{Branch: "feat/a", WorktreePath: "/repo/feat/a", Lock: LockInfo{Owner: "alice", Purpose: "agent"}},
+		{Branch: "feat/b", WorktreePath: "/repo/feat/b"},
+	}}
+
+	got := result.Format(FormatOptions{}).Stdout
+	want := "twig lock: feat/a by alice (agent)\ntwig lock: feat/b\n"
+	if got != want {
+		t.Errorf("Stdout = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,438 @@
+package twig
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// MoveCommand moves worktrees with git worktree move, keeping git's
+// metadata and the symlinks between worktrees intact.
+type MoveCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+}
+
+// MoveOptions configures the move and migrate operations.
+type MoveOptions struct {
+	// Force specifies the force level. -f -f moves locked worktrees.
+	Force  WorktreeForceLevel
+	DryRun bool
+}
+
+// NewMoveCommand creates a MoveCommand with explicit dependencies.
+func NewMoveCommand(fs FileSystem, git *GitRunner, cfg *Config) *MoveCommand {
+	return &MoveCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+	}
+}
+
+// NewDefaultMoveCommand creates a MoveCommand with production defaults.
+func NewDefaultMoveCommand(cfg *Config) *MoveCommand {
+	return NewMoveCommand(osFS{}, NewGitRunner(cfg.WorktreeSourceDir), cfg)
+}
+
+// MovedWorktree holds the result of a single worktree move.
+type MovedWorktree struct {
+	Branch      string
+	From        string
+	To          string
+	CleanedDirs []string // Empty parent directories of From that were removed
+	SkipReason  string   // Why migrate left the worktree where it is
+	Err         error    // nil if success
+}
+
+// RepointedSymlink is a symlink whose target was rewritten after a move.
+type RepointedSymlink struct {
+	Path      string // Absolute path of the symlink after the move
+	OldTarget string
+	NewTarget string
+	Err       error // nil if success
+}
+
+// MoveResult aggregates results from move and migrate operations.
+type MoveResult struct {
+	Worktrees []MovedWorktree
+	Symlinks  []RepointedSymlink
+	Warnings  []string
+	Migrate   bool
+	DryRun    bool
+}
+
+// HasErrors returns true if any errors occurred.
+func (r MoveResult) HasErrors() bool {
+	return r.ErrorCount() > 0
+}
+
+// ErrorCount returns the number of failed moves and symlink updates.
+func (r MoveResult) ErrorCount() int {
+	count := 0
+	for _, wt := range r.Worktrees {
+		if wt.Err != nil {
+			count++
+		}
+	}
+	for _, l := range r.Symlinks {
+		if l.Err != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Format formats the MoveResult for display.
+func (r MoveResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+	name := "move"
+	if r.Migrate {
+		name = "migrate"
+	}
+
+	for _, w := range r.Warnings {
+		fmt.Fprintf(&stderr, "warning: %s\n", w)
+	}
+
+	var moved, skipped int
+	for _, wt := range r.Worktrees {
+		switch {
+		case wt.SkipReason != "":
+			skipped++
+			if opts.Verbose || r.DryRun {
+				fmt.Fprintf(&stdout, "Skipped %s: %s\n", wt.Branch, wt.SkipReason)
+			}
+		case wt.Err != nil:
+			formatRemoveError(&stderr, wt.Branch, wt.Err, opts.Verbose)
+		case r.DryRun:
+			moved++
+			fmt.Fprintf(&stdout, "Would move worktree: %s -> %s\n", wt.From, wt.To)
+			for _, dir := range wt.CleanedDirs {
+				fmt.Fprintf(&stdout, "Would remove empty directory: %s\n", dir)
+			}
+		default:
+			moved++
+			if opts.Verbose {
+				fmt.Fprintf(&stdout, "Moved worktree: %s -> %s\n", wt.From, wt.To)
+				for _, dir := range wt.CleanedDirs {
+					fmt.Fprintf(&stdout, "Removed empty directory: %s\n", dir)
+				}
+			}
+		}
+	}
+
+	for _, l := range r.Symlinks {
+		switch {
+		case l.Err != nil:
+			fmt.Fprintf(&stderr, "error: failed to repoint symlink %s: %v\n", l.Path, l.Err)
+		case r.DryRun:
+			fmt.Fprintf(&stdout, "Would repoint symlink %s: %s -> %s\n", l.Path, l.OldTarget, l.NewTarget)
+		case opts.Verbose:
+			fmt.Fprintf(&stdout, "Repointed symlink %s: %s -> %s\n", l.Path, l.OldTarget, l.NewTarget)
+		}
+	}
+
+	if r.DryRun {
+		if moved == 0 {
+			fmt.Fprintf(&stdout, "twig %s: nothing to move\n", name)
+		}
+		return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	}
+
+	for _, wt := range r.Worktrees {
+		if wt.SkipReason == "" && wt.Err == nil {
+			fmt.Fprintf(&stdout, "twig %s: %s (%s -> %s)\n", name, wt.Branch, wt.From, wt.To)
+		}
+	}
+	if moved == 0 && len(r.Worktrees) == skipped {
+		fmt.Fprintf(&stdout, "twig %s: nothing to move\n", name)
+	}
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Move moves the worktree of branch to dst, an absolute path.
+// cwd is used to prevent moving the worktree containing it.
+func (c *MoveCommand) Move(branch, dst, cwd string, opts MoveOptions) (MoveResult, error) {
+	result := MoveResult{DryRun: opts.DryRun}
+
+	worktrees, err := c.Git.WorktreeList()
+	if err != nil {
+		return result, err
+	}
+	wt, err := findWorktree(worktrees, branch)
+	if err != nil {
+		return result, err
+	}
+
+	dst = filepath.Clean(dst)
+	switch {
+	case wt.Path == worktrees[0].Path:
+		return result, errors.New("cannot move the main worktree")
+	case wt.Prunable:
+		return result, fmt.Errorf("worktree directory %s does not exist", wt.Path)
+	case dst == wt.Path:
+		return result, fmt.Errorf("worktree for %s is already at %s", branch, dst)
+	case isWithin(cwd, wt.Path):
+		return result, fmt.Errorf("cannot move: current directory is inside worktree %s", wt.Path)
+	}
+	if _, err := c.FS.Stat(dst); err == nil {
+		return result, kindErrorf(ErrDirectoryExists, "destination %s already exists", dst)
+	}
+
+	result.Worktrees = []MovedWorktree{{Branch: branch, From: wt.Path, To: dst}}
+	return c.run(worktrees, result, opts)
+}
+
+// Migrate moves every worktree that is not where twig add would create it
+// today into the configured layout (worktree_destination_base_dir and
+// profiles). The main worktree, detached and prunable worktrees are left
+// alone, as are locked worktrees unless opts.Force is WorktreeForceLevelLocked.
+func (c *MoveCommand) Migrate(cwd string, opts MoveOptions) (MoveResult, error) {
+	result := MoveResult{Migrate: true, DryRun: opts.DryRun}
+
+	worktrees, err := c.Git.WorktreeList()
+	if err != nil {
+		return result, err
+	}
+
+	for i, wt := range worktrees {
+		if i == 0 || wt.Bare {
+			continue
+		}
+
+		m := MovedWorktree{Branch: wt.Branch, From: wt.Path}
+		if wt.Detached {
+			m.Branch = wt.Path
+			m.SkipReason = string(SkipDetached)
+			result.Worktrees = append(result.Worktrees, m)
+			continue
+		}
+
+		destBaseDir := c.Config.DestBaseDirFor(c.Config.ProfileFor(wt.Branch))
+		if destBaseDir == "" {
+			return result, errors.New("worktree destination base directory is not configured")
+		}
+		m.To = filepath.Join(destBaseDir, wt.Branch)
+		if m.To == wt.Path {
+			continue
+		}
+
+		switch {
+		case wt.Prunable:
+			m.SkipReason = string(SkipPrunable)
+		case isWithin(cwd, wt.Path):
+			m.SkipReason = string(SkipCurrentDir)
+		case wt.Locked && opts.Force < WorktreeForceLevelLocked:
+			m.SkipReason = string(SkipLocked)
+		default:
+			if _, err := c.FS.Stat(m.To); err == nil {
+				m.SkipReason = fmt.Sprintf("%s already exists", m.To)
+			}
+		}
+		result.Worktrees = append(result.Worktrees, m)
+	}
+
+	return c.run(worktrees, result, opts)
+}
+
+// run performs the moves in result that are not skipped: git worktree move,
+// git worktree repair, re-pointing symlinks and empty directory cleanup.
+func (c *MoveCommand) run(worktrees []Worktree, result MoveResult, opts MoveOptions) (MoveResult, error) {
+	pending := false
+	for _, m := range result.Worktrees {
+		pending = pending || m.SkipReason == ""
+	}
+	if !pending {
+		return result, nil
+	}
+
+	// Symlinks are read before moving, so that each link can be resolved
+	// from where it was created.
+	links, warnings := c.collectSymlinks(worktrees)
+	result.Warnings = warnings
+
+	// Indexes of the worktrees that were moved (or would be, with DryRun)
+	var movedIdx []int
+	for i := range result.Worktrees {
+		m := &result.Worktrees[i]
+		if m.SkipReason != "" {
+			continue
+		}
+		if !opts.DryRun {
+			if err := c.FS.MkdirAll(filepath.Dir(m.To), 0755); err != nil {
+				m.Err = fmt.Errorf("failed to create directory %s: %w", filepath.Dir(m.To), err)
+				continue
+			}
+			if _, err := c.Git.WorktreeMove(m.From, m.To, WithForceMove(opts.Force)); err != nil {
+				m.Err = err
+				continue
+			}
+			// Reported, but the worktree has moved all the same
+			if _, err := c.Git.WorktreeRepair(m.To); err != nil {
+				m.Err = err
+			}
+		}
+		movedIdx = append(movedIdx, i)
+	}
+
+	var moved []MovedWorktree
+	for _, i := range movedIdx {
+		moved = append(moved, result.Worktrees[i])
+	}
+	result.Symlinks = repointSymlinks(links, moved)
+	if !opts.DryRun {
+		for i, l := range result.Symlinks {
+			result.Symlinks[i].Err = replaceSymlink(c.FS, l.Path, l.OldTarget, l.NewTarget)
+		}
+	}
+
+	for _, i := range movedIdx {
+		m := &result.Worktrees[i]
+		baseDir := c.cleanupBaseDir(*m)
+		if !opts.DryRun {
+			m.CleanedDirs = removeEmptyParentDirs(c.FS, baseDir, m.From)
+			continue
+		}
+		m.CleanedDirs = predictEmptyParentDirs(c.FS, baseDir, m.From)
+		// Directories that will hold a moved worktree do not become empty
+		for j, dir := range m.CleanedDirs {
+			if receivesMove(moved, dir) {
+				m.CleanedDirs = m.CleanedDirs[:j]
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// receivesMove reports whether one of moves puts a worktree inside dir.
+func receivesMove(moves []MovedWorktree, dir string) bool {
+	for _, m := range moves {
+		if isWithin(m.To, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanupBaseDir returns the directory up to which empty parents of the
+// moved worktree's old location are removed. That is the configured
+// destination base directory containing it. For layouts that are no longer
+// configured, the old base directory the branch path is relative to is
+// removed as well once empty.
+func (c *MoveCommand) cleanupBaseDir(m MovedWorktree) string {
+	if dir := c.Config.destBaseDirContaining(m.From); dir != "" {
+		return dir
+	}
+	if dir, ok := strings.CutSuffix(m.From, string(filepath.Separator)+filepath.FromSlash(m.Branch)); ok {
+		return filepath.Dir(dir)
+	}
+	return ""
+}
+
+// worktreeSymlink is a symlink found in a worktree before moving.
+type worktreeSymlink struct {
+	Path   string
+	Target string
+}
+
+// collectSymlinks returns the twig-created symlinks of all worktrees that
+// exist on disk. Like relink, only links matching the configured symlink
+// patterns that point to the same path in another worktree are considered;
+// other symlinks are left alone. Worktrees whose files cannot be read are
+// skipped with a warning.
+func (c *MoveCommand) collectSymlinks(worktrees []Worktree) ([]worktreeSymlink, []string) {
+	var links []worktreeSymlink
+	var warnings []string
+	seen := make(map[string]bool)
+	for _, wt := range worktrees {
+		if wt.Bare || wt.Prunable {
+			continue
+		}
+		expanded, err := expandPatterns(c.FS, wt.Path, c.Config.Symlinks, c.Config.SymlinkExcludes)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipped symlinks of %s: %v", wt.Path, err))
+			continue
+		}
+		for _, rel := range expanded.Paths {
+			path := filepath.Join(wt.Path, filepath.FromSlash(rel))
+			// Worktrees nested in another one are matched twice
+			if seen[path] {
+				continue
+			}
+			target, err := c.FS.Readlink(path)
+			if err != nil {
+				// Not a symlink
+				continue
+			}
+			resolved := target
+			if !filepath.IsAbs(resolved) {
+				resolved = filepath.Join(filepath.Dir(path), resolved)
+			}
+			if !slices.ContainsFunc(worktrees, func(other Worktree) bool {
+				return other.Path != wt.Path && !other.Bare && resolved == filepath.Join(other.Path, filepath.FromSlash(rel))
+			}) {
+				// Not created by twig
+				continue
+			}
+			seen[path] = true
+			links = append(links, worktreeSymlink{Path: path, Target: target})
+		}
+	}
+	return links, warnings
+}
+
+// repointSymlinks returns the symlinks that no longer resolve to the same
+// file after moves, with targets that do. Absolute targets inside a moved
+// worktree are rewritten to its new location, relative targets are
+// recomputed from the symlink's new location. Links that stay valid, such
+// as relative links within a moved worktree, are left alone.
+func repointSymlinks(links []worktreeSymlink, moves []MovedWorktree) []RepointedSymlink {
+	translate := func(path string) string {
+		for _, m := range moves {
+			if path == m.From {
+				return m.To
+			}
+			if rest, ok := strings.CutPrefix(path, m.From+string(filepath.Separator)); ok {
+				return filepath.Join(m.To, rest)
+			}
+		}
+		return path
+	}
+
+	var repointed []RepointedSymlink
+	for _, l := range links {
+		resolved := l.Target
+		if !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(filepath.Dir(l.Path), resolved)
+		}
+		newPath, newResolved := translate(l.Path), translate(resolved)
+		if newPath == l.Path && newResolved == resolved {
+			continue
+		}
+
+		newTarget := newResolved
+		if !filepath.IsAbs(l.Target) {
+			rel, err := filepath.Rel(filepath.Dir(newPath), newResolved)
+			if err != nil {
+				continue
+			}
+			newTarget = rel
+		}
+		if newTarget == l.Target || newTarget == filepath.Clean(l.Target) {
+			continue
+		}
+		repointed = append(repointed, RepointedSymlink{Path: newPath, OldTarget: l.Target, NewTarget: newTarget})
+	}
+	return repointed
+}
+
+// isWithin reports whether path is dir or inside it.
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,151 @@
+//go:build integration
+
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestMoveCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	// setup adds feat/a and feat/b with a relative .envrc symlink each,
+	// and absolute data and notes symlinks in feat/b pointing into feat/a.
+	// Only data matches the configured symlink patterns.
+	setup := func(t *testing.T) (*Config, string) {
+		t.Helper()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t,
+			testutil.Symlinks(".envrc"), testutil.SymlinkStyle(string(SymlinkStyleRelative)))
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("secret\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, branch := range []string{"feat/a", "feat/b"} {
+			if _, err := NewDefaultAddCommand(result.Config, AddOptions{}).Run(branch); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(repoDir, "feat", "a", "data"), []byte("data\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"data", "notes"} {
+			if err := os.Symlink(filepath.Join(repoDir, "feat", "a", "data"), filepath.Join(repoDir, "feat", "b", name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result.Config.Symlinks = append(result.Config.Symlinks, "data")
+		return result.Config, repoDir
+	}
+
+	assertContent := func(t *testing.T, path, want string) {
+		t.Helper()
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("failed to read %s: %v", path, err)
+			return
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+
+	t.Run("Move", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, repoDir := setup(t)
+		dst := filepath.Join(repoDir, "elsewhere", "a")
+
+		result, err := NewDefaultMoveCommand(cfg).Move("feat/a", dst, cfg.WorktreeSourceDir, MoveOptions{})
+		if err != nil || result.HasErrors() {
+			t.Fatalf("move failed: %v %+v", err, result)
+		}
+
+		porcelain := testutil.RunGit(t, cfg.WorktreeSourceDir, "worktree", "list", "--porcelain")
+		if !strings.Contains(porcelain, "worktree "+dst+"\n") {
+			t.Errorf("git should know the new location:\n%s", porcelain)
+		}
+		if branch := strings.TrimSpace(testutil.RunGit(t, dst, "branch", "--show-current")); branch != "feat/a" {
+			t.Errorf("branch at new location = %q", branch)
+		}
+		assertContent(t, filepath.Join(dst, ".envrc"), "secret\n")
+		assertContent(t, filepath.Join(repoDir, "feat", "b", "data"), "data\n")
+		if target, _ := os.Readlink(filepath.Join(repoDir, "feat", "b", "data")); target != filepath.Join(dst, "data") {
+			t.Errorf("feat/b/data -> %q, want %q", target, filepath.Join(dst, "data"))
+		}
+		// Symlinks outside the configured patterns are not twig's to rewrite
+		if target, _ := os.Readlink(filepath.Join(repoDir, "feat", "b", "notes")); target != filepath.Join(repoDir, "feat", "a", "data") {
+			t.Errorf("feat/b/notes -> %q, want it unchanged", target)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, repoDir := setup(t)
+		dst := filepath.Join(repoDir, "elsewhere", "a")
+
+		result, err := NewDefaultMoveCommand(cfg).Move("feat/a", dst, cfg.WorktreeSourceDir, MoveOptions{DryRun: true})
+		if err != nil || result.HasErrors() {
+			t.Fatalf("move failed: %v %+v", err, result)
+		}
+		// .envrc stays valid at the same depth; only feat/b/data is repointed
+		if len(result.Symlinks) != 1 || result.Symlinks[0].NewTarget != filepath.Join(dst, "data") {
+			t.Errorf("Symlinks = %+v, want feat/b/data repointed", result.Symlinks)
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "feat", "a")); err != nil {
+			t.Error("dry run should not move the worktree")
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, "elsewhere")); !os.IsNotExist(err) {
+			t.Error("dry run should not create directories")
+		}
+	})
+
+	t.Run("Migrate", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, repoDir := setup(t)
+		testutil.RunGit(t, cfg.WorktreeSourceDir, "worktree", "lock", filepath.Join(repoDir, "feat", "b"))
+		cfg.WorktreeDestBaseDir = filepath.Join(repoDir, "worktrees")
+		cmd := NewDefaultMoveCommand(cfg)
+
+		result, err := cmd.Migrate(cfg.WorktreeSourceDir, MoveOptions{})
+		if err != nil || result.HasErrors() {
+			t.Fatalf("migrate failed: %v %+v", err, result)
+		}
+		if len(result.Worktrees) != 2 || result.Worktrees[1].SkipReason != string(SkipLocked) {
+			t.Fatalf("Worktrees = %+v, want feat/b skipped as locked", result.Worktrees)
+		}
+		newA := filepath.Join(cfg.WorktreeDestBaseDir, "feat", "a")
+		assertContent(t, filepath.Join(newA, ".envrc"), "secret\n")
+		assertContent(t, filepath.Join(repoDir, "feat", "b", "data"), "data\n")
+
+		// Locked worktrees move with -ff, after which the old layout is gone
+		result, err = cmd.Migrate(cfg.WorktreeSourceDir, MoveOptions{Force: WorktreeForceLevelLocked})
+		if err != nil || result.HasErrors() {
+			t.Fatalf("migrate failed: %v %+v", err, result)
+		}
+		newB := filepath.Join(cfg.WorktreeDestBaseDir, "feat", "b")
+		assertContent(t, filepath.Join(newB, ".envrc"), "secret\n")
+		assertContent(t, filepath.Join(newB, "data"), "data\n")
+		if _, err := os.Stat(filepath.Join(repoDir, "feat")); !os.IsNotExist(err) {
+			t.Error("empty directories of the old layout should be removed")
+		}
+		if out := testutil.RunGit(t, cfg.WorktreeSourceDir, "worktree", "list", "--porcelain"); !strings.Contains(out, "locked") {
+			t.Errorf("feat/b should stay locked:\n%s", out)
+		}
+
+		result, err = cmd.Migrate(cfg.WorktreeSourceDir, MoveOptions{})
+		if err != nil || len(result.Worktrees) != 0 {
+			t.Errorf("second migrate = %+v, %v, want nothing to move", result, err)
+		}
+	})
+}
@@ -0,0 +1,524 @@
+package twig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRepointSymlinks(t *testing.T) {
+	t.Parallel()
+
+	moves := []MovedWorktree{{Branch: "feat/a", From: "/repo/old/feat/a", To: "/repo/new/feat/a"}}
+
+	tests := []struct {
+		name string
+		link worktreeSymlink
+		want []RepointedSymlink
+	}{
+		{
+			name: "absolute_into_main_stays",
+			link: worktreeSymlink{Path: "/repo/old/feat/a/.envrc", Target: "/repo/main/.envrc"},
+		},
+		{
+			name: "relative_out_of_moved_worktree_same_depth",
+			link: worktreeSymlink{Path: "/repo/old/feat/a/.envrc", Target: "../../../main/.envrc"},
+		},
+		{
+			name: "relative_within_moved_worktree",
+			link: worktreeSymlink{Path: "/repo/old/feat/a/config/app.yml", Target: "../app.yml"},
+		},
+		{
+			name: "absolute_within_moved_worktree",
+			link: worktreeSymlink{Path: "/repo/old/feat/a/app.yml", Target: "/repo/old/feat/a/config/app.yml"},
+			want: []RepointedSymlink{{
+				Path: "/repo/new/feat/a/app.yml", OldTarget: "/repo/old/feat/a/config/app.yml",
+				NewTarget: "/repo/new/feat/a/config/app.yml",
+			}},
+		},
+		{
+			name: "absolute_from_other_worktree",
+			link: worktreeSymlink{Path: "/repo/old/feat/b/data", Target: "/repo/old/feat/a/data"},
+			want: []RepointedSymlink{{
+				Path: "/repo/old/feat/b/data", OldTarget: "/repo/old/feat/a/data",
+				NewTarget: "/repo/new/feat/a/data",
+			}},
+		},
+		{
+			name: "relative_from_other_worktree",
+			link: worktreeSymlink{Path: "/repo/old/feat/b/data", Target: "../a/data"},
+			want: []RepointedSymlink{{
+				Path: "/repo/old/feat/b/data", OldTarget: "../a/data",
+				NewTarget: "../../../new/feat/a/data",
+			}},
+		},
+		{
+			name: "unrelated",
+			link: worktreeSymlink{Path: "/repo/old/feat/b/.envrc", Target: "../../../main/.envrc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := repointSymlinks([]worktreeSymlink{tt.link}, moves)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("repointSymlinks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newMoveMock returns a mock with a main worktree, feat/a under /repo/old,
+// a locked feat/b under /repo/locked and worktrees migrate skips, recording
+// worktree move and repair calls.
+func newMoveMock(calls *[]string, moveErr error) *testutil.MockGitExecutor {
+	base := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/old/feat/a", Branch: "feat/a"},
+			{Path: "/repo/locked/feat/b", Branch: "feat/b", Locked: true},
+			{Path: "/repo/other/gone", Branch: "gone", Prunable: true},
+			{Path: "/repo/other/detached", HEAD: "abc1234", Detached: true},
+		},
+	}
+	return &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			if args[0] == "-C" {
+				args = args[2:]
+			}
+			if args[0] == "worktree" && (args[1] == "move" || args[1] == "repair") {
+				*calls = append(*calls, strings.Join(args, " "))
+				if args[1] == "move" && moveErr != nil {
+					return nil, moveErr
+				}
+				return nil, nil
+			}
+			return base.Run(args...)
+		},
+	}
+}
+
+func TestMoveCommand_Move(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		branch        string
+		dst           string
+		cwd           string
+		opts          MoveOptions
+		existing      []string
+		moveErr       error
+		wantCalls     []string
+		wantSymlinks  []string // "path -> new target"
+		wantCleaned   []string
+		wantErr       string
+		wantResultErr bool
+	}{
+		{
+			name:   "success",
+			branch: "feat/a",
+			dst:    "/repo/new/a",
+			cwd:    "/repo/main",
+			wantCalls: []string{
+				"worktree move /repo/old/feat/a /repo/new/a",
+				"worktree repair /repo/new/a",
+			},
+			wantSymlinks: []string{"/repo/locked/feat/b/data -> /repo/new/a/data"},
+			wantCleaned:  []string{"/repo/old/feat", "/repo/old"},
+		},
+		{
+			name:         "dry_run",
+			branch:       "feat/a",
+			dst:          "/repo/new/a",
+			cwd:          "/repo/main",
+			opts:         MoveOptions{DryRun: true},
+			wantSymlinks: []string{"/repo/locked/feat/b/data -> /repo/new/a/data"},
+			wantCleaned:  []string{"/repo/old/feat", "/repo/old"},
+		},
+		{
+			name:   "locked_with_force",
+			branch: "feat/b",
+			dst:    "/repo/new/b",
+			cwd:    "/repo/main",
+			opts:   MoveOptions{Force: WorktreeForceLevelLocked},
+			wantCalls: []string{
+				"worktree move -f -f /repo/locked/feat/b /repo/new/b",
+				"worktree repair /repo/new/b",
+			},
+			wantCleaned: []string{"/repo/locked/feat", "/repo/locked"},
+		},
+		{
+			name:          "git_error",
+			branch:        "feat/b",
+			dst:           "/repo/new/b",
+			cwd:           "/repo/main",
+			moveErr:       errors.New("fatal: cannot move a locked working tree"),
+			wantCalls:     []string{"worktree move /repo/locked/feat/b /repo/new/b"},
+			wantResultErr: true,
+		},
+		{
+			name:    "main_worktree",
+			branch:  "main",
+			dst:     "/repo/new/main",
+			wantErr: "cannot move the main worktree",
+		},
+		{
+			name:    "unknown_branch",
+			branch:  "feat/z",
+			dst:     "/repo/new/z",
+			wantErr: `branch "feat/z" is not checked out`,
+		},
+		{
+			name:    "prunable",
+			branch:  "gone",
+			dst:     "/repo/new/gone",
+			wantErr: "does not exist",
+		},
+		{
+			name:    "same_path",
+			branch:  "feat/a",
+			dst:     "/repo/old/feat/a/",
+			wantErr: "already at /repo/old/feat/a",
+		},
+		{
+			name:    "cwd_inside",
+			branch:  "feat/a",
+			dst:     "/repo/new/a",
+			cwd:     "/repo/old/feat/a/sub",
+			wantErr: "current directory is inside",
+		},
+		{
+			name:     "destination_exists",
+			branch:   "feat/a",
+			dst:      "/repo/new/a",
+			existing: []string{"/repo/new/a"},
+			wantErr:  "destination /repo/new/a already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls, replaced, removed []string
+			dirContents := map[string][]os.DirEntry{
+				"/repo/old":           {mockDirEntry{name: "feat", isDir: true}},
+				"/repo/old/feat":      {mockDirEntry{name: "a", isDir: true}},
+				"/repo/locked":        {mockDirEntry{name: "feat", isDir: true}},
+				"/repo/locked/feat":   {mockDirEntry{name: "b", isDir: true}},
+				"/repo/locked/feat/b": {mockDirEntry{name: "data", symlink: true}},
+			}
+			mockFS := &testutil.MockFS{
+				ExistingPaths: tt.existing,
+				// Directories reflect the moves and removals made so far
+				ReadDirFunc: func(name string) ([]os.DirEntry, error) {
+					var entries []os.DirEntry
+					for _, e := range dirContents[name] {
+						path := filepath.Join(name, e.Name())
+						if !slices.Contains(removed, path) && !slices.ContainsFunc(calls, func(c string) bool { return strings.HasSuffix(c, " "+path+" "+tt.dst) }) {
+							entries = append(entries, e)
+						}
+					}
+					return entries, nil
+				},
+				RemoveFunc: func(name string) error {
+					removed = append(removed, name)
+					return nil
+				},
+				GlobResults: map[string][]string{"data": {"data"}},
+				LinkTargets: map[string]string{"/repo/locked/feat/b/data": "/repo/old/feat/a/data"},
+				SymlinkFunc: func(oldname, newname string) error {
+					replaced = append(replaced, newname+" -> "+oldname)
+					return nil
+				},
+			}
+			cmd := NewMoveCommand(mockFS, &GitRunner{Executor: newMoveMock(&calls, tt.moveErr)}, &Config{Symlinks: []string{"data"}})
+
+			result, err := cmd.Move(tt.branch, tt.dst, tt.cwd, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(calls) > 0 {
+					t.Errorf("expected no git changes, got %v", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+			if result.HasErrors() != tt.wantResultErr {
+				t.Fatalf("HasErrors() = %v, want %v (%+v)", result.HasErrors(), tt.wantResultErr, result)
+			}
+			if tt.wantResultErr {
+				return
+			}
+
+			var symlinks []string
+			for _, l := range result.Symlinks {
+				symlinks = append(symlinks, l.Path+" -> "+l.NewTarget)
+			}
+			if !slices.Equal(symlinks, tt.wantSymlinks) {
+				t.Errorf("Symlinks = %q, want %q", symlinks, tt.wantSymlinks)
+			}
+			if tt.opts.DryRun {
+				if len(replaced) > 0 {
+					t.Errorf("dry run replaced symlinks: %v", replaced)
+				}
+			} else if !slices.Equal(replaced, tt.wantSymlinks) {
+				t.Errorf("replaced = %q, want %q", replaced, tt.wantSymlinks)
+			}
+			if got := result.Worktrees[0].CleanedDirs; !slices.Equal(got, tt.wantCleaned) {
+				t.Errorf("CleanedDirs = %q, want %q", got, tt.wantCleaned)
+			}
+		})
+	}
+}
+
+func TestMoveCommand_CollectSymlinks(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []Worktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/feat/a", Branch: "feat/a"},
+		{Path: "/repo/feat/b", Branch: "feat/b"},
+	}
+	mockFS := &testutil.MockFS{
+		GlobFunc: func(dir, pattern string) ([]string, error) {
+			if dir == "/repo/feat/b" {
+				return nil, errors.New("permission denied")
+			}
+			return map[string][]string{
+				".envrc":  {".envrc"},
+				"build/*": {"build/out", "build/cache"},
+			}[pattern], nil
+		},
+		LinkTargets: map[string]string{
+			// Created by twig add
+			"/repo/feat/a/.envrc": "../../main/.envrc",
+			// Matches a pattern, but points elsewhere in the worktree
+			"/repo/feat/a/build/out": "/repo/feat/a/dist/out",
+			// Does not match any pattern
+			"/repo/feat/a/node_modules/.bin/tool": "../tool/cli.js",
+		},
+	}
+	cmd := NewMoveCommand(mockFS, &GitRunner{Executor: &testutil.MockGitExecutor{}}, &Config{
+		Symlinks: []string{".envrc", "build/*"},
+	})
+
+	links, warnings := cmd.collectSymlinks(worktrees)
+
+	want := []worktreeSymlink{{Path: "/repo/feat/a/.envrc", Target: "../../main/.envrc"}}
+	if !slices.Equal(links, want) {
+		t.Errorf("links = %+v, want %+v", links, want)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "/repo/feat/b: invalid glob pattern") {
+		t.Errorf("warnings = %q", warnings)
+	}
+
+	got := MoveResult{Warnings: warnings}.Format(FormatOptions{}).Stderr
+	if !strings.HasPrefix(got, "warning: skipped symlinks of /repo/feat/b: ") {
+		t.Errorf("Stderr = %q", got)
+	}
+}
+
+func TestMoveCommand_Migrate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		config      *Config
+		cwd         string
+		opts        MoveOptions
+		existing    []string
+		wantCalls   []string
+		wantSkipped map[string]string
+		wantErr     string
+	}{
+		{
+			name:   "moves_into_configured_layout",
+			config: &Config{WorktreeDestBaseDir: "/repo/new"},
+			cwd:    "/repo/main",
+			wantCalls: []string{
+				"worktree move /repo/old/feat/a /repo/new/feat/a",
+				"worktree repair /repo/new/feat/a",
+			},
+			wantSkipped: map[string]string{
+				"feat/b":               string(SkipLocked),
+				"gone":                 "prunable",
+				"/repo/other/detached": string(SkipDetached),
+			},
+		},
+		{
+			name: "profile_base_dir_and_force",
+			config: &Config{
+				WorktreeDestBaseDir: "/repo/new",
+				Profiles:            []Profile{{Name: "b", Match: "feat/b", WorktreeDestBaseDir: "/repo/b"}},
+			},
+			cwd:  "/repo/main",
+			opts: MoveOptions{Force: WorktreeForceLevelLocked},
+			wantCalls: []string{
+				"worktree move -f -f /repo/old/feat/a /repo/new/feat/a",
+				"worktree repair /repo/new/feat/a",
+				"worktree move -f -f /repo/locked/feat/b /repo/b/feat/b",
+				"worktree repair /repo/b/feat/b",
+			},
+			wantSkipped: map[string]string{
+				"gone":                 "prunable",
+				"/repo/other/detached": string(SkipDetached),
+			},
+		},
+		{
+			name:     "current_dir_and_existing_destination",
+			config:   &Config{WorktreeDestBaseDir: "/repo/new"},
+			cwd:      "/repo/locked/feat/b",
+			opts:     MoveOptions{Force: WorktreeForceLevelLocked},
+			existing: []string{"/repo/new/feat/a"},
+			wantSkipped: map[string]string{
+				"feat/a":               "/repo/new/feat/a already exists",
+				"feat/b":               string(SkipCurrentDir),
+				"gone":                 "prunable",
+				"/repo/other/detached": string(SkipDetached),
+			},
+		},
+		{
+			name:   "already_in_layout",
+			config: &Config{WorktreeDestBaseDir: "/repo/old"},
+			cwd:    "/repo/main",
+			wantSkipped: map[string]string{
+				"feat/b":               string(SkipLocked),
+				"gone":                 "prunable",
+				"/repo/other/detached": string(SkipDetached),
+			},
+		},
+		{
+			name:    "not_configured",
+			config:  &Config{},
+			wantErr: "not configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			mockFS := &testutil.MockFS{ExistingPaths: tt.existing}
+			cmd := NewMoveCommand(mockFS, &GitRunner{Executor: newMoveMock(&calls, nil)}, tt.config)
+
+			result, err := cmd.Migrate(tt.cwd, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+			skipped := make(map[string]string)
+			for _, wt := range result.Worktrees {
+				if wt.SkipReason != "" {
+					skipped[wt.Branch] = wt.SkipReason
+				}
+			}
+			if len(skipped) != len(tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+			for branch, reason := range tt.wantSkipped {
+				if skipped[branch] != reason {
+					t.Errorf("skipped[%s] = %q, want %q", branch, skipped[branch], reason)
+				}
+			}
+		})
+	}
+}
+
+func TestMoveResult_Format(t *testing.T) {
+	t.Parallel()
+
+	moved := MovedWorktree{Branch: "feat/a", From: "/old/feat/a", To: "/new/feat/a", CleanedDirs: []string{"/old/feat"}}
+	skipped := MovedWorktree{Branch: "feat/b", From: "/old/feat/b", SkipReason: string(SkipLocked)}
+	link := RepointedSymlink{Path: "/old/feat/c/data", OldTarget: "/old/feat/a/data", NewTarget: "/new/feat/a/data"}
+
+	tests := []struct {
+		name       string
+		result     MoveResult
+		opts       FormatOptions
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "move",
+			result:     MoveResult{Worktrees: []MovedWorktree{moved}, Symlinks: []RepointedSymlink{link}},
+			wantStdout: "twig move: feat/a (/old/feat/a -> /new/feat/a)\n",
+		},
+		{
+			name:   "migrate_verbose",
+			result: MoveResult{Migrate: true, Worktrees: []MovedWorktree{moved, skipped}, Symlinks: []RepointedSymlink{link}},
+			opts:   FormatOptions{Verbose: true},
+			wantStdout: "Moved worktree: /old/feat/a -> /new/feat/a\n" +
+				"Removed empty directory: /old/feat\n" +
+				"Skipped feat/b: locked\n" +
+				"Repointed symlink /old/feat/c/data: /old/feat/a/data -> /new/feat/a/data\n" +
+				"twig migrate: feat/a (/old/feat/a -> /new/feat/a)\n",
+		},
+		{
+			name:   "dry_run",
+			result: MoveResult{Migrate: true, DryRun: true, Worktrees: []MovedWorktree{moved, skipped}, Symlinks: []RepointedSymlink{link}},
+			wantStdout: "Would move worktree: /old/feat/a -> /new/feat/a\n" +
+				"Would remove empty directory: /old/feat\n" +
+				"Skipped feat/b: locked\n" +
+				"Would repoint symlink /old/feat/c/data: /old/feat/a/data -> /new/feat/a/data\n",
+		},
+		{
+			name:       "nothing_to_move",
+			result:     MoveResult{Migrate: true, Worktrees: []MovedWorktree{skipped}},
+			wantStdout: "twig migrate: nothing to move\n",
+		},
+		{
+			name:       "nothing_to_move_empty",
+			result:     MoveResult{Migrate: true},
+			wantStdout: "twig migrate: nothing to move\n",
+		},
+		{
+			name: "symlink_error",
+			result: MoveResult{
+				Worktrees: []MovedWorktree{moved},
+				Symlinks:  []RepointedSymlink{{Path: "/old/feat/c/data", Err: errors.New("permission denied")}},
+			},
+			wantStdout: "twig move: feat/a (/old/feat/a -> /new/feat/a)\n",
+			wantStderr: "error: failed to repoint symlink /old/feat/c/data: permission denied\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(tt.opts)
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}
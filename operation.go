@@ -0,0 +1,145 @@
+package twig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Stash operation modes.
+const (
+	// OperationCarry moves changes: the stash is dropped once applied.
+	OperationCarry = "carry"
+	// OperationSync copies changes: the source worktree keeps them and the
+	// stash is dropped once applied.
+	OperationSync = "sync"
+)
+
+// operationsDir is the directory under the git common dir that holds
+// markers of in-progress carry and sync operations.
+const operationsDir = "twig/operations"
+
+// StashOperation is the marker of an in-progress carry or sync.
+// It is written right after changes are stashed and removed once the stash
+// is no longer needed, so a leftover marker means twig was interrupted.
+type StashOperation struct {
+	Mode        string     `json:"mode"`
+	Scope       StashScope `json:"scope,omitempty"`
+	Hash        string     `json:"hash"`
+	Source      string     `json:"source"`      // Worktree the changes were taken from
+	Destination string     `json:"destination"` // Worktree the changes are applied to
+	Branch      string     `json:"branch"`
+	PID         int        `json:"pid"`
+	StartedAt   time.Time  `json:"started_at"`
+}
+
+// Running reports whether the process that started the operation is still
+// alive, in which case the operation is not interrupted but in progress.
+func (op StashOperation) Running() bool {
+	return op.PID > 0 && processAlive(op.PID)
+}
+
+// newStashOperation creates a marker for the current process.
+func newStashOperation(mode string, scope StashScope, hash, source, destination, branch string) StashOperation {
+	return StashOperation{
+		Mode:        mode,
+		Scope:       scope,
+		Hash:        hash,
+		Source:      source,
+		Destination: destination,
+		Branch:      branch,
+		PID:         os.Getpid(),
+		StartedAt:   time.Now(),
+	}
+}
+
+// operationStore persists StashOperation markers as JSON files.
+type operationStore struct {
+	FS  FileSystem
+	Dir string
+}
+
+// newOperationStore returns the store of the repository git belongs to.
+// Markers live in the common git dir, so they are shared by all worktrees.
+func newOperationStore(fs FileSystem, git *GitRunner) (*operationStore, error) {
+	commonDir, err := git.CommonDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate git directory: %w", err)
+	}
+	return &operationStore{FS: fs, Dir: filepath.Join(commonDir, operationsDir)}, nil
+}
+
+func (s *operationStore) path(hash string) string {
+	return filepath.Join(s.Dir, hash+".json")
+}
+
+// Save writes the marker for op.
+func (s *operationStore) Save(op StashOperation) error {
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := s.FS.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	return s.FS.WriteFile(s.path(op.Hash), append(data, '\n'), 0644)
+}
+
+// Remove deletes the marker for hash. A missing marker is not an error.
+func (s *operationStore) Remove(hash string) error {
+	if err := s.FS.Remove(s.path(hash)); err != nil && !s.FS.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List returns all markers, oldest first.
+func (s *operationStore) List() ([]StashOperation, error) {
+	entries, err := s.FS.ReadDir(s.Dir)
+	if err != nil {
+		if s.FS.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ops []StashOperation
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := s.FS.ReadFile(filepath.Join(s.Dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var op StashOperation
+		if err := json.Unmarshal(data, &op); err != nil {
+			return nil, fmt.Errorf("invalid operation marker %s: %w", e.Name(), err)
+		}
+		ops = append(ops, op)
+	}
+	slices.SortFunc(ops, func(a, b StashOperation) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return ops, nil
+}
+
+// rollbackStash undoes the stash of op after a failed operation: carried
+// changes are put back into the source worktree, while a sync left the
+// source untouched and only needs its stash dropped. The marker is kept if
+// the changes could not be restored, so that twig recover can find them.
+func rollbackStash(source *GitRunner, store *operationStore, op StashOperation) {
+	if op.Mode == OperationCarry {
+		if _, err := source.StashRestore(op.Hash, op.Scope); err != nil {
+			return
+		}
+	} else {
+		_, _ = source.StashDropByHash(op.Hash)
+	}
+	if store != nil {
+		_ = store.Remove(op.Hash)
+	}
+}
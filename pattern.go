@@ -0,0 +1,245 @@
+package twig
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// negatePrefix marks a pattern entry that excludes earlier matches.
+const negatePrefix = "!"
+
+// globRule is a single entry of an ordered pattern list.
+type globRule struct {
+	pattern string
+	negate  bool
+}
+
+// patternSet evaluates an ordered list of glob patterns like .gitignore:
+// the last rule matching a path decides whether it is included, and
+// "!pattern" entries exclude paths matched by earlier entries.
+// excludes are always applied, regardless of order.
+// A path is also excluded when any of its parent directories is excluded.
+type patternSet struct {
+	rules    []globRule
+	excludes []string
+}
+
+func newPatternSet(patterns, excludes []string) patternSet {
+	var s patternSet
+	for _, p := range patterns {
+		if rest, ok := strings.CutPrefix(p, negatePrefix); ok {
+			s.rules = append(s.rules, globRule{pattern: rest, negate: true})
+		} else {
+			s.rules = append(s.rules, globRule{pattern: p})
+		}
+	}
+	s.excludes = excludes
+	return s
+}
+
+// hasExclusions reports whether any pattern can exclude paths.
+func (s patternSet) hasExclusions() bool {
+	if len(s.excludes) > 0 {
+		return true
+	}
+	for _, r := range s.rules {
+		if r.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// decision returns 1 if the last matching rule includes p, -1 if p is
+// excluded by a rule or an exclude pattern, and 0 if nothing matches.
+func (s patternSet) decision(p string) int {
+	for _, e := range s.excludes {
+		if ok, _ := doublestar.Match(e, p); ok {
+			return -1
+		}
+	}
+	d := 0
+	for _, r := range s.rules {
+		if ok, _ := doublestar.Match(r.pattern, p); ok {
+			if r.negate {
+				d = -1
+			} else {
+				d = 1
+			}
+		}
+	}
+	return d
+}
+
+// excluded reports whether p or any of its parent directories is excluded.
+func (s patternSet) excluded(p string) bool {
+	for q := p; q != "." && q != "/" && q != ""; q = path.Dir(q) {
+		if s.decision(q) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// patternExpansion is the result of expanding a patternSet against a directory.
+type patternExpansion struct {
+	// Paths are the included paths relative to the directory, in pattern order.
+	// A directory appears only if nothing beneath it is excluded; otherwise it
+	// is replaced by its included children.
+	Paths []string
+	// Unmatched lists positive patterns that matched no files.
+	Unmatched []string
+}
+
+// expandPatterns expands patterns against dir using fsys.
+func expandPatterns(fsys FileSystem, dir string, patterns, excludes []string) (patternExpansion, error) {
+	var result patternExpansion
+	s := newPatternSet(patterns, excludes)
+
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, r := range s.rules {
+		if r.negate {
+			if !doublestar.ValidatePattern(r.pattern) {
+				return result, fmt.Errorf("invalid glob pattern %s%s", negatePrefix, r.pattern)
+			}
+			continue
+		}
+		matches, err := fsys.Glob(dir, r.pattern)
+		if err != nil {
+			return result, fmt.Errorf("invalid glob pattern %s: %w", r.pattern, err)
+		}
+		if len(matches) == 0 {
+			result.Unmatched = append(result.Unmatched, r.pattern)
+			continue
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				candidates = append(candidates, m)
+			}
+		}
+	}
+
+	// Collect existing excluded paths so that directories containing them
+	// are expanded instead of being used as a whole.
+	var excludedPaths []string
+	if s.hasExclusions() {
+		var negPatterns []string
+		for _, r := range s.rules {
+			if r.negate {
+				negPatterns = append(negPatterns, r.pattern)
+			}
+		}
+		for _, p := range append(negPatterns, s.excludes...) {
+			matches, err := fsys.Glob(dir, p)
+			if err != nil {
+				return result, fmt.Errorf("invalid glob pattern %s: %w", p, err)
+			}
+			for _, m := range matches {
+				if s.excluded(m) {
+					excludedPaths = append(excludedPaths, m)
+				}
+			}
+		}
+	}
+	hasExcludedBelow := func(p string) bool {
+		for _, e := range excludedPaths {
+			if strings.HasPrefix(e, p+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Paths beneath an emitted path are already covered by it.
+	emitted := make(map[string]bool)
+	covered := func(p string) bool {
+		for q := p; q != "." && q != "/" && q != ""; q = path.Dir(q) {
+			if emitted[q] {
+				return true
+			}
+		}
+		return false
+	}
+	expanded := make(map[string]bool)
+	var emit func(p string) error
+	emit = func(p string) error {
+		if expanded[p] || covered(p) || s.excluded(p) {
+			return nil
+		}
+		if !hasExcludedBelow(p) {
+			emitted[p] = true
+			result.Paths = append(result.Paths, p)
+			return nil
+		}
+		expanded[p] = true
+		entries, err := fsys.ReadDir(filepath.Join(dir, p))
+		if err != nil {
+			return fmt.Errorf("failed to read directory %s: %w", p, err)
+		}
+		for _, e := range entries {
+			if err := emit(path.Join(p, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, c := range candidates {
+		if s.decision(c) <= 0 {
+			continue
+		}
+		if err := emit(c); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// matchPaths matches patterns against a list of paths, such as the changed
+// files of a worktree, instead of the files on disk. This way deleted files
+// and the old side of renames can be matched too. A pattern matching a
+// directory matches the paths beneath it.
+func matchPaths(paths, patterns []string) (patternExpansion, error) {
+	var result patternExpansion
+	s := newPatternSet(patterns, nil)
+	for _, r := range s.rules {
+		if !doublestar.ValidatePattern(r.pattern) {
+			if r.negate {
+				return result, fmt.Errorf("invalid glob pattern %s%s", negatePrefix, r.pattern)
+			}
+			return result, fmt.Errorf("invalid glob pattern %s", r.pattern)
+		}
+	}
+
+	matched := make([]bool, len(s.rules))
+	for _, p := range paths {
+		included := false
+		for q := p; q != "." && q != "/" && q != ""; q = path.Dir(q) {
+			for i, r := range s.rules {
+				if ok, _ := doublestar.Match(r.pattern, q); ok && !r.negate {
+					matched[i] = true
+				}
+			}
+			if s.decision(q) > 0 {
+				included = true
+			}
+		}
+		if included && !s.excluded(p) {
+			result.Paths = append(result.Paths, p)
+		}
+	}
+
+	for i, r := range s.rules {
+		if !r.negate && !matched[i] {
+			result.Unmatched = append(result.Unmatched, r.pattern)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,172 @@
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExpandPatterns(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, f := range []string{
+		".envrc",
+		".claude/settings.json",
+		".claude/commands/review.md",
+		".claude/cache/a.bin",
+		".claude/cache/b.bin",
+		"config/app.local.yml",
+		"config/db.local.yml",
+		"config/app.yml",
+	} {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		patterns      []string
+		excludes      []string
+		wantPaths     []string
+		wantUnmatched []string
+		wantErr       bool
+	}{
+		{
+			name:      "directory without exclusions is kept whole",
+			patterns:  []string{".claude"},
+			wantPaths: []string{".claude"},
+		},
+		{
+			name:      "negation expands directory around excluded subtree",
+			patterns:  []string{".claude/**", "!.claude/cache/**"},
+			wantPaths: []string{".claude/commands", ".claude/settings.json"},
+		},
+		{
+			name:      "negation of a single file",
+			patterns:  []string{"config/*.local.*", "!config/db.local.yml"},
+			wantPaths: []string{"config/app.local.yml"},
+		},
+		{
+			name:      "later pattern re-includes negated path",
+			patterns:  []string{"config/*.local.*", "!config/*", "config/db.local.yml"},
+			wantPaths: []string{"config/db.local.yml"},
+		},
+		{
+			name:      "excludes apply regardless of order",
+			patterns:  []string{".envrc", ".claude"},
+			excludes:  []string{".claude/cache"},
+			wantPaths: []string{".envrc", ".claude/commands", ".claude/settings.json"},
+		},
+		{
+			name:          "unmatched positive pattern is reported",
+			patterns:      []string{".envrc", ".missing"},
+			wantPaths:     []string{".envrc"},
+			wantUnmatched: []string{".missing"},
+		},
+		{
+			name:     "invalid negated pattern",
+			patterns: []string{".envrc", "![invalid"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := expandPatterns(osFS{}, dir, tt.patterns, tt.excludes)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got.Paths, tt.wantPaths) {
+				t.Errorf("Paths = %v, want %v", got.Paths, tt.wantPaths)
+			}
+			if !slices.Equal(got.Unmatched, tt.wantUnmatched) {
+				t.Errorf("Unmatched = %v, want %v", got.Unmatched, tt.wantUnmatched)
+			}
+		})
+	}
+}
+
+func TestMatchPaths(t *testing.T) {
+	t.Parallel()
+
+	// Changed files, including ones that no longer exist on disk
+	paths := []string{"api/handler.go", "api/old.go", "api/handler_test.go", "cmd/main.go", "README.md"}
+
+	tests := []struct {
+		name          string
+		patterns      []string
+		wantPaths     []string
+		wantUnmatched []string
+		wantErr       bool
+	}{
+		{
+			name:      "glob",
+			patterns:  []string{"api/*.go"},
+			wantPaths: []string{"api/handler.go", "api/old.go", "api/handler_test.go"},
+		},
+		{
+			name:      "directory matches files beneath it",
+			patterns:  []string{"cmd"},
+			wantPaths: []string{"cmd/main.go"},
+		},
+		{
+			name:      "negation",
+			patterns:  []string{"**/*.go", "!**/*_test.go"},
+			wantPaths: []string{"api/handler.go", "api/old.go", "cmd/main.go"},
+		},
+		{
+			name:      "negated directory",
+			patterns:  []string{"**/*.go", "!api"},
+			wantPaths: []string{"cmd/main.go"},
+		},
+		{
+			name:          "unmatched pattern is reported",
+			patterns:      []string{"README.md", "docs/**"},
+			wantPaths:     []string{"README.md"},
+			wantUnmatched: []string{"docs/**"},
+		},
+		{
+			name:     "invalid pattern",
+			patterns: []string{"[invalid"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := matchPaths(paths, tt.patterns)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got.Paths, tt.wantPaths) {
+				t.Errorf("Paths = %v, want %v", got.Paths, tt.wantPaths)
+			}
+			if !slices.Equal(got.Unmatched, tt.wantUnmatched) {
+				t.Errorf("Unmatched = %v, want %v", got.Unmatched, tt.wantUnmatched)
+			}
+		})
+	}
+}
@@ -0,0 +1,497 @@
+package twig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrPickCanceled is returned when the picker is closed without a choice.
+var ErrPickCanceled = errors.New("pick canceled")
+
+// errTerminalUnsupported is returned by openTerminal on platforms without
+// a raw terminal for the picker.
+var errTerminalUnsupported = errors.New("the interactive picker is not supported on this platform")
+
+// Terminal is an interactive terminal in raw mode.
+type Terminal interface {
+	io.ReadWriter
+	Size() (width, height int)
+	// Restore leaves raw mode and releases the terminal.
+	Restore() error
+}
+
+// PickCommand lets the user choose worktrees interactively.
+type PickCommand struct {
+	Git          *GitRunner
+	OpenTerminal func() (Terminal, error)
+}
+
+// PickOptions configures the pick operation.
+type PickOptions struct {
+	Multi     bool   // Allow choosing several worktrees with Tab
+	Query     string // Initial filter query
+	Removable bool   // Offer only worktrees twig remove accepts: not main, not detached
+}
+
+// NewPickCommand creates a PickCommand with explicit dependencies (for testing).
+func NewPickCommand(git *GitRunner, openTerminal func() (Terminal, error)) *PickCommand {
+	return &PickCommand{
+		Git:          git,
+		OpenTerminal: openTerminal,
+	}
+}
+
+// NewDefaultPickCommand creates a PickCommand with production defaults.
+func NewDefaultPickCommand(dir string) *PickCommand {
+	return NewPickCommand(NewGitRunner(dir), openTerminal)
+}
+
+// PickResult holds the chosen worktrees.
+type PickResult struct {
+	Worktrees []Worktree
+}
+
+// PickFormatOptions configures pick output formatting.
+type PickFormatOptions struct {
+	Branch bool // Print branch names instead of paths
+}
+
+// Format formats the PickResult for display, one worktree per line.
+func (r PickResult) Format(opts PickFormatOptions) FormatResult {
+	var stdout strings.Builder
+	for _, wt := range r.Worktrees {
+		if opts.Branch {
+			stdout.WriteString(wt.Branch)
+		} else {
+			stdout.WriteString(wt.Path)
+		}
+		stdout.WriteString("\n")
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// Run shows the picker on the terminal and returns the chosen worktrees.
+// Returns ErrPickCanceled if the user quits with Esc or Ctrl-C.
+func (c *PickCommand) Run(opts PickOptions) (PickResult, error) {
+	var result PickResult
+
+	worktrees, err := c.Git.WorktreeList()
+	if err != nil {
+		return result, err
+	}
+
+	var items []Worktree
+	mainSeen := false
+	for _, wt := range worktrees {
+		if wt.Bare {
+			continue
+		}
+		isMain := !mainSeen
+		mainSeen = true
+		if opts.Removable && (isMain || wt.Detached) {
+			continue
+		}
+		items = append(items, wt)
+	}
+	if len(items) == 0 {
+		return result, errors.New("no worktrees to pick")
+	}
+
+	term, err := c.OpenTerminal()
+	if errors.Is(err, errTerminalUnsupported) {
+		return result, fmt.Errorf("%w, pass one of these instead:\n%s", err, formatCandidates(items))
+	}
+	if err != nil {
+		return result, err
+	}
+	defer term.Restore()
+
+	// Draw on the alternate screen, so the shell's screen is left intact
+	fmt.Fprint(term, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(term, "\x1b[?25h\x1b[?1049l")
+
+	p := newPicker(items, opts.Multi)
+	p.setQuery(opts.Query)
+	width, height := term.Size()
+	previews := make(map[string][]string)
+	in := bufio.NewReader(term)
+
+	for {
+		var preview []string
+		if wt, ok := p.current(); ok {
+			if _, cached := previews[wt.Path]; !cached {
+				previews[wt.Path] = c.preview(wt)
+			}
+			preview = previews[wt.Path]
+		}
+		fmt.Fprint(term, p.render(width, height, preview))
+
+		k, err := readKey(in)
+		if err != nil {
+			return result, fmt.Errorf("failed to read terminal input: %w", err)
+		}
+		switch p.handle(k) {
+		case pickDone:
+			result.Worktrees = p.chosen()
+			return result, nil
+		case pickCanceled:
+			return result, ErrPickCanceled
+		}
+	}
+}
+
+// formatCandidates lists the worktrees the picker would have offered,
+// one branch (or path, when detached) and path per line.
+func formatCandidates(items []Worktree) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, wt := range items {
+		name := wt.Branch
+		if name == "" {
+			name = "(detached)"
+		}
+		fmt.Fprintf(w, "  %s\t%s\n", name, wt.Path)
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// preview returns the status and recent commits of a worktree.
+func (c *PickCommand) preview(wt Worktree) []string {
+	lines := []string{wt.Path}
+	if wt.Prunable {
+		return append(lines, "", "worktree directory is missing (prunable)")
+	}
+
+	git := c.Git.InDir(wt.Path)
+	if out, err := git.Run(GitCmdStatus, "--short", "--branch"); err != nil {
+		lines = append(lines, "", err.Error())
+	} else {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(strings.TrimRight(string(out), "\n"), "\n")...)
+	}
+	if out, err := git.Run(GitCmdLog, "--oneline", "-n", "10"); err == nil && len(out) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(strings.TrimRight(string(out), "\n"), "\n")...)
+	}
+	return lines
+}
+
+// keyKind is the kind of a key press read from the terminal.
+type keyKind int
+
+const (
+	keyRune keyKind = iota
+	keyEnter
+	keyEsc
+	keyCancel
+	keyBackspace
+	keyClear
+	keyTab
+	keyUp
+	keyDown
+	keyUnknown
+)
+
+type key struct {
+	kind keyKind
+	r    rune
+}
+
+// readKey reads a key press from a terminal in raw mode.
+func readKey(in *bufio.Reader) (key, error) {
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return key{}, err
+	}
+	switch r {
+	case '\r', '\n':
+		return key{kind: keyEnter}, nil
+	case 3: // Ctrl-C
+		return key{kind: keyCancel}, nil
+	case 127, 8: // Backspace, Ctrl-H
+		return key{kind: keyBackspace}, nil
+	case 21: // Ctrl-U
+		return key{kind: keyClear}, nil
+	case '\t':
+		return key{kind: keyTab}, nil
+	case 16: // Ctrl-P
+		return key{kind: keyUp}, nil
+	case 14: // Ctrl-N
+		return key{kind: keyDown}, nil
+	case 27:
+		// A lone Esc arrives without the rest of an escape sequence
+		if in.Buffered() == 0 {
+			return key{kind: keyEsc}, nil
+		}
+		intro, _ := in.ReadByte()
+		if intro != '[' && intro != 'O' {
+			return key{kind: keyEsc}, nil
+		}
+		// Consume the whole sequence, so that the rest of keys such as
+		// F5 (ESC [ 1 5 ~) is not typed into the query. SS3 sequences
+		// end after one byte; CSI sequences end with a byte in @ to ~,
+		// after any parameter and intermediate bytes.
+		var final byte
+		for in.Buffered() > 0 {
+			b, _ := in.ReadByte()
+			if intro == 'O' || (b >= 0x40 && b <= 0x7e) {
+				final = b
+				break
+			}
+		}
+		switch final {
+		case 'A':
+			return key{kind: keyUp}, nil
+		case 'B':
+			return key{kind: keyDown}, nil
+		}
+		return key{kind: keyUnknown}, nil
+	}
+	if unicode.IsControl(r) {
+		return key{kind: keyUnknown}, nil
+	}
+	return key{kind: keyRune, r: r}, nil
+}
+
+// pickState is the state of the picker after a key press.
+type pickState int
+
+const (
+	pickActive pickState = iota
+	pickDone
+	pickCanceled
+)
+
+// picker is the state of the interactive picker, independent of the terminal.
+type picker struct {
+	items    []Worktree
+	multi    bool
+	query    []rune
+	matches  []int // Indices of items matching the query, best first
+	cursor   int   // Index into matches
+	offset   int   // First visible match
+	selected map[int]bool
+}
+
+func newPicker(items []Worktree, multi bool) *picker {
+	p := &picker{items: items, multi: multi, selected: make(map[int]bool)}
+	p.filter()
+	return p
+}
+
+func (p *picker) setQuery(query string) {
+	p.query = []rune(query)
+	p.filter()
+}
+
+// filter matches the items against the query, ranking better matches first.
+func (p *picker) filter() {
+	type match struct{ index, score int }
+	var matches []match
+	query := string(p.query)
+	for i, wt := range p.items {
+		if score, ok := fuzzyScore(query, pickLabel(wt)+" "+wt.Path); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return b.score - a.score })
+
+	p.matches = p.matches[:0]
+	for _, m := range matches {
+		p.matches = append(p.matches, m.index)
+	}
+	p.cursor, p.offset = 0, 0
+}
+
+// current returns the item under the cursor.
+func (p *picker) current() (Worktree, bool) {
+	if len(p.matches) == 0 {
+		return Worktree{}, false
+	}
+	return p.items[p.matches[p.cursor]], true
+}
+
+// handle applies a key press.
+func (p *picker) handle(k key) pickState {
+	switch k.kind {
+	case keyRune:
+		p.setQuery(string(append(p.query, k.r)))
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.setQuery(string(p.query[:len(p.query)-1]))
+		}
+	case keyClear:
+		p.setQuery("")
+	case keyUp:
+		p.cursor = max(p.cursor-1, 0)
+	case keyDown:
+		p.cursor = max(min(p.cursor+1, len(p.matches)-1), 0)
+	case keyTab:
+		if p.multi && len(p.matches) > 0 {
+			i := p.matches[p.cursor]
+			p.selected[i] = !p.selected[i]
+			p.cursor = min(p.cursor+1, len(p.matches)-1)
+		}
+	case keyEnter:
+		if len(p.chosen()) > 0 {
+			return pickDone
+		}
+	case keyEsc, keyCancel:
+		return pickCanceled
+	}
+	return pickActive
+}
+
+// chosen returns the selected items in list order, or the item under the
+// cursor if none is selected.
+func (p *picker) chosen() []Worktree {
+	var chosen []Worktree
+	for i, wt := range p.items {
+		if p.selected[i] {
+			chosen = append(chosen, wt)
+		}
+	}
+	if len(chosen) == 0 {
+		if wt, ok := p.current(); ok {
+			chosen = append(chosen, wt)
+		}
+	}
+	return chosen
+}
+
+// render draws the prompt, the matching items and the preview of the
+// current item as one frame.
+func (p *picker) render(width, height int, preview []string) string {
+	lines := []string{"> " + string(p.query)}
+	count := fmt.Sprintf("  %d/%d", len(p.matches), len(p.items))
+	if p.multi {
+		selected := 0
+		for _, s := range p.selected {
+			if s {
+				selected++
+			}
+		}
+		count += fmt.Sprintf(" (%d selected)", selected)
+	}
+	lines = append(lines, count)
+
+	// The list takes the upper half and the preview the rest,
+	// unless the terminal is too small for both
+	avail := max(height-len(lines), 1)
+	listHeight := avail
+	if avail >= 6 {
+		listHeight = avail / 2
+	}
+
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+listHeight {
+		p.offset = p.cursor - listHeight + 1
+	}
+
+	labelWidth := 0
+	for _, wt := range p.items {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(pickLabel(wt)))
+	}
+	for row := range listHeight {
+		i := p.offset + row
+		if i >= len(p.matches) {
+			lines = append(lines, "")
+			continue
+		}
+		wt := p.items[p.matches[i]]
+		marker, mark := " ", " "
+		if i == p.cursor {
+			marker = ">"
+		}
+		if p.selected[p.matches[i]] {
+			mark = "*"
+		}
+		label := pickLabel(wt)
+		pad := strings.Repeat(" ", labelWidth-utf8.RuneCountInString(label))
+		lines = append(lines, marker+mark+label+pad+"  "+wt.Path)
+	}
+
+	if previewHeight := avail - listHeight - 1; previewHeight > 0 {
+		lines = append(lines, strings.Repeat("─", width))
+		for row := range previewHeight {
+			if row < len(preview) {
+				lines = append(lines, strings.ReplaceAll(preview[row], "\t", "    "))
+			} else {
+				lines = append(lines, "")
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(truncate(line, width))
+		sb.WriteString("\x1b[K")
+	}
+	sb.WriteString("\x1b[J")
+	return sb.String()
+}
+
+// pickLabel returns the name a worktree is listed under.
+func pickLabel(wt Worktree) string {
+	if wt.Detached {
+		return "(detached HEAD)"
+	}
+	return wt.Branch
+}
+
+// truncate cuts s to at most width runes.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+// fuzzyScore reports whether the runes of query appear in text in order,
+// ignoring case, and scores the match: matches at the start of a word and
+// consecutive matches score higher, gaps between matches lower.
+func fuzzyScore(query, text string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+
+	score, qi, last := 0, 0, -1
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == 0 || strings.ContainsRune("/-_. ", t[ti-1]) {
+			score += 8
+		}
+		if last >= 0 {
+			if ti == last+1 {
+				score += 4
+			} else {
+				score -= min(ti-last-1, 4)
+			}
+		}
+		last = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score, true
+}
@@ -0,0 +1,39 @@
+//go:build integration
+
+package twig
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestPickCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	repoDir, mainDir := testutil.SetupTestRepo(t)
+	featDir := filepath.Join(repoDir, "feat", "login")
+	testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/login", featDir)
+	testutil.RunGit(t, featDir, "commit", "--allow-empty", "-m", "add login form")
+
+	term := &mockTerminal{Reader: strings.NewReader("login\r")}
+	cmd := NewPickCommand(NewGitRunner(mainDir), func() (Terminal, error) { return term, nil })
+
+	result, err := cmd.Run(PickOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Worktrees) != 1 || result.Worktrees[0].Path != featDir {
+		t.Fatalf("picked = %+v, want %s", result.Worktrees, featDir)
+	}
+
+	// The preview shows the branch status and recent commits
+	screen := term.out.String()
+	for _, want := range []string{"## feat/login", "add login form"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("preview should contain %q", want)
+		}
+	}
+}
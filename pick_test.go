@@ -0,0 +1,263 @@
+package twig
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// mockTerminal replays input and records what the picker draws.
+type mockTerminal struct {
+	*strings.Reader
+	out      bytes.Buffer
+	restored bool
+}
+
+func (m *mockTerminal) Write(b []byte) (int, error) { return m.out.Write(b) }
+func (m *mockTerminal) Size() (int, int)            { return 80, 20 }
+func (m *mockTerminal) Restore() error {
+	m.restored = true
+	return nil
+}
+
+func newPickMockGit() *testutil.MockGitExecutor {
+	return &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/login", Branch: "feat/login"},
+			{Path: "/repo/feat/logout", Branch: "feat/logout"},
+			{Path: "/repo/fix/typo", Branch: "fix/typo"},
+			{Path: "/repo/detached", HEAD: "abc1234", Detached: true},
+		},
+	}
+}
+
+func TestPickCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     PickOptions
+		want     []string
+		wantErr  error
+		wantDraw string
+	}{
+		{
+			name:  "enter_picks_first",
+			input: "\r",
+			want:  []string{"/repo/main"},
+		},
+		{
+			name:  "filter_and_move",
+			input: "lo\x1b[B\r",
+			want:  []string{"/repo/feat/logout"},
+		},
+		{
+			name:  "initial_query",
+			input: "\r",
+			opts:  PickOptions{Query: "typo"},
+			want:  []string{"/repo/fix/typo"},
+		},
+		{
+			name:  "backspace_and_clear",
+			input: "typx\x7fo\x15fix\r",
+			want:  []string{"/repo/fix/typo"},
+		},
+		{
+			name:     "multi_select_in_list_order",
+			input:    "\x0e\x0e\t\x10\x10\t\r",
+			opts:     PickOptions{Multi: true},
+			want:     []string{"/repo/feat/login", "/repo/feat/logout"},
+			wantDraw: "(2 selected)",
+		},
+		{
+			name:  "tab_ignored_without_multi",
+			input: "\t\r",
+			want:  []string{"/repo/main"},
+		},
+		{
+			name:  "removable_excludes_main_and_detached",
+			input: "\x1b[B\x1b[B\x1b[B\x1b[B\r",
+			opts:  PickOptions{Removable: true},
+			want:  []string{"/repo/fix/typo"},
+		},
+		{
+			name:  "enter_without_match_is_ignored",
+			input: "zzz\x15\r",
+			want:  []string{"/repo/main"},
+		},
+		{
+			name:     "preview_shows_status_and_log",
+			input:    "\r",
+			opts:     PickOptions{Query: "typo"},
+			want:     []string{"/repo/fix/typo"},
+			wantDraw: "abc1234 fix typo",
+		},
+		{
+			name:    "esc_cancels",
+			input:   "feat\x1b",
+			wantErr: ErrPickCanceled,
+		},
+		{
+			name:    "ctrl_c_cancels",
+			input:   "\x03",
+			wantErr: ErrPickCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			term := &mockTerminal{Reader: strings.NewReader(tt.input)}
+			base := newPickMockGit()
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					if args[0] == "-C" {
+						args = args[2:]
+					}
+					switch args[0] {
+					case "status":
+						return []byte("## fix/typo\n M README.md\n"), nil
+					case "log":
+						return []byte("abc1234 fix typo\n"), nil
+					}
+					return base.Run(args...)
+				},
+			}
+			cmd := NewPickCommand(&GitRunner{Executor: mockGit}, func() (Terminal, error) { return term, nil })
+
+			result, err := cmd.Run(tt.opts)
+			if !term.restored {
+				t.Error("terminal should be restored")
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, wt := range result.Worktrees {
+				got = append(got, wt.Path)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("picked = %v, want %v", got, tt.want)
+			}
+			if tt.wantDraw != "" && !strings.Contains(term.out.String(), tt.wantDraw) {
+				t.Errorf("screen should contain %q", tt.wantDraw)
+			}
+		})
+	}
+}
+
+func TestPickCommand_Run_TerminalError(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewPickCommand(&GitRunner{Executor: newPickMockGit()}, func() (Terminal, error) {
+		return nil, errors.New("failed to open terminal")
+	})
+	if _, err := cmd.Run(PickOptions{}); err == nil || !strings.Contains(err.Error(), "failed to open terminal") {
+		t.Errorf("error = %v, want terminal error", err)
+	}
+}
+
+func TestPickCommand_Run_TerminalUnsupported(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewPickCommand(&GitRunner{Executor: newPickMockGit()}, func() (Terminal, error) {
+		return nil, errTerminalUnsupported
+	})
+	_, err := cmd.Run(PickOptions{Removable: true})
+	want := "the interactive picker is not supported on this platform, pass one of these instead:\n" +
+		"  feat/login   /repo/feat/login\n" +
+		"  feat/logout  /repo/feat/logout\n" +
+		"  fix/typo     /repo/fix/typo"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	t.Parallel()
+
+	// F5, Home, Ctrl-Up and F1 are read as a whole and ignored
+	in := bufio.NewReader(strings.NewReader("a\x1b[A\x1b[B\x1bOA\x1b[15~\x1b[1~\x1b[1;5A\x1bOP\r\x7f\x15\t\x03é"))
+	want := []key{
+		{kind: keyRune, r: 'a'},
+		{kind: keyUp},
+		{kind: keyDown},
+		{kind: keyUp},
+		{kind: keyUnknown},
+		{kind: keyUnknown},
+		{kind: keyUp},
+		{kind: keyUnknown},
+		{kind: keyEnter},
+		{kind: keyBackspace},
+		{kind: keyClear},
+		{kind: keyTab},
+		{kind: keyCancel},
+		{kind: keyRune, r: 'é'},
+	}
+	for i, w := range want {
+		got, err := readKey(in)
+		if err != nil {
+			t.Fatalf("key %d: %v", i, err)
+		}
+		if got != w {
+			t.Errorf("key %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query, text string
+		wantOK      bool
+	}{
+		{"", "anything", true},
+		{"fl", "feat/login", true},
+		{"FL", "feat/login", true},
+		{"lf", "feat/login", false},
+		{"loginx", "feat/login", false},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzyScore(tt.query, tt.text); ok != tt.wantOK {
+			t.Errorf("fuzzyScore(%q, %q) ok = %v, want %v", tt.query, tt.text, ok, tt.wantOK)
+		}
+	}
+
+	// Word starts and consecutive runes rank higher than scattered matches
+	boundary, _ := fuzzyScore("log", "feat/login")
+	scattered, _ := fuzzyScore("log", "fix/lint-org")
+	if boundary <= scattered {
+		t.Errorf("score of word start %d should exceed scattered %d", boundary, scattered)
+	}
+}
+
+func TestPickResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := PickResult{Worktrees: []Worktree{
+		{Path: "/repo/feat/a", Branch: "feat/a"},
+		{Path: "/repo/feat/b", Branch: "feat/b"},
+	}}
+	if got := result.Format(PickFormatOptions{}).Stdout; got != "/repo/feat/a\n/repo/feat/b\n" {
+		t.Errorf("paths = %q", got)
+	}
+	if got := result.Format(PickFormatOptions{Branch: true}).Stdout; got != "feat/a\nfeat/b\n" {
+		t.Errorf("branches = %q", got)
+	}
+}
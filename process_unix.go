@@ -0,0 +1,20 @@
+//go:build !windows
+
+package twig
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	// EPERM means the process exists but belongs to another user
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package twig
+
+import "os"
+
+// processAlive reports whether a process with pid exists.
+// On Windows, FindProcess fails if the process does not exist.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
@@ -0,0 +1,113 @@
+package twig
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Profile holds settings applied to branches whose name matches a glob.
+// Profiles are declared as [[profile]] tables in settings files.
+type Profile struct {
+	Name                string   `toml:"name"`
+	Match               string   `toml:"match"`
+	Symlinks            []string `toml:"symlinks"`      // Appended to the configured symlinks
+	SkipSymlinks        []string `toml:"skip_symlinks"` // Configured symlink patterns to leave out
+	Sparse              []string `toml:"sparse"`        // Replaces the configured sparse directories
+	DefaultSource       string   `toml:"default_source"`
+	Lock                bool     `toml:"lock"`
+	LockReason          string   `toml:"lock_reason"`
+	WorktreeDestBaseDir string   `toml:"worktree_destination_base_dir"`
+}
+
+// DisplayName returns the profile name, falling back to its match pattern.
+func (p Profile) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Match
+}
+
+// Matches reports whether branch matches the profile's glob.
+// Uses doublestar semantics: "*" stops at "/", "**" crosses it.
+func (p Profile) Matches(branch string) bool {
+	ok, err := doublestar.Match(p.Match, branch)
+	return err == nil && ok
+}
+
+// ProfileFor returns the first profile matching branch, or nil.
+// Profiles from higher-precedence settings files are checked first.
+func (c *Config) ProfileFor(branch string) *Profile {
+	for i := range c.Profiles {
+		if c.Profiles[i].Matches(branch) {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// SymlinksFor returns the symlink patterns for a worktree using profile.
+// A nil profile returns the configured symlinks unchanged.
+func (c *Config) SymlinksFor(profile *Profile) []string {
+	if profile == nil {
+		return c.Symlinks
+	}
+	var symlinks []string
+	for _, s := range c.Symlinks {
+		if !slices.Contains(profile.SkipSymlinks, s) {
+			symlinks = append(symlinks, s)
+		}
+	}
+	for _, s := range profile.Symlinks {
+		if !slices.Contains(symlinks, s) {
+			symlinks = append(symlinks, s)
+		}
+	}
+	return symlinks
+}
+
+// SparseFor returns the sparse-checkout directories for a worktree using
+// profile. An empty result means a full checkout.
+func (c *Config) SparseFor(profile *Profile) []string {
+	if profile != nil && len(profile.Sparse) > 0 {
+		return profile.Sparse
+	}
+	return c.Sparse
+}
+
+// DestBaseDirFor returns the worktree destination base directory using profile.
+func (c *Config) DestBaseDirFor(profile *Profile) string {
+	if profile != nil && profile.WorktreeDestBaseDir != "" {
+		return profile.WorktreeDestBaseDir
+	}
+	return c.WorktreeDestBaseDir
+}
+
+// DefaultSourceFor returns the default source branch for branch,
+// preferring the matching profile's default_source.
+func (c *Config) DefaultSourceFor(branch string) string {
+	if p := c.ProfileFor(branch); p != nil && p.DefaultSource != "" {
+		return p.DefaultSource
+	}
+	return c.DefaultSource
+}
+
+// destBaseDirContaining returns the configured destination base directory
+// (global or from a profile) that most closely contains path,
+// or an empty string if path is outside all of them.
+func (c *Config) destBaseDirContaining(path string) string {
+	dirs := []string{c.WorktreeDestBaseDir}
+	for _, p := range c.Profiles {
+		dirs = append(dirs, p.WorktreeDestBaseDir)
+	}
+	var best string
+	for _, dir := range dirs {
+		if dir != "" && len(dir) > len(best) &&
+			strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			best = dir
+		}
+	}
+	return best
+}
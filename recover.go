@@ -0,0 +1,245 @@
+package twig
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RecoverAction selects what twig recover does with unfinished operations.
+type RecoverAction string
+
+const (
+	// RecoverList only reports unfinished operations and orphaned stashes.
+	RecoverList RecoverAction = ""
+	// RecoverApply finishes operations by applying changes to the destination.
+	// The source is left as is: a sync never removed its changes.
+	RecoverApply RecoverAction = "apply"
+	// RecoverRestore puts changes back into the source worktree.
+	RecoverRestore RecoverAction = "restore"
+)
+
+// orphanStashMessages are the stash messages used by twig carry and sync.
+var orphanStashMessages = []string{"twig carry", "twig sync"}
+
+// RecoverCommand finds and recovers carry and sync operations that were
+// interrupted, using the markers written while they run.
+type RecoverCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+}
+
+// RecoverOptions configures the recover operation.
+type RecoverOptions struct {
+	Action RecoverAction
+	// Hashes limits the operations to those whose stash hash starts with
+	// one of these prefixes. Empty means all unfinished operations.
+	Hashes []string
+}
+
+// NewRecoverCommand creates a RecoverCommand with explicit dependencies.
+func NewRecoverCommand(fs FileSystem, git *GitRunner, cfg *Config) *RecoverCommand {
+	return &RecoverCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+	}
+}
+
+// NewDefaultRecoverCommand creates a RecoverCommand with production defaults.
+func NewDefaultRecoverCommand(cfg *Config) *RecoverCommand {
+	return NewRecoverCommand(osFS{}, NewGitRunner(cfg.WorktreeSourceDir), cfg)
+}
+
+// RecoverResult holds the result of a recover operation.
+type RecoverResult struct {
+	Action RecoverAction
+	// Operations are the unfinished operations (list) or the recovered ones.
+	Operations []StashOperation
+	// InProgress are operations whose twig process is still running.
+	InProgress []StashOperation
+	// Orphans are twig stashes without an operation marker.
+	Orphans []StashRef
+}
+
+// Format formats the RecoverResult for display.
+func (r RecoverResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+
+	if r.Action != RecoverList {
+		for _, op := range r.Operations {
+			if r.Action == RecoverRestore {
+				fmt.Fprintf(&stdout, "Restored %s to %s\n", shortHash(op.Hash), op.Source)
+			} else {
+				fmt.Fprintf(&stdout, "Applied %s to %s\n", shortHash(op.Hash), op.Destination)
+			}
+		}
+		verb := "restored"
+		if r.Action == RecoverApply {
+			verb = "applied"
+		}
+		fmt.Fprintf(&stdout, "twig recover: %d operations %s\n", len(r.Operations), verb)
+		return FormatResult{Stdout: stdout.String()}
+	}
+
+	if len(r.Operations) == 0 && len(r.InProgress) == 0 && len(r.Orphans) == 0 {
+		return FormatResult{Stdout: "twig recover: nothing to recover\n"}
+	}
+
+	writeOps := func(title string, ops []StashOperation) {
+		if len(ops) == 0 {
+			return
+		}
+		fmt.Fprintf(&stdout, "%s:\n", title)
+		for _, op := range ops {
+			fmt.Fprintf(&stdout, "  %s  %-5s  %s -> %s (%s), started %s\n",
+				shortHash(op.Hash), op.Mode, op.Source, op.Destination, op.Branch,
+				op.StartedAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+	writeOps("Unfinished operations", r.Operations)
+	writeOps("In progress", r.InProgress)
+	if len(r.Orphans) > 0 {
+		stdout.WriteString("Orphaned stashes:\n")
+		for _, o := range r.Orphans {
+			fmt.Fprintf(&stdout, "  %s  %s  %s\n", o.ShortHash(), o.Ref, o.Message)
+		}
+	}
+
+	if len(r.Operations) > 0 {
+		stdout.WriteString("\nRun 'twig recover --restore' to put the changes back into the source worktree,\n" +
+			"or 'twig recover --apply' to finish the operations.\n")
+	}
+	if len(r.Orphans) > 0 {
+		stdout.WriteString("\nApply orphaned stashes with 'git stash apply <hash>'.\n")
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// Pending returns the unfinished operations whose process is no longer
+// running. It is cheap enough to run on every twig invocation.
+func (c *RecoverCommand) Pending() ([]StashOperation, error) {
+	store, err := newOperationStore(c.FS, c.Git)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(ops, StashOperation.Running), nil
+}
+
+// Run lists unfinished operations and orphaned stashes, or recovers
+// the selected operations according to opts.Action.
+func (c *RecoverCommand) Run(opts RecoverOptions) (RecoverResult, error) {
+	result := RecoverResult{Action: opts.Action}
+
+	store, err := newOperationStore(c.FS, c.Git)
+	if err != nil {
+		return result, err
+	}
+	ops, err := store.List()
+	if err != nil {
+		return result, err
+	}
+
+	var selected []StashOperation
+	for _, h := range opts.Hashes {
+		i := slices.IndexFunc(ops, func(op StashOperation) bool {
+			return strings.HasPrefix(op.Hash, h)
+		})
+		if i < 0 {
+			return result, fmt.Errorf("no unfinished operation matches %s", h)
+		}
+		if ops[i].Running() {
+			return result, fmt.Errorf("operation %s is still in progress (pid %d)", shortHash(ops[i].Hash), ops[i].PID)
+		}
+		selected = append(selected, ops[i])
+	}
+	if len(opts.Hashes) == 0 {
+		for _, op := range ops {
+			if op.Running() {
+				result.InProgress = append(result.InProgress, op)
+			} else {
+				selected = append(selected, op)
+			}
+		}
+	}
+
+	if opts.Action == RecoverList {
+		result.Operations = selected
+		result.Orphans, err = c.orphans(ops)
+		return result, err
+	}
+
+	for _, op := range selected {
+		if err := c.recover(op, opts.Action); err != nil {
+			return result, fmt.Errorf("failed to recover %s: %w", shortHash(op.Hash), err)
+		}
+		_ = store.Remove(op.Hash)
+		result.Operations = append(result.Operations, op)
+	}
+	return result, nil
+}
+
+// recover applies the stash of op according to action and drops it.
+func (c *RecoverCommand) recover(op StashOperation, action RecoverAction) error {
+	switch action {
+	case RecoverRestore:
+		// A sync leaves the changes in the source: dropping the stash is enough
+		if op.Mode == OperationSync {
+			break
+		}
+		if _, err := c.FS.Stat(op.Source); err != nil {
+			return fmt.Errorf("worktree %s does not exist: %w", op.Source, err)
+		}
+		if _, err := c.Git.InDir(op.Source).StashRestore(op.Hash, op.Scope); err != nil {
+			return fmt.Errorf("failed to restore changes to %s: %w", op.Source, err)
+		}
+		return nil
+	case RecoverApply:
+		if _, err := c.FS.Stat(op.Destination); err != nil {
+			return fmt.Errorf("worktree %s does not exist: %w", op.Destination, err)
+		}
+		if _, err := c.Git.InDir(op.Destination).StashApplyByHash(op.Hash, op.Scope); err != nil {
+			return fmt.Errorf("failed to apply changes to %s: %w", op.Destination, err)
+		}
+	default:
+		return fmt.Errorf("unknown recover action %q", action)
+	}
+	_, _ = c.Git.StashDropByHash(op.Hash)
+	return nil
+}
+
+// orphans returns twig stashes that no operation marker refers to:
+// private stash refs left behind, and "twig carry"/"twig sync" entries in
+// the shared stash stack created by older versions of twig.
+func (c *RecoverCommand) orphans(ops []StashOperation) ([]StashRef, error) {
+	private, err := c.Git.PrivateStashes()
+	if err != nil {
+		return nil, err
+	}
+	stack, err := c.Git.StashList()
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []StashRef
+	for _, s := range private {
+		if !slices.ContainsFunc(ops, func(op StashOperation) bool { return op.Hash == s.Hash }) {
+			orphans = append(orphans, s)
+		}
+	}
+	for _, s := range stack {
+		for _, msg := range orphanStashMessages {
+			// Stash subjects look like "On <branch>: twig carry"
+			if strings.HasSuffix(s.Message, ": "+msg) {
+				orphans = append(orphans, s)
+				break
+			}
+		}
+	}
+	return orphans, nil
+}
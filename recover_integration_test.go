@@ -0,0 +1,172 @@
+//go:build integration
+
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRecoverCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	// interrupt simulates twig being killed right after stashing the changes
+	// of mainDir for a carry to destination.
+	interrupt := func(t *testing.T, mainDir, destination string) string {
+		t.Helper()
+
+		if err := os.WriteFile(filepath.Join(mainDir, "work.txt"), []byte("work"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git := NewGitRunner(mainDir)
+		hash, err := git.StashPush("twig carry", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store, err := newOperationStore(osFS{}, git)
+		if err != nil {
+			t.Fatal(err)
+		}
+		op := newStashOperation(OperationCarry, StashScopeAll, hash, mainDir, destination, "feature/recover")
+		op.PID = 0 // The interrupted process is gone
+		if err := store.Save(op); err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	assertRecovered := func(t *testing.T, mainDir string) {
+		t.Helper()
+
+		if refs := testutil.RunGit(t, mainDir, "for-each-ref", StashRefPrefix); strings.TrimSpace(refs) != "" {
+			t.Errorf("private stash refs should be deleted, got %q", refs)
+		}
+		cmd := NewRecoverCommand(osFS{}, NewGitRunner(mainDir), &Config{})
+		pending, err := cmd.Pending()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("expected no pending operations, got %+v", pending)
+		}
+	}
+
+	t.Run("RestoreInterruptedCarry", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+		hash := interrupt(t, mainDir, filepath.Join(repoDir, "feature", "recover"))
+
+		cmd := NewRecoverCommand(osFS{}, NewGitRunner(mainDir), &Config{})
+		pending, err := cmd.Pending()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 1 || pending[0].Hash != hash {
+			t.Fatalf("Pending = %+v, want operation %s", pending, hash)
+		}
+
+		list, err := cmd.Run(RecoverOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Operations) != 1 || len(list.Orphans) != 0 {
+			t.Errorf("list = %+v, want 1 operation and no orphans", list)
+		}
+
+		if _, err := cmd.Run(RecoverOptions{Action: RecoverRestore}); err != nil {
+			t.Fatalf("restore failed: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(mainDir, "work.txt"))
+		if err != nil || string(content) != "work" {
+			t.Errorf("work.txt = %q (err=%v), want restored content", content, err)
+		}
+		assertRecovered(t, mainDir)
+	})
+
+	t.Run("ApplyInterruptedCarry", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+		wtPath := filepath.Join(repoDir, "feature", "recover")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/recover", wtPath)
+		hash := interrupt(t, mainDir, wtPath)
+
+		cmd := NewRecoverCommand(osFS{}, NewGitRunner(mainDir), &Config{})
+		if _, err := cmd.Run(RecoverOptions{Action: RecoverApply, Hashes: []string{hash[:7]}}); err != nil {
+			t.Fatalf("apply failed: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(wtPath, "work.txt"))
+		if err != nil || string(content) != "work" {
+			t.Errorf("destination work.txt = %q (err=%v), want carried content", content, err)
+		}
+		if _, err := os.Stat(filepath.Join(mainDir, "work.txt")); !os.IsNotExist(err) {
+			t.Errorf("source work.txt should stay carried away, got err=%v", err)
+		}
+		assertRecovered(t, mainDir)
+	})
+
+	t.Run("ListsOrphanedStashes", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t, testutil.WithoutSettings())
+
+		// Stash left by an older twig version
+		if err := os.WriteFile(filepath.Join(mainDir, "legacy.txt"), []byte("legacy"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, mainDir, "stash", "push", "-u", "-m", "twig carry")
+		// Private stash without a marker
+		if err := os.WriteFile(filepath.Join(mainDir, "private.txt"), []byte("private"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewGitRunner(mainDir).StashPush("twig sync", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewRecoverCommand(osFS{}, NewGitRunner(mainDir), &Config{})
+		result, err := cmd.Run(RecoverOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Orphans) != 2 {
+			t.Fatalf("Orphans = %+v, want 2", result.Orphans)
+		}
+		if !strings.HasPrefix(result.Orphans[0].Ref, StashRefPrefix) || result.Orphans[1].Ref != "stash@{0}" {
+			t.Errorf("Orphans = %+v", result.Orphans)
+		}
+	})
+
+	t.Run("CompletedCarryLeavesNoMarker", func(t *testing.T) {
+		t.Parallel()
+
+		_, mainDir := testutil.SetupTestRepo(t)
+		testutil.RunGit(t, mainDir, "add", ".twig")
+		testutil.RunGit(t, mainDir, "commit", "-m", "add twig settings")
+		if err := os.WriteFile(filepath.Join(mainDir, "work.txt"), []byte("work"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addCmd := NewDefaultAddCommand(result.Config, AddOptions{CarryFrom: mainDir})
+		if _, err := addCmd.Run("feature/completed"); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		entries, err := os.ReadDir(filepath.Join(mainDir, ".git", "twig", "operations"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("operation markers should be removed, got %d", len(entries))
+		}
+		assertRecovered(t, mainDir)
+	})
+}
@@ -0,0 +1,285 @@
+package twig
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRecoverCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	const markerDir = "/repo/main/.git/twig/operations"
+	carryOp := StashOperation{
+		Mode:        OperationCarry,
+		Hash:        "aaaa1111",
+		Source:      "/repo/main",
+		Destination: "/repo/feat/a",
+		Branch:      "feat/a",
+		StartedAt:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	syncOp := StashOperation{
+		Mode:        OperationSync,
+		Hash:        "bbbb2222",
+		Source:      "/repo/main",
+		Destination: "/repo/feat/b",
+		Branch:      "feat/b",
+		StartedAt:   time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	stagedOp := StashOperation{
+		Mode:        OperationCarry,
+		Scope:       StashScopeStaged,
+		Hash:        "dddd4444",
+		Source:      "/repo/main",
+		Destination: "/repo/feat/d",
+		Branch:      "feat/d",
+		StartedAt:   time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC),
+	}
+	runningOp := StashOperation{
+		Mode:        OperationCarry,
+		Hash:        "cccc3333",
+		Source:      "/repo/main",
+		Destination: "/repo/feat/c",
+		Branch:      "feat/c",
+		PID:         os.Getpid(),
+		StartedAt:   time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name        string
+		ops         []StashOperation
+		opts        RecoverOptions
+		existing    []string
+		applyErr    error
+		wantErr     string
+		wantOps     []string // hashes of result.Operations
+		wantRunning []string
+		wantOrphans []string
+		wantApplied []string // dirs the stash was applied in, with " --index" if restaged
+		wantRemoved []string // removed marker hashes
+	}{
+		{
+			name:        "list",
+			ops:         []StashOperation{syncOp, carryOp, runningOp},
+			wantOps:     []string{"aaaa1111", "bbbb2222"},
+			wantRunning: []string{"cccc3333"},
+			wantOrphans: []string{"refs/twig/stash/orphan", "stash@{1}"},
+		},
+		{
+			name:        "restore_all",
+			ops:         []StashOperation{carryOp, syncOp, runningOp},
+			opts:        RecoverOptions{Action: RecoverRestore},
+			existing:    []string{"/repo/main"},
+			wantOps:     []string{"aaaa1111", "bbbb2222"},
+			wantRunning: []string{"cccc3333"},
+			wantApplied: []string{"/repo/main --index"},
+			wantRemoved: []string{"aaaa1111", "bbbb2222"},
+		},
+		{
+			name:        "apply_sync_to_destination",
+			ops:         []StashOperation{carryOp, syncOp},
+			opts:        RecoverOptions{Action: RecoverApply, Hashes: []string{"bbbb"}},
+			existing:    []string{"/repo/main", "/repo/feat/b"},
+			wantOps:     []string{"bbbb2222"},
+			wantApplied: []string{"/repo/feat/b"},
+			wantRemoved: []string{"bbbb2222"},
+		},
+		{
+			name:        "apply_staged_keeps_index",
+			ops:         []StashOperation{stagedOp},
+			opts:        RecoverOptions{Action: RecoverApply},
+			existing:    []string{"/repo/feat/d"},
+			wantOps:     []string{"dddd4444"},
+			wantApplied: []string{"/repo/feat/d --index"},
+			wantRemoved: []string{"dddd4444"},
+		},
+		{
+			name:     "apply_without_destination",
+			ops:      []StashOperation{carryOp},
+			opts:     RecoverOptions{Action: RecoverApply},
+			existing: []string{"/repo/main"},
+			wantErr:  "worktree /repo/feat/a does not exist",
+		},
+		{
+			name:     "apply_failure_keeps_marker",
+			ops:      []StashOperation{carryOp},
+			opts:     RecoverOptions{Action: RecoverRestore},
+			existing: []string{"/repo/main"},
+			applyErr: os.ErrInvalid,
+			wantErr:  "failed to restore changes to /repo/main",
+		},
+		{
+			name:    "unknown_hash",
+			ops:     []StashOperation{carryOp},
+			opts:    RecoverOptions{Action: RecoverRestore, Hashes: []string{"ffff"}},
+			wantErr: "no unfinished operation matches ffff",
+		},
+		{
+			name:    "running_operation",
+			ops:     []StashOperation{runningOp},
+			opts:    RecoverOptions{Action: RecoverRestore, Hashes: []string{"cccc"}},
+			wantErr: "still in progress",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var entries []os.DirEntry
+			contents := make(map[string][]byte)
+			for _, op := range tt.ops {
+				data, err := json.Marshal(op)
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries = append(entries, mockDirEntry{name: op.Hash + ".json"})
+				contents[markerDir+"/"+op.Hash+".json"] = data
+			}
+			var removed []string
+			mockFS := &testutil.MockFS{
+				ExistingPaths: tt.existing,
+				DirContents:   map[string][]os.DirEntry{markerDir: entries},
+				FileContents:  contents,
+				RemoveFunc: func(name string) error {
+					removed = append(removed, strings.TrimSuffix(strings.TrimPrefix(name, markerDir+"/"), ".json"))
+					return nil
+				},
+			}
+
+			var applied []string
+			base := &testutil.MockGitExecutor{}
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					dir, rest := args[1], args[2:]
+					switch {
+					case rest[0] == "stash" && rest[1] == "apply":
+						if tt.applyErr != nil {
+							return nil, tt.applyErr
+						}
+						if slices.Contains(rest, "--index") {
+							dir += " --index"
+						}
+						applied = append(applied, dir)
+						return nil, nil
+					case rest[0] == "stash" && rest[1] == "list":
+						return []byte("stash@{0}\x00dddd4444\x00On main: wip\x00\n" +
+							"stash@{1}\x00eeee5555\x00On main: twig carry\x00\n"), nil
+					case rest[0] == "for-each-ref" && rest[len(rest)-1] == StashRefPrefix:
+						return []byte("refs/twig/stash/1\x00aaaa1111\x00twig carry\x00\n" +
+							"refs/twig/stash/orphan\x00ffff6666\x00twig sync\x00\n"), nil
+					}
+					return base.Run(args...)
+				},
+			}
+
+			cmd := NewRecoverCommand(mockFS, &GitRunner{Executor: mockGit, Dir: "/repo/main"}, &Config{})
+			result, err := cmd.Run(tt.opts)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(removed) > 0 {
+					t.Errorf("markers should be kept on failure, removed %v", removed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			hashes := func(ops []StashOperation) []string {
+				var hs []string
+				for _, op := range ops {
+					hs = append(hs, op.Hash)
+				}
+				return hs
+			}
+			if got := hashes(result.Operations); !slices.Equal(got, tt.wantOps) {
+				t.Errorf("Operations = %v, want %v", got, tt.wantOps)
+			}
+			if got := hashes(result.InProgress); !slices.Equal(got, tt.wantRunning) {
+				t.Errorf("InProgress = %v, want %v", got, tt.wantRunning)
+			}
+			var orphans []string
+			for _, o := range result.Orphans {
+				orphans = append(orphans, o.Ref)
+			}
+			if !slices.Equal(orphans, tt.wantOrphans) {
+				t.Errorf("Orphans = %v, want %v", orphans, tt.wantOrphans)
+			}
+			if !slices.Equal(applied, tt.wantApplied) {
+				t.Errorf("applied in %v, want %v", applied, tt.wantApplied)
+			}
+			if !slices.Equal(removed, tt.wantRemoved) {
+				t.Errorf("removed markers %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestRecoverResult_Format(t *testing.T) {
+	t.Parallel()
+
+	op := StashOperation{
+		Mode:        OperationCarry,
+		Hash:        "aaaa1111bbbb",
+		Source:      "/repo/main",
+		Destination: "/repo/feat/a",
+		Branch:      "feat/a",
+		StartedAt:   time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name   string
+		result RecoverResult
+		want   string
+	}{
+		{
+			name:   "nothing",
+			result: RecoverResult{},
+			want:   "twig recover: nothing to recover\n",
+		},
+		{
+			name: "list",
+			result: RecoverResult{
+				Operations: []StashOperation{op},
+				Orphans:    []StashRef{{Ref: "stash@{0}", Hash: "eeee5555ffff", Message: "On main: twig carry"}},
+			},
+			want: "Unfinished operations:\n" +
+				"  aaaa111  carry  /repo/main -> /repo/feat/a (feat/a), started 2026-01-01 09:30:00\n" +
+				"Orphaned stashes:\n" +
+				"  eeee555  stash@{0}  On main: twig carry\n" +
+				"\nRun 'twig recover --restore' to put the changes back into the source worktree,\n" +
+				"or 'twig recover --apply' to finish the operations.\n" +
+				"\nApply orphaned stashes with 'git stash apply <hash>'.\n",
+		},
+		{
+			name:   "restored",
+			result: RecoverResult{Action: RecoverRestore, Operations: []StashOperation{op}},
+			want:   "Restored aaaa111 to /repo/main\ntwig recover: 1 operations restored\n",
+		},
+		{
+			name:   "applied",
+			result: RecoverResult{Action: RecoverApply, Operations: []StashOperation{op}},
+			want:   "Applied aaaa111 to /repo/feat/a\ntwig recover: 1 operations applied\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(FormatOptions{})
+			if got.Stdout != tt.want {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.want)
+			}
+		})
+	}
+}
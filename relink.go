@@ -0,0 +1,229 @@
+package twig
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// RelinkCommand converts twig-created symlinks in a worktree between
+// absolute and relative style.
+type RelinkCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+}
+
+// RelinkOptions configures the relink operation.
+type RelinkOptions struct {
+	// Style is the target style. Empty means the configured symlink_style.
+	Style  SymlinkStyle
+	DryRun bool
+}
+
+// NewRelinkCommand creates a RelinkCommand with explicit dependencies.
+func NewRelinkCommand(fs FileSystem, git *GitRunner, cfg *Config) *RelinkCommand {
+	return &RelinkCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+	}
+}
+
+// NewDefaultRelinkCommand creates a RelinkCommand with production defaults.
+func NewDefaultRelinkCommand(cfg *Config) *RelinkCommand {
+	return NewRelinkCommand(osFS{}, NewGitRunner(cfg.WorktreeSourceDir), cfg)
+}
+
+// RelinkedSymlink holds the result of a single symlink conversion.
+type RelinkedSymlink struct {
+	Path      string // Symlink path relative to the worktree
+	OldTarget string
+	NewTarget string
+}
+
+// RelinkResult holds the result of a relink operation.
+type RelinkResult struct {
+	Branch       string
+	WorktreePath string
+	Style        SymlinkStyle
+	Relinked     []RelinkedSymlink
+	Unchanged    int // twig-created symlinks already in the requested style
+	DryRun       bool
+}
+
+// Format formats the RelinkResult for display.
+func (r RelinkResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+
+	if r.DryRun {
+		for _, l := range r.Relinked {
+			fmt.Fprintf(&stdout, "Would relink %s: %s -> %s\n", l.Path, l.OldTarget, l.NewTarget)
+		}
+		return FormatResult{Stdout: stdout.String()}
+	}
+
+	if opts.Verbose {
+		for _, l := range r.Relinked {
+			fmt.Fprintf(&stdout, "Relinked %s: %s -> %s\n", l.Path, l.OldTarget, l.NewTarget)
+		}
+	}
+
+	name := r.Branch
+	if name == "" {
+		name = r.WorktreePath
+	}
+	fmt.Fprintf(&stdout, "twig relink: %s (%d symlinks converted to %s", name, len(r.Relinked), r.Style)
+	if r.Unchanged > 0 {
+		fmt.Fprintf(&stdout, ", %d unchanged", r.Unchanged)
+	}
+	stdout.WriteString(")\n")
+
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// Run converts the twig-created symlinks of the worktree for branch.
+// An empty branch selects the worktree containing cwd.
+//
+// A symlink is considered twig-created when it points to the same relative
+// path inside another worktree of the repository, which is how twig add links
+// files from the source worktree.
+func (c *RelinkCommand) Run(branch, cwd string, opts RelinkOptions) (RelinkResult, error) {
+	style := opts.Style
+	if style == "" {
+		style = c.Config.SymlinkStyle
+	}
+	style, err := ParseSymlinkStyle(string(style))
+	if err != nil {
+		return RelinkResult{}, err
+	}
+	result := RelinkResult{Branch: branch, Style: style, DryRun: opts.DryRun}
+
+	worktrees, err := c.Git.WorktreeList()
+	if err != nil {
+		return result, err
+	}
+
+	var target *Worktree
+	for i, wt := range worktrees {
+		if branch != "" {
+			if wt.Branch == branch {
+				target = &worktrees[i]
+				break
+			}
+			continue
+		}
+		// Pick the innermost worktree containing cwd
+		if (cwd == wt.Path || strings.HasPrefix(cwd, wt.Path+string(filepath.Separator))) &&
+			(target == nil || len(wt.Path) > len(target.Path)) {
+			target = &worktrees[i]
+		}
+	}
+	if target == nil {
+		if branch != "" {
+			return result, fmt.Errorf("no worktree found for branch %q", branch)
+		}
+		return result, fmt.Errorf("%s is not inside a worktree", cwd)
+	}
+	result.Branch = target.Branch
+	result.WorktreePath = target.Path
+
+	var sources []string
+	for _, wt := range worktrees {
+		if wt.Path != target.Path && !wt.Bare {
+			sources = append(sources, wt.Path)
+		}
+	}
+
+	err = walkSymlinks(c.FS, target.Path, "", func(rel string) error {
+		linkPath := filepath.Join(target.Path, rel)
+		oldTarget, err := c.FS.Readlink(linkPath)
+		if err != nil {
+			return fmt.Errorf("failed to read symlink %s: %w", rel, err)
+		}
+
+		resolved := oldTarget
+		if !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(filepath.Dir(linkPath), resolved)
+		}
+		var src string
+		for _, s := range sources {
+			if resolved == filepath.Join(s, rel) {
+				src = resolved
+				break
+			}
+		}
+		if src == "" {
+			// Not created by twig
+			return nil
+		}
+
+		newTarget, err := linkTarget(src, linkPath, style)
+		if err != nil {
+			return err
+		}
+		if newTarget == oldTarget {
+			result.Unchanged++
+			return nil
+		}
+
+		if !opts.DryRun {
+			if err := replaceSymlink(c.FS, linkPath, oldTarget, newTarget); err != nil {
+				return fmt.Errorf("failed to relink %s: %w", rel, err)
+			}
+		}
+		result.Relinked = append(result.Relinked, RelinkedSymlink{
+			Path:      rel,
+			OldTarget: oldTarget,
+			NewTarget: newTarget,
+		})
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// walkSymlinks calls fn with the root-relative path of every symlink under
+// root/rel. Symlinked directories and .git are not descended into.
+func walkSymlinks(fsys FileSystem, root, rel string, fn func(rel string) error) error {
+	entries, err := fsys.ReadDir(filepath.Join(root, rel))
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", filepath.Join(root, rel), err)
+	}
+	for _, e := range entries {
+		if e.Name() == ".git" {
+			continue
+		}
+		p := filepath.Join(rel, e.Name())
+		switch {
+		case e.Type()&fs.ModeSymlink != 0:
+			if err := fn(p); err != nil {
+				return err
+			}
+		case e.IsDir():
+			if err := walkSymlinks(fsys, root, p, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replaceSymlink points the symlink at path to newTarget,
+// restoring oldTarget if the new link cannot be created.
+func replaceSymlink(fsys FileSystem, path, oldTarget, newTarget string) error {
+	if err := fsys.Remove(path); err != nil {
+		return err
+	}
+	if err := fsys.Symlink(newTarget, path); err != nil {
+		if restoreErr := fsys.Symlink(oldTarget, path); restoreErr != nil {
+			return fmt.Errorf("%w (restore failed: %v)", err, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+//go:build integration
+
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRelinkCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ConvertsBetweenStyles", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t, testutil.Symlinks(".envrc", "config/*.local.yml"))
+
+		if err := os.WriteFile(filepath.Join(mainDir, ".envrc"), []byte("export A=1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(mainDir, "config"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mainDir, "config", "app.local.yml"), []byte("a: 1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addCmd := NewDefaultAddCommand(result.Config, AddOptions{})
+		if _, err := addCmd.Run("feature/relink"); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		wtPath := filepath.Join(repoDir, "feature", "relink")
+		// A symlink not created by twig must be left alone
+		if err := os.Symlink("/nonexistent/target", filepath.Join(wtPath, "other")); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := NewDefaultRelinkCommand(result.Config)
+		relinkResult, err := cmd.Run("feature/relink", mainDir, RelinkOptions{Style: SymlinkStyleRelative})
+		if err != nil {
+			t.Fatalf("relink failed: %v", err)
+		}
+		if len(relinkResult.Relinked) != 2 {
+			t.Fatalf("expected 2 relinked symlinks, got %+v", relinkResult.Relinked)
+		}
+
+		want := map[string]string{
+			".envrc":               "../../main/.envrc",
+			"config/app.local.yml": "../../../main/config/app.local.yml",
+			"other":                "/nonexistent/target",
+		}
+		for path, target := range want {
+			got, err := os.Readlink(filepath.Join(wtPath, path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != target {
+				t.Errorf("%s -> %q, want %q", path, got, target)
+			}
+		}
+
+		// Relative links survive moving the repository
+		movedDir := filepath.Join(filepath.Dir(repoDir), "moved")
+		if err := os.Rename(repoDir, movedDir); err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(filepath.Join(movedDir, "feature", "relink", ".envrc"))
+		if err != nil {
+			t.Fatalf("relative symlink broken after move: %v", err)
+		}
+		if string(content) != "export A=1" {
+			t.Errorf(".envrc content = %q, want %q", string(content), "export A=1")
+		}
+		if err := os.Rename(movedDir, repoDir); err != nil {
+			t.Fatal(err)
+		}
+
+		// Convert back from inside the worktree
+		relinkResult, err = cmd.Run("", filepath.Join(wtPath, "config"), RelinkOptions{Style: SymlinkStyleAbsolute})
+		if err != nil {
+			t.Fatalf("relink failed: %v", err)
+		}
+		if len(relinkResult.Relinked) != 2 {
+			t.Fatalf("expected 2 relinked symlinks, got %+v", relinkResult.Relinked)
+		}
+		got, err := os.Readlink(filepath.Join(wtPath, ".envrc"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != filepath.Join(mainDir, ".envrc") {
+			t.Errorf(".envrc -> %q, want %q", got, filepath.Join(mainDir, ".envrc"))
+		}
+	})
+}
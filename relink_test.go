@@ -0,0 +1,234 @@
+package twig
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestRelinkCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	worktrees := []testutil.MockWorktree{
+		{Path: "/repo/main", Branch: "main"},
+		{Path: "/repo/feat/a", Branch: "feat/a"},
+	}
+	dirContents := map[string][]os.DirEntry{
+		"/repo/feat/a": {
+			mockDirEntry{name: ".git"},
+			mockDirEntry{name: ".envrc", symlink: true},
+			mockDirEntry{name: "config", isDir: true},
+			mockDirEntry{name: "vendor", symlink: true},
+		},
+		"/repo/feat/a/config": {
+			mockDirEntry{name: "app.local.yml", symlink: true},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		branch       string
+		cwd          string
+		opts         RelinkOptions
+		config       *Config
+		linkTargets  map[string]string
+		symlinkErr   error
+		wantErr      string
+		wantRelinked map[string]string // path -> new target
+		wantUnchange int
+		wantCreated  []string
+	}{
+		{
+			name:   "absolute_to_relative",
+			branch: "feat/a",
+			opts:   RelinkOptions{Style: SymlinkStyleRelative},
+			config: &Config{},
+			linkTargets: map[string]string{
+				"/repo/feat/a/.envrc":               "/repo/main/.envrc",
+				"/repo/feat/a/config/app.local.yml": "/repo/main/config/app.local.yml",
+				"/repo/feat/a/vendor":               "/opt/vendor", // not created by twig
+			},
+			wantRelinked: map[string]string{
+				".envrc":               "../../main/.envrc",
+				"config/app.local.yml": "../../../main/config/app.local.yml",
+			},
+			wantCreated: []string{"/repo/feat/a/.envrc", "/repo/feat/a/config/app.local.yml"},
+		},
+		{
+			name:   "relative_to_absolute_from_cwd_with_configured_style",
+			cwd:    "/repo/feat/a/config",
+			config: &Config{SymlinkStyle: SymlinkStyleAbsolute},
+			linkTargets: map[string]string{
+				"/repo/feat/a/.envrc":               "../../main/.envrc",
+				"/repo/feat/a/config/app.local.yml": "/repo/main/config/app.local.yml",
+				"/repo/feat/a/vendor":               "../vendor",
+			},
+			wantRelinked: map[string]string{
+				".envrc": "/repo/main/.envrc",
+			},
+			wantUnchange: 1,
+			wantCreated:  []string{"/repo/feat/a/.envrc"},
+		},
+		{
+			name:   "dry_run",
+			branch: "feat/a",
+			opts:   RelinkOptions{Style: SymlinkStyleRelative, DryRun: true},
+			config: &Config{},
+			linkTargets: map[string]string{
+				"/repo/feat/a/.envrc":               "/repo/main/.envrc",
+				"/repo/feat/a/config/app.local.yml": "../../../main/config/app.local.yml",
+				"/repo/feat/a/vendor":               "/opt/vendor",
+			},
+			wantRelinked: map[string]string{
+				".envrc": "../../main/.envrc",
+			},
+			wantUnchange: 1,
+		},
+		{
+			name:    "invalid_style",
+			branch:  "feat/a",
+			opts:    RelinkOptions{Style: "hard"},
+			config:  &Config{},
+			wantErr: "invalid symlink style",
+		},
+		{
+			name:    "branch_without_worktree",
+			branch:  "feat/missing",
+			config:  &Config{},
+			wantErr: "no worktree found",
+		},
+		{
+			name:   "symlink_failure_restores_old_link",
+			branch: "feat/a",
+			opts:   RelinkOptions{Style: SymlinkStyleRelative},
+			config: &Config{},
+			linkTargets: map[string]string{
+				"/repo/feat/a/.envrc": "/repo/main/.envrc",
+			},
+			symlinkErr: errors.New("permission denied"),
+			wantErr:    "failed to relink .envrc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var created []string
+			var restored bool
+			mockFS := &testutil.MockFS{
+				DirContents: dirContents,
+				LinkTargets: tt.linkTargets,
+				SymlinkFunc: func(oldname, newname string) error {
+					if tt.symlinkErr != nil {
+						if oldname == tt.linkTargets[newname] {
+							restored = true
+							return nil
+						}
+						return tt.symlinkErr
+					}
+					created = append(created, newname)
+					return nil
+				},
+			}
+			git := &GitRunner{
+				Executor: &testutil.MockGitExecutor{Worktrees: worktrees},
+				Dir:      "/repo/main",
+			}
+
+			cmd := NewRelinkCommand(mockFS, git, tt.config)
+			cwd := tt.cwd
+			if cwd == "" {
+				cwd = "/repo/main"
+			}
+			result, err := cmd.Run(tt.branch, cwd, tt.opts)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if tt.symlinkErr != nil && !restored {
+					t.Error("expected the original symlink to be restored")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Branch != "feat/a" {
+				t.Errorf("Branch = %q, want %q", result.Branch, "feat/a")
+			}
+			if len(result.Relinked) != len(tt.wantRelinked) {
+				t.Fatalf("Relinked = %+v, want %v", result.Relinked, tt.wantRelinked)
+			}
+			for _, l := range result.Relinked {
+				if want := tt.wantRelinked[l.Path]; l.NewTarget != want {
+					t.Errorf("%s: NewTarget = %q, want %q", l.Path, l.NewTarget, want)
+				}
+			}
+			if result.Unchanged != tt.wantUnchange {
+				t.Errorf("Unchanged = %d, want %d", result.Unchanged, tt.wantUnchange)
+			}
+			if strings.Join(created, ",") != strings.Join(tt.wantCreated, ",") {
+				t.Errorf("created symlinks = %v, want %v", created, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestRelinkResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := RelinkResult{
+		Branch: "feat/a",
+		Style:  SymlinkStyleRelative,
+		Relinked: []RelinkedSymlink{
+			{Path: ".envrc", OldTarget: "/repo/main/.envrc", NewTarget: "../../main/.envrc"},
+		},
+		Unchanged: 2,
+	}
+
+	tests := []struct {
+		name    string
+		result  RelinkResult
+		verbose bool
+		want    string
+	}{
+		{
+			name:   "default",
+			result: result,
+			want:   "twig relink: feat/a (1 symlinks converted to relative, 2 unchanged)\n",
+		},
+		{
+			name:    "verbose",
+			result:  result,
+			verbose: true,
+			want: "Relinked .envrc: /repo/main/.envrc -> ../../main/.envrc\n" +
+				"twig relink: feat/a (1 symlinks converted to relative, 2 unchanged)\n",
+		},
+		{
+			name: "dry_run",
+			result: func() RelinkResult {
+				r := result
+				r.DryRun = true
+				return r
+			}(),
+			want: "Would relink .envrc: /repo/main/.envrc -> ../../main/.envrc\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(FormatOptions{Verbose: tt.verbose})
+			if got.Stdout != tt.want {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.want)
+			}
+		})
+	}
+}
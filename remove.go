@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // RemoveCommand removes git worktrees with their associated branches.
@@ -20,6 +21,16 @@ type RemoveOptions struct {
 	// Matches git worktree behavior: -f for unclean, -f -f for locked.
 	Force  WorktreeForceLevel
 	DryRun bool
+	// Backup snapshots uncommitted changes and the branch tip before a
+	// forced removal. Nothing is removed if the backup fails.
+	Backup bool
+	// Remote also deletes the upstream branch on its remote after the local
+	// branch. Nothing is removed if the remote branch has commits the local
+	// branch does not.
+	Remote bool
+	// Trash moves the worktree directory to the trash instead of deleting
+	// it, keeping the branch tip in a trash ref. Backups are not taken then.
+	Trash bool
 }
 
 // NewRemoveCommand creates a RemoveCommand with explicit dependencies.
@@ -40,13 +51,62 @@ func NewDefaultRemoveCommand(cfg *Config) *RemoveCommand {
 type RemovedWorktree struct {
 	Branch       string
 	WorktreePath string
-	CleanedDirs  []string // Empty parent directories that were removed
-	Pruned       bool     // Stale worktree record was pruned (directory was already deleted)
+	CleanedDirs  []string               // Empty parent directories that were removed
+	Pruned       bool                   // Stale worktree record was pruned (directory was already deleted)
+	Backup       string                 // Name of the backup taken before a forced removal
+	Trash        string                 // Name of the trash entry holding the worktree directory
+	Remotes      []RemoteBranchDeletion // Upstream branches deleted with RemoveOptions.Remote
 	DryRun       bool
 	GitOutput    []byte
 	Err          error // nil if success
 }
 
+// RemoteBranchDeletion holds the result of deleting an upstream branch on its remote.
+type RemoteBranchDeletion struct {
+	Remote string // Remote name
+	Branch string // Short name of the upstream, e.g. "origin/feat/a"
+	Gone   bool   // Already deleted on the remote; only the stale remote-tracking ref was pruned
+	Err    error  // nil if success
+}
+
+// failure returns the error of the removal, or of the first failed remote
+// branch deletion. The local removal has succeeded in the latter case.
+func (r RemovedWorktree) failure() error {
+	if r.Err != nil {
+		return r.Err
+	}
+	for _, d := range r.Remotes {
+		if d.Err != nil {
+			return d.Err
+		}
+	}
+	return nil
+}
+
+// summary returns the notes shown after the branch on the summary line.
+func (r RemovedWorktree) summary() string {
+	var notes []string
+	if r.Backup != "" {
+		notes = append(notes, "backup "+r.Backup)
+	}
+	if r.Trash != "" {
+		notes = append(notes, "trash "+r.Trash)
+	}
+	for _, d := range r.Remotes {
+		switch {
+		case d.Err != nil:
+		case d.Gone:
+			notes = append(notes, d.Branch+" already deleted")
+		default:
+			notes = append(notes, "deleted "+d.Branch)
+		}
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, ", ") + ")"
+}
+
 // RemoveResult aggregates results from remove operations.
 type RemoveResult struct {
 	Removed []RemovedWorktree
@@ -55,7 +115,7 @@ type RemoveResult struct {
 // HasErrors returns true if any errors occurred.
 func (r RemoveResult) HasErrors() bool {
 	for _, wt := range r.Removed {
-		if wt.Err != nil {
+		if wt.failure() != nil {
 			return true
 		}
 	}
@@ -66,13 +126,29 @@ func (r RemoveResult) HasErrors() bool {
 func (r RemoveResult) ErrorCount() int {
 	count := 0
 	for _, wt := range r.Removed {
-		if wt.Err != nil {
+		if wt.failure() != nil {
 			count++
 		}
 	}
 	return count
 }
 
+// Errors returns the errors of the failed removals.
+func (r RemoveResult) Errors() []error {
+	return removalErrors(r.Removed)
+}
+
+// removalErrors returns the errors of the failed removals in removed.
+func removalErrors(removed []RemovedWorktree) []error {
+	var errs []error
+	for _, wt := range removed {
+		if err := wt.failure(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
 // Format formats the RemoveResult for display.
 func (r RemoveResult) Format(opts FormatOptions) FormatResult {
 	var stdout, stderr strings.Builder
@@ -90,7 +166,7 @@ func (r RemoveResult) Format(opts FormatOptions) FormatResult {
 	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
 }
 
-// formatRemoveError formats an error from the remove operation.
+// formatRemoveError formats an error from the remove or move operation.
 // It shows a short error message, and optionally the detailed git error.
 func formatRemoveError(w *strings.Builder, branch string, err error, verbose bool) {
 	var gitErr *GitError
@@ -110,11 +186,13 @@ func formatRemoveError(w *strings.Builder, branch string, err error, verbose boo
 
 // Format formats the RemovedWorktree for display.
 func (r RemovedWorktree) Format(opts FormatOptions) FormatResult {
-	var stdout strings.Builder
+	var stdout, stderr strings.Builder
 
 	if r.DryRun {
 		if r.Pruned {
 			fmt.Fprintf(&stdout, "Would prune stale worktree record\n")
+		} else if r.Trash != "" {
+			fmt.Fprintf(&stdout, "Would move worktree to trash: %s\n", r.WorktreePath)
 		} else if r.WorktreePath != "" {
 			fmt.Fprintf(&stdout, "Would remove worktree: %s\n", r.WorktreePath)
 		}
@@ -122,6 +200,13 @@ func (r RemovedWorktree) Format(opts FormatOptions) FormatResult {
 		for _, dir := range r.CleanedDirs {
 			fmt.Fprintf(&stdout, "Would remove empty directory: %s\n", dir)
 		}
+		for _, d := range r.Remotes {
+			if d.Gone {
+				fmt.Fprintf(&stdout, "Remote branch already deleted: %s\n", d.Branch)
+			} else {
+				fmt.Fprintf(&stdout, "Would delete remote branch: %s\n", d.Branch)
+			}
+		}
 		return FormatResult{Stdout: stdout.String()}
 	}
 
@@ -131,17 +216,33 @@ func (r RemovedWorktree) Format(opts FormatOptions) FormatResult {
 		}
 		if r.Pruned {
 			fmt.Fprintf(&stdout, "Pruned stale worktree and deleted branch: %s\n", r.Branch)
+		} else if r.Trash != "" {
+			fmt.Fprintf(&stdout, "Moved worktree to trash and deleted branch: %s\n", r.Branch)
 		} else {
 			fmt.Fprintf(&stdout, "Removed worktree and branch: %s\n", r.Branch)
 		}
 		for _, dir := range r.CleanedDirs {
 			fmt.Fprintf(&stdout, "Removed empty directory: %s\n", dir)
 		}
+		for _, d := range r.Remotes {
+			switch {
+			case d.Err != nil:
+			case d.Gone:
+				fmt.Fprintf(&stdout, "Pruned stale remote-tracking branch: %s\n", d.Branch)
+			default:
+				fmt.Fprintf(&stdout, "Deleted remote branch: %s\n", d.Branch)
+			}
+		}
 	}
 
-	fmt.Fprintf(&stdout, "twig remove: %s\n", r.Branch)
+	fmt.Fprintf(&stdout, "twig remove: %s%s\n", r.Branch, r.summary())
+	for _, d := range r.Remotes {
+		if d.Err != nil {
+			formatRemoveError(&stderr, r.Branch, d.Err, opts.Verbose)
+		}
+	}
 
-	return FormatResult{Stdout: stdout.String()}
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
 }
 
 // Run removes the worktree and branch for the given branch name.
@@ -175,21 +276,40 @@ func (c *RemoveCommand) Run(branch string, cwd string, opts RemoveOptions) (Remo
 		return result, fmt.Errorf("cannot remove: current directory is inside worktree %s", wtInfo.Path)
 	}
 
+	remote, err := c.prepareRemoteDelete(branch, opts)
+	if err != nil {
+		return result, err
+	}
+
 	if opts.DryRun {
+		if opts.Trash {
+			result.Trash = newTrashEntry(c.Config, branch, wtInfo.Path, time.Now()).Name
+		}
 		result.CleanedDirs = c.predictEmptyParentDirs(wtInfo.Path)
+		result.Remotes = c.deleteRemote(remote, opts)
 		return result, nil
 	}
 
 	var gitOutput []byte
-	var wtOpts []WorktreeRemoveOption
-	if opts.Force > WorktreeForceLevelNone {
-		wtOpts = append(wtOpts, WithForceRemove(opts.Force))
-	}
-	wtOut, err := c.Git.WorktreeRemove(wtInfo.Path, wtOpts...)
-	if err != nil {
-		return result, err
+	if opts.Trash {
+		if err := c.trash(wtInfo, opts, &result); err != nil {
+			return result, err
+		}
+	} else {
+		if err := c.backup(branch, wtInfo.Path, opts, &result); err != nil {
+			return result, err
+		}
+
+		var wtOpts []WorktreeRemoveOption
+		if opts.Force > WorktreeForceLevelNone {
+			wtOpts = append(wtOpts, WithForceRemove(opts.Force))
+		}
+		wtOut, err := c.Git.WorktreeRemove(wtInfo.Path, wtOpts...)
+		if err != nil {
+			return result, result.keptBackup(err)
+		}
+		gitOutput = append(gitOutput, wtOut...)
 	}
-	gitOutput = append(gitOutput, wtOut...)
 
 	result.CleanedDirs = c.cleanupEmptyParentDirs(wtInfo.Path)
 
@@ -199,24 +319,37 @@ func (c *RemoveCommand) Run(branch string, cwd string, opts RemoveOptions) (Remo
 	}
 	brOut, err := c.Git.BranchDelete(branch, branchOpts...)
 	if err != nil {
-		return result, err
+		return result, result.keptBackup(err)
 	}
 	gitOutput = append(gitOutput, brOut...)
 
 	result.GitOutput = gitOutput
+	result.Remotes = c.deleteRemote(remote, opts)
 	return result, nil
 }
 
 // removePrunable handles removal of a prunable worktree (directory already deleted).
 // It prunes the stale worktree record and deletes the branch.
 func (c *RemoveCommand) removePrunable(branch string, opts RemoveOptions, result RemovedWorktree) (RemovedWorktree, error) {
+	remote, err := c.prepareRemoteDelete(branch, opts)
+	if err != nil {
+		result.Err = err
+		return result, err
+	}
+
 	if opts.DryRun {
+		result.Remotes = c.deleteRemote(remote, opts)
 		return result, nil
 	}
 
+	if err := c.backup(branch, "", opts, &result); err != nil {
+		result.Err = err
+		return result, err
+	}
+
 	// Prune stale worktree records
 	if _, err := c.Git.WorktreePrune(); err != nil {
-		return result, fmt.Errorf("failed to prune worktrees: %w", err)
+		return result, result.keptBackup(fmt.Errorf("failed to prune worktrees: %w", err))
 	}
 
 	// Delete the branch
@@ -226,34 +359,205 @@ func (c *RemoveCommand) removePrunable(branch string, opts RemoveOptions, result
 	}
 	brOut, err := c.Git.BranchDelete(branch, branchOpts...)
 	if err != nil {
-		result.Err = err
-		return result, err
+		result.Err = result.keptBackup(err)
+		return result, result.Err
 	}
 	result.GitOutput = brOut
+	result.Remotes = c.deleteRemote(remote, opts)
+
+	return result, nil
+}
 
+// removeBranch deletes a local branch that is not checked out in any
+// worktree, and its upstream branch with opts.Remote.
+func (c *RemoveCommand) removeBranch(branch string, opts RemoveOptions) (RemovedWorktree, error) {
+	result := RemovedWorktree{Branch: branch, DryRun: opts.DryRun}
+
+	remote, err := c.prepareRemoteDelete(branch, opts)
+	if err != nil {
+		return result, err
+	}
+	if !opts.DryRun {
+		// Clean has checked the branch is merged or its upstream is gone,
+		// which git branch -d does not recognize.
+		out, err := c.Git.BranchDelete(branch, WithForceDelete())
+		if err != nil {
+			return result, err
+		}
+		result.GitOutput = out
+	}
+	result.Remotes = c.deleteRemote(remote, opts)
 	return result, nil
 }
 
+// remoteDelete is an upstream branch to delete with RemoveOptions.Remote.
+type remoteDelete struct {
+	upstream Upstream
+	hash     string // Commit the remote branch is expected at, empty if it is gone
+}
+
+// prepareRemoteDelete resolves the upstream of branch before anything is
+// removed, while its tracking configuration still exists. It fetches the
+// remote branch and refuses if it has commits that branch does not.
+// Returns nil if opts.Remote is not set or branch has no upstream.
+//
+// Only an upstream of the same name is deleted: a branch created with
+// --track origin/main has origin/main as its upstream, which is shared.
+// The default branch and default_source are never deleted.
+func (c *RemoveCommand) prepareRemoteDelete(branch string, opts RemoveOptions) (*remoteDelete, error) {
+	if !opts.Remote {
+		return nil, nil
+	}
+	upstream, err := c.Git.BranchUpstream(branch)
+	if err != nil || upstream == nil {
+		return nil, err
+	}
+	name := strings.TrimPrefix(upstream.Ref, RefsHeadsPrefix)
+	if name != branch {
+		return nil, fmt.Errorf(
+			"cannot delete %s: it is not the remote counterpart of %s, nothing was removed (remove without --remote)",
+			upstream, branch)
+	}
+	if c.isDefaultBranch(name) {
+		return nil, fmt.Errorf(
+			"cannot delete %s: it is the default branch, nothing was removed (remove without --remote)", upstream)
+	}
+
+	remote := &remoteDelete{upstream: *upstream}
+	// Dry-run uses the remote-tracking branch as last fetched
+	if !opts.DryRun {
+		exists, err := c.Git.FetchUpstream(*upstream)
+		if err != nil {
+			return nil, fmt.Errorf("%w, nothing was removed", err)
+		}
+		if !exists {
+			return remote, nil
+		}
+	}
+	hash, err := c.Git.revParse("--verify", "--quiet", upstream.TrackingRef)
+	if err != nil {
+		return remote, nil
+	}
+	if _, err := c.Git.Run(GitCmdMergeBase, "--is-ancestor", upstream.TrackingRef, RefsHeadsPrefix+branch); err != nil {
+		return nil, kindErrorf(ErrBranchNotMerged,
+			"cannot delete %s: it has commits that %s does not, nothing was removed", upstream, branch)
+	}
+	remote.hash = hash
+	return remote, nil
+}
+
+// isDefaultBranch reports whether branch is the repository's default branch
+// or the configured default_source.
+func (c *RemoveCommand) isDefaultBranch(branch string) bool {
+	if c.Config != nil && c.Config.DefaultSource != "" && branch == c.Config.DefaultSource {
+		return true
+	}
+	defaultBranch, err := c.Git.DefaultBranch()
+	return err == nil && branch == defaultBranch
+}
+
+// deleteRemote deletes the upstream branch prepared by prepareRemoteDelete,
+// or prunes its remote-tracking branch if it is already gone on the remote.
+// Failures are recorded in the result, as the local removal has succeeded.
+func (c *RemoveCommand) deleteRemote(remote *remoteDelete, opts RemoveOptions) []RemoteBranchDeletion {
+	if remote == nil {
+		return nil
+	}
+	deletion := RemoteBranchDeletion{
+		Remote: remote.upstream.Remote,
+		Branch: remote.upstream.String(),
+		Gone:   remote.hash == "",
+	}
+	if !opts.DryRun {
+		if deletion.Gone {
+			deletion.Err = c.Git.DeleteRef(remote.upstream.TrackingRef)
+		} else {
+			_, deletion.Err = c.Git.RemoteBranchDelete(remote.upstream, remote.hash)
+		}
+	}
+	return []RemoteBranchDeletion{deletion}
+}
+
+// backup snapshots the worktree at wtPath and the tip of branch when a
+// forced removal was requested with opts.Backup.
+func (c *RemoveCommand) backup(branch, wtPath string, opts RemoveOptions, result *RemovedWorktree) error {
+	if !opts.Backup || opts.Force == WorktreeForceLevelNone {
+		return nil
+	}
+	backup, err := createBackup(c.Git, branch, wtPath, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to back up %s, nothing was removed: %w", branch, err)
+	}
+	result.Backup = backup.Name
+	return nil
+}
+
+// keptBackup notes the backup taken before a removal that then failed, so
+// that it is not left behind unreported.
+func (r RemovedWorktree) keptBackup(err error) error {
+	if r.Backup == "" {
+		return err
+	}
+	return fmt.Errorf("%w (backup %s was kept)", err, r.Backup)
+}
+
+// trash moves the worktree to the trash, applying the checks git worktree
+// remove would: unclean worktrees need force, locked ones need -ff.
+func (c *RemoveCommand) trash(wt *Worktree, opts RemoveOptions, result *RemovedWorktree) error {
+	if wt.Locked && opts.Force < WorktreeForceLevelLocked {
+		return kindErrorf(ErrWorktreeLocked,
+			"cannot move %s to the trash: worktree is locked, use 'twig unlock' first or -ff", wt.Path)
+	}
+	if opts.Force == WorktreeForceLevelNone {
+		hasChanges, err := c.Git.InDir(wt.Path).HasChanges()
+		if err != nil {
+			return err
+		}
+		if hasChanges {
+			return kindErrorf(ErrWorktreeDirty,
+				"cannot move %s to the trash: worktree has uncommitted changes, use --force", wt.Path)
+		}
+	}
+
+	entry, err := moveToTrash(c.FS, c.Git, c.Config, *wt, time.Now())
+	if err != nil {
+		return err
+	}
+	result.Trash = entry.Name
+	return nil
+}
+
 // cleanupEmptyParentDirs removes empty parent directories up to WorktreeDestBaseDir.
 // Returns the list of directories that were removed. Errors are ignored since
 // cleanup failures should not fail the overall remove operation.
 func (c *RemoveCommand) cleanupEmptyParentDirs(wtPath string) []string {
+	return removeEmptyParentDirs(c.FS, c.Config.destBaseDirContaining(wtPath), wtPath)
+}
+
+// predictEmptyParentDirs predicts which parent directories would become empty
+// if wtPath were removed. Used for dry-run mode.
+func (c *RemoveCommand) predictEmptyParentDirs(wtPath string) []string {
+	return predictEmptyParentDirs(c.FS, c.Config.destBaseDirContaining(wtPath), wtPath)
+}
+
+// removeEmptyParentDirs removes the empty parent directories of path below
+// baseDir, innermost first. Returns the directories that were removed.
+func removeEmptyParentDirs(fsys FileSystem, baseDir, path string) []string {
 	var cleaned []string
-	baseDir := c.Config.WorktreeDestBaseDir
 	if baseDir == "" {
 		return cleaned
 	}
 
-	current := filepath.Dir(wtPath)
+	current := filepath.Dir(path)
 	for current != baseDir && strings.HasPrefix(current, baseDir) {
-		entries, err := c.FS.ReadDir(current)
+		entries, err := fsys.ReadDir(current)
 		if err != nil {
 			break
 		}
 		if len(entries) > 0 {
 			break
 		}
-		if err := c.FS.Remove(current); err != nil {
+		if err := fsys.Remove(current); err != nil {
 			break
 		}
 		cleaned = append(cleaned, current)
@@ -263,21 +567,20 @@ func (c *RemoveCommand) cleanupEmptyParentDirs(wtPath string) []string {
 	return cleaned
 }
 
-// predictEmptyParentDirs predicts which parent directories would become empty
-// if wtPath were removed. Used for dry-run mode.
-func (c *RemoveCommand) predictEmptyParentDirs(wtPath string) []string {
+// predictEmptyParentDirs returns the parent directories of path below
+// baseDir that would become empty if path were removed.
+func predictEmptyParentDirs(fsys FileSystem, baseDir, path string) []string {
 	var wouldClean []string
-	baseDir := c.Config.WorktreeDestBaseDir
 	if baseDir == "" {
 		return wouldClean
 	}
 
 	// Track the path being "removed" in simulation
-	removedPath := wtPath
-	current := filepath.Dir(wtPath)
+	removedPath := path
+	current := filepath.Dir(path)
 
 	for current != baseDir && strings.HasPrefix(current, baseDir) {
-		entries, err := c.FS.ReadDir(current)
+		entries, err := fsys.ReadDir(current)
 		if err != nil {
 			break
 		}
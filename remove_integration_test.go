@@ -140,6 +140,9 @@ func TestRemoveCommand_Integration(t *testing.T) {
 		if hint := gitErr.Hint(); hint == "" {
 			t.Error("GitError.Hint() should return hint for uncommitted changes")
 		}
+		if !errors.Is(err, ErrWorktreeDirty) {
+			t.Errorf("error should match ErrWorktreeDirty: %v", err)
+		}
 
 		// Now verify -f (WorktreeForceLevelUnclean) succeeds for uncommitted changes
 		_, err = cmd.Run("feature/force-test", mainDir, RemoveOptions{Force: WorktreeForceLevelUnclean})
@@ -187,10 +190,13 @@ func TestRemoveCommand_Integration(t *testing.T) {
 		if gitErr.Op != OpWorktreeRemove {
 			t.Errorf("GitError.Op = %v, want %v", gitErr.Op, OpWorktreeRemove)
 		}
-		expectedHint := "run 'git worktree unlock <path>' first, or use 'twig remove --force'"
+		expectedHint := "run 'twig unlock <branch>' first, or use 'twig remove -ff'"
 		if hint := gitErr.Hint(); hint != expectedHint {
 			t.Errorf("GitError.Hint() = %q, want %q", hint, expectedHint)
 		}
+		if !errors.Is(err, ErrWorktreeLocked) {
+			t.Errorf("error should match ErrWorktreeLocked: %v", err)
+		}
 
 		// Verify worktree is still locked
 		out := testutil.RunGit(t, mainDir, "worktree", "list", "--porcelain")
@@ -717,3 +723,105 @@ func TestRemoveCommand_Integration(t *testing.T) {
 		}
 	})
 }
+
+func TestRemoveCommand_Integration_Remote(t *testing.T) {
+	t.Parallel()
+
+	// setup creates a worktree for feature/remote whose branch is pushed
+	// to a local bare remote.
+	setup := func(t *testing.T) (cmd *RemoveCommand, repoDir, mainDir, remoteDir string) {
+		t.Helper()
+
+		repoDir, mainDir = testutil.SetupTestRepo(t)
+		remoteDir = filepath.Join(repoDir, "remote.git")
+		testutil.RunGit(t, repoDir, "init", "--bare", remoteDir)
+		testutil.RunGit(t, mainDir, "remote", "add", "origin", remoteDir)
+		testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+
+		wtPath := filepath.Join(repoDir, "feature", "remote")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feature/remote", wtPath)
+		testutil.RunGit(t, wtPath, "push", "-u", "origin", "feature/remote")
+
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd = &RemoveCommand{
+			FS:     osFS{},
+			Git:    NewGitRunner(mainDir),
+			Config: result.Config,
+		}
+		return cmd, repoDir, mainDir, remoteDir
+	}
+
+	t.Run("DeletesRemoteBranch", func(t *testing.T) {
+		t.Parallel()
+
+		cmd, _, mainDir, remoteDir := setup(t)
+
+		result, err := cmd.Run("feature/remote", mainDir, RemoveOptions{Remote: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		want := []RemoteBranchDeletion{{Remote: "origin", Branch: "origin/feature/remote"}}
+		if len(result.Remotes) != 1 || result.Remotes[0] != want[0] {
+			t.Errorf("Remotes = %+v, want %+v", result.Remotes, want)
+		}
+		if out := testutil.RunGit(t, remoteDir, "branch", "--list", "feature/remote"); strings.TrimSpace(out) != "" {
+			t.Errorf("remote branch should be deleted, got: %s", out)
+		}
+		if out := testutil.RunGit(t, mainDir, "branch", "-r", "--list", "origin/feature/remote"); strings.TrimSpace(out) != "" {
+			t.Errorf("remote-tracking branch should be deleted, got: %s", out)
+		}
+	})
+
+	t.Run("RefusesWhenRemoteHasCommits", func(t *testing.T) {
+		t.Parallel()
+
+		cmd, repoDir, mainDir, remoteDir := setup(t)
+
+		// Another clone pushes a commit the local branch does not have
+		otherDir := filepath.Join(repoDir, "other")
+		testutil.RunGit(t, repoDir, "clone", "--branch", "feature/remote", remoteDir, otherDir)
+		testutil.RunGit(t, otherDir, "-c", "user.name=Other", "-c", "user.email=other@example.com",
+			"commit", "--allow-empty", "-m", "remote only")
+		testutil.RunGit(t, otherDir, "push", "origin", "feature/remote")
+
+		_, err := cmd.Run("feature/remote", mainDir, RemoveOptions{Remote: true, Force: WorktreeForceLevelUnclean})
+		if err == nil || !strings.Contains(err.Error(), "has commits that feature/remote does not") {
+			t.Fatalf("error = %v, want refusal", err)
+		}
+		if !errors.Is(err, ErrBranchNotMerged) {
+			t.Errorf("error should match ErrBranchNotMerged: %v", err)
+		}
+
+		if out := testutil.RunGit(t, mainDir, "branch", "--list", "feature/remote"); strings.TrimSpace(out) == "" {
+			t.Error("local branch should not be deleted")
+		}
+		if out := testutil.RunGit(t, remoteDir, "branch", "--list", "feature/remote"); strings.TrimSpace(out) == "" {
+			t.Error("remote branch should not be deleted")
+		}
+	})
+
+	t.Run("PrunesWhenRemoteBranchGone", func(t *testing.T) {
+		t.Parallel()
+
+		cmd, _, mainDir, remoteDir := setup(t)
+
+		// The remote branch is deleted elsewhere, leaving a stale remote-tracking branch
+		testutil.RunGit(t, remoteDir, "branch", "-D", "feature/remote")
+
+		result, err := cmd.Run("feature/remote", mainDir, RemoveOptions{Remote: true})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		if len(result.Remotes) != 1 || !result.Remotes[0].Gone || result.Remotes[0].Err != nil {
+			t.Errorf("Remotes = %+v, want one gone deletion", result.Remotes)
+		}
+		if out := testutil.RunGit(t, mainDir, "branch", "-r", "--list", "origin/feature/remote"); strings.TrimSpace(out) != "" {
+			t.Errorf("stale remote-tracking branch should be pruned, got: %s", out)
+		}
+	})
+}
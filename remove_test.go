@@ -3,6 +3,7 @@ package twig
 import (
 	"errors"
 	"os"
+	"os/exec"
 	"slices"
 	"strings"
 	"testing"
@@ -493,6 +494,362 @@ func TestRemoveCommand_Run(t *testing.T) {
 	}
 }
 
+func TestRemoveCommand_Run_Backup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       RemoveOptions
+		prunable   bool
+		backupErr  error
+		wantBackup bool
+		wantErr    string
+	}{
+		{
+			name:       "forced",
+			opts:       RemoveOptions{Force: WorktreeForceLevelUnclean, Backup: true},
+			wantBackup: true,
+		},
+		{
+			name:       "forced_prunable",
+			opts:       RemoveOptions{Force: WorktreeForceLevelUnclean, Backup: true},
+			prunable:   true,
+			wantBackup: true,
+		},
+		{
+			name: "not_forced",
+			opts: RemoveOptions{Backup: true},
+		},
+		{
+			name: "disabled",
+			opts: RemoveOptions{Force: WorktreeForceLevelUnclean},
+		},
+		{
+			name: "dry_run",
+			opts: RemoveOptions{Force: WorktreeForceLevelUnclean, Backup: true, DryRun: true},
+		},
+		{
+			name:      "failure_aborts_removal",
+			opts:      RemoveOptions{Force: WorktreeForceLevelUnclean, Backup: true},
+			backupErr: errors.New("exit status 128"),
+			wantErr:   "failed to back up feature/test, nothing was removed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var backupRefs, removals []string
+			base := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/feature/test", Branch: "feature/test", Prunable: tt.prunable},
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					cmd := args[2:]
+					switch {
+					case cmd[0] == "update-ref":
+						backupRefs = append(backupRefs, cmd[3])
+						return nil, tt.backupErr
+					case cmd[0] == "worktree" && cmd[1] != "list",
+						cmd[0] == "branch" && slices.Contains(cmd, "-D"):
+						removals = append(removals, strings.Join(cmd, " "))
+					}
+					return base.Run(args...)
+				},
+			}
+
+			cmd := &RemoveCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: mockGit},
+				Config: &Config{WorktreeSourceDir: "/repo/main"},
+			}
+			result, err := cmd.Run("feature/test", "/other/dir", tt.opts)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(removals) > 0 {
+					t.Errorf("nothing should be removed, got %v", removals)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !tt.wantBackup {
+				if len(backupRefs) > 0 || result.Backup != "" {
+					t.Errorf("expected no backup, got refs %v, Backup %q", backupRefs, result.Backup)
+				}
+				return
+			}
+			if len(backupRefs) != 1 || !strings.HasPrefix(backupRefs[0], BackupRefPrefix+"feature/test/") {
+				t.Fatalf("backup refs = %v", backupRefs)
+			}
+			if BackupRefPrefix+result.Backup != backupRefs[0] {
+				t.Errorf("Backup = %q, want name of %q", result.Backup, backupRefs[0])
+			}
+			if got := result.Format(FormatOptions{}).Stdout; got != "twig remove: feature/test (backup "+result.Backup+")\n" {
+				t.Errorf("Stdout = %q", got)
+			}
+		})
+	}
+}
+
+func TestRemoveCommand_Run_BackupKeptOnFailure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		prunable  bool
+		failingOp string // git command that fails after the backup
+	}{
+		{name: "worktree_remove", failingOp: "worktree remove"},
+		{name: "branch_delete", failingOp: "branch -D"},
+		{name: "prunable_branch_delete", prunable: true, failingOp: "branch -D"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var backupRef string
+			base := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/feature/test", Branch: "feature/test", Prunable: tt.prunable},
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					cmd := args[2:]
+					switch {
+					case cmd[0] == "update-ref":
+						backupRef = cmd[3]
+						return nil, nil
+					case strings.HasPrefix(strings.Join(cmd, " "), tt.failingOp):
+						return nil, errors.New("exit status 1")
+					}
+					return base.Run(args...)
+				},
+			}
+
+			cmd := &RemoveCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: mockGit},
+				Config: &Config{WorktreeSourceDir: "/repo/main"},
+			}
+			_, err := cmd.Run("feature/test", "/other/dir", RemoveOptions{
+				Force:  WorktreeForceLevelUnclean,
+				Backup: true,
+			})
+
+			if backupRef == "" {
+				t.Fatal("expected a backup to be taken")
+			}
+			want := "(backup " + strings.TrimPrefix(backupRef, BackupRefPrefix) + " was kept)"
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("error = %v, want containing %q", err, want)
+			}
+		})
+	}
+}
+
+func TestRemoveCommand_Run_Remote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		opts          RemoveOptions
+		upstream      string
+		defaultSource string
+		fetchErr      error
+		diverged      bool
+		pushErr       error
+		wantErr       string
+		wantRemotes   []RemoteBranchDeletion
+		wantCalls     []string
+		wantStdout    string
+	}{
+		{
+			name:        "deletes_upstream",
+			opts:        RemoveOptions{Remote: true},
+			upstream:    "origin\x00refs/heads/feature/test\x00refs/remotes/origin/feature/test\n",
+			wantRemotes: []RemoteBranchDeletion{{Remote: "origin", Branch: "origin/feature/test"}},
+			wantCalls: []string{
+				"fetch --no-tags origin +refs/heads/feature/test:refs/remotes/origin/feature/test",
+				"push --porcelain --force-with-lease=refs/heads/feature/test:abc123 origin --delete refs/heads/feature/test",
+				"update-ref -d refs/remotes/origin/feature/test",
+			},
+			wantStdout: "twig remove: feature/test (deleted origin/feature/test)\n",
+		},
+		{
+			name:        "already_gone_prunes_tracking_ref",
+			opts:        RemoveOptions{Remote: true},
+			upstream:    "origin\x00refs/heads/feature/test\x00refs/remotes/origin/feature/test\n",
+			fetchErr:    &exec.ExitError{Stderr: []byte("fatal: couldn't find remote ref refs/heads/feature/test")},
+			wantRemotes: []RemoteBranchDeletion{{Remote: "origin", Branch: "origin/feature/test", Gone: true}},
+			wantCalls: []string{
+				"fetch --no-tags origin +refs/heads/feature/test:refs/remotes/origin/feature/test",
+				"update-ref -d refs/remotes/origin/feature/test",
+			},
+			wantStdout: "twig remove: feature/test (origin/feature/test already deleted)\n",
+		},
+		{
+			name:     "diverged_refuses",
+			opts:     RemoveOptions{Remote: true},
+			upstream: "origin\x00refs/heads/feature/test\x00refs/remotes/origin/feature/test\n",
+			diverged: true,
+			wantErr:  "cannot delete origin/feature/test: it has commits that feature/test does not, nothing was removed",
+		},
+		{
+			name:     "upstream_with_other_name_refuses",
+			opts:     RemoveOptions{Remote: true},
+			upstream: "origin\x00refs/heads/main\x00refs/remotes/origin/main\n",
+			wantErr:  "cannot delete origin/main: it is not the remote counterpart of feature/test, nothing was removed",
+		},
+		{
+			name:          "default_branch_refuses",
+			opts:          RemoveOptions{Remote: true, DryRun: true},
+			upstream:      "origin\x00refs/heads/feature/test\x00refs/remotes/origin/feature/test\n",
+			defaultSource: "feature/test",
+			wantErr:       "cannot delete origin/feature/test: it is the default branch, nothing was removed",
+		},
+		{
+			name:     "fetch_failure_refuses",
+			opts:     RemoveOptions{Remote: true},
+			upstream: "origin\x00refs/heads/feature/test\x00refs/remotes/origin/feature/test\n",
+			fetchErr: &exec.ExitError{Stderr: []byte("fatal: could not read from remote repository")},
+			wantErr:  "failed to fetch origin/feature/test",
+		},
+		{
+			name:     "push_failure_is_recorded",
+			opts:     RemoveOptions{Remote: true},
+			upstream: "origin\x00refs/heads/feature/test\x00refs/remotes/origin/feature/test\n",
+			pushErr:  errors.New("exit status 1"),
+			wantCalls: []string{
+				"fetch --no-tags origin +refs/heads/feature/test:refs/remotes/origin/feature/test",
+				"push --porcelain --force-with-lease=refs/heads/feature/test:abc123 origin --delete refs/heads/feature/test",
+			},
+			wantStdout: "twig remove: feature/test\n",
+		},
+		{
+			name:       "no_upstream",
+			opts:       RemoveOptions{Remote: true},
+			upstream:   "\x00\x00\n",
+			wantStdout: "twig remove: feature/test\n",
+		},
+		{
+			name:       "tracks_local_branch",
+			opts:       RemoveOptions{Remote: true},
+			upstream:   ".\x00refs/heads/main\x00refs/heads/main\n",
+			wantStdout: "twig remove: feature/test\n",
+		},
+		{
+			name:        "dry_run_does_not_fetch_or_push",
+			opts:        RemoveOptions{Remote: true, DryRun: true},
+			upstream:    "origin\x00refs/heads/feature/test\x00refs/remotes/origin/feature/test\n",
+			wantRemotes: []RemoteBranchDeletion{{Remote: "origin", Branch: "origin/feature/test"}},
+			wantStdout:  "Would remove worktree: /repo/feature/test\nWould delete branch: feature/test\nWould delete remote branch: origin/feature/test\n",
+		},
+		{
+			name:       "not_requested",
+			opts:       RemoveOptions{},
+			upstream:   "origin\x00refs/heads/feature/test\x00refs/remotes/origin/feature/test\n",
+			wantStdout: "twig remove: feature/test\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls, removals []string
+			base := &testutil.MockGitExecutor{
+				Worktrees: []testutil.MockWorktree{
+					{Path: "/repo/feature/test", Branch: "feature/test"},
+				},
+			}
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					cmd := args[2:]
+					switch cmd[0] {
+					case "for-each-ref":
+						return []byte(tt.upstream), nil
+					case "fetch":
+						calls = append(calls, strings.Join(cmd, " "))
+						return nil, tt.fetchErr
+					case "rev-parse":
+						if cmd[len(cmd)-1] == "refs/remotes/origin/feature/test" {
+							return []byte("abc123\n"), nil
+						}
+					case "merge-base":
+						if tt.diverged {
+							return nil, errors.New("exit status 1")
+						}
+						return nil, nil
+					case "push":
+						calls = append(calls, strings.Join(cmd, " "))
+						return nil, tt.pushErr
+					case "update-ref":
+						calls = append(calls, strings.Join(cmd, " "))
+						return nil, nil
+					case "worktree", "branch":
+						if cmd[1] != "list" {
+							removals = append(removals, strings.Join(cmd, " "))
+						}
+					}
+					return base.Run(args...)
+				},
+			}
+
+			cmd := &RemoveCommand{
+				FS:     &testutil.MockFS{},
+				Git:    &GitRunner{Executor: mockGit},
+				Config: &Config{WorktreeSourceDir: "/repo/main", DefaultSource: tt.defaultSource},
+			}
+			result, err := cmd.Run("feature/test", "/other/dir", tt.opts)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				if len(removals) > 0 {
+					t.Errorf("nothing should be removed, got %v", removals)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+			if tt.pushErr != nil {
+				if len(result.Remotes) != 1 || result.Remotes[0].Err == nil {
+					t.Fatalf("Remotes = %+v, want a failed deletion", result.Remotes)
+				}
+				removeResult := RemoveResult{Removed: []RemovedWorktree{result}}
+				if !removeResult.HasErrors() || removeResult.ErrorCount() != 1 {
+					t.Error("a failed remote deletion should count as an error")
+				}
+				if got := result.Format(FormatOptions{}).Stderr; got != "error: feature/test: failed to delete remote branch\n" {
+					t.Errorf("Stderr = %q", got)
+				}
+			} else if !slices.Equal(result.Remotes, tt.wantRemotes) {
+				t.Errorf("Remotes = %+v, want %+v", result.Remotes, tt.wantRemotes)
+			}
+			if got := result.Format(FormatOptions{}).Stdout; got != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got, tt.wantStdout)
+			}
+		})
+	}
+}
+
 func TestRemoveCommand_CleanupEmptyParentDirs(t *testing.T) {
 	t.Parallel()
 
@@ -697,13 +1054,19 @@ func TestRemovedWorktree_Format_WithCleanedDirs(t *testing.T) {
 
 // mockDirEntry implements os.DirEntry for testing.
 type mockDirEntry struct {
-	name  string
-	isDir bool
+	name    string
+	isDir   bool
+	symlink bool
 }
 
-func (m mockDirEntry) Name() string               { return m.name }
-func (m mockDirEntry) IsDir() bool                { return m.isDir }
-func (m mockDirEntry) Type() os.FileMode          { return 0 }
+func (m mockDirEntry) Name() string { return m.name }
+func (m mockDirEntry) IsDir() bool  { return m.isDir }
+func (m mockDirEntry) Type() os.FileMode {
+	if m.symlink {
+		return os.ModeSymlink
+	}
+	return 0
+}
 func (m mockDirEntry) Info() (os.FileInfo, error) { return nil, nil }
 
 func TestGitError_Hint(t *testing.T) {
@@ -733,7 +1096,7 @@ func TestGitError_Hint(t *testing.T) {
 				Op:     OpWorktreeRemove,
 				Stderr: "fatal: cannot remove a locked working tree",
 			},
-			want: "run 'git worktree unlock <path>' first, or use 'twig remove --force'",
+			want: "run 'twig unlock <branch>' first, or use 'twig remove -ff'",
 		},
 		{
 			name: "unknown_error",
@@ -830,7 +1193,7 @@ func TestRemoveResult_Format_WithHint(t *testing.T) {
 				}},
 			},
 			opts:       FormatOptions{Verbose: false},
-			wantStderr: "error: feature/a: failed to remove worktree\nhint: run 'git worktree unlock <path>' first, or use 'twig remove --force'\n",
+			wantStderr: "error: feature/a: failed to remove worktree\nhint: run 'twig unlock <branch>' first, or use 'twig remove -ff'\n",
 		},
 		{
 			name: "non_git_error_fallback",
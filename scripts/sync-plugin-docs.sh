@@ -13,6 +13,7 @@ mkdir -p "$REFERENCES_DIR/commands"
 
 # Copy all reference docs
 cp "$DOCS_DIR/configuration.md" "$REFERENCES_DIR/"
+cp "$DOCS_DIR/exit-codes.md" "$REFERENCES_DIR/"
 cp "$DOCS_DIR/commands"/*.md "$REFERENCES_DIR/commands/"
 
 echo "Plugin docs synced successfully"
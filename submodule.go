@@ -0,0 +1,78 @@
+package twig
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// SubmoduleMode controls how submodules are populated in new worktrees.
+type SubmoduleMode string
+
+const (
+	// SubmoduleModeNone leaves submodules uninitialized, like git worktree add (default).
+	SubmoduleModeNone SubmoduleMode = "none"
+	// SubmoduleModeInit initializes and checks out the top-level submodules.
+	SubmoduleModeInit SubmoduleMode = "init"
+	// SubmoduleModeRecursive also initializes nested submodules.
+	SubmoduleModeRecursive SubmoduleMode = "recursive"
+)
+
+// ParseSubmoduleMode parses a submodules value. An empty string means none.
+func ParseSubmoduleMode(s string) (SubmoduleMode, error) {
+	switch SubmoduleMode(s) {
+	case "", SubmoduleModeNone:
+		return SubmoduleModeNone, nil
+	case SubmoduleModeInit:
+		return SubmoduleModeInit, nil
+	case SubmoduleModeRecursive:
+		return SubmoduleModeRecursive, nil
+	default:
+		return "", fmt.Errorf("invalid submodules mode %q (valid modes: %s, %s, %s)",
+			s, SubmoduleModeNone, SubmoduleModeInit, SubmoduleModeRecursive)
+	}
+}
+
+// SubmoduleResult holds the outcome of initializing one submodule.
+type SubmoduleResult struct {
+	Path string
+	// Reference is the source worktree's checkout of the submodule whose
+	// objects were copied instead of fetching them, empty if none was used.
+	Reference string
+	Err       error
+}
+
+// initSubmodules populates the submodules of the worktree at wtPath,
+// borrowing objects from the checkouts in srcDir where they exist.
+// Failures are reported per submodule so one broken URL does not hide the rest.
+func initSubmodules(fs FileSystem, git *GitRunner, srcDir, wtPath string, mode SubmoduleMode) ([]SubmoduleResult, error) {
+	if mode == "" || mode == SubmoduleModeNone {
+		return nil, nil
+	}
+
+	wtGit := git.InDir(wtPath)
+	paths, err := wtGit.SubmodulePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SubmoduleResult
+	for _, path := range paths {
+		result := SubmoduleResult{Path: path}
+
+		var opts []SubmoduleUpdateOption
+		if mode == SubmoduleModeRecursive {
+			opts = append(opts, WithSubmoduleRecursive())
+		}
+		ref := filepath.Join(srcDir, path)
+		if _, err := fs.Stat(filepath.Join(ref, ".git")); err == nil {
+			result.Reference = ref
+			opts = append(opts, WithSubmoduleReference(ref))
+		}
+
+		if _, err := wtGit.SubmoduleUpdate(path, opts...); err != nil {
+			result.Err = err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,42 @@
+package twig
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// SymlinkStyle controls how symlink targets are written.
+type SymlinkStyle string
+
+const (
+	// SymlinkStyleAbsolute links to the absolute path of the source file (default).
+	SymlinkStyleAbsolute SymlinkStyle = "absolute"
+	// SymlinkStyleRelative links relative to the symlink's directory, so links keep
+	// working when the repository and its worktrees are moved together.
+	SymlinkStyleRelative SymlinkStyle = "relative"
+)
+
+// ParseSymlinkStyle parses a symlink_style value. An empty string means absolute.
+func ParseSymlinkStyle(s string) (SymlinkStyle, error) {
+	switch SymlinkStyle(s) {
+	case "", SymlinkStyleAbsolute:
+		return SymlinkStyleAbsolute, nil
+	case SymlinkStyleRelative:
+		return SymlinkStyleRelative, nil
+	default:
+		return "", fmt.Errorf("invalid symlink style %q (valid styles: %s, %s)",
+			s, SymlinkStyleAbsolute, SymlinkStyleRelative)
+	}
+}
+
+// linkTarget returns the target to write for a symlink at dst pointing to src.
+func linkTarget(src, dst string, style SymlinkStyle) (string, error) {
+	if style != SymlinkStyleRelative {
+		return src, nil
+	}
+	rel, err := filepath.Rel(filepath.Dir(dst), src)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute relative path from %s to %s: %w", dst, src, err)
+	}
+	return rel, nil
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+
+package twig
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ttyTerminal is the controlling terminal in raw mode. It is opened
+// directly so that the picker works while stdout is captured, as in
+// cd "$(twig pick)".
+type ttyTerminal struct {
+	*os.File
+	saved string // stty settings to restore
+}
+
+// openTerminal opens the controlling terminal and puts it in raw mode.
+func openTerminal() (Terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terminal: %w", err)
+	}
+	t := &ttyTerminal{File: tty}
+
+	saved, err := t.stty("-g")
+	if err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("failed to read terminal settings: %w", err)
+	}
+	t.saved = strings.TrimSpace(saved)
+	if _, err := t.stty("raw", "-echo"); err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("failed to set terminal to raw mode: %w", err)
+	}
+	return t, nil
+}
+
+// stty runs stty on the terminal.
+func (t *ttyTerminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.File
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// Size returns the terminal size, or 80x24 if it cannot be read.
+func (t *ttyTerminal) Size() (width, height int) {
+	out, err := t.stty("size")
+	if err == nil {
+		if _, err := fmt.Sscan(out, &height, &width); err == nil && width > 0 && height > 0 {
+			return width, height
+		}
+	}
+	return 80, 24
+}
+
+// Restore restores the terminal settings and closes the terminal.
+func (t *ttyTerminal) Restore() error {
+	_, err := t.stty(t.saved)
+	if closeErr := t.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package twig
+
+// openTerminal is not supported on Windows, whose console has no stty.
+// The picker lists its candidates in the error instead.
+func openTerminal() (Terminal, error) {
+	return nil, errTerminalUnsupported
+}
@@ -0,0 +1,240 @@
+package twig
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// encodeTOMLAssignment renders "key = value" using the TOML encoder.
+func encodeTOMLAssignment(key string, value any) (string, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]any{key: value}); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// setTOMLKey sets a top-level key in TOML content.
+// An existing assignment is replaced in place so that comments and the
+// formatting of other lines are preserved. A new assignment is inserted
+// before the first table header, or appended at the end of the file.
+func setTOMLKey(content []byte, key, assignment string) []byte {
+	lines := splitTOMLLines(content)
+
+	if start, end := findTOMLKey(lines, key); start >= 0 {
+		if comment := tomlTrailingComment(lines[end-1]); comment != "" {
+			assignment += " " + comment
+		}
+		lines = append(lines[:start], append([]string{assignment}, lines[end:]...)...)
+		return joinTOMLLines(lines)
+	}
+
+	if header := firstTOMLTable(lines); header >= 0 {
+		insert := []string{assignment, ""}
+		lines = append(lines[:header], append(insert, lines[header:]...)...)
+		return joinTOMLLines(lines)
+	}
+
+	lines = append(lines, assignment)
+	return joinTOMLLines(lines)
+}
+
+// unsetTOMLKey removes a top-level key assignment from TOML content.
+// Returns false if the key was not present.
+func unsetTOMLKey(content []byte, key string) ([]byte, bool) {
+	lines := splitTOMLLines(content)
+
+	start, end := findTOMLKey(lines, key)
+	if start < 0 {
+		return content, false
+	}
+	lines = append(lines[:start], lines[end:]...)
+	return joinTOMLLines(lines), true
+}
+
+// findTOMLKey locates the lines of a top-level assignment of key.
+// Returns the start line and the exclusive end line, or -1, -1 if absent.
+func findTOMLKey(lines []string, key string) (int, int) {
+	for _, st := range scanTOMLStatements(lines) {
+		if !st.table && st.key == key {
+			return st.start, st.end
+		}
+	}
+	return -1, -1
+}
+
+// firstTOMLTable returns the line index of the first table header, or -1.
+func firstTOMLTable(lines []string) int {
+	for _, st := range scanTOMLStatements(lines) {
+		if st.table {
+			return st.start
+		}
+	}
+	return -1
+}
+
+// tomlStatement is a top-level key assignment or the first table header.
+type tomlStatement struct {
+	start, end int    // line range; end is exclusive
+	key        string // unquoted key name, empty for table headers
+	table      bool
+}
+
+// scanTOMLStatements lists the top-level statements up to and including
+// the first table header. Values spanning several lines (arrays and
+// multi-line strings) are covered by a single statement, so their content
+// is never mistaken for an assignment.
+func scanTOMLStatements(lines []string) []tomlStatement {
+	var stmts []tomlStatement
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			return append(stmts, tomlStatement{start: i, end: i + 1, table: true})
+		}
+		name, rest, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		depth, open := tomlScanValue(rest, "")
+		end := i + 1
+		for (depth > 0 || open != "") && end < len(lines) {
+			var d int
+			d, open = tomlScanValue(lines[end], open)
+			depth += d
+			end++
+		}
+		stmts = append(stmts, tomlStatement{start: i, end: end, key: tomlKeyName(name)})
+		i = end - 1
+	}
+	return stmts
+}
+
+// tomlKeyName returns the name of a simple key, removing its quotes.
+func tomlKeyName(name string) string {
+	name = strings.TrimSpace(name)
+	if len(name) < 2 {
+		return name
+	}
+	switch {
+	case name[0] == '\'' && name[len(name)-1] == '\'':
+		return name[1 : len(name)-1]
+	case name[0] == '"' && name[len(name)-1] == '"':
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			return unquoted
+		}
+	}
+	return name
+}
+
+// verifyTOMLEdit checks that edited differs from original only in the
+// top-level key, which must hold the value of assignment, or be absent
+// when assignment is empty. The line editor does not understand every
+// TOML construct, so this catches edits that would touch another setting.
+func verifyTOMLEdit(original, edited []byte, key, assignment string) error {
+	want := map[string]any{}
+	if _, err := toml.Decode(string(original), &want); err != nil {
+		return fmt.Errorf("failed to parse: %w", err)
+	}
+	delete(want, key)
+	if assignment != "" {
+		value := map[string]any{}
+		if _, err := toml.Decode(assignment, &value); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		want[key] = value[key]
+	}
+
+	got := map[string]any{}
+	if _, err := toml.Decode(string(edited), &got); err != nil {
+		return fmt.Errorf("edit would produce invalid TOML: %w", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("%s is written in a form the editor does not support", key)
+	}
+	return nil
+}
+
+// tomlTrailingComment returns the comment at the end of a line, if any.
+func tomlTrailingComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+// tomlScanValue returns the net change in array nesting for a line and
+// the delimiter of a multi-line string still open at its end. open is the
+// delimiter of the multi-line string the line starts in, if any. Brackets
+// inside strings and comments are ignored.
+func tomlScanValue(line, open string) (int, string) {
+	delta := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		if open != "" {
+			j := strings.Index(line[i:], open)
+			if j < 0 {
+				return delta, open
+			}
+			i += j + len(open) - 1
+			open = ""
+			continue
+		}
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], "'''"):
+			open = line[i : i+3]
+			i += 2
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return delta, ""
+		case ch == '[':
+			delta++
+		case ch == ']':
+			delta--
+		}
+	}
+	return delta, open
+}
+
+func splitTOMLLines(content []byte) []string {
+	s := strings.TrimSuffix(string(content), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func joinTOMLLines(lines []string) []byte {
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
@@ -0,0 +1,214 @@
+package twig
+
+import "testing"
+
+func TestSetTOMLKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		content    string
+		key        string
+		assignment string
+		want       string
+	}{
+		{
+			name:       "empty file",
+			content:    "",
+			key:        "default_source",
+			assignment: `default_source = "main"`,
+			want:       "default_source = \"main\"\n",
+		},
+		{
+			name:       "replace preserves comments",
+			content:    "# comment\ndefault_source = \"main\" # trailing\n\n# other\nsymlinks = []\n",
+			key:        "default_source",
+			assignment: `default_source = "develop"`,
+			want:       "# comment\ndefault_source = \"develop\" # trailing\n\n# other\nsymlinks = []\n",
+		},
+		{
+			name:       "replace multi-line array",
+			content:    "symlinks = [\n  \".envrc\", # env\n  \"a]b\",\n]\ndefault_source = \"main\"\n",
+			key:        "symlinks",
+			assignment: `symlinks = [".tool-versions"]`,
+			want:       "symlinks = [\".tool-versions\"]\ndefault_source = \"main\"\n",
+		},
+		{
+			name:       "commented-out key is not replaced",
+			content:    "# default_source = \"main\"\n",
+			key:        "default_source",
+			assignment: `default_source = "develop"`,
+			want:       "# default_source = \"main\"\ndefault_source = \"develop\"\n",
+		},
+		{
+			name:       "insert before first table",
+			content:    "symlinks = []\n\n[table]\ndefault_source = \"x\"\n",
+			key:        "default_source",
+			assignment: `default_source = "main"`,
+			want:       "symlinks = []\n\ndefault_source = \"main\"\n\n[table]\ndefault_source = \"x\"\n",
+		},
+		{
+			name:       "replace quoted key",
+			content:    "\"default_source\" = \"main\"\n'symlink_style' = \"absolute\"\n",
+			key:        "symlink_style",
+			assignment: `symlink_style = "relative"`,
+			want:       "\"default_source\" = \"main\"\nsymlink_style = \"relative\"\n",
+		},
+		{
+			name:       "multi-line string content is not an assignment",
+			content:    "note = \"\"\"\ndefault_source = \"x\"\n[table]\n\"\"\"\ndefault_source = \"main\"\n",
+			key:        "default_source",
+			assignment: `default_source = "develop"`,
+			want:       "note = \"\"\"\ndefault_source = \"x\"\n[table]\n\"\"\"\ndefault_source = \"develop\"\n",
+		},
+		{
+			name:       "replace multi-line literal string",
+			content:    "default_source = '''\nmain\n'''\nsymlinks = []\n",
+			key:        "default_source",
+			assignment: `default_source = "develop"`,
+			want:       "default_source = \"develop\"\nsymlinks = []\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := string(setTOMLKey([]byte(tt.content), tt.key, tt.assignment))
+			if got != tt.want {
+				t.Errorf("setTOMLKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnsetTOMLKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		content     string
+		key         string
+		want        string
+		wantRemoved bool
+	}{
+		{
+			name:        "removes scalar",
+			content:     "# keep\ndefault_source = \"main\"\nsymlinks = []\n",
+			key:         "default_source",
+			want:        "# keep\nsymlinks = []\n",
+			wantRemoved: true,
+		},
+		{
+			name:        "removes multi-line array",
+			content:     "symlinks = [\n  \".envrc\",\n]\n# keep\n",
+			key:         "symlinks",
+			want:        "# keep\n",
+			wantRemoved: true,
+		},
+		{
+			name:        "missing key",
+			content:     "symlinks = []\n",
+			key:         "default_source",
+			want:        "symlinks = []\n",
+			wantRemoved: false,
+		},
+		{
+			name:        "key inside table is ignored",
+			content:     "[table]\ndefault_source = \"main\"\n",
+			key:         "default_source",
+			want:        "[table]\ndefault_source = \"main\"\n",
+			wantRemoved: false,
+		},
+		{
+			name:        "removes quoted key",
+			content:     "\"default_source\" = \"main\"\n# keep\n",
+			key:         "default_source",
+			want:        "# keep\n",
+			wantRemoved: true,
+		},
+		{
+			name:        "key inside multi-line string is ignored",
+			content:     "note = '''\ndefault_source = \"main\"\n'''\n",
+			key:         "default_source",
+			want:        "note = '''\ndefault_source = \"main\"\n'''\n",
+			wantRemoved: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, removed := unsetTOMLKey([]byte(tt.content), tt.key)
+			if string(got) != tt.want {
+				t.Errorf("unsetTOMLKey() = %q, want %q", got, tt.want)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestVerifyTOMLEdit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		original   string
+		edited     string
+		key        string
+		assignment string
+		wantErr    bool
+	}{
+		{
+			name:       "set",
+			original:   "symlinks = []\n",
+			edited:     "symlinks = []\ndefault_source = \"main\"\n",
+			key:        "default_source",
+			assignment: `default_source = "main"`,
+		},
+		{
+			name:     "unset",
+			original: "symlinks = []\ndefault_source = \"main\"\n",
+			edited:   "symlinks = []\n",
+			key:      "default_source",
+		},
+		{
+			name:       "other setting changed",
+			original:   "note = \"\"\"\na\n\"\"\"\n",
+			edited:     "note = \"\"\"\ndefault_source = \"main\"\n\"\"\"\n",
+			key:        "default_source",
+			assignment: `default_source = "main"`,
+			wantErr:    true,
+		},
+		{
+			name:       "dotted key left in place",
+			original:   "default_source.x = 1\n",
+			edited:     "default_source = \"main\"\n\ndefault_source.x = 1\n",
+			key:        "default_source",
+			assignment: `default_source = "main"`,
+			wantErr:    true,
+		},
+		{
+			name:       "invalid original",
+			original:   "default_source = \n",
+			edited:     "default_source = \"main\"\n",
+			key:        "default_source",
+			assignment: `default_source = "main"`,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := verifyTOMLEdit([]byte(tt.original), []byte(tt.edited), tt.key, tt.assignment)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyTOMLEdit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
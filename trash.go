@@ -0,0 +1,402 @@
+package twig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// TrashRefPrefix is the ref namespace keeping the branch tips of trashed
+// worktrees. Each entry is stored as <prefix><branch>/<timestamp>.
+const TrashRefPrefix = "refs/twig/trash/"
+
+// trashDirName is the directory below the worktree destination base
+// directory that holds trashed worktree directories.
+const trashDirName = ".twig-trash"
+
+// TrashEntry is a worktree directory moved to the trash instead of being
+// deleted. The directory keeps everything the worktree held, including
+// ignored and untracked files, and the ref keeps the branch tip.
+type TrashEntry struct {
+	Name   string // <branch>/<timestamp>, the ref without TrashRefPrefix
+	Branch string
+	Time   time.Time
+	Tip    string // Branch tip when the worktree was trashed
+	Dir    string // Directory holding the worktree's files
+}
+
+// Ref returns the full ref name of the trash entry.
+func (e TrashEntry) Ref() string {
+	return TrashRefPrefix + e.Name
+}
+
+// trashDir returns the directory holding the trashed worktrees of cfg.
+func trashDir(cfg *Config) string {
+	return filepath.Join(cfg.WorktreeDestBaseDir, trashDirName)
+}
+
+// trashDirFor returns the trash directory for the worktree at wtPath: the
+// one in the destination base directory containing it, global or from a
+// profile, so that the worktree is moved within its own file system.
+// Worktrees outside all of them use the trash of the global base directory.
+func trashDirFor(cfg *Config, wtPath string) string {
+	if dir := cfg.destBaseDirContaining(wtPath); dir != "" {
+		return filepath.Join(dir, trashDirName)
+	}
+	return trashDir(cfg)
+}
+
+// root returns the trash directory the entry was moved to, which empty
+// parent directories are removed up to.
+func (e TrashEntry) root() string {
+	return strings.TrimSuffix(e.Dir, string(filepath.Separator)+filepath.FromSlash(e.Name))
+}
+
+// newTrashEntry returns the trash entry the worktree of branch at wtPath
+// trashed at now would get, unless another entry was created in the same
+// second.
+func newTrashEntry(cfg *Config, branch, wtPath string, now time.Time) TrashEntry {
+	name := timedRefName(branch, now, 1)
+	return TrashEntry{
+		Name:   name,
+		Branch: branch,
+		Time:   now.UTC().Truncate(time.Second),
+		Dir:    filepath.Join(trashDirFor(cfg, wtPath), filepath.FromSlash(name)),
+	}
+}
+
+// moveToTrash moves the directory of wt to the trash, keeps the branch tip
+// in a trash ref and unregisters the worktree. Nothing is changed if the
+// directory cannot be moved.
+//
+// The trash ref points at a commit whose parent is the tip and whose
+// message records the directory, so that the entry is found even after
+// the destination base directory changes.
+func moveToTrash(fsys FileSystem, git *GitRunner, cfg *Config, wt Worktree, now time.Time) (TrashEntry, error) {
+	entry := newTrashEntry(cfg, wt.Branch, wt.Path, now)
+
+	tip, err := git.revParse("--verify", RefsHeadsPrefix+wt.Branch)
+	if err != nil {
+		return entry, fmt.Errorf("failed to resolve branch %s: %w", wt.Branch, err)
+	}
+	entry.Tip = tip
+
+	// The worktree's record is its administrative directory,
+	// <commondir>/worktrees/<name>
+	adminDir, err := git.InDir(wt.Path).revParse("--absolute-git-dir")
+	if err != nil {
+		return entry, fmt.Errorf("failed to find the git directory of %s: %w", wt.Path, err)
+	}
+	if filepath.Base(filepath.Dir(adminDir)) != "worktrees" {
+		return entry, fmt.Errorf("%s is not a linked worktree", wt.Path)
+	}
+
+	if err := fsys.MkdirAll(filepath.Dir(entry.Dir), 0755); err != nil {
+		return entry, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	// Entries created in the same second get a numeric suffix
+	root := entry.root()
+	for n := 1; ; n++ {
+		entry.Name = timedRefName(wt.Branch, now, n)
+		entry.Dir = filepath.Join(root, filepath.FromSlash(entry.Name))
+		err = writeTrashRef(git, entry)
+		if !errors.Is(err, errRefExists) || n == maxTimedRefAttempts {
+			break
+		}
+	}
+	if err != nil {
+		return entry, fmt.Errorf("failed to keep the tip of %s: %w", wt.Branch, err)
+	}
+	if err := fsys.Rename(wt.Path, entry.Dir); err != nil {
+		_ = git.DeleteRef(entry.Ref())
+		return entry, fmt.Errorf("failed to move %s to the trash, nothing was removed: %w", wt.Path, err)
+	}
+
+	// The moved directory is no longer a worktree. Only its own record is
+	// dropped; a repository-wide prune would also drop the records of
+	// other worktrees that are missing for now, such as on unmounted drives.
+	_ = fsys.Remove(filepath.Join(entry.Dir, ".git"))
+	if err := fsys.RemoveAll(adminDir); err != nil {
+		return entry, fmt.Errorf("failed to unregister worktree %s: %w", wt.Path, err)
+	}
+	return entry, nil
+}
+
+// writeTrashRef creates the trash ref of entry, pointing at a commit with
+// the tip's tree, the tip as parent and the entry's directory as body.
+func writeTrashRef(git *GitRunner, entry TrashEntry) error {
+	message := "twig trash: " + entry.Branch
+	hash, err := git.committer().commitTree(entry.Tip+"^{tree}", message+"\n\n"+entry.Dir, entry.Tip)
+	if err != nil {
+		return err
+	}
+	return git.createRef(message, entry.Ref(), hash)
+}
+
+// listTrash returns all trash entries, ordered by branch and then by time.
+// Entries without a recorded directory are looked for in the trash of cfg.
+func listTrash(git *GitRunner, cfg *Config) ([]TrashEntry, error) {
+	out, err := git.Run(GitCmdForEachRef,
+		"--format=%(refname)%00%(parent)%00%(contents:body)%00", TrashRefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	var entries []TrashEntry
+	for _, record := range splitRecords(out) {
+		fields := strings.Split(record, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		name, branch, t, ok := parseTimedRef(TrashRefPrefix, fields[0])
+		parents := strings.Fields(fields[1])
+		if !ok || len(parents) == 0 {
+			continue
+		}
+		dir := strings.TrimSpace(fields[2])
+		if dir == "" {
+			dir = filepath.Join(trashDir(cfg), filepath.FromSlash(name))
+		}
+		entries = append(entries, TrashEntry{
+			Name:   name,
+			Branch: branch,
+			Time:   t,
+			Tip:    parents[0],
+			Dir:    dir,
+		})
+	}
+	return entries, nil
+}
+
+// TrashCommand lists, restores and empties trashed worktrees.
+type TrashCommand struct {
+	FS     FileSystem
+	Git    *GitRunner
+	Config *Config
+}
+
+// TrashEmptyOptions configures the empty operation.
+type TrashEmptyOptions struct {
+	OlderThan time.Duration
+	DryRun    bool
+	Now       time.Time // Zero means the current time
+}
+
+// NewTrashCommand creates a TrashCommand with explicit dependencies (for testing).
+func NewTrashCommand(fs FileSystem, git *GitRunner, cfg *Config) *TrashCommand {
+	return &TrashCommand{
+		FS:     fs,
+		Git:    git,
+		Config: cfg,
+	}
+}
+
+// NewDefaultTrashCommand creates a TrashCommand with production defaults.
+func NewDefaultTrashCommand(cfg *Config) *TrashCommand {
+	return NewTrashCommand(osFS{}, NewGitRunner(cfg.WorktreeSourceDir), cfg)
+}
+
+// TrashListResult holds the result of a trash list operation.
+type TrashListResult struct {
+	Entries []TrashEntry
+}
+
+// TrashRestoreResult holds the result of a trash restore operation.
+type TrashRestoreResult struct {
+	Entry         TrashEntry
+	BranchCreated bool // The branch was recreated at the entry's tip
+	WorktreePath  string
+}
+
+// TrashEmptyResult holds the result of a trash empty operation.
+type TrashEmptyResult struct {
+	Deleted []TrashEntry
+	DryRun  bool
+}
+
+// Format formats the TrashListResult for display.
+func (r TrashListResult) Format(opts ListFormatOptions) FormatResult {
+	var buf bytes.Buffer
+	if opts.Quiet {
+		for _, e := range r.Entries {
+			fmt.Fprintln(&buf, e.Name)
+		}
+		return FormatResult{Stdout: buf.String()}
+	}
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, e := range r.Entries {
+		fmt.Fprintf(w, "%s\t%s %s\n", e.Name, shortHash(e.Tip), e.Dir)
+	}
+	w.Flush()
+	return FormatResult{Stdout: buf.String()}
+}
+
+// Format formats the TrashRestoreResult for display.
+func (r TrashRestoreResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+	if opts.Verbose {
+		if r.BranchCreated {
+			fmt.Fprintf(&stdout, "Recreated branch %s at %s\n", r.Entry.Branch, shortHash(r.Entry.Tip))
+		}
+		fmt.Fprintf(&stdout, "Moved %s to %s\n", r.Entry.Dir, r.WorktreePath)
+	}
+	fmt.Fprintf(&stdout, "twig trash: restored %s into %s\n", r.Entry.Name, r.WorktreePath)
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// Format formats the TrashEmptyResult for display.
+func (r TrashEmptyResult) Format(opts FormatOptions) FormatResult {
+	var stdout strings.Builder
+	for _, e := range r.Deleted {
+		if r.DryRun {
+			fmt.Fprintf(&stdout, "Would delete: %s\n", e.Name)
+		} else {
+			fmt.Fprintf(&stdout, "twig trash: deleted %s\n", e.Name)
+		}
+	}
+	if len(r.Deleted) == 0 {
+		stdout.WriteString("No trashed worktrees to delete\n")
+	}
+	return FormatResult{Stdout: stdout.String()}
+}
+
+// List returns all trash entries, optionally limited to branch.
+func (c *TrashCommand) List(branch string) (TrashListResult, error) {
+	entries, err := listTrash(c.Git, c.Config)
+	if err != nil {
+		return TrashListResult{}, err
+	}
+	var result TrashListResult
+	for _, e := range entries {
+		if branch == "" || e.Branch == branch {
+			result.Entries = append(result.Entries, e)
+		}
+	}
+	return result, nil
+}
+
+// Restore puts a trashed worktree back where twig add would create it.
+// The branch is recreated at the trashed tip if it no longer exists, the
+// worktree is registered with git worktree add without a checkout, and the
+// preserved directory takes the place of the new one. Files are restored
+// as they were, but changes that were staged are unstaged.
+// name is an entry name or a branch, which selects its latest entry.
+func (c *TrashCommand) Restore(name string) (TrashRestoreResult, error) {
+	var result TrashRestoreResult
+
+	entry, err := c.find(name)
+	if err != nil {
+		return result, err
+	}
+	result.Entry = entry
+
+	if _, err := c.FS.Stat(entry.Dir); err != nil {
+		return result, fmt.Errorf("trashed directory of %s is missing: %w", entry.Name, err)
+	}
+
+	destBaseDir := c.Config.DestBaseDirFor(c.Config.ProfileFor(entry.Branch))
+	wtPath := filepath.Join(destBaseDir, entry.Branch)
+	if _, err := c.FS.Stat(wtPath); err == nil {
+		return result, kindErrorf(ErrDirectoryExists, "directory already exists: %s", wtPath)
+	}
+
+	if c.Git.LocalBranchExists(entry.Branch) {
+		tip, err := c.Git.revParse("--verify", RefsHeadsPrefix+entry.Branch)
+		if err != nil {
+			return result, err
+		}
+		if tip != entry.Tip {
+			return result, fmt.Errorf("branch %s already exists at a different commit, delete or rename it first", entry.Branch)
+		}
+	} else {
+		if _, err := c.Git.Run(GitCmdBranch, entry.Branch, entry.Tip); err != nil {
+			return result, fmt.Errorf("failed to recreate branch %s: %w", entry.Branch, err)
+		}
+		result.BranchCreated = true
+	}
+
+	// Register an empty worktree, then move the preserved directory in its place
+	if _, err := c.Git.WorktreeAdd(wtPath, entry.Branch, WithNoCheckout()); err != nil {
+		return result, fmt.Errorf("failed to register worktree %s: %w", wtPath, err)
+	}
+	if err := c.FS.Rename(filepath.Join(wtPath, ".git"), filepath.Join(entry.Dir, ".git")); err != nil {
+		return result, fmt.Errorf("failed to restore %s: %w", entry.Name, err)
+	}
+	if err := c.FS.Remove(wtPath); err != nil {
+		return result, fmt.Errorf("failed to restore %s: %w", entry.Name, err)
+	}
+	if err := c.FS.Rename(entry.Dir, wtPath); err != nil {
+		return result, fmt.Errorf("failed to restore %s: %w", entry.Name, err)
+	}
+	result.WorktreePath = wtPath
+
+	// A worktree added without checkout has an empty index
+	if _, err := c.Git.InDir(wtPath).Run(GitCmdReset, "-q"); err != nil {
+		return result, fmt.Errorf("failed to reset the index of %s: %w", wtPath, err)
+	}
+
+	if _, err := c.Git.Run(GitCmdUpdateRef, "-d", entry.Ref()); err != nil {
+		return result, fmt.Errorf("failed to delete trash entry %s: %w", entry.Name, err)
+	}
+	removeEmptyParentDirs(c.FS, entry.root(), entry.Dir)
+
+	return result, nil
+}
+
+// find returns the trash entry called name, or the latest entry of branch name.
+func (c *TrashCommand) find(name string) (TrashEntry, error) {
+	entries, err := listTrash(c.Git, c.Config)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+	var latest *TrashEntry
+	for i, e := range entries {
+		if e.Name == name {
+			return e, nil
+		}
+		if e.Branch == name && (latest == nil || timedRefNewer(e.Name, e.Time, latest.Name, latest.Time)) {
+			latest = &entries[i]
+		}
+	}
+	if latest == nil {
+		return TrashEntry{}, fmt.Errorf("no trashed worktree found for %q", name)
+	}
+	return *latest, nil
+}
+
+// Empty deletes trash entries older than opts.OlderThan, with their
+// directories.
+func (c *TrashCommand) Empty(opts TrashEmptyOptions) (TrashEmptyResult, error) {
+	result := TrashEmptyResult{DryRun: opts.DryRun}
+
+	entries, err := listTrash(c.Git, c.Config)
+	if err != nil {
+		return result, err
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	for _, e := range entries {
+		if now.Sub(e.Time) < opts.OlderThan {
+			continue
+		}
+		if !opts.DryRun {
+			if err := c.FS.RemoveAll(e.Dir); err != nil {
+				return result, fmt.Errorf("failed to delete %s: %w", e.Dir, err)
+			}
+			if _, err := c.Git.Run(GitCmdUpdateRef, "-d", e.Ref()); err != nil {
+				return result, fmt.Errorf("failed to delete trash entry %s: %w", e.Name, err)
+			}
+			removeEmptyParentDirs(c.FS, e.root(), e.Dir)
+		}
+		result.Deleted = append(result.Deleted, e)
+	}
+	return result, nil
+}
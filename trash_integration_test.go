@@ -0,0 +1,335 @@
+//go:build integration
+
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+func TestTrash_Integration(t *testing.T) {
+	t.Parallel()
+
+	// setup creates feat/wip with an unmerged commit plus modified, untracked
+	// and ignored files, and returns the loaded config, the worktree path
+	// and the branch tip.
+	setup := func(t *testing.T) (*Config, string, string) {
+		t.Helper()
+
+		repoDir, mainDir := testutil.SetupTestRepo(t)
+		result, err := LoadConfig(mainDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewDefaultAddCommand(result.Config, AddOptions{}).Run("feat/wip"); err != nil {
+			t.Fatal(err)
+		}
+		wtPath := filepath.Join(repoDir, "feat", "wip")
+
+		for name, content := range map[string]string{".gitignore": "*.log\nbuild/\n", "tracked.txt": "v1\n"} {
+			if err := os.WriteFile(filepath.Join(wtPath, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		testutil.RunGit(t, wtPath, "add", ".")
+		testutil.RunGit(t, wtPath, "commit", "-m", "unmerged work")
+		tip := strings.TrimSpace(testutil.RunGit(t, wtPath, "rev-parse", "HEAD"))
+
+		if err := os.MkdirAll(filepath.Join(wtPath, "build"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range map[string]string{
+			"tracked.txt": "v2\n", "untracked.txt": "new\n", "debug.log": "ignored\n", "build/out.bin": "artifact\n",
+		} {
+			if err := os.WriteFile(filepath.Join(wtPath, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return result.Config, wtPath, tip
+	}
+
+	t.Run("RemoveAndRestore", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, wtPath, tip := setup(t)
+
+		// Local changes still need --force
+		_, err := NewDefaultRemoveCommand(cfg).Run("feat/wip", cfg.WorktreeSourceDir, RemoveOptions{Trash: true})
+		if err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+			t.Fatalf("err = %v, want uncommitted changes error", err)
+		}
+
+		removed, err := NewDefaultRemoveCommand(cfg).Run("feat/wip", cfg.WorktreeSourceDir, RemoveOptions{
+			Force: WorktreeForceLevelUnclean,
+			Trash: true,
+		})
+		if err != nil {
+			t.Fatalf("remove failed: %v", err)
+		}
+		if !strings.HasPrefix(removed.Trash, "feat/wip/") {
+			t.Errorf("Trash = %q", removed.Trash)
+		}
+		if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+			t.Fatal("worktree directory should be moved away")
+		}
+		if NewGitRunner(cfg.WorktreeSourceDir).LocalBranchExists("feat/wip") {
+			t.Error("branch should be deleted")
+		}
+		if out := testutil.RunGit(t, cfg.WorktreeSourceDir, "worktree", "list"); strings.Contains(out, "feat/wip") {
+			t.Errorf("worktree should be unregistered:\n%s", out)
+		}
+
+		trash := NewDefaultTrashCommand(cfg)
+		list, err := trash.List("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Entries) != 1 || list.Entries[0].Name != removed.Trash || list.Entries[0].Tip != tip {
+			t.Fatalf("entries = %+v, want one entry at %s", list.Entries, tip)
+		}
+		if !strings.HasPrefix(list.Entries[0].Dir, filepath.Join(cfg.WorktreeDestBaseDir, ".twig-trash")+string(filepath.Separator)) {
+			t.Errorf("Dir = %s", list.Entries[0].Dir)
+		}
+		if _, err := os.Stat(filepath.Join(list.Entries[0].Dir, "build", "out.bin")); err != nil {
+			t.Errorf("ignored build artifacts should be kept: %v", err)
+		}
+
+		restored, err := trash.Restore("feat/wip")
+		if err != nil {
+			t.Fatalf("restore failed: %v", err)
+		}
+		if !restored.BranchCreated || restored.WorktreePath != wtPath {
+			t.Errorf("restored = %+v", restored)
+		}
+		if got := strings.TrimSpace(testutil.RunGit(t, wtPath, "rev-parse", "HEAD")); got != tip {
+			t.Errorf("HEAD = %s, want %s", got, tip)
+		}
+		if out := testutil.RunGit(t, cfg.WorktreeSourceDir, "worktree", "list"); !strings.Contains(out, wtPath) {
+			t.Errorf("worktree should be registered:\n%s", out)
+		}
+		status := testutil.RunGit(t, wtPath, "status", "--porcelain")
+		for _, want := range []string{" M tracked.txt", "?? untracked.txt"} {
+			if !strings.Contains(status, want) {
+				t.Errorf("status should contain %q, got:\n%s", want, status)
+			}
+		}
+		for _, name := range []string{"debug.log", filepath.Join("build", "out.bin")} {
+			if _, err := os.Stat(filepath.Join(wtPath, name)); err != nil {
+				t.Errorf("%s should be restored: %v", name, err)
+			}
+		}
+
+		list, err = trash.List("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Entries) != 0 {
+			t.Errorf("entries after restore = %+v", list.Entries)
+		}
+		if _, err := os.Stat(filepath.Join(cfg.WorktreeDestBaseDir, ".twig-trash", "feat")); !os.IsNotExist(err) {
+			t.Error("empty trash directories should be removed")
+		}
+	})
+
+	t.Run("CleanAndEmpty", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := setup(t)
+
+		result, err := NewDefaultCleanCommand(cfg).Run(cfg.WorktreeSourceDir, CleanOptions{
+			Yes:   true,
+			Force: WorktreeForceLevelUnclean,
+			Trash: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Removed) != 1 || result.Removed[0].Trash == "" {
+			t.Fatalf("Removed = %+v, want one trashed removal", result.Removed)
+		}
+
+		trash := NewDefaultTrashCommand(cfg)
+		list, err := trash.List("feat/wip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Entries) != 1 {
+			t.Fatalf("entries = %+v", list.Entries)
+		}
+		dir := list.Entries[0].Dir
+
+		emptied, err := trash.Empty(TrashEmptyOptions{OlderThan: 24 * time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(emptied.Deleted) != 0 {
+			t.Errorf("Deleted = %+v, want nothing older than a day", emptied.Deleted)
+		}
+
+		emptied, err = trash.Empty(TrashEmptyOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(emptied.Deleted) != 1 {
+			t.Errorf("Deleted = %+v", emptied.Deleted)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Error("trashed directory should be deleted")
+		}
+		if out := testutil.RunGit(t, cfg.WorktreeSourceDir, "for-each-ref", TrashRefPrefix); out != "" {
+			t.Errorf("trash refs = %q", out)
+		}
+	})
+	t.Run("KeepsOtherWorktreeRecords", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := setup(t)
+		if _, err := NewDefaultAddCommand(cfg, AddOptions{}).Run("feat/offline"); err != nil {
+			t.Fatal(err)
+		}
+		// A worktree on a drive that is not mounted right now
+		offline := filepath.Join(cfg.WorktreeDestBaseDir, "feat", "offline")
+		unmounted := offline + ".unmounted"
+		if err := os.Rename(offline, unmounted); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewDefaultRemoveCommand(cfg).Run("feat/wip", cfg.WorktreeSourceDir, RemoveOptions{
+			Force: WorktreeForceLevelUnclean,
+			Trash: true,
+		}); err != nil {
+			t.Fatalf("remove failed: %v", err)
+		}
+
+		out := testutil.RunGit(t, cfg.WorktreeSourceDir, "worktree", "list", "--porcelain")
+		if !strings.Contains(out, "worktree "+offline+"\n") {
+			t.Errorf("record of the missing worktree should be kept:\n%s", out)
+		}
+		if strings.Contains(out, "feat/wip") {
+			t.Errorf("trashed worktree should be unregistered:\n%s", out)
+		}
+		if err := os.Rename(unmounted, offline); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, offline, "status")
+	})
+
+	t.Run("RestoreAfterBaseDirChange", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, tip := setup(t)
+		removed, err := NewDefaultRemoveCommand(cfg).Run("feat/wip", cfg.WorktreeSourceDir, RemoveOptions{
+			Force: WorktreeForceLevelUnclean,
+			Trash: true,
+		})
+		if err != nil {
+			t.Fatalf("remove failed: %v", err)
+		}
+		trashed := filepath.Join(cfg.WorktreeDestBaseDir, ".twig-trash", filepath.FromSlash(removed.Trash))
+
+		moved := *cfg
+		moved.WorktreeDestBaseDir = filepath.Join(t.TempDir(), "worktrees")
+		trash := NewDefaultTrashCommand(&moved)
+		list, err := trash.List("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Entries) != 1 || list.Entries[0].Dir != trashed {
+			t.Fatalf("entries = %+v, want one entry in %s", list.Entries, trashed)
+		}
+
+		restored, err := trash.Restore("feat/wip")
+		if err != nil {
+			t.Fatalf("restore failed: %v", err)
+		}
+		if want := filepath.Join(moved.WorktreeDestBaseDir, "feat", "wip"); restored.WorktreePath != want {
+			t.Errorf("WorktreePath = %s, want %s", restored.WorktreePath, want)
+		}
+		if got := strings.TrimSpace(testutil.RunGit(t, restored.WorktreePath, "rev-parse", "HEAD")); got != tip {
+			t.Errorf("HEAD = %s, want %s", got, tip)
+		}
+		if _, err := os.Stat(filepath.Join(cfg.WorktreeDestBaseDir, ".twig-trash", "feat")); !os.IsNotExist(err) {
+			t.Error("empty trash directories should be removed")
+		}
+	})
+
+	t.Run("ProfileBaseDir", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, _, _ := setup(t)
+		profileBase := filepath.Join(t.TempDir(), "experiments")
+		profiled := *cfg
+		profiled.Profiles = []Profile{{Match: "exp/*", WorktreeDestBaseDir: profileBase}}
+		wtPath := filepath.Join(profileBase, "exp", "a")
+		testutil.RunGit(t, cfg.WorktreeSourceDir, "worktree", "add", "-b", "exp/a", wtPath)
+
+		removed, err := NewDefaultRemoveCommand(&profiled).Run("exp/a", cfg.WorktreeSourceDir, RemoveOptions{Trash: true})
+		if err != nil {
+			t.Fatalf("remove failed: %v", err)
+		}
+
+		// The worktree stays within its own base directory
+		trashed := filepath.Join(profileBase, ".twig-trash", filepath.FromSlash(removed.Trash))
+		if _, err := os.Stat(trashed); err != nil {
+			t.Fatalf("worktree should be moved to %s: %v", trashed, err)
+		}
+		if _, err := os.Stat(filepath.Join(cfg.WorktreeDestBaseDir, ".twig-trash")); !os.IsNotExist(err) {
+			t.Error("the global trash directory should not be used")
+		}
+
+		restored, err := NewDefaultTrashCommand(&profiled).Restore("exp/a")
+		if err != nil {
+			t.Fatalf("restore failed: %v", err)
+		}
+		if restored.WorktreePath != wtPath {
+			t.Errorf("WorktreePath = %s, want %s", restored.WorktreePath, wtPath)
+		}
+		if _, err := os.Stat(filepath.Join(profileBase, ".twig-trash", "exp")); !os.IsNotExist(err) {
+			t.Error("empty trash directories should be removed")
+		}
+	})
+
+	t.Run("SameSecond", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, wtPath, _ := setup(t)
+		git := NewGitRunner(cfg.WorktreeSourceDir)
+		now := time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC)
+
+		var names []string
+		for range 2 {
+			worktrees, err := git.WorktreeList()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wt, err := findWorktree(worktrees, "feat/wip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry, err := moveToTrash(osFS{}, git, cfg, wt, now)
+			if err != nil {
+				t.Fatalf("moveToTrash failed: %v", err)
+			}
+			names = append(names, entry.Name)
+			// The branch is kept, so the worktree can be added again
+			testutil.RunGit(t, cfg.WorktreeSourceDir, "worktree", "add", wtPath, "feat/wip")
+		}
+
+		if want := []string{"feat/wip/20260101-093000", "feat/wip/20260101-093000-2"}; !slices.Equal(names, want) {
+			t.Errorf("names = %v, want %v", names, want)
+		}
+		list, err := NewDefaultTrashCommand(cfg).List("feat/wip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Entries) != 2 || list.Entries[0].Dir == list.Entries[1].Dir {
+			t.Errorf("entries = %+v, want two entries with their own directories", list.Entries)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package twig
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// trashRefs is for-each-ref output for two trashed worktrees of feat/a and
+// one of feat/b/c. The first entry was trashed under an earlier destination
+// base directory; the feat/b/c entry has no recorded directory.
+const trashRefs = "refs/twig/trash/feat/a/20260101-120000\x00tipa1234567\x00/old/base/.twig-trash/feat/a/20260101-120000\n\x00\n" +
+	"refs/twig/trash/feat/a/20260115-120000\x00tipa7654321\x00/repo/main-worktree/.twig-trash/feat/a/20260115-120000\n\x00\n" +
+	"refs/twig/trash/feat/b/c/20260110-080000\x00tipb1234567\x00\x00\n" +
+	"refs/twig/trash/malformed\x00tipd1234567\x00\x00\n"
+
+func TestTrashCommand_List(t *testing.T) {
+	t.Parallel()
+
+	mockGit := &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			return []byte(trashRefs), nil
+		},
+	}
+	cfg := &Config{WorktreeDestBaseDir: "/repo/main-worktree"}
+	cmd := NewTrashCommand(&testutil.MockFS{}, &GitRunner{Executor: mockGit}, cfg)
+
+	result, err := cmd.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trash := filepath.Join("/repo/main-worktree", ".twig-trash")
+	want := []TrashEntry{
+		{Name: "feat/a/20260101-120000", Branch: "feat/a", Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+			Tip: "tipa1234567", Dir: "/old/base/.twig-trash/feat/a/20260101-120000"},
+		{Name: "feat/a/20260115-120000", Branch: "feat/a", Time: time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC),
+			Tip: "tipa7654321", Dir: filepath.Join(trash, "feat", "a", "20260115-120000")},
+		{Name: "feat/b/c/20260110-080000", Branch: "feat/b/c", Time: time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC),
+			Tip: "tipb1234567", Dir: filepath.Join(trash, "feat", "b", "c", "20260110-080000")},
+	}
+	if !slices.Equal(result.Entries, want) {
+		t.Errorf("Entries = %+v, want %+v", result.Entries, want)
+	}
+
+	result, err = cmd.List("feat/b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Branch != "feat/b/c" {
+		t.Errorf("filtered Entries = %+v", result.Entries)
+	}
+
+	formatted := TrashListResult{Entries: want[1:]}.Format(ListFormatOptions{})
+	wantStdout := "feat/a/20260115-120000    tipa765 " + want[1].Dir + "\n" +
+		"feat/b/c/20260110-080000  tipb123 " + want[2].Dir + "\n"
+	if formatted.Stdout != wantStdout {
+		t.Errorf("Stdout = %q, want %q", formatted.Stdout, wantStdout)
+	}
+	formatted = TrashListResult{Entries: want[1:]}.Format(ListFormatOptions{Quiet: true})
+	if formatted.Stdout != "feat/a/20260115-120000\nfeat/b/c/20260110-080000\n" {
+		t.Errorf("quiet Stdout = %q", formatted.Stdout)
+	}
+}
+
+func TestTrashCommand_Restore_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		arg      string
+		existing []string // paths that exist
+		wantErr  string
+	}{
+		{
+			name:    "unknown_entry",
+			arg:     "feat/x",
+			wantErr: `no trashed worktree found for "feat/x"`,
+		},
+		{
+			name:    "trashed_directory_missing",
+			arg:     "feat/a",
+			wantErr: "trashed directory of feat/a/20260115-120000 is missing",
+		},
+		{
+			name: "worktree_path_taken",
+			arg:  "feat/a/20260101-120000",
+			existing: []string{
+				"/old/base/.twig-trash/feat/a/20260101-120000",
+				filepath.Join("/repo/main-worktree", "feat", "a"),
+			},
+			wantErr: "directory already exists: " + filepath.Join("/repo/main-worktree", "feat", "a"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					calls = append(calls, args[2])
+					return []byte(trashRefs), nil
+				},
+			}
+			mockFS := &testutil.MockFS{ExistingPaths: tt.existing}
+			cfg := &Config{WorktreeDestBaseDir: "/repo/main-worktree"}
+			cmd := NewTrashCommand(mockFS, &GitRunner{Executor: mockGit}, cfg)
+
+			_, err := cmd.Restore(tt.arg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if !slices.Equal(calls, []string{"for-each-ref"}) {
+				t.Errorf("git calls = %v, want only for-each-ref", calls)
+			}
+		})
+	}
+}
+
+func TestTrashCommand_Find_SameSecond(t *testing.T) {
+	t.Parallel()
+
+	// for-each-ref sorts by name, so "-10" comes before "-2"
+	refs := "refs/twig/trash/feat/a/20260101-120000\x00tipa1234567\x00\x00\n" +
+		"refs/twig/trash/feat/a/20260101-120000-10\x00tipa1234567\x00\x00\n" +
+		"refs/twig/trash/feat/a/20260101-120000-2\x00tipa1234567\x00\x00\n"
+	mockGit := &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			return []byte(refs), nil
+		},
+	}
+	cfg := &Config{WorktreeDestBaseDir: "/repo/main-worktree"}
+	cmd := NewTrashCommand(&testutil.MockFS{}, &GitRunner{Executor: mockGit}, cfg)
+
+	e, err := cmd.find("feat/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Name != "feat/a/20260101-120000-10" {
+		t.Errorf("find() = %s, want the last entry of the latest second", e.Name)
+	}
+}
+
+func TestTrashCommand_Empty(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 20, 8, 0, 0, 0, time.UTC)
+	trash := filepath.Join("/repo/main-worktree", ".twig-trash")
+
+	tests := []struct {
+		name        string
+		opts        TrashEmptyOptions
+		wantDeleted []string
+		wantRemoved []string
+		wantRefs    []string
+		wantStdout  string
+	}{
+		{
+			name:        "everything",
+			opts:        TrashEmptyOptions{Now: now},
+			wantDeleted: []string{"feat/a/20260101-120000", "feat/a/20260115-120000", "feat/b/c/20260110-080000"},
+			wantRemoved: []string{
+				"/old/base/.twig-trash/feat/a/20260101-120000",
+				filepath.Join(trash, "feat", "a", "20260115-120000"),
+				filepath.Join(trash, "feat", "b", "c", "20260110-080000"),
+			},
+			wantRefs: []string{
+				"refs/twig/trash/feat/a/20260101-120000",
+				"refs/twig/trash/feat/a/20260115-120000",
+				"refs/twig/trash/feat/b/c/20260110-080000",
+			},
+			wantStdout: "twig trash: deleted feat/a/20260101-120000\n" +
+				"twig trash: deleted feat/a/20260115-120000\n" +
+				"twig trash: deleted feat/b/c/20260110-080000\n",
+		},
+		{
+			name:        "older_than_7d",
+			opts:        TrashEmptyOptions{OlderThan: 7 * 24 * time.Hour, Now: now},
+			wantDeleted: []string{"feat/a/20260101-120000", "feat/b/c/20260110-080000"},
+			wantRemoved: []string{
+				"/old/base/.twig-trash/feat/a/20260101-120000",
+				filepath.Join(trash, "feat", "b", "c", "20260110-080000"),
+			},
+			wantRefs: []string{
+				"refs/twig/trash/feat/a/20260101-120000",
+				"refs/twig/trash/feat/b/c/20260110-080000",
+			},
+			wantStdout: "twig trash: deleted feat/a/20260101-120000\n" +
+				"twig trash: deleted feat/b/c/20260110-080000\n",
+		},
+		{
+			name:        "dry_run",
+			opts:        TrashEmptyOptions{OlderThan: 15 * 24 * time.Hour, Now: now, DryRun: true},
+			wantDeleted: []string{"feat/a/20260101-120000"},
+			wantStdout:  "Would delete: feat/a/20260101-120000\n",
+		},
+		{
+			name:       "nothing_old_enough",
+			opts:       TrashEmptyOptions{OlderThan: 30 * 24 * time.Hour, Now: now},
+			wantStdout: "No trashed worktrees to delete\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var refs, removed []string
+			mockGit := &testutil.MockGitExecutor{
+				RunFunc: func(args ...string) ([]byte, error) {
+					if args[2] == "update-ref" {
+						refs = append(refs, args[4])
+						return nil, nil
+					}
+					return []byte(trashRefs), nil
+				},
+			}
+			mockFS := &testutil.MockFS{
+				RemoveAllFunc: func(path string) error {
+					removed = append(removed, path)
+					return nil
+				},
+			}
+			cfg := &Config{WorktreeDestBaseDir: "/repo/main-worktree"}
+			cmd := NewTrashCommand(mockFS, &GitRunner{Executor: mockGit}, cfg)
+
+			result, err := cmd.Empty(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var deleted []string
+			for _, e := range result.Deleted {
+				deleted = append(deleted, e.Name)
+			}
+			if !slices.Equal(deleted, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if !slices.Equal(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			if !slices.Equal(refs, tt.wantRefs) {
+				t.Errorf("refs = %v, want %v", refs, tt.wantRefs)
+			}
+			if got := result.Format(FormatOptions{}).Stdout; got != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got, tt.wantStdout)
+			}
+		})
+	}
+}
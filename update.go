@@ -0,0 +1,376 @@
+package twig
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// UpdateStrategy selects how worktrees are brought up to date.
+type UpdateStrategy string
+
+const (
+	// UpdateStrategyFF fast-forwards each branch to its upstream.
+	UpdateStrategyFF UpdateStrategy = "ff"
+	// UpdateStrategyRebase rebases each branch onto the target branch.
+	UpdateStrategyRebase UpdateStrategy = "rebase"
+	// UpdateStrategyMerge merges the target branch into each branch.
+	UpdateStrategyMerge UpdateStrategy = "merge"
+)
+
+// ParseUpdateStrategy validates an update strategy name.
+func ParseUpdateStrategy(s string) (UpdateStrategy, error) {
+	switch strategy := UpdateStrategy(s); strategy {
+	case UpdateStrategyFF, UpdateStrategyRebase, UpdateStrategyMerge:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("invalid strategy %q (must be ff, rebase or merge)", s)
+}
+
+// UpdateOutcome is the result category of a single worktree update.
+type UpdateOutcome string
+
+const (
+	UpdateUpdated  UpdateOutcome = "updated"
+	UpdateUpToDate UpdateOutcome = "up to date"
+	UpdateAborted  UpdateOutcome = "aborted"
+	UpdateSkipped  UpdateOutcome = "skip"
+	UpdateFailed   UpdateOutcome = "failed"
+)
+
+// Skip reasons specific to update.
+const (
+	SkipNoUpstream SkipReason = "no upstream"
+	SkipDiverged   SkipReason = "diverged from upstream"
+)
+
+// UpdateCommand brings worktrees up to date with their upstream or a target branch.
+type UpdateCommand struct {
+	Git *GitRunner
+}
+
+// UpdateOptions configures the update operation.
+type UpdateOptions struct {
+	All      bool     // Update every worktree
+	Branches []string // Worktrees to update; the current worktree if empty and All is false
+	Strategy UpdateStrategy
+	Target   string // Branch to rebase onto or merge (auto-detect if empty)
+	NoFetch  bool
+}
+
+// NewUpdateCommand creates an UpdateCommand with explicit dependencies (for testing).
+func NewUpdateCommand(git *GitRunner) *UpdateCommand {
+	return &UpdateCommand{
+		Git: git,
+	}
+}
+
+// NewDefaultUpdateCommand creates an UpdateCommand with production defaults.
+func NewDefaultUpdateCommand(dir string) *UpdateCommand {
+	return NewUpdateCommand(NewGitRunner(dir))
+}
+
+// UpdatedWorktree holds the result of updating a single worktree.
+type UpdatedWorktree struct {
+	Branch       string
+	WorktreePath string
+	Outcome      UpdateOutcome
+	From         string // HEAD before the update
+	To           string // HEAD after the update
+	SkipReason   SkipReason
+	Err          error // Why the update failed or was aborted
+}
+
+// UpdateResult aggregates results from update operations.
+type UpdateResult struct {
+	Worktrees    []UpdatedWorktree
+	Strategy     UpdateStrategy
+	TargetBranch string // Empty for the ff strategy
+}
+
+// HasErrors returns true if any update failed or was aborted.
+func (r UpdateResult) HasErrors() bool {
+	return r.ErrorCount() > 0
+}
+
+// ErrorCount returns the number of failed or aborted updates.
+func (r UpdateResult) ErrorCount() int {
+	count := 0
+	for _, wt := range r.Worktrees {
+		if wt.Outcome == UpdateFailed || wt.Outcome == UpdateAborted {
+			count++
+		}
+	}
+	return count
+}
+
+// Format formats the UpdateResult for display, grouped by outcome.
+// Up-to-date worktrees are listed with Verbose only.
+func (r UpdateResult) Format(opts FormatOptions) FormatResult {
+	var stdout, stderr strings.Builder
+
+	groups := make(map[UpdateOutcome][]UpdatedWorktree)
+	for _, wt := range r.Worktrees {
+		groups[wt.Outcome] = append(groups[wt.Outcome], wt)
+	}
+
+	for _, wt := range groups[UpdateFailed] {
+		fmt.Fprintf(&stderr, "error: %s: %v\n", wt.Branch, wt.Err)
+	}
+
+	printed := false
+	printGroup := func(outcome UpdateOutcome, detail func(UpdatedWorktree) string) {
+		if len(groups[outcome]) == 0 {
+			return
+		}
+		if printed {
+			fmt.Fprintln(&stdout)
+		}
+		printed = true
+		fmt.Fprintf(&stdout, "%s:\n", outcome)
+		for _, wt := range groups[outcome] {
+			if d := detail(wt); d != "" {
+				fmt.Fprintf(&stdout, "  %s (%s)\n", wt.Branch, d)
+			} else {
+				fmt.Fprintf(&stdout, "  %s\n", wt.Branch)
+			}
+		}
+	}
+
+	printGroup(UpdateUpdated, func(wt UpdatedWorktree) string {
+		return shortHash(wt.From) + ".." + shortHash(wt.To)
+	})
+	printGroup(UpdateAborted, func(wt UpdatedWorktree) string { return wt.Err.Error() })
+	if opts.Verbose {
+		printGroup(UpdateUpToDate, func(UpdatedWorktree) string { return "" })
+	}
+	printGroup(UpdateSkipped, func(wt UpdatedWorktree) string { return string(wt.SkipReason) })
+
+	if len(groups[UpdateUpdated]) == 0 && len(groups[UpdateAborted]) == 0 && len(groups[UpdateFailed]) == 0 {
+		if printed {
+			fmt.Fprintln(&stdout)
+		}
+		fmt.Fprintln(&stdout, "No worktrees to update")
+	}
+
+	return FormatResult{Stdout: stdout.String(), Stderr: stderr.String()}
+}
+
+// Run fetches all remotes once, then updates the selected worktrees.
+// Worktrees that are detached, prunable, locked or have uncommitted
+// changes are skipped. A rebase or merge that stops on conflicts is
+// aborted, leaving the worktree as it was.
+//
+// With the rebase and merge strategies the target worktree is updated
+// first by fast-forwarding it to its upstream, so that the other branches
+// build on the latest target. When the local target is still behind its
+// upstream, for instance because its worktree was not selected, the other
+// branches build on the upstream instead.
+func (c *UpdateCommand) Run(cwd string, opts UpdateOptions) (UpdateResult, error) {
+	result := UpdateResult{Strategy: opts.Strategy}
+	if result.Strategy == "" {
+		result.Strategy = UpdateStrategyFF
+	}
+	if opts.All && len(opts.Branches) > 0 {
+		return result, errors.New("branches and --all cannot be combined")
+	}
+
+	worktrees, err := c.Git.WorktreeList()
+	if err != nil {
+		return result, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	var selected []Worktree
+	switch {
+	case opts.All:
+		for _, wt := range worktrees {
+			if !wt.Bare {
+				selected = append(selected, wt)
+			}
+		}
+	case len(opts.Branches) > 0:
+		for _, branch := range opts.Branches {
+			wt, err := findWorktree(worktrees, branch)
+			if err != nil {
+				return result, err
+			}
+			selected = append(selected, wt)
+		}
+	default:
+		wt, ok := worktreeContaining(worktrees, cwd)
+		if !ok {
+			return result, fmt.Errorf("%s is not inside a worktree, specify branches or --all", cwd)
+		}
+		selected = append(selected, wt)
+	}
+
+	if result.Strategy != UpdateStrategyFF {
+		target, err := resolveTargetBranch(c.Git, opts.Target)
+		if err != nil {
+			return result, err
+		}
+		result.TargetBranch = target
+		// Update the target first; the others build on it
+		slices.SortStableFunc(selected, func(a, b Worktree) int {
+			switch {
+			case a.Branch == target && b.Branch != target:
+				return -1
+			case b.Branch == target && a.Branch != target:
+				return 1
+			}
+			return 0
+		})
+	}
+
+	if !opts.NoFetch {
+		if _, err := c.Git.Run(GitCmdFetch, "--all"); err != nil {
+			return result, fmt.Errorf("failed to fetch: %w", err)
+		}
+	}
+
+	// What the other worktrees build on, resolved once the target worktree
+	// (sorted first) has been updated
+	base := ""
+	for _, wt := range selected {
+		if result.Strategy != UpdateStrategyFF && wt.Branch != result.TargetBranch && base == "" {
+			base = c.updateBase(result.TargetBranch)
+		}
+		result.Worktrees = append(result.Worktrees, c.update(wt, result.Strategy, result.TargetBranch, base))
+	}
+
+	return result, nil
+}
+
+// updateBase returns what branches are rebased onto or merge with the
+// rebase and merge strategies: the target branch, or its upstream when the
+// local target is behind it. A target that has diverged from its upstream
+// is used as is.
+func (c *UpdateCommand) updateBase(target string) string {
+	upstream, err := c.Git.revParse("--abbrev-ref", target+"@{upstream}")
+	if err != nil {
+		return target
+	}
+	// Up to date with or ahead of the upstream
+	if _, err := c.Git.Run(GitCmdMergeBase, "--is-ancestor", upstream, target); err == nil {
+		return target
+	}
+	if _, err := c.Git.Run(GitCmdMergeBase, "--is-ancestor", target, upstream); err != nil {
+		return target
+	}
+	return upstream
+}
+
+// update updates a single worktree. The target worktree is fast-forwarded
+// to its upstream; with the rebase and merge strategies, other worktrees
+// are rebased onto or merge base.
+func (c *UpdateCommand) update(wt Worktree, strategy UpdateStrategy, target, base string) UpdatedWorktree {
+	u := UpdatedWorktree{Branch: wt.Branch, WorktreePath: wt.Path}
+	skip := func(reason SkipReason) UpdatedWorktree {
+		u.Outcome = UpdateSkipped
+		u.SkipReason = reason
+		return u
+	}
+	fail := func(err error) UpdatedWorktree {
+		u.Outcome = UpdateFailed
+		u.Err = err
+		return u
+	}
+
+	switch {
+	case wt.Detached:
+		u.Branch = wt.Path
+		return skip(SkipDetached)
+	case wt.Prunable:
+		return skip(SkipPrunable)
+	case wt.Locked:
+		return skip(SkipLocked)
+	}
+
+	git := c.Git.InDir(wt.Path)
+	if hasChanges, err := git.HasChanges(); err != nil || hasChanges {
+		return skip(SkipHasChanges)
+	}
+
+	head, err := git.revParse("HEAD")
+	if err != nil {
+		return fail(err)
+	}
+	u.From = head
+
+	// The target itself, like every branch with the ff strategy, follows its upstream
+	if strategy == UpdateStrategyFF || wt.Branch == target {
+		upstream, err := git.revParse("--abbrev-ref", wt.Branch+"@{upstream}")
+		if err != nil {
+			return skip(SkipNoUpstream)
+		}
+		if _, err := git.Run(GitCmdMergeBase, "--is-ancestor", "HEAD", upstream); err != nil {
+			return skip(SkipDiverged)
+		}
+		if _, err := git.Run(GitCmdMerge, "--ff-only", upstream); err != nil {
+			return fail(fmt.Errorf("failed to fast-forward to %s: %w", upstream, err))
+		}
+	} else {
+		op := "rebase onto " + base
+		args := []string{GitCmdRebase, base}
+		abort := []string{GitCmdRebase, "--abort"}
+		if strategy == UpdateStrategyMerge {
+			op = "merge of " + base
+			args = []string{GitCmdMerge, "--no-edit", base}
+			abort = []string{GitCmdMerge, "--abort"}
+		}
+		if _, err := git.Run(args...); err != nil {
+			// A conflicting rebase or merge stays in progress until aborted
+			if _, abortErr := git.Run(abort...); abortErr != nil {
+				return fail(fmt.Errorf("%s failed: %w", op, err))
+			}
+			u.Outcome = UpdateAborted
+			u.Err = fmt.Errorf("%s had conflicts, aborted", op)
+			return u
+		}
+	}
+
+	u.To, err = git.revParse("HEAD")
+	if err != nil {
+		return fail(err)
+	}
+	u.Outcome = UpdateUpdated
+	if u.To == u.From {
+		u.Outcome = UpdateUpToDate
+	}
+	return u
+}
+
+// worktreeContaining returns the non-bare worktree containing dir,
+// preferring the most deeply nested one.
+func worktreeContaining(worktrees []Worktree, dir string) (Worktree, bool) {
+	var best Worktree
+	found := false
+	for _, wt := range worktrees {
+		if !wt.Bare && isWithin(dir, wt.Path) && len(wt.Path) > len(best.Path) {
+			best, found = wt, true
+		}
+	}
+	return best, found
+}
+
+// resolveTargetBranch returns target, or the branch of the first non-bare
+// worktree (usually main) if target is empty.
+func resolveTargetBranch(git *GitRunner, target string) (string, error) {
+	if target != "" {
+		return target, nil
+	}
+
+	worktrees, err := git.WorktreeList()
+	if err != nil {
+		return "", fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	for _, wt := range worktrees {
+		if !wt.Bare && wt.Branch != "" {
+			return wt.Branch, nil
+		}
+	}
+
+	return "", fmt.Errorf("no target branch found")
+}
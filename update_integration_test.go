@@ -0,0 +1,152 @@
+//go:build integration
+
+package twig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// setupUpdateRepo creates a repository with an origin remote and returns
+// the repo dir, the main worktree and a function that commits a file to
+// origin/main from another clone.
+func setupUpdateRepo(t *testing.T) (repoDir, mainDir string, pushUpstream func(file, content string)) {
+	t.Helper()
+
+	repoDir, mainDir = testutil.SetupTestRepo(t)
+	// Untracked settings would make main dirty and skipped
+	testutil.RunGit(t, mainDir, "add", ".twig")
+	testutil.RunGit(t, mainDir, "commit", "-m", "add settings")
+	remoteDir := filepath.Join(repoDir, "remote.git")
+	testutil.RunGit(t, repoDir, "init", "--bare", "--initial-branch=main", remoteDir)
+	testutil.RunGit(t, mainDir, "remote", "add", "origin", remoteDir)
+	testutil.RunGit(t, mainDir, "push", "-u", "origin", "main")
+
+	cloneDir := filepath.Join(repoDir, "clone")
+	testutil.RunGit(t, repoDir, "clone", remoteDir, cloneDir)
+	testutil.RunGit(t, cloneDir, "config", "user.email", "test@example.com")
+	testutil.RunGit(t, cloneDir, "config", "user.name", "Test")
+
+	return repoDir, mainDir, func(file, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(cloneDir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		testutil.RunGit(t, cloneDir, "add", file)
+		testutil.RunGit(t, cloneDir, "commit", "-m", "update "+file)
+		testutil.RunGit(t, cloneDir, "push", "origin", "main")
+	}
+}
+
+// commitFile commits a file in the worktree at dir.
+func commitFile(t *testing.T, dir, file, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.RunGit(t, dir, "add", file)
+	testutil.RunGit(t, dir, "commit", "-m", "change "+file)
+}
+
+func TestUpdateCommand_Integration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("FastForward", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir, pushUpstream := setupUpdateRepo(t)
+		featDir := filepath.Join(repoDir, "feat", "a")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/a", featDir)
+		pushUpstream("upstream.txt", "upstream\n")
+
+		result, err := NewDefaultUpdateCommand(mainDir).Run(mainDir, UpdateOptions{All: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]UpdateOutcome{"main": UpdateUpdated, "feat/a": UpdateSkipped}
+		for _, wt := range result.Worktrees {
+			if wt.Outcome != want[wt.Branch] {
+				t.Errorf("%s: outcome = %q, want %q", wt.Branch, wt.Outcome, want[wt.Branch])
+			}
+		}
+		if _, err := os.Stat(filepath.Join(mainDir, "upstream.txt")); err != nil {
+			t.Errorf("main should be fast-forwarded: %v", err)
+		}
+	})
+
+	t.Run("RebaseAbortsConflicts", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir, pushUpstream := setupUpdateRepo(t)
+		cleanDir := filepath.Join(repoDir, "feat", "clean")
+		conflictDir := filepath.Join(repoDir, "feat", "conflict")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/clean", cleanDir)
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/conflict", conflictDir)
+		commitFile(t, cleanDir, "clean.txt", "clean\n")
+		commitFile(t, conflictDir, "shared.txt", "ours\n")
+		conflictHead := testutil.RunGit(t, conflictDir, "rev-parse", "HEAD")
+		pushUpstream("shared.txt", "theirs\n")
+
+		result, err := NewDefaultUpdateCommand(mainDir).Run(mainDir, UpdateOptions{
+			All:      true,
+			Strategy: UpdateStrategyRebase,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]UpdateOutcome{
+			"main":          UpdateUpdated,
+			"feat/clean":    UpdateUpdated,
+			"feat/conflict": UpdateAborted,
+		}
+		for _, wt := range result.Worktrees {
+			if wt.Outcome != want[wt.Branch] {
+				t.Errorf("%s: outcome = %q (%v), want %q", wt.Branch, wt.Outcome, wt.Err, want[wt.Branch])
+			}
+		}
+
+		// feat/clean now builds on the updated main
+		testutil.RunGit(t, cleanDir, "merge-base", "--is-ancestor", "main", "HEAD")
+
+		// feat/conflict is left as it was, with no rebase in progress
+		if got := testutil.RunGit(t, conflictDir, "rev-parse", "HEAD"); got != conflictHead {
+			t.Errorf("feat/conflict HEAD = %s, want %s", got, conflictHead)
+		}
+		if status := testutil.RunGit(t, conflictDir, "status", "--porcelain"); strings.TrimSpace(status) != "" {
+			t.Errorf("feat/conflict should be clean, got:\n%s", status)
+		}
+	})
+	t.Run("RebaseOntoUpstreamOfUnselectedTarget", func(t *testing.T) {
+		t.Parallel()
+
+		repoDir, mainDir, pushUpstream := setupUpdateRepo(t)
+		featDir := filepath.Join(repoDir, "feat", "a")
+		testutil.RunGit(t, mainDir, "worktree", "add", "-b", "feat/a", featDir)
+		commitFile(t, featDir, "feat.txt", "feat\n")
+		mainHead := testutil.RunGit(t, mainDir, "rev-parse", "HEAD")
+		pushUpstream("upstream.txt", "upstream\n")
+
+		result, err := NewDefaultUpdateCommand(mainDir).Run(mainDir, UpdateOptions{
+			Branches: []string{"feat/a"},
+			Strategy: UpdateStrategyRebase,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Worktrees) != 1 || result.Worktrees[0].Outcome != UpdateUpdated {
+			t.Fatalf("Worktrees = %+v, want feat/a updated", result.Worktrees)
+		}
+
+		// feat/a builds on the fetched upstream although main was not selected
+		testutil.RunGit(t, featDir, "merge-base", "--is-ancestor", "origin/main", "HEAD")
+		if got := testutil.RunGit(t, mainDir, "rev-parse", "HEAD"); got != mainHead {
+			t.Errorf("main HEAD = %s, want it untouched at %s", got, mainHead)
+		}
+	})
+}
@@ -0,0 +1,327 @@
+package twig
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/708u/twig/internal/testutil"
+)
+
+// updateMockState describes a worktree for newUpdateMock.
+type updateMockState struct {
+	dirty      bool
+	noUpstream bool
+	diverged   bool
+	conflict   bool // rebase and merge stop on conflicts
+	upToDate   bool // HEAD does not change
+	behind     bool // the branch checked out here is behind its upstream
+}
+
+// newUpdateMock returns a mock with main, feat/a and feat/b worktrees under
+// /repo plus a locked and a detached one, recording fetch, merge and rebase
+// calls prefixed with the worktree directory.
+func newUpdateMock(calls *[]string, states map[string]updateMockState, fetchErr error) *testutil.MockGitExecutor {
+	base := &testutil.MockGitExecutor{
+		Worktrees: []testutil.MockWorktree{
+			{Path: "/repo/main", Branch: "main"},
+			{Path: "/repo/feat/a", Branch: "feat/a"},
+			{Path: "/repo/feat/b", Branch: "feat/b"},
+			{Path: "/repo/feat/locked", Branch: "feat/locked", Locked: true},
+			{Path: "/repo/detached", HEAD: "abc1234", Detached: true},
+		},
+	}
+	heads := make(map[string]string)
+	return &testutil.MockGitExecutor{
+		RunFunc: func(args ...string) ([]byte, error) {
+			dir := ""
+			if args[0] == "-C" {
+				dir, args = args[1], args[2:]
+			}
+			state := states[dir]
+			record := func() { *calls = append(*calls, dir+": "+strings.Join(args, " ")) }
+			switch args[0] {
+			case "fetch":
+				record()
+				return nil, fetchErr
+			case "status":
+				if state.dirty {
+					return []byte(" M modified.go\x00"), nil
+				}
+				return nil, nil
+			case "rev-parse":
+				if strings.HasSuffix(args[len(args)-1], "@{upstream}") {
+					if state.noUpstream {
+						return nil, errors.New("exit status 128")
+					}
+					return []byte("origin/" + strings.TrimSuffix(args[len(args)-1], "@{upstream}") + "\n"), nil
+				}
+				if heads[dir] == "" {
+					heads[dir] = "1111111aaaa"
+				}
+				return []byte(heads[dir] + "\n"), nil
+			case "merge-base":
+				// merge-base --is-ancestor <upstream> <branch>
+				if state.behind && strings.HasPrefix(args[2], "origin/") {
+					return nil, errors.New("exit status 1")
+				}
+				if state.diverged {
+					return nil, errors.New("exit status 1")
+				}
+				return nil, nil
+			case "merge", "rebase":
+				record()
+				if slices.Contains(args, "--abort") {
+					return nil, nil
+				}
+				if state.conflict {
+					return nil, errors.New("exit status 1")
+				}
+				if !state.upToDate {
+					heads[dir] = "2222222bbbb"
+				}
+				return nil, nil
+			}
+			return base.Run(args...)
+		},
+	}
+}
+
+func TestUpdateCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		cwd          string
+		opts         UpdateOptions
+		states       map[string]updateMockState
+		fetchErr     error
+		wantCalls    []string
+		wantOutcomes []string // "branch outcome[ reason]"
+		wantErr      string
+	}{
+		{
+			name: "ff_all",
+			cwd:  "/repo/main",
+			opts: UpdateOptions{All: true},
+			states: map[string]updateMockState{
+				"/repo/main":   {upToDate: true},
+				"/repo/feat/b": {dirty: true},
+			},
+			wantCalls: []string{
+				"/repo/main: fetch --all",
+				"/repo/main: merge --ff-only origin/main",
+				"/repo/feat/a: merge --ff-only origin/feat/a",
+			},
+			wantOutcomes: []string{
+				"main up to date",
+				"feat/a updated",
+				"feat/b skip has uncommitted changes",
+				"feat/locked skip locked",
+				"/repo/detached skip detached HEAD",
+			},
+		},
+		{
+			name: "ff_no_upstream_and_diverged",
+			cwd:  "/repo/main",
+			opts: UpdateOptions{Branches: []string{"feat/a", "feat/b"}, NoFetch: true},
+			states: map[string]updateMockState{
+				"/repo/feat/a": {noUpstream: true},
+				"/repo/feat/b": {diverged: true},
+			},
+			wantOutcomes: []string{
+				"feat/a skip no upstream",
+				"feat/b skip diverged from upstream",
+			},
+		},
+		{
+			name: "current_worktree",
+			cwd:  "/repo/feat/b/sub",
+			opts: UpdateOptions{NoFetch: true},
+			wantCalls: []string{
+				"/repo/feat/b: merge --ff-only origin/feat/b",
+			},
+			wantOutcomes: []string{"feat/b updated"},
+		},
+		{
+			name: "rebase_updates_target_first_and_aborts_conflicts",
+			cwd:  "/repo",
+			opts: UpdateOptions{Branches: []string{"feat/a", "feat/b", "main"}, Strategy: UpdateStrategyRebase},
+			states: map[string]updateMockState{
+				"/repo/feat/b": {conflict: true},
+			},
+			wantCalls: []string{
+				"/repo/main: fetch --all",
+				"/repo/main: merge --ff-only origin/main",
+				"/repo/feat/a: rebase main",
+				"/repo/feat/b: rebase main",
+				"/repo/feat/b: rebase --abort",
+			},
+			wantOutcomes: []string{
+				"main updated",
+				"feat/a updated",
+				"feat/b aborted",
+			},
+		},
+		{
+			name: "rebase_onto_upstream_of_unselected_target",
+			cwd:  "/repo",
+			opts: UpdateOptions{Branches: []string{"feat/a"}, Strategy: UpdateStrategyRebase},
+			states: map[string]updateMockState{
+				"/repo/main": {behind: true},
+			},
+			wantCalls: []string{
+				"/repo/main: fetch --all",
+				"/repo/feat/a: rebase origin/main",
+			},
+			wantOutcomes: []string{"feat/a updated"},
+		},
+		{
+			name: "merge_local_target_when_up_to_date",
+			cwd:  "/repo",
+			opts: UpdateOptions{Branches: []string{"feat/a"}, Strategy: UpdateStrategyMerge, NoFetch: true},
+			wantCalls: []string{
+				"/repo/feat/a: merge --no-edit main",
+			},
+			wantOutcomes: []string{"feat/a updated"},
+		},
+		{
+			name:   "merge_with_explicit_target",
+			cwd:    "/repo",
+			opts:   UpdateOptions{Branches: []string{"feat/a"}, Strategy: UpdateStrategyMerge, Target: "develop", NoFetch: true},
+			states: map[string]updateMockState{"/repo/feat/a": {conflict: true}},
+			wantCalls: []string{
+				"/repo/feat/a: merge --no-edit develop",
+				"/repo/feat/a: merge --abort",
+			},
+			wantOutcomes: []string{"feat/a aborted"},
+		},
+		{
+			name:     "fetch_error",
+			cwd:      "/repo/main",
+			opts:     UpdateOptions{All: true},
+			fetchErr: errors.New("could not resolve host"),
+			wantErr:  "failed to fetch",
+		},
+		{
+			name:    "unknown_branch",
+			opts:    UpdateOptions{Branches: []string{"feat/z"}},
+			wantErr: `branch "feat/z" is not checked out`,
+		},
+		{
+			name:    "outside_worktrees",
+			cwd:     "/elsewhere",
+			wantErr: "not inside a worktree",
+		},
+		{
+			name:    "branches_and_all",
+			opts:    UpdateOptions{All: true, Branches: []string{"feat/a"}},
+			wantErr: "cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			mockGit := newUpdateMock(&calls, tt.states, tt.fetchErr)
+			cmd := NewUpdateCommand(&GitRunner{Executor: mockGit, Dir: "/repo/main"})
+
+			result, err := cmd.Run(tt.cwd, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+			var outcomes []string
+			for _, wt := range result.Worktrees {
+				outcome := wt.Branch + " " + string(wt.Outcome)
+				if wt.SkipReason != "" {
+					outcome += " " + string(wt.SkipReason)
+				}
+				outcomes = append(outcomes, outcome)
+			}
+			if !slices.Equal(outcomes, tt.wantOutcomes) {
+				t.Errorf("outcomes = %q, want %q", outcomes, tt.wantOutcomes)
+			}
+		})
+	}
+}
+
+func TestParseUpdateStrategy(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"ff", "rebase", "merge"} {
+		if got, err := ParseUpdateStrategy(s); err != nil || string(got) != s {
+			t.Errorf("ParseUpdateStrategy(%q) = %q, %v", s, got, err)
+		}
+	}
+	if _, err := ParseUpdateStrategy("squash"); err == nil {
+		t.Error("ParseUpdateStrategy(squash) should fail")
+	}
+}
+
+func TestUpdateResult_Format(t *testing.T) {
+	t.Parallel()
+
+	result := UpdateResult{Worktrees: []UpdatedWorktree{
+		{Branch: "main", Outcome: UpdateUpToDate},
+		{Branch: "feat/a", Outcome: UpdateUpdated, From: "1111111aaaa", To: "2222222bbbb"},
+		{Branch: "feat/b", Outcome: UpdateAborted, Err: errors.New("rebase onto main had conflicts, aborted")},
+		{Branch: "feat/c", Outcome: UpdateSkipped, SkipReason: SkipHasChanges},
+		{Branch: "feat/d", Outcome: UpdateFailed, Err: errors.New("boom")},
+	}}
+
+	tests := []struct {
+		name       string
+		result     UpdateResult
+		opts       FormatOptions
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:   "grouped",
+			result: result,
+			wantStdout: "updated:\n  feat/a (1111111..2222222)\n\n" +
+				"aborted:\n  feat/b (rebase onto main had conflicts, aborted)\n\n" +
+				"skip:\n  feat/c (has uncommitted changes)\n",
+			wantStderr: "error: feat/d: boom\n",
+		},
+		{
+			name:   "verbose_lists_up_to_date",
+			result: UpdateResult{Worktrees: result.Worktrees[:2]},
+			opts:   FormatOptions{Verbose: true},
+			wantStdout: "updated:\n  feat/a (1111111..2222222)\n\n" +
+				"up to date:\n  main\n",
+		},
+		{
+			name:       "nothing_updated",
+			result:     UpdateResult{Worktrees: []UpdatedWorktree{result.Worktrees[0], result.Worktrees[3]}},
+			wantStdout: "skip:\n  feat/c (has uncommitted changes)\n\nNo worktrees to update\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.result.Format(tt.opts)
+			if got.Stdout != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", got.Stdout, tt.wantStdout)
+			}
+			if got.Stderr != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", got.Stderr, tt.wantStderr)
+			}
+		})
+	}
+}